@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 )
@@ -12,6 +13,13 @@ type (
 		HTTP `yaml:"http"`
 		Log  `yaml:"logger"`
 		PG   `yaml:"postgres"`
+
+		Reservation `yaml:"reservation"`
+		Adjustment  `yaml:"adjustment"`
+		Idempotency `yaml:"idempotency"`
+		Snapshot    `yaml:"snapshot"`
+		LowStock    `yaml:"low_stock"`
+		Pricing     `yaml:"pricing"`
 	}
 
 	App struct {
@@ -34,6 +42,37 @@ type (
 		DBPass  string `env-required:"true" yaml:"db_pass" env:"DB_PASS"`
 		DBName  string `env-required:"true" yaml:"db_name" env:"DB_NAME"`
 	}
+
+	Reservation struct {
+		TTL            time.Duration `yaml:"ttl"             env:"RESERVATION_TTL"             env-default:"30m"`
+		ExpiryInterval time.Duration `yaml:"expiry_interval" env:"RESERVATION_EXPIRY_INTERVAL" env-default:"1m"`
+	}
+
+	Adjustment struct {
+		ReasonCodes []string `yaml:"reason_codes" env:"ADJUSTMENT_REASON_CODES" env-separator:"," env-default:"DAMAGE,LOSS,FOUND,WRITE_OFF"`
+	}
+
+	Idempotency struct {
+		TTL             time.Duration `yaml:"ttl"              env:"IDEMPOTENCY_TTL"              env-default:"24h"`
+		CleanupInterval time.Duration `yaml:"cleanup_interval" env:"IDEMPOTENCY_CLEANUP_INTERVAL" env-default:"1h"`
+		Lease           time.Duration `yaml:"lease"            env:"IDEMPOTENCY_LEASE"            env-default:"1m"`
+	}
+
+	Snapshot struct {
+		Interval time.Duration `yaml:"interval" env:"SNAPSHOT_INTERVAL" env-default:"1h"`
+	}
+
+	LowStock struct {
+		CheckInterval time.Duration `yaml:"check_interval" env:"LOW_STOCK_CHECK_INTERVAL" env-default:"15m"`
+		Notifier      string        `yaml:"notifier"       env:"LOW_STOCK_NOTIFIER"`
+		WebhookURL    string        `yaml:"webhook_url"    env:"LOW_STOCK_WEBHOOK_URL"`
+		EmailTo       []string      `yaml:"email_to"       env:"LOW_STOCK_EMAIL_TO"       env-separator:","`
+		NotifyTimeout time.Duration `yaml:"notify_timeout" env:"LOW_STOCK_NOTIFY_TIMEOUT" env-default:"5s"`
+	}
+
+	Pricing struct {
+		ScheduleInterval time.Duration `yaml:"schedule_interval" env:"PRICING_SCHEDULE_INTERVAL" env-default:"1m"`
+	}
 )
 
 // NewConfig returns app config.
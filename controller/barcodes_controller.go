@@ -0,0 +1,86 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/budsx/retail-management/model"
+	"github.com/gorilla/mux"
+)
+
+// AddProductBarcode registers a barcode for the product. The type is guessed
+// from the code when the request leaves it out.
+func (c *Controller) AddProductBarcode(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	var barcode model.ProductBarcode
+	err = json.NewDecoder(r.Body).Decode(&barcode)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	barcode, err = c.service.AddProductBarcode(r.Context(), productID, barcode)
+	if errors.Is(err, model.ErrInvalidBarcode) {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid barcode")
+		return
+	}
+	if errors.Is(err, model.ErrBarcodeInUse) {
+		sendErrorResponse(w, http.StatusConflict, "Barcode already registered")
+		return
+	}
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	sendSuccessResponse(w, http.StatusCreated, barcode)
+}
+
+func (c *Controller) DeleteProductBarcode(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	productID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	err = c.service.DeleteProductBarcode(r.Context(), productID, vars["barcode"])
+	if errors.Is(err, model.ErrBarcodeNotFound) {
+		sendErrorResponse(w, http.StatusNotFound, "Barcode not found")
+		return
+	}
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	sendSuccessResponse(w, http.StatusOK, "Barcode deleted successfully")
+}
+
+// LookupBarcode returns the product a scanned code belongs to. A GS1-128
+// label, given with its application identifiers in brackets or with the group
+// separators URL-encoded, also returns its lot and expiry date.
+func (c *Controller) LookupBarcode(w http.ResponseWriter, r *http.Request) {
+	scan, err := c.service.LookupBarcode(r.Context(), mux.Vars(r)["barcode"])
+	if errors.Is(err, model.ErrInvalidBarcode) {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid barcode")
+		return
+	}
+	if errors.Is(err, model.ErrBarcodeNotFound) {
+		sendErrorResponse(w, http.StatusNotFound, "Barcode not found")
+		return
+	}
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	sendSuccessResponse(w, http.StatusOK, scan)
+}
@@ -0,0 +1,121 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/budsx/retail-management/middleware"
+	"github.com/budsx/retail-management/model"
+	"github.com/gorilla/mux"
+)
+
+// SetBundleComponents replaces the product's bill of materials with the
+// components in the body.
+func (c *Controller) SetBundleComponents(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	var components []model.BundleComponent
+	err = json.NewDecoder(r.Body).Decode(&components)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	err = c.service.SetBundleComponents(r.Context(), productID, components)
+	if errors.Is(err, model.ErrVariantParent) {
+		sendErrorResponse(w, http.StatusBadRequest, "Stock is kept per variant")
+		return
+	}
+	if errors.Is(err, model.ErrInvalidBundle) {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid bundle components")
+		return
+	}
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	sendSuccessResponse(w, http.StatusOK, "Bundle components saved successfully")
+}
+
+// GetBundleAvailability reports how many of a bundle can be shipped, from one
+// location with ?location_id=.
+func (c *Controller) GetBundleAvailability(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	var locationID int64
+	if locationIDStr := r.URL.Query().Get("location_id"); locationIDStr != "" {
+		locationID, err = strconv.ParseInt(locationIDStr, 10, 64)
+		if err != nil {
+			sendErrorResponse(w, http.StatusBadRequest, "Invalid location ID")
+			return
+		}
+	}
+
+	availability, err := c.service.GetBundleAvailability(r.Context(), productID, locationID)
+	if errors.Is(err, model.ErrNotBundle) {
+		sendErrorResponse(w, http.StatusNotFound, "Product is not a bundle")
+		return
+	}
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	sendSuccessResponse(w, http.StatusOK, availability)
+}
+
+// AssembleBundle builds finished bundles from components at the location in
+// the body.
+func (c *Controller) AssembleBundle(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.ContextKeyUserID).(int64)
+
+	productID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	var assembly model.StockTransaction
+	err = json.NewDecoder(r.Body).Decode(&assembly)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	assembly.ProductID = productID
+	assembly.CreatedBy = userID
+	transaction, err := c.service.AssembleBundle(r.Context(), assembly)
+	if errors.Is(err, model.ErrLocationRequired) {
+		sendErrorResponse(w, http.StatusBadRequest, "location_id is required")
+		return
+	}
+	if errors.Is(err, model.ErrNotBundle) {
+		sendErrorResponse(w, http.StatusNotFound, "Product is not a bundle")
+		return
+	}
+	if errors.Is(err, model.ErrArchived) {
+		sendErrorResponse(w, http.StatusConflict, "Archived")
+		return
+	}
+	if errors.Is(err, model.ErrInsufficientStock) {
+		sendErrorResponse(w, http.StatusConflict, "Insufficient stock")
+		return
+	}
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	sendSuccessResponse(w, http.StatusCreated, transaction)
+}
@@ -0,0 +1,122 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/budsx/retail-management/model"
+	"github.com/gorilla/mux"
+)
+
+func (c *Controller) AddCategory(w http.ResponseWriter, r *http.Request) {
+	var category model.Category
+	err := json.NewDecoder(r.Body).Decode(&category)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	category, err = c.service.AddCategory(r.Context(), category)
+	if errors.Is(err, model.ErrInvalidCategory) {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid category")
+		return
+	}
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	sendSuccessResponse(w, http.StatusCreated, category)
+}
+
+// GetCategories returns the category tree.
+func (c *Controller) GetCategories(w http.ResponseWriter, r *http.Request) {
+	categories, err := c.service.GetCategories(r.Context())
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	sendSuccessResponse(w, http.StatusOK, categories)
+}
+
+// GetCategoryByID returns a category with its subcategories.
+func (c *Controller) GetCategoryByID(w http.ResponseWriter, r *http.Request) {
+	categoryID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid category ID")
+		return
+	}
+
+	category, err := c.service.GetCategoryByID(r.Context(), categoryID)
+	if errors.Is(err, model.ErrCategoryNotFound) {
+		sendErrorResponse(w, http.StatusNotFound, "Category not found")
+		return
+	}
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	sendSuccessResponse(w, http.StatusOK, category)
+}
+
+// EditCategory renames a category and moves it under parent_id, or to the
+// root when parent_id is left out.
+func (c *Controller) EditCategory(w http.ResponseWriter, r *http.Request) {
+	categoryID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid category ID")
+		return
+	}
+
+	var category model.Category
+	err = json.NewDecoder(r.Body).Decode(&category)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	category.CategoryID = categoryID
+	err = c.service.EditCategory(r.Context(), category)
+	if errors.Is(err, model.ErrCategoryNotFound) {
+		sendErrorResponse(w, http.StatusNotFound, "Category not found")
+		return
+	}
+	if errors.Is(err, model.ErrInvalidCategory) {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid category")
+		return
+	}
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	sendSuccessResponse(w, http.StatusOK, "Category updated successfully")
+}
+
+func (c *Controller) DeleteCategory(w http.ResponseWriter, r *http.Request) {
+	categoryID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid category ID")
+		return
+	}
+
+	err = c.service.DeleteCategory(r.Context(), categoryID)
+	if errors.Is(err, model.ErrCategoryNotFound) {
+		sendErrorResponse(w, http.StatusNotFound, "Category not found")
+		return
+	}
+	if errors.Is(err, model.ErrCategoryInUse) {
+		sendErrorResponse(w, http.StatusConflict, "Category still has products or subcategories")
+		return
+	}
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	sendSuccessResponse(w, http.StatusOK, "Category deleted successfully")
+}
@@ -0,0 +1,126 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/budsx/retail-management/middleware"
+	"github.com/budsx/retail-management/model"
+)
+
+// IdempotencyKeyHeader lets a client retry a create request without creating
+// the resource twice.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// idempotencyFinishTimeout bounds storing the response or releasing the key
+// once the request has been handled.
+const idempotencyFinishTimeout = 5 * time.Second
+
+// WithIdempotency wraps a create handler so that a request sent again with the
+// same Idempotency-Key gets the original response instead of being processed
+// twice. Reusing a key with a different request is rejected with 409.
+func (c *Controller) WithIdempotency(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyKeyHeader)
+		if key == "" {
+			next(w, r)
+			return
+		}
+		if len(key) > 255 {
+			sendErrorResponse(w, http.StatusBadRequest, "Idempotency key is too long")
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			sendErrorResponse(w, http.StatusBadRequest, "Invalid request payload")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		record, started, err := c.service.StartIdempotentRequest(r.Context(), key, requestFingerprint(r, body))
+		if errors.Is(err, model.ErrIdempotencyKeyMismatch) {
+			sendErrorResponse(w, http.StatusConflict, "Idempotency key reused with a different request")
+			return
+		}
+		if errors.Is(err, model.ErrIdempotencyKeyInProgress) {
+			sendErrorResponse(w, http.StatusConflict, "Request with this idempotency key is still in progress")
+			return
+		}
+		if err != nil {
+			sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+			return
+		}
+
+		if !started {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(record.StatusCode)
+			w.Write(record.ResponseBody)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+		defer func() {
+			statusCode, body := recorder.statusCode, recorder.body.Bytes()
+			// A panicking handler releases the key like any other server error
+			p := recover()
+			if p != nil {
+				statusCode, body = http.StatusInternalServerError, nil
+			}
+
+			// The client may be gone by now, so the request's context is not used
+			ctx, cancel := detachedContext(r.Context())
+			defer cancel()
+			err := c.service.FinishIdempotentRequest(ctx, key, statusCode, body)
+			if err != nil {
+				log.Printf("idempotency key %q left unfinished until its lease runs out: %s", key, err.Error())
+			}
+
+			if p != nil {
+				panic(p)
+			}
+		}()
+		next(recorder, r)
+	}
+}
+
+// detachedContext carries the user of a request into a fresh context that is
+// not cancelled with the request.
+func detachedContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	detached := context.WithValue(context.Background(), middleware.ContextKeyUserID, ctx.Value(middleware.ContextKeyUserID))
+	detached = context.WithValue(detached, middleware.ContextKeyUsername, ctx.Value(middleware.ContextKeyUsername))
+	return context.WithTimeout(detached, idempotencyFinishTimeout)
+}
+
+// requestFingerprint identifies a request by its method, path and body.
+func requestFingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	io.WriteString(hash, r.Method+" "+r.URL.Path+"\n")
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// responseRecorder passes a response through while keeping a copy of it.
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (rec *responseRecorder) WriteHeader(statusCode int) {
+	rec.statusCode = statusCode
+	rec.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
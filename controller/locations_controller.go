@@ -2,6 +2,7 @@ package controller
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -18,6 +19,10 @@ func (c *Controller) AddLocation(w http.ResponseWriter, r *http.Request) {
 	}
 
 	err = c.service.AddLocation(r.Context(), location)
+	if errors.Is(err, model.ErrArchived) {
+		sendErrorResponse(w, http.StatusConflict, "Archived")
+		return
+	}
 	if err != nil {
 		sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
 		return
@@ -0,0 +1,118 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/budsx/retail-management/model"
+	"github.com/gorilla/mux"
+)
+
+// GetPriceHistory lists the prices a product has had and its scheduled
+// changes.
+func (c *Controller) GetPriceHistory(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	prices, err := c.service.GetPriceHistory(r.Context(), productID)
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	sendSuccessResponse(w, http.StatusOK, prices)
+}
+
+// GetPriceAt returns the price of a product in effect at ?at=, an RFC 3339
+// timestamp or a date meaning the end of that day, or now when it is left out.
+func (c *Controller) GetPriceAt(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	at, err := parseTimeParam(r, "at", true)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid at")
+		return
+	}
+	if at == nil {
+		now := time.Now()
+		at = &now
+	}
+
+	price, err := c.service.GetPriceAt(r.Context(), productID, *at)
+	if errors.Is(err, model.ErrPriceNotFound) {
+		sendErrorResponse(w, http.StatusNotFound, "No price at that time")
+		return
+	}
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	sendSuccessResponse(w, http.StatusOK, price)
+}
+
+// SchedulePriceChange sets the price a product takes at effective_from.
+func (c *Controller) SchedulePriceChange(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	var price model.ProductPrice
+	err = json.NewDecoder(r.Body).Decode(&price)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	price.ProductID = productID
+	price, err = c.service.SchedulePriceChange(r.Context(), price)
+	if errors.Is(err, model.ErrInvalidPriceChange) {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid price change")
+		return
+	}
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	sendSuccessResponse(w, http.StatusCreated, price)
+}
+
+func (c *Controller) CancelPriceChange(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	productID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	priceID, err := strconv.ParseInt(vars["price_id"], 10, 64)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid price ID")
+		return
+	}
+
+	err = c.service.CancelPriceChange(r.Context(), productID, priceID)
+	if errors.Is(err, model.ErrPriceNotFound) {
+		sendErrorResponse(w, http.StatusNotFound, "Scheduled price change not found")
+		return
+	}
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	sendSuccessResponse(w, http.StatusOK, "Price change cancelled successfully")
+}
@@ -2,8 +2,12 @@ package controller
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/budsx/retail-management/model"
 	"github.com/gorilla/mux"
@@ -18,6 +22,22 @@ func (c *Controller) AddProduct(w http.ResponseWriter, r *http.Request) {
 	}
 
 	err = c.service.AddProduct(r.Context(), product)
+	if errors.Is(err, model.ErrCategoryNotFound) {
+		sendErrorResponse(w, http.StatusBadRequest, "Unknown category")
+		return
+	}
+	if errors.Is(err, model.ErrInvalidVariant) {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid variant")
+		return
+	}
+	if errors.Is(err, model.ErrInvalidUnit) {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid unit of measure")
+		return
+	}
+	if errors.Is(err, model.ErrUnknownUnit) {
+		sendErrorResponse(w, http.StatusBadRequest, "Unknown unit of measure")
+		return
+	}
 	if err != nil {
 		sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
 		return
@@ -26,7 +46,38 @@ func (c *Controller) AddProduct(w http.ResponseWriter, r *http.Request) {
 	sendSuccessResponse(w, http.StatusCreated, "Product added successfully")
 }
 
+// GetProducts lists products a page at a time by ?page= and ?limit=. Given a
+// ?cursor=, empty for the first page, it follows the cursor of the previous
+// page instead.
 func (c *Controller) GetProducts(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseProductFilter(r)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid product filter: "+err.Error())
+		return
+	}
+
+	if r.URL.Query().Has("cursor") {
+		products, cursors, err := c.service.GetProductsByCursor(r.Context(), filter, parseCursorPagination(r))
+		if errors.Is(err, model.ErrInvalidProductQuery) {
+			sendErrorResponse(w, http.StatusBadRequest, "Invalid product query")
+			return
+		}
+		if errors.Is(err, model.ErrInvalidPagination) {
+			sendErrorResponse(w, http.StatusBadRequest, "Invalid pagination")
+			return
+		}
+		if errors.Is(err, model.ErrCategoryNotFound) {
+			sendErrorResponse(w, http.StatusBadRequest, "Unknown category")
+			return
+		}
+		if err != nil {
+			sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+			return
+		}
+		sendPagedResponse(w, http.StatusOK, products, cursors)
+		return
+	}
+
 	page, err := strconv.Atoi(r.URL.Query().Get("page"))
 	if err != nil {
 		page = 1
@@ -41,7 +92,15 @@ func (c *Controller) GetProducts(w http.ResponseWriter, r *http.Request) {
 		Limit: int32(limit),
 	}
 
-	products, err := c.service.GetProducts(r.Context(), pagination)
+	products, err := c.service.GetProducts(r.Context(), filter, pagination)
+	if errors.Is(err, model.ErrInvalidProductQuery) {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid product query")
+		return
+	}
+	if errors.Is(err, model.ErrCategoryNotFound) {
+		sendErrorResponse(w, http.StatusBadRequest, "Unknown category")
+		return
+	}
 	if err != nil {
 		sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
 		return
@@ -49,6 +108,74 @@ func (c *Controller) GetProducts(w http.ResponseWriter, r *http.Request) {
 	sendSuccessResponse(w, http.StatusOK, products)
 }
 
+// parseProductFilter reads the product listing filter: ?q= searches name,
+// description and SKU; ?category= takes a category and its subcategories;
+// min_price, max_price, created_from, created_to, updated_from and updated_to
+// bound the results; sort names the field to sort by and order=desc reverses
+// it. Archived products are listed with include_archived=true.
+func parseProductFilter(r *http.Request) (model.ProductFilter, error) {
+	query := r.URL.Query()
+	filter := model.ProductFilter{
+		Search: strings.TrimSpace(query.Get("q")),
+		SortBy: query.Get("sort"),
+	}
+
+	switch strings.ToLower(query.Get("order")) {
+	case "", "asc":
+	case "desc":
+		filter.Descending = true
+	default:
+		return model.ProductFilter{}, errors.New("order must be asc or desc")
+	}
+
+	includeArchived, err := parseIncludeArchived(r)
+	if err != nil {
+		return model.ProductFilter{}, errors.New("include_archived must be true or false")
+	}
+	filter.IncludeArchived = includeArchived
+
+	if category := query.Get("category"); category != "" {
+		categoryID, err := strconv.ParseInt(category, 10, 64)
+		if err != nil || categoryID < 1 {
+			return model.ProductFilter{}, errors.New("category must be a category ID")
+		}
+		filter.CategoryID = categoryID
+	}
+
+	prices := map[string]**float64{"min_price": &filter.MinPrice, "max_price": &filter.MaxPrice}
+	for key, price := range prices {
+		value := query.Get(key)
+		if value == "" {
+			continue
+		}
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return model.ProductFilter{}, fmt.Errorf("%s must be a number", key)
+		}
+		*price = &parsed
+	}
+
+	dates := []struct {
+		key      string
+		endOfDay bool
+		value    **time.Time
+	}{
+		{"created_from", false, &filter.CreatedFrom},
+		{"created_to", true, &filter.CreatedTo},
+		{"updated_from", false, &filter.UpdatedFrom},
+		{"updated_to", true, &filter.UpdatedTo},
+	}
+	for _, date := range dates {
+		parsed, err := parseTimeParam(r, date.key, date.endOfDay)
+		if err != nil {
+			return model.ProductFilter{}, fmt.Errorf("%s must be a date or an RFC 3339 timestamp", date.key)
+		}
+		*date.value = parsed
+	}
+
+	return filter, nil
+}
+
 func (c *Controller) GetProductByID(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	idStr, ok := vars["id"]
@@ -64,6 +191,10 @@ func (c *Controller) GetProductByID(w http.ResponseWriter, r *http.Request) {
 	}
 
 	product, err := c.service.GetProductByID(r.Context(), productID)
+	if errors.Is(err, model.ErrProductNotFound) {
+		sendErrorResponse(w, http.StatusNotFound, "Product not found")
+		return
+	}
 	if err != nil {
 		sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
 		return
@@ -95,9 +226,126 @@ func (c *Controller) EditProduct(w http.ResponseWriter, r *http.Request) {
 
 	updatedProduct.ProductID = productID
 	err = c.service.EditProduct(r.Context(), updatedProduct)
+	if errors.Is(err, model.ErrProductNotFound) {
+		sendErrorResponse(w, http.StatusNotFound, "Product not found")
+		return
+	}
+	if errors.Is(err, model.ErrInvalidBundle) {
+		sendErrorResponse(w, http.StatusBadRequest, "A bundle or bundle component cannot be serialized")
+		return
+	}
+	if errors.Is(err, model.ErrSerializationLocked) {
+		sendErrorResponse(w, http.StatusConflict, "Serial tracking cannot change while the product holds stock")
+		return
+	}
+	if errors.Is(err, model.ErrUnknownUnit) {
+		sendErrorResponse(w, http.StatusBadRequest, "Unknown unit of measure")
+		return
+	}
+	if errors.Is(err, model.ErrCategoryNotFound) {
+		sendErrorResponse(w, http.StatusBadRequest, "Unknown category")
+		return
+	}
 	if err != nil {
 		sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
 		return
 	}
 	sendSuccessResponse(w, http.StatusOK, "Product updated successfully")
 }
+
+// DeleteProduct archives a product, leaving its stock history in place.
+func (c *Controller) DeleteProduct(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	err = c.service.DeleteProduct(r.Context(), productID)
+	if errors.Is(err, model.ErrProductNotFound) {
+		sendErrorResponse(w, http.StatusNotFound, "Product not found")
+		return
+	}
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	sendSuccessResponse(w, http.StatusOK, "Product deleted successfully")
+}
+
+// RestoreProduct brings back an archived product.
+func (c *Controller) RestoreProduct(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	err = c.service.RestoreProduct(r.Context(), productID)
+	if errors.Is(err, model.ErrProductNotFound) {
+		sendErrorResponse(w, http.StatusNotFound, "Product not found")
+		return
+	}
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	sendSuccessResponse(w, http.StatusOK, "Product restored successfully")
+}
+
+// SetProductUnit sets how many base units one {unit} of the product is.
+func (c *Controller) SetProductUnit(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	productID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	var unit model.ProductUnit
+	err = json.NewDecoder(r.Body).Decode(&unit)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	unit.UnitCode = vars["unit"]
+	err = c.service.SetProductUnit(r.Context(), productID, unit)
+	if errors.Is(err, model.ErrInvalidUnit) {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid unit of measure")
+		return
+	}
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	sendSuccessResponse(w, http.StatusOK, "Unit saved successfully")
+}
+
+func (c *Controller) DeleteProductUnit(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	productID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	err = c.service.DeleteProductUnit(r.Context(), productID, vars["unit"])
+	if errors.Is(err, model.ErrUnknownUnit) {
+		sendErrorResponse(w, http.StatusNotFound, "Unit not found")
+		return
+	}
+	if errors.Is(err, model.ErrInvalidUnit) {
+		sendErrorResponse(w, http.StatusConflict, "Unit is in use")
+		return
+	}
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	sendSuccessResponse(w, http.StatusOK, "Unit deleted successfully")
+}
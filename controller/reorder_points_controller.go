@@ -0,0 +1,116 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/budsx/retail-management/model"
+	"github.com/gorilla/mux"
+)
+
+func (c *Controller) SetReorderPoint(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	warehouseID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid warehouse ID")
+		return
+	}
+
+	productID, err := strconv.ParseInt(vars["product_id"], 10, 64)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	var reorderPoint model.ReorderPoint
+	err = json.NewDecoder(r.Body).Decode(&reorderPoint)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	reorderPoint.WarehouseID = warehouseID
+	reorderPoint.ProductID = productID
+	err = c.service.SetReorderPoint(r.Context(), reorderPoint)
+	if errors.Is(err, model.ErrVariantParent) {
+		sendErrorResponse(w, http.StatusBadRequest, "Stock is kept per variant")
+		return
+	}
+	if errors.Is(err, model.ErrInvalidReorderPoint) {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid reorder point")
+		return
+	}
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	sendSuccessResponse(w, http.StatusOK, "Reorder point saved successfully")
+}
+
+func (c *Controller) GetReorderPoints(w http.ResponseWriter, r *http.Request) {
+	warehouseID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid warehouse ID")
+		return
+	}
+
+	reorderPoints, err := c.service.GetReorderPoints(r.Context(), warehouseID)
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	sendSuccessResponse(w, http.StatusOK, reorderPoints)
+}
+
+func (c *Controller) DeleteReorderPoint(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	warehouseID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid warehouse ID")
+		return
+	}
+
+	productID, err := strconv.ParseInt(vars["product_id"], 10, 64)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	err = c.service.DeleteReorderPoint(r.Context(), warehouseID, productID)
+	if errors.Is(err, model.ErrReorderPointNotFound) {
+		sendErrorResponse(w, http.StatusNotFound, "Reorder point not found")
+		return
+	}
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	sendSuccessResponse(w, http.StatusOK, "Reorder point deleted successfully")
+}
+
+// GetLowStockItems lists the items below their reorder point, limited to one
+// warehouse with ?warehouse_id=.
+func (c *Controller) GetLowStockItems(w http.ResponseWriter, r *http.Request) {
+	var warehouseID int64
+	if warehouseIDStr := r.URL.Query().Get("warehouse_id"); warehouseIDStr != "" {
+		var err error
+		warehouseID, err = strconv.ParseInt(warehouseIDStr, 10, 64)
+		if err != nil {
+			sendErrorResponse(w, http.StatusBadRequest, "Invalid warehouse ID")
+			return
+		}
+	}
+
+	items, err := c.service.GetLowStockItems(r.Context(), warehouseID)
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	sendSuccessResponse(w, http.StatusOK, items)
+}
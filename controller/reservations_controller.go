@@ -0,0 +1,148 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/budsx/retail-management/middleware"
+	"github.com/budsx/retail-management/model"
+	"github.com/gorilla/mux"
+)
+
+func (c *Controller) CreateReservation(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.ContextKeyUserID).(int64)
+	if userID == 0 {
+		sendErrorResponse(w, http.StatusUnauthorized, "Unathorized")
+		return
+	}
+
+	var reservation model.Reservation
+	err := json.NewDecoder(r.Body).Decode(&reservation)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	reservation.CreatedBy = userID
+	created, err := c.service.CreateReservation(r.Context(), reservation)
+	if errors.Is(err, model.ErrVariantParent) {
+		sendErrorResponse(w, http.StatusBadRequest, "Stock is kept per variant")
+		return
+	}
+	if errors.Is(err, model.ErrLocationRequired) {
+		sendErrorResponse(w, http.StatusBadRequest, "location_id is required")
+		return
+	}
+	if errors.Is(err, model.ErrInvalidQuantity) {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid quantity")
+		return
+	}
+	if errors.Is(err, model.ErrInvalidMovement) {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid location")
+		return
+	}
+	if errors.Is(err, model.ErrLocationNotFound) {
+		sendErrorResponse(w, http.StatusNotFound, "Location not found")
+		return
+	}
+	if errors.Is(err, model.ErrProductNotFound) {
+		sendErrorResponse(w, http.StatusNotFound, "Product not found")
+		return
+	}
+	if errors.Is(err, model.ErrInsufficientStock) {
+		sendErrorResponse(w, http.StatusConflict, "Insufficient available stock")
+		return
+	}
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	sendSuccessResponse(w, http.StatusCreated, created)
+}
+
+func (c *Controller) GetReservationByID(w http.ResponseWriter, r *http.Request) {
+	reservationID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid reservation ID")
+		return
+	}
+
+	reservation, err := c.service.GetReservationByID(r.Context(), reservationID)
+	if errors.Is(err, model.ErrReservationNotFound) {
+		sendErrorResponse(w, http.StatusNotFound, "Reservation not found")
+		return
+	}
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	sendSuccessResponse(w, http.StatusOK, reservation)
+}
+
+func (c *Controller) ConfirmReservation(w http.ResponseWriter, r *http.Request) {
+	reservationID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid reservation ID")
+		return
+	}
+
+	var confirmation model.ReservationConfirmation
+	err = json.NewDecoder(r.Body).Decode(&confirmation)
+	if err != nil && err != io.EOF {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	reservation, err := c.service.ConfirmReservation(r.Context(), reservationID, confirmation.SerialNumbers)
+	if errors.Is(err, model.ErrReservationNotFound) {
+		sendErrorResponse(w, http.StatusNotFound, "Reservation not found")
+		return
+	}
+	if errors.Is(err, model.ErrInvalidMovement) {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid serial numbers")
+		return
+	}
+	if errors.Is(err, model.ErrReservationNotActive) {
+		sendErrorResponse(w, http.StatusConflict, "Reservation is not active")
+		return
+	}
+	if errors.Is(err, model.ErrSerialNotAvailable) {
+		sendErrorResponse(w, http.StatusConflict, "Serial number not available")
+		return
+	}
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	sendSuccessResponse(w, http.StatusOK, reservation)
+}
+
+func (c *Controller) ReleaseReservation(w http.ResponseWriter, r *http.Request) {
+	reservationID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid reservation ID")
+		return
+	}
+
+	err = c.service.ReleaseReservation(r.Context(), reservationID)
+	if errors.Is(err, model.ErrReservationNotFound) {
+		sendErrorResponse(w, http.StatusNotFound, "Reservation not found")
+		return
+	}
+	if errors.Is(err, model.ErrReservationNotActive) {
+		sendErrorResponse(w, http.StatusConflict, "Reservation is not active")
+		return
+	}
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	sendSuccessResponse(w, http.StatusOK, "Reservation released successfully")
+}
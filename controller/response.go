@@ -3,17 +3,21 @@ package controller
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
 
+	"github.com/budsx/retail-management/model"
 	"github.com/budsx/retail-management/utils"
 )
 
 type ErrorResponse struct {
-	Error string `json:"error"`
+	Error   string      `json:"error"`
+	Details interface{} `json:"details,omitempty"`
 }
 
 type SuccessResponse struct {
-	Message string      `json:"message"`
-	Data    interface{} `json:"data,omitempty"`
+	Message    string             `json:"message"`
+	Data       interface{}        `json:"data,omitempty"`
+	Pagination *model.PageCursors `json:"pagination,omitempty"`
 }
 
 func sendSuccessResponse(w http.ResponseWriter, statusCode int, data interface{}) {
@@ -26,6 +30,19 @@ func sendSuccessResponse(w http.ResponseWriter, statusCode int, data interface{}
 	json.NewEncoder(w).Encode(response)
 }
 
+// sendPagedResponse is sendSuccessResponse for a page of a listing, with the
+// cursors leading to the pages either side of it.
+func sendPagedResponse(w http.ResponseWriter, statusCode int, data interface{}, cursors model.PageCursors) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	response := SuccessResponse{
+		Message:    utils.SuccessMessage,
+		Data:       data,
+		Pagination: &cursors,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
 func sendErrorResponse(w http.ResponseWriter, statusCode int, errorMessage string) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
@@ -34,3 +51,38 @@ func sendErrorResponse(w http.ResponseWriter, statusCode int, errorMessage strin
 	}
 	json.NewEncoder(w).Encode(response)
 }
+
+// sendErrorResponseWithDetails is sendErrorResponse with data explaining the
+// error, such as the failing lines of a batch.
+func sendErrorResponseWithDetails(w http.ResponseWriter, statusCode int, errorMessage string, details interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	response := ErrorResponse{
+		Error:   errorMessage,
+		Details: details,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// parseCursorPagination reads ?cursor= and ?limit=, which defaults to 10.
+func parseCursorPagination(r *http.Request) model.CursorPagination {
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil {
+		limit = 10
+	}
+
+	return model.CursorPagination{
+		Cursor: r.URL.Query().Get("cursor"),
+		Limit:  int32(limit),
+	}
+}
+
+// parseIncludeArchived reads ?include_archived=, which lists archived rows
+// along with the others.
+func parseIncludeArchived(r *http.Request) (bool, error) {
+	value := r.URL.Query().Get("include_archived")
+	if value == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(value)
+}
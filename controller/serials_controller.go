@@ -0,0 +1,29 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/budsx/retail-management/model"
+	"github.com/gorilla/mux"
+)
+
+func (c *Controller) GetSerialHistory(w http.ResponseWriter, r *http.Request) {
+	serialNumber := mux.Vars(r)["serial_number"]
+	if serialNumber == "" {
+		sendErrorResponse(w, http.StatusBadRequest, "Missing serial number")
+		return
+	}
+
+	serials, err := c.service.GetSerialHistory(r.Context(), serialNumber)
+	if errors.Is(err, model.ErrSerialNotFound) {
+		sendErrorResponse(w, http.StatusNotFound, "Serial number not found")
+		return
+	}
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	sendSuccessResponse(w, http.StatusOK, serials)
+}
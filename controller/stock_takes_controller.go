@@ -0,0 +1,237 @@
+package controller
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/budsx/retail-management/model"
+	"github.com/gorilla/mux"
+)
+
+func (c *Controller) OpenStockTake(w http.ResponseWriter, r *http.Request) {
+	var stockTake model.StockTake
+	err := json.NewDecoder(r.Body).Decode(&stockTake)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	created, err := c.service.OpenStockTake(r.Context(), stockTake)
+	if errors.Is(err, model.ErrWarehouseNotFound) {
+		sendErrorResponse(w, http.StatusNotFound, "Warehouse not found")
+		return
+	}
+	if errors.Is(err, model.ErrLocationNotFound) {
+		sendErrorResponse(w, http.StatusNotFound, "Location not found")
+		return
+	}
+	if errors.Is(err, model.ErrLocationRequired) || errors.Is(err, model.ErrInvalidMovement) {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid location")
+		return
+	}
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	sendSuccessResponse(w, http.StatusCreated, created)
+}
+
+func (c *Controller) GetStockTakeByID(w http.ResponseWriter, r *http.Request) {
+	stockTakeID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid stock take ID")
+		return
+	}
+
+	stockTake, err := c.service.GetStockTakeByID(r.Context(), stockTakeID)
+	if errors.Is(err, model.ErrStockTakeNotFound) {
+		sendErrorResponse(w, http.StatusNotFound, "Stock take not found")
+		return
+	}
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	sendSuccessResponse(w, http.StatusOK, stockTake)
+}
+
+// RecordStockTakeCounts takes a JSON array of counts, or a CSV upload with a
+// product_id,location_id,counted_quantity header when sent as text/csv.
+func (c *Controller) RecordStockTakeCounts(w http.ResponseWriter, r *http.Request) {
+	stockTakeID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid stock take ID")
+		return
+	}
+
+	var counts []model.StockTakeCount
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "text/csv" {
+		counts, err = readStockTakeCountsCSV(r.Body)
+	} else {
+		err = json.NewDecoder(r.Body).Decode(&counts)
+	}
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	stockTake, err := c.service.RecordStockTakeCounts(r.Context(), stockTakeID, counts)
+	if errors.Is(err, model.ErrInvalidQuantity) {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid quantity")
+		return
+	}
+	if errors.Is(err, model.ErrProductNotFound) {
+		sendErrorResponse(w, http.StatusNotFound, "Product not found")
+		return
+	}
+	if errors.Is(err, model.ErrLocationNotFound) {
+		sendErrorResponse(w, http.StatusNotFound, "Location not found")
+		return
+	}
+	if errors.Is(err, model.ErrVariantParent) {
+		sendErrorResponse(w, http.StatusBadRequest, "Stock is kept per variant")
+		return
+	}
+	if errors.Is(err, model.ErrStockTakeNotFound) {
+		sendErrorResponse(w, http.StatusNotFound, "Stock take not found")
+		return
+	}
+	if errors.Is(err, model.ErrStockTakeNotOpen) {
+		sendErrorResponse(w, http.StatusConflict, "Stock take is not open")
+		return
+	}
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	sendSuccessResponse(w, http.StatusOK, stockTake)
+}
+
+func (c *Controller) GetStockTakeVariances(w http.ResponseWriter, r *http.Request) {
+	stockTakeID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid stock take ID")
+		return
+	}
+
+	variances, err := c.service.GetStockTakeVariances(r.Context(), stockTakeID)
+	if errors.Is(err, model.ErrStockTakeNotFound) {
+		sendErrorResponse(w, http.StatusNotFound, "Stock take not found")
+		return
+	}
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	sendSuccessResponse(w, http.StatusOK, variances)
+}
+
+func (c *Controller) ApproveStockTake(w http.ResponseWriter, r *http.Request) {
+	stockTakeID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid stock take ID")
+		return
+	}
+
+	stockTake, err := c.service.ApproveStockTake(r.Context(), stockTakeID)
+	if errors.Is(err, model.ErrArchived) {
+		sendErrorResponse(w, http.StatusConflict, "Archived")
+		return
+	}
+	if errors.Is(err, model.ErrStockTakeNotFound) {
+		sendErrorResponse(w, http.StatusNotFound, "Stock take not found")
+		return
+	}
+	if errors.Is(err, model.ErrStockTakeNotOpen) {
+		sendErrorResponse(w, http.StatusConflict, "Stock take is not open")
+		return
+	}
+	if errors.Is(err, model.ErrInsufficientStock) {
+		sendErrorResponse(w, http.StatusConflict, "Insufficient available stock")
+		return
+	}
+	if errors.Is(err, model.ErrSerializedVariance) {
+		sendErrorResponse(w, http.StatusConflict, "Serialized stock must be adjusted by serial number")
+		return
+	}
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	sendSuccessResponse(w, http.StatusOK, stockTake)
+}
+
+func (c *Controller) CancelStockTake(w http.ResponseWriter, r *http.Request) {
+	stockTakeID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid stock take ID")
+		return
+	}
+
+	err = c.service.CancelStockTake(r.Context(), stockTakeID)
+	if errors.Is(err, model.ErrStockTakeNotFound) {
+		sendErrorResponse(w, http.StatusNotFound, "Stock take not found")
+		return
+	}
+	if errors.Is(err, model.ErrStockTakeNotOpen) {
+		sendErrorResponse(w, http.StatusConflict, "Stock take is not open")
+		return
+	}
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	sendSuccessResponse(w, http.StatusOK, "Stock take cancelled successfully")
+}
+
+func readStockTakeCountsCSV(body io.Reader) ([]model.StockTakeCount, error) {
+	records, err := csv.NewReader(body).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) == 0 {
+		return nil, fmt.Errorf("empty upload")
+	}
+
+	columns := map[string]int{}
+	for i, name := range records[0] {
+		columns[name] = i
+	}
+	for _, name := range []string{"product_id", "location_id", "counted_quantity"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("missing column %s", name)
+		}
+	}
+
+	counts := make([]model.StockTakeCount, 0, len(records)-1)
+	for line, record := range records[1:] {
+		var count model.StockTakeCount
+		count.ProductID, err = strconv.ParseInt(record[columns["product_id"]], 10, 64)
+		if err == nil {
+			count.LocationID, err = strconv.ParseInt(record[columns["location_id"]], 10, 64)
+		}
+		if err == nil {
+			count.CountedQuantity, err = strconv.ParseInt(record[columns["counted_quantity"]], 10, 64)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line+2, err)
+		}
+		counts = append(counts, count)
+	}
+
+	return counts, nil
+}
@@ -3,13 +3,33 @@ package controller
 import (
 	"net/http"
 	"strconv"
+	"time"
 
+	"github.com/budsx/retail-management/model"
 	"github.com/gorilla/mux"
 )
 
+const defaultExpiryWindowDays = 30
+
+// GetTotalStocks reports current stock, or with ?as_of= the stock on hand at
+// that time. ?rollup=category reports it per category.
 func (c *Controller) GetTotalStocks(w http.ResponseWriter, r *http.Request) {
+	if c.sendStockRollup(w, r, 0) {
+		return
+	}
+
+	asOf, ok, err := parseAsOf(r)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid as_of")
+		return
+	}
 
-	totalStock, err := c.service.GetTotalStocks(r.Context())
+	var totalStock []model.ProductStock
+	if ok {
+		totalStock, err = c.service.GetTotalStocksAsOf(r.Context(), asOf)
+	} else {
+		totalStock, err = c.service.GetTotalStocks(r.Context())
+	}
 	if err != nil {
 		sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
 		return
@@ -27,7 +47,22 @@ func (c *Controller) GetTotalStockByLocation(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	totalStock, err := c.service.GetTotalStockByLocation(r.Context(), locationID)
+	if c.sendStockRollup(w, r, locationID) {
+		return
+	}
+
+	asOf, ok, err := parseAsOf(r)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid as_of")
+		return
+	}
+
+	var totalStock []model.ProductStock
+	if ok {
+		totalStock, err = c.service.GetTotalStockByLocationAsOf(r.Context(), locationID, asOf)
+	} else {
+		totalStock, err = c.service.GetTotalStockByLocation(r.Context(), locationID)
+	}
 	if err != nil {
 		sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
 		return
@@ -35,3 +70,99 @@ func (c *Controller) GetTotalStockByLocation(w http.ResponseWriter, r *http.Requ
 
 	sendSuccessResponse(w, http.StatusOK, totalStock)
 }
+
+// GetExpiringLots lists lots in a warehouse expiring within ?days= (default 30).
+func (c *Controller) GetExpiringLots(w http.ResponseWriter, r *http.Request) {
+	warehouseID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid warehouse ID")
+		return
+	}
+
+	days := defaultExpiryWindowDays
+	if daysStr := r.URL.Query().Get("days"); daysStr != "" {
+		days, err = strconv.Atoi(daysStr)
+		if err != nil || days < 0 {
+			sendErrorResponse(w, http.StatusBadRequest, "Invalid days")
+			return
+		}
+	}
+
+	lots, err := c.service.GetExpiringLots(r.Context(), warehouseID, days)
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	sendSuccessResponse(w, http.StatusOK, lots)
+}
+
+// sendStockRollup answers a total-stock request for ?rollup=category with the
+// stock of the location, or of all locations when locationID is 0, rolled up
+// the category tree, and for ?rollup=parent with the stock of variants added
+// up per parent product. It reports whether it answered the request.
+func (c *Controller) sendStockRollup(w http.ResponseWriter, r *http.Request, locationID int64) bool {
+	rollup := r.URL.Query().Get("rollup")
+	switch rollup {
+	case "":
+		return false
+	case "category", "parent":
+	default:
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid rollup")
+		return true
+	}
+
+	asOf, err := parseTimeParam(r, "as_of", true)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid as_of")
+		return true
+	}
+
+	var stocks interface{}
+	if rollup == "category" {
+		stocks, err = c.service.GetCategoryStocks(r.Context(), locationID, asOf)
+	} else {
+		stocks, err = c.service.GetParentStocks(r.Context(), locationID, asOf)
+	}
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return true
+	}
+
+	sendSuccessResponse(w, http.StatusOK, stocks)
+	return true
+}
+
+// parseAsOf reads ?as_of= as an RFC 3339 timestamp, or as a date meaning the
+// end of that day in UTC. It reports false when the parameter is not set.
+func parseAsOf(r *http.Request) (time.Time, bool, error) {
+	asOf, err := parseTimeParam(r, "as_of", true)
+	if err != nil || asOf == nil {
+		return time.Time{}, false, err
+	}
+	return *asOf, true, nil
+}
+
+// parseTimeParam reads a query parameter as an RFC 3339 timestamp, or as a
+// date meaning the start of that day in UTC, or its end when endOfDay is set.
+// It returns nil when the parameter is not set.
+func parseTimeParam(r *http.Request, key string, endOfDay bool) (*time.Time, error) {
+	value := r.URL.Query().Get(key)
+	if value == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err == nil {
+		return &t, nil
+	}
+
+	t, err = time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, err
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1).Add(-time.Microsecond)
+	}
+	return &t, nil
+}
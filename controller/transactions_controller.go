@@ -2,8 +2,10 @@ package controller
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/budsx/retail-management/middleware"
 	"github.com/budsx/retail-management/model"
@@ -25,6 +27,62 @@ func (c *Controller) CreateStockTransaction(w http.ResponseWriter, r *http.Reque
 
 	stockTransaction.CreatedBy = userID
 	err = c.service.CreateStockTransaction(r.Context(), stockTransaction)
+	if errors.Is(err, model.ErrLocationRequired) {
+		sendErrorResponse(w, http.StatusBadRequest, "location_id is required")
+		return
+	}
+	if errors.Is(err, model.ErrInvalidMovement) {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid stock movement")
+		return
+	}
+	if errors.Is(err, model.ErrLocationNotFound) {
+		sendErrorResponse(w, http.StatusNotFound, "Location not found")
+		return
+	}
+	if errors.Is(err, model.ErrProductNotFound) {
+		sendErrorResponse(w, http.StatusNotFound, "Product not found")
+		return
+	}
+	if errors.Is(err, model.ErrInsufficientStock) {
+		sendErrorResponse(w, http.StatusConflict, "Insufficient stock")
+		return
+	}
+	if errors.Is(err, model.ErrSerialNotAvailable) {
+		sendErrorResponse(w, http.StatusConflict, "Serial number not available")
+		return
+	}
+	if errors.Is(err, model.ErrInvalidReasonCode) {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid reason code")
+		return
+	}
+	if errors.Is(err, model.ErrUnknownUnit) {
+		sendErrorResponse(w, http.StatusBadRequest, "Unknown unit of measure")
+		return
+	}
+	if errors.Is(err, model.ErrInvalidQuantity) {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid quantity")
+		return
+	}
+	if errors.Is(err, model.ErrInvalidUnitCost) {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid unit cost")
+		return
+	}
+	if errors.Is(err, model.ErrVariantParent) {
+		sendErrorResponse(w, http.StatusBadRequest, "Stock is kept per variant")
+		return
+	}
+	if errors.Is(err, model.ErrInvalidBarcode) {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid barcode")
+		return
+	}
+	if errors.Is(err, model.ErrBarcodeNotFound) {
+		sendErrorResponse(w, http.StatusBadRequest, "Unknown barcode")
+		return
+	}
+	if errors.Is(err, model.ErrArchived) {
+		sendErrorResponse(w, http.StatusConflict, "Archived")
+		return
+	}
 	if err != nil {
 		sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
 		return
@@ -33,14 +91,94 @@ func (c *Controller) CreateStockTransaction(w http.ResponseWriter, r *http.Reque
 	sendSuccessResponse(w, http.StatusCreated, "Stock transaction created successfully")
 }
 
+// CreateStockTransactionBatch posts many lines in one go. A rejected batch is
+// answered with 422 and the error of every failing line.
+func (c *Controller) CreateStockTransactionBatch(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.ContextKeyUserID).(int64)
+
+	var batch model.StockTransactionBatch
+	err := json.NewDecoder(r.Body).Decode(&batch)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	for i := range batch.Lines {
+		batch.Lines[i].CreatedBy = userID
+	}
+
+	posted, err := c.service.CreateStockTransactionBatch(r.Context(), batch)
+	var batchErr *model.StockBatchError
+	if errors.As(err, &batchErr) {
+		sendErrorResponseWithDetails(w, http.StatusUnprocessableEntity, "Stock transaction batch rejected", batchErr.Lines)
+		return
+	}
+	if errors.Is(err, model.ErrInvalidBatchSize) {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid number of batch lines")
+		return
+	}
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	sendSuccessResponse(w, http.StatusCreated, posted)
+}
+
+func (c *Controller) ReceiveStockTransfer(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	idStr := vars["id"]
+	transactionID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid transaction ID")
+		return
+	}
+
+	err = c.service.ReceiveStockTransfer(r.Context(), transactionID)
+	if errors.Is(err, model.ErrTransferNotFound) {
+		sendErrorResponse(w, http.StatusNotFound, "Transfer not found")
+		return
+	}
+	if errors.Is(err, model.ErrTransferNotInTransit) {
+		sendErrorResponse(w, http.StatusConflict, "Transfer is not in transit")
+		return
+	}
+	if errors.Is(err, model.ErrArchived) {
+		sendErrorResponse(w, http.StatusConflict, "Archived")
+		return
+	}
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	sendSuccessResponse(w, http.StatusOK, "Stock transfer received successfully")
+}
+
+// GetStockTransactions lists the user's transactions newest first, limited to
+// the given reason codes when ?reason= is set (repeated or comma separated).
+// Pages follow ?cursor= and hold up to ?limit= transactions.
 func (c *Controller) GetStockTransactions(w http.ResponseWriter, r *http.Request) {
-	transactions, err := c.service.GetStockTransactions(r.Context())
+	var filter model.StockTransactionFilter
+	for _, reasons := range r.URL.Query()["reason"] {
+		for _, reason := range strings.Split(reasons, ",") {
+			if reason != "" {
+				filter.ReasonCodes = append(filter.ReasonCodes, reason)
+			}
+		}
+	}
+
+	transactions, cursors, err := c.service.GetStockTransactions(r.Context(), filter, parseCursorPagination(r))
+	if errors.Is(err, model.ErrInvalidPagination) {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid pagination")
+		return
+	}
 	if err != nil {
 		sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
 		return
 	}
 
-	sendSuccessResponse(w, http.StatusOK, transactions)
+	sendPagedResponse(w, http.StatusOK, transactions, cursors)
 }
 
 func (c *Controller) GetStockTransactionByID(w http.ResponseWriter, r *http.Request) {
@@ -53,6 +191,10 @@ func (c *Controller) GetStockTransactionByID(w http.ResponseWriter, r *http.Requ
 	}
 
 	transaction, err := c.service.GetStockTransactionByID(r.Context(), transactionID)
+	if errors.Is(err, model.ErrTransactionNotFound) {
+		sendErrorResponse(w, http.StatusNotFound, "Transaction not found")
+		return
+	}
 	if err != nil {
 		sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
 		return
@@ -76,6 +218,10 @@ func (c *Controller) DeleteLocationByUserID(w http.ResponseWriter, r *http.Reque
 	}
 
 	err = c.service.DeleteLocationByUserID(r.Context(), locationID)
+	if errors.Is(err, model.ErrLocationNotFound) {
+		sendErrorResponse(w, http.StatusNotFound, "Location not found")
+		return
+	}
 	if err != nil {
 		sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
 		return
@@ -83,3 +229,67 @@ func (c *Controller) DeleteLocationByUserID(w http.ResponseWriter, r *http.Reque
 
 	sendSuccessResponse(w, http.StatusOK, "Location deleted successfully")
 }
+
+func (c *Controller) RestoreLocationByUserID(w http.ResponseWriter, r *http.Request) {
+	locationID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid location ID")
+		return
+	}
+
+	err = c.service.RestoreLocationByUserID(r.Context(), locationID)
+	if errors.Is(err, model.ErrLocationNotFound) {
+		sendErrorResponse(w, http.StatusNotFound, "Location not found")
+		return
+	}
+	if errors.Is(err, model.ErrArchived) {
+		sendErrorResponse(w, http.StatusConflict, "Archived")
+		return
+	}
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	sendSuccessResponse(w, http.StatusOK, "Location restored successfully")
+}
+
+func (c *Controller) ReverseStockTransaction(w http.ResponseWriter, r *http.Request) {
+	transactionID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid transaction ID")
+		return
+	}
+
+	reversal, err := c.service.ReverseStockTransaction(r.Context(), transactionID)
+	if errors.Is(err, model.ErrTransactionNotFound) {
+		sendErrorResponse(w, http.StatusNotFound, "Transaction not found")
+		return
+	}
+	if errors.Is(err, model.ErrTransactionReversed) {
+		sendErrorResponse(w, http.StatusConflict, "Transaction already reversed")
+		return
+	}
+	if errors.Is(err, model.ErrTransactionNotReversible) {
+		sendErrorResponse(w, http.StatusConflict, "Transaction cannot be reversed")
+		return
+	}
+	if errors.Is(err, model.ErrInsufficientStock) {
+		sendErrorResponse(w, http.StatusConflict, "Insufficient stock")
+		return
+	}
+	if errors.Is(err, model.ErrSerialNotAvailable) {
+		sendErrorResponse(w, http.StatusConflict, "Serial number not available")
+		return
+	}
+	if errors.Is(err, model.ErrArchived) {
+		sendErrorResponse(w, http.StatusConflict, "Archived")
+		return
+	}
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	sendSuccessResponse(w, http.StatusCreated, reversal)
+}
@@ -0,0 +1,337 @@
+//go:build integration
+
+package controller
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/budsx/retail-management/middleware"
+	"github.com/budsx/retail-management/model"
+	"github.com/budsx/retail-management/repository"
+	"github.com/budsx/retail-management/services"
+	"github.com/budsx/retail-management/utils"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// These tests hammer the stock endpoints against a real, migrated Postgres.
+// Run them with `make test-integration`; they are skipped when DB_HOST is unset.
+// Fixtures use unique names so the suite can be re-run against the same database.
+
+type integrationServer struct {
+	server *httptest.Server
+	db     *sql.DB
+	token  string
+}
+
+type stockFixture struct {
+	productID   int64
+	warehouseID int64
+	locationIDs []int64
+}
+
+func newIntegrationServer(t *testing.T) *integrationServer {
+	t.Helper()
+
+	host := os.Getenv("DB_HOST")
+	if host == "" {
+		t.Skip("DB_HOST is not set, skipping integration test")
+	}
+	dbConfig := repository.DBConfig{
+		Host:     host,
+		User:     os.Getenv("DB_USER"),
+		Password: os.Getenv("DB_PASS"),
+		DBName:   os.Getenv("DB_NAME"),
+	}
+
+	repo, err := repository.NewRetailManagementRepository(repository.RepoConfig{DBConfig: dbConfig})
+	require.NoError(t, err)
+	t.Cleanup(repo.Close)
+
+	db, err := sql.Open("postgres", fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
+		dbConfig.Host, 5432, dbConfig.User, dbConfig.Password, dbConfig.DBName))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	controller := NewRetailManagementController(services.NewRetailManagementService(*repo, utils.NewLogger("error"), services.ServiceConfig{
+		ReservationTTL: time.Minute,
+	}))
+
+	r := mux.NewRouter()
+	private := r.PathPrefix("/v1").Subrouter()
+	private.Use(middleware.TokenValidationMiddleware)
+	private.HandleFunc("/stock-transactions", controller.CreateStockTransaction).Methods("POST")
+
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+
+	token, err := utils.GenerateJWT(1, "integration")
+	require.NoError(t, err)
+
+	return &integrationServer{server: server, db: db, token: token}
+}
+
+// seedStock creates a product and a warehouse with one location per balance.
+func (s *integrationServer) seedStock(t *testing.T, balances ...int64) stockFixture {
+	t.Helper()
+
+	suffix := time.Now().UnixNano()
+	var fixture stockFixture
+
+	err := s.db.QueryRow(`INSERT INTO mst_product (product_name, description, price, sku) VALUES ($1, '', 1000, $2) RETURNING product_id`,
+		fmt.Sprintf("Integration %d", suffix), fmt.Sprintf("INT-%d", suffix)).Scan(&fixture.productID)
+	require.NoError(t, err)
+
+	err = s.db.QueryRow(`INSERT INTO mst_warehouse (warehouse_name) VALUES ($1) RETURNING warehouse_id`,
+		fmt.Sprintf("Gudang Integration %d", suffix)).Scan(&fixture.warehouseID)
+	require.NoError(t, err)
+
+	for i, balance := range balances {
+		var locationID int64
+		err = s.db.QueryRow(`INSERT INTO mst_location (location_name, warehouse_id) VALUES ($1, $2) RETURNING location_id`,
+			fmt.Sprintf("Rak %d-%d", suffix, i), fixture.warehouseID).Scan(&locationID)
+		require.NoError(t, err)
+
+		_, err = s.db.Exec(`INSERT INTO mst_stock (product_id, warehouse_id, location_id, stock_quantity) VALUES ($1, $2, $3, $4)`,
+			fixture.productID, fixture.warehouseID, locationID, balance)
+		require.NoError(t, err)
+
+		fixture.locationIDs = append(fixture.locationIDs, locationID)
+	}
+
+	return fixture
+}
+
+// addLocation creates an empty location in the fixture's warehouse.
+func (s *integrationServer) addLocation(t *testing.T, fixture stockFixture) int64 {
+	t.Helper()
+
+	var locationID int64
+	err := s.db.QueryRow(`INSERT INTO mst_location (location_name, warehouse_id) VALUES ($1, $2) RETURNING location_id`,
+		fmt.Sprintf("Rak %d", time.Now().UnixNano()), fixture.warehouseID).Scan(&locationID)
+	require.NoError(t, err)
+	return locationID
+}
+
+func (s *integrationServer) postStockTransaction(t *testing.T, transaction model.StockTransaction) int {
+	payload, err := json.Marshal(transaction)
+	if err != nil {
+		t.Error(err)
+		return 0
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.server.URL+"/v1/stock-transactions", bytes.NewReader(payload))
+	if err != nil {
+		t.Error(err)
+		return 0
+	}
+	req.Header.Set("Authorization", "Bearer "+s.token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Error(err)
+		return 0
+	}
+	defer resp.Body.Close()
+
+	return resp.StatusCode
+}
+
+func (s *integrationServer) locationBalance(t *testing.T, productID, locationID int64) int64 {
+	t.Helper()
+
+	var balance int64
+	err := s.db.QueryRow(`SELECT stock_quantity FROM mst_stock WHERE product_id = $1 AND location_id = $2`, productID, locationID).Scan(&balance)
+	require.NoError(t, err)
+	return balance
+}
+
+func TestIntegration_CreateStockTransaction_ConcurrentOutDoesNotOversell(t *testing.T) {
+	s := newIntegrationServer(t)
+
+	const (
+		initialStock = 50
+		perRequest   = 3
+		workers      = 40
+	)
+	fixture := s.seedStock(t, initialStock)
+	locationID := fixture.locationIDs[0]
+
+	var created, rejected int64
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+
+			status := s.postStockTransaction(t, model.StockTransaction{
+				ProductID:       fixture.productID,
+				WarehouseID:     fixture.warehouseID,
+				LocationID:      locationID,
+				TransactionType: model.StockOut,
+				Quantity:        perRequest,
+			})
+			switch status {
+			case http.StatusCreated:
+				atomic.AddInt64(&created, 1)
+			case http.StatusConflict:
+				atomic.AddInt64(&rejected, 1)
+			default:
+				t.Errorf("unexpected status %d", status)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	assert.Equal(t, int64(initialStock/perRequest), created)
+	assert.Equal(t, int64(workers-initialStock/perRequest), rejected)
+	assert.Equal(t, int64(initialStock-created*perRequest), s.locationBalance(t, fixture.productID, locationID))
+
+	var postings int64
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM trx_stock WHERE product_id = $1`, fixture.productID).Scan(&postings)
+	require.NoError(t, err)
+	assert.Equal(t, created, postings)
+}
+
+func TestIntegration_CreateStockTransaction_ConcurrentInAndOut(t *testing.T) {
+	s := newIntegrationServer(t)
+
+	const (
+		initialStock = 20
+		workers      = 30
+	)
+	fixture := s.seedStock(t, initialStock)
+	locationID := fixture.locationIDs[0]
+
+	var received, shipped int64
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+
+			transaction := model.StockTransaction{
+				ProductID:       fixture.productID,
+				WarehouseID:     fixture.warehouseID,
+				LocationID:      locationID,
+				TransactionType: model.StockOut,
+				Quantity:        4,
+			}
+			if i%2 == 0 {
+				transaction.TransactionType = model.StockIn
+				transaction.Quantity = 2
+			}
+
+			status := s.postStockTransaction(t, transaction)
+			switch {
+			case status == http.StatusCreated && transaction.TransactionType == model.StockIn:
+				atomic.AddInt64(&received, transaction.Quantity)
+			case status == http.StatusCreated:
+				atomic.AddInt64(&shipped, transaction.Quantity)
+			case status != http.StatusConflict:
+				t.Errorf("unexpected status %d", status)
+			}
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	balance := s.locationBalance(t, fixture.productID, locationID)
+	assert.GreaterOrEqual(t, balance, int64(0))
+	assert.Equal(t, initialStock+received-shipped, balance)
+}
+
+func TestIntegration_CreateStockTransaction_OpposingTransfers(t *testing.T) {
+	s := newIntegrationServer(t)
+
+	const (
+		initialStock = 100
+		workers      = 20
+	)
+	fixture := s.seedStock(t, initialStock, initialStock)
+	first, second := fixture.locationIDs[0], fixture.locationIDs[1]
+
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+
+			source, destination := first, second
+			if i%2 == 0 {
+				source, destination = second, first
+			}
+
+			status := s.postStockTransaction(t, model.StockTransaction{
+				ProductID:             fixture.productID,
+				LocationID:            source,
+				DestinationLocationID: destination,
+				TransactionType:       model.StockTransfer,
+				Quantity:              5,
+			})
+			assert.Equal(t, http.StatusCreated, status)
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	assert.Equal(t, int64(initialStock), s.locationBalance(t, fixture.productID, first))
+	assert.Equal(t, int64(initialStock), s.locationBalance(t, fixture.productID, second))
+}
+
+func TestIntegration_CreateStockTransaction_FirstReceiptAndEmptying(t *testing.T) {
+	s := newIntegrationServer(t)
+
+	fixture := s.seedStock(t)
+	locationID := s.addLocation(t, fixture)
+
+	const workers = 10
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+
+			status := s.postStockTransaction(t, model.StockTransaction{
+				ProductID:       fixture.productID,
+				LocationID:      locationID,
+				TransactionType: model.StockIn,
+				Quantity:        3,
+			})
+			assert.Equal(t, http.StatusCreated, status)
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	assert.Equal(t, int64(workers*3), s.locationBalance(t, fixture.productID, locationID))
+
+	status := s.postStockTransaction(t, model.StockTransaction{
+		ProductID:       fixture.productID,
+		LocationID:      locationID,
+		TransactionType: model.StockOut,
+		Quantity:        workers * 3,
+	})
+	assert.Equal(t, http.StatusCreated, status)
+	assert.Equal(t, int64(0), s.locationBalance(t, fixture.productID, locationID))
+}
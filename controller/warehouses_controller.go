@@ -2,6 +2,7 @@ package controller
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -18,6 +19,10 @@ func (c *Controller) AddWarehouseByUserID(w http.ResponseWriter, r *http.Request
 	}
 
 	err = c.service.AddWarehouseByUserID(r.Context(), warehouse)
+	if errors.Is(err, model.ErrInvalidCostingMethod) {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid costing method")
+		return
+	}
 	if err != nil {
 		sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
 		return
@@ -26,14 +31,59 @@ func (c *Controller) AddWarehouseByUserID(w http.ResponseWriter, r *http.Request
 	sendSuccessResponse(w, http.StatusCreated, "Warehouse added successfully")
 }
 
+// GetWarehousesByUserID lists the user's warehouses, following ?cursor= up to
+// ?limit= at a time. Archived warehouses are listed with ?include_archived=true.
 func (c *Controller) GetWarehousesByUserID(w http.ResponseWriter, r *http.Request) {
-	warehouses, err := c.service.GetWarehouseByUserID(r.Context())
+	includeArchived, err := parseIncludeArchived(r)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid include_archived")
+		return
+	}
+
+	warehouses, cursors, err := c.service.GetWarehouseByUserID(r.Context(), includeArchived, parseCursorPagination(r))
+	if errors.Is(err, model.ErrInvalidPagination) {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid pagination")
+		return
+	}
 	if err != nil {
 		sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
 		return
 	}
 
-	sendSuccessResponse(w, http.StatusOK, warehouses)
+	sendPagedResponse(w, http.StatusOK, warehouses, cursors)
+}
+
+// GetWarehouseLocations lists the locations of a warehouse, following ?cursor=
+// up to ?limit= at a time. Archived locations are listed with
+// ?include_archived=true.
+func (c *Controller) GetWarehouseLocations(w http.ResponseWriter, r *http.Request) {
+	warehouseID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid warehouse ID")
+		return
+	}
+
+	includeArchived, err := parseIncludeArchived(r)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid include_archived")
+		return
+	}
+
+	locations, cursors, err := c.service.GetLocationsByWarehouse(r.Context(), warehouseID, includeArchived, parseCursorPagination(r))
+	if errors.Is(err, model.ErrWarehouseNotFound) {
+		sendErrorResponse(w, http.StatusNotFound, "Warehouse not found")
+		return
+	}
+	if errors.Is(err, model.ErrInvalidPagination) {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid pagination")
+		return
+	}
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	sendPagedResponse(w, http.StatusOK, locations, cursors)
 }
 
 func (c *Controller) EditWarehouseByUserID(w http.ResponseWriter, r *http.Request) {
@@ -59,6 +109,14 @@ func (c *Controller) EditWarehouseByUserID(w http.ResponseWriter, r *http.Reques
 
 	warehouse.WarehouseID = warehouseID
 	err = c.service.EditWarehouseByUserID(r.Context(), warehouse)
+	if errors.Is(err, model.ErrInvalidCostingMethod) {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid costing method")
+		return
+	}
+	if errors.Is(err, model.ErrCostingMethodLocked) {
+		sendErrorResponse(w, http.StatusConflict, "Costing method cannot change while the warehouse holds stock")
+		return
+	}
 	if err != nil {
 		sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
 		return
@@ -66,3 +124,73 @@ func (c *Controller) EditWarehouseByUserID(w http.ResponseWriter, r *http.Reques
 
 	sendSuccessResponse(w, http.StatusOK, "Warehouse updated successfully")
 }
+
+// DeleteWarehouseByUserID archives a warehouse and its locations.
+func (c *Controller) DeleteWarehouseByUserID(w http.ResponseWriter, r *http.Request) {
+	warehouseID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid warehouse ID")
+		return
+	}
+
+	err = c.service.DeleteWarehouseByUserID(r.Context(), warehouseID)
+	if errors.Is(err, model.ErrWarehouseNotFound) {
+		sendErrorResponse(w, http.StatusNotFound, "Warehouse not found")
+		return
+	}
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	sendSuccessResponse(w, http.StatusOK, "Warehouse deleted successfully")
+}
+
+// RestoreWarehouseByUserID brings back an archived warehouse and the locations
+// archived with it.
+func (c *Controller) RestoreWarehouseByUserID(w http.ResponseWriter, r *http.Request) {
+	warehouseID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid warehouse ID")
+		return
+	}
+
+	err = c.service.RestoreWarehouseByUserID(r.Context(), warehouseID)
+	if errors.Is(err, model.ErrWarehouseNotFound) {
+		sendErrorResponse(w, http.StatusNotFound, "Warehouse not found")
+		return
+	}
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	sendSuccessResponse(w, http.StatusOK, "Warehouse restored successfully")
+}
+
+// GetInventoryValuation values a warehouse's stock, as of ?as_of= when given.
+func (c *Controller) GetInventoryValuation(w http.ResponseWriter, r *http.Request) {
+	warehouseID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid warehouse ID")
+		return
+	}
+
+	asOf, _, err := parseAsOf(r)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid as_of")
+		return
+	}
+
+	valuation, err := c.service.GetInventoryValuation(r.Context(), warehouseID, asOf)
+	if errors.Is(err, model.ErrWarehouseNotFound) {
+		sendErrorResponse(w, http.StatusNotFound, "Warehouse not found")
+		return
+	}
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	sendSuccessResponse(w, http.StatusOK, valuation)
+}
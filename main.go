@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
@@ -10,6 +11,7 @@ import (
 	"github.com/budsx/retail-management/controller"
 	"github.com/budsx/retail-management/middleware"
 	"github.com/budsx/retail-management/repository"
+	"github.com/budsx/retail-management/repository/notifier"
 	"github.com/budsx/retail-management/services"
 	"github.com/budsx/retail-management/utils"
 	"github.com/gorilla/mux"
@@ -37,7 +39,23 @@ func main() {
 	}
 	defer repo.Close()
 
-	service := services.NewRetailManagementService(*repo, logger)
+	repo.Notifier, err = notifier.New(notifier.Config{
+		Kind:       conf.LowStock.Notifier,
+		WebhookURL: conf.LowStock.WebhookURL,
+		EmailTo:    conf.LowStock.EmailTo,
+		Timeout:    conf.LowStock.NotifyTimeout,
+	}, logger)
+	if err != nil {
+		log.Println(err.Error())
+		return
+	}
+
+	service := services.NewRetailManagementService(*repo, logger, services.ServiceConfig{
+		ReservationTTL:    conf.Reservation.TTL,
+		AdjustmentReasons: conf.Adjustment.ReasonCodes,
+		IdempotencyTTL:    conf.Idempotency.TTL,
+		IdempotencyLease:  conf.Idempotency.Lease,
+	})
 	controller := controller.NewRetailManagementController(service)
 
 	r := mux.NewRouter()
@@ -56,27 +74,100 @@ func main() {
 
 	// Product
 	private.HandleFunc("/product/{id}", controller.GetProductByID).Methods("GET")
-	private.HandleFunc("/product", controller.AddProduct).Methods("POST")
+	private.HandleFunc("/product", controller.WithIdempotency(controller.AddProduct)).Methods("POST")
 	private.HandleFunc("/product/{id}", controller.EditProduct).Methods("PUT")
+	private.HandleFunc("/product/{id}", controller.DeleteProduct).Methods("DELETE")
+	private.HandleFunc("/product/{id}/restore", controller.RestoreProduct).Methods("POST")
 	private.HandleFunc("/products", controller.GetProducts).Methods("GET")
+	private.HandleFunc("/product/{id}/units/{unit}", controller.SetProductUnit).Methods("PUT")
+	private.HandleFunc("/product/{id}/units/{unit}", controller.DeleteProductUnit).Methods("DELETE")
+	private.HandleFunc("/product/{id}/barcodes", controller.AddProductBarcode).Methods("POST")
+	private.HandleFunc("/product/{id}/barcodes/{barcode}", controller.DeleteProductBarcode).Methods("DELETE")
+	private.HandleFunc("/product/{id}/prices", controller.GetPriceHistory).Methods("GET")
+	private.HandleFunc("/product/{id}/prices", controller.WithIdempotency(controller.SchedulePriceChange)).Methods("POST")
+	private.HandleFunc("/product/{id}/prices/{price_id}", controller.CancelPriceChange).Methods("DELETE")
+	private.HandleFunc("/product/{id}/price", controller.GetPriceAt).Methods("GET")
+	private.HandleFunc("/product/{id}/components", controller.SetBundleComponents).Methods("PUT")
+	private.HandleFunc("/product/{id}/availability", controller.GetBundleAvailability).Methods("GET")
+	private.HandleFunc("/product/{id}/assemble", controller.WithIdempotency(controller.AssembleBundle)).Methods("POST")
+
+	// Category
+	private.HandleFunc("/category", controller.WithIdempotency(controller.AddCategory)).Methods("POST")
+	private.HandleFunc("/categories", controller.GetCategories).Methods("GET")
+	private.HandleFunc("/category/{id}", controller.GetCategoryByID).Methods("GET")
+	private.HandleFunc("/category/{id}", controller.EditCategory).Methods("PUT")
+	private.HandleFunc("/category/{id}", controller.DeleteCategory).Methods("DELETE")
 
 	// Warehouse
-	private.HandleFunc("/warehouse", controller.AddWarehouseByUserID).Methods("POST")
+	private.HandleFunc("/warehouse", controller.WithIdempotency(controller.AddWarehouseByUserID)).Methods("POST")
 	private.HandleFunc("/warehouse/{id}", controller.EditWarehouseByUserID).Methods("PUT")
+	private.HandleFunc("/warehouse/{id}", controller.DeleteWarehouseByUserID).Methods("DELETE")
+	private.HandleFunc("/warehouse/{id}/restore", controller.RestoreWarehouseByUserID).Methods("POST")
 	private.HandleFunc("/warehouses", controller.GetWarehousesByUserID).Methods("GET")
+	private.HandleFunc("/warehouse/{id}/locations", controller.GetWarehouseLocations).Methods("GET")
+	private.HandleFunc("/warehouse/{id}/expiring-lots", controller.GetExpiringLots).Methods("GET")
+	private.HandleFunc("/warehouse/{id}/valuation", controller.GetInventoryValuation).Methods("GET")
+	private.HandleFunc("/warehouse/{id}/reorder-points", controller.GetReorderPoints).Methods("GET")
+	private.HandleFunc("/warehouse/{id}/reorder-points/{product_id}", controller.SetReorderPoint).Methods("PUT")
+	private.HandleFunc("/warehouse/{id}/reorder-points/{product_id}", controller.DeleteReorderPoint).Methods("DELETE")
+	private.HandleFunc("/low-stock", controller.GetLowStockItems).Methods("GET")
 
 	// Location
-	private.HandleFunc("/location", controller.AddLocation).Methods("POST")
+	private.HandleFunc("/location", controller.WithIdempotency(controller.AddLocation)).Methods("POST")
 	private.HandleFunc("/location/{id}", controller.EditLocationByUserID).Methods("PUT")
 	private.HandleFunc("/location/{id}", controller.DeleteLocationByUserID).Methods("DELETE")
+	private.HandleFunc("/location/{id}/restore", controller.RestoreLocationByUserID).Methods("POST")
 
 	// Stock
-	private.HandleFunc("/stock-transactions", controller.CreateStockTransaction).Methods("POST")
+	private.HandleFunc("/stock-transactions", controller.WithIdempotency(controller.CreateStockTransaction)).Methods("POST")
 	private.HandleFunc("/stock-transactions", controller.GetStockTransactions).Methods("GET")
+	private.HandleFunc("/stock-transactions/batch", controller.WithIdempotency(controller.CreateStockTransactionBatch)).Methods("POST")
 	private.HandleFunc("/stock-transactions/{id}", controller.GetStockTransactionByID).Methods("GET")
+	private.HandleFunc("/stock-transactions/{id}/receive", controller.ReceiveStockTransfer).Methods("POST")
+	private.HandleFunc("/stock-transactions/{id}/reverse", controller.ReverseStockTransaction).Methods("POST")
 	private.HandleFunc("/total-stocks", controller.GetTotalStocks).Methods("GET")
 	private.HandleFunc("/total-stock/{location_id}", controller.GetTotalStockByLocation).Methods("GET")
 
+	// Stock Take
+	private.HandleFunc("/stock-takes", controller.OpenStockTake).Methods("POST")
+	private.HandleFunc("/stock-takes/{id}", controller.GetStockTakeByID).Methods("GET")
+	private.HandleFunc("/stock-takes/{id}/counts", controller.RecordStockTakeCounts).Methods("POST")
+	private.HandleFunc("/stock-takes/{id}/variances", controller.GetStockTakeVariances).Methods("GET")
+	private.HandleFunc("/stock-takes/{id}/approve", controller.ApproveStockTake).Methods("POST")
+	private.HandleFunc("/stock-takes/{id}/cancel", controller.CancelStockTake).Methods("POST")
+
+	// Barcode
+	private.HandleFunc("/barcode/{barcode}", controller.LookupBarcode).Methods("GET")
+
+	// Serial
+	private.HandleFunc("/serials/{serial_number}", controller.GetSerialHistory).Methods("GET")
+
+	// Reservation
+	private.HandleFunc("/reservations", controller.CreateReservation).Methods("POST")
+	private.HandleFunc("/reservations/{id}", controller.GetReservationByID).Methods("GET")
+	private.HandleFunc("/reservations/{id}/confirm", controller.ConfirmReservation).Methods("POST")
+	private.HandleFunc("/reservations/{id}/release", controller.ReleaseReservation).Methods("POST")
+
+	// Background Jobs
+	jobCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	go utils.RunPeriodically(jobCtx, conf.Reservation.ExpiryInterval, func(ctx context.Context) {
+		service.ExpireReservations(ctx)
+	})
+	go utils.RunPeriodically(jobCtx, conf.Idempotency.CleanupInterval, func(ctx context.Context) {
+		service.ExpireIdempotencyKeys(ctx)
+	})
+	go utils.RunPeriodically(jobCtx, conf.Snapshot.Interval, func(ctx context.Context) {
+		service.CreateStockSnapshot(ctx)
+	})
+	go service.RunLowStockChecker(jobCtx)
+	go utils.RunPeriodically(jobCtx, conf.LowStock.CheckInterval, func(ctx context.Context) {
+		service.CheckLowStock(ctx, 0, 0)
+	})
+	go utils.RunPeriodically(jobCtx, conf.Pricing.ScheduleInterval, func(ctx context.Context) {
+		service.ApplyScheduledPrices(ctx)
+	})
+
 	// Run Server
 	srv := &http.Server{
 		Handler:      r,
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS "trx_stock_location_id_idx";
+DROP INDEX IF EXISTS "mst_stock_product_location_key";
+ALTER TABLE "trx_stock" DROP COLUMN IF EXISTS "location_id";
+ALTER TABLE "mst_stock" DROP COLUMN IF EXISTS "location_id";
@@ -0,0 +1,24 @@
+BEGIN;
+
+-- Stock is tracked per location (bin/rack) and rolled up to its warehouse
+ALTER TABLE mst_stock ADD COLUMN location_id INT REFERENCES mst_location(location_id);
+ALTER TABLE trx_stock ADD COLUMN location_id INT REFERENCES mst_location(location_id);
+
+-- Warehouses without a location get a default one to hold their existing stock
+INSERT INTO mst_location (location_name, warehouse_id)
+SELECT 'Default', w.warehouse_id FROM mst_warehouse w
+WHERE NOT EXISTS (SELECT 1 FROM mst_location l WHERE l.warehouse_id = w.warehouse_id);
+
+-- Existing balances and movements are assigned to the first location of their warehouse
+UPDATE mst_stock s SET location_id = (
+    SELECT MIN(l.location_id) FROM mst_location l WHERE l.warehouse_id = s.warehouse_id
+);
+
+UPDATE trx_stock t SET location_id = (
+    SELECT MIN(l.location_id) FROM mst_location l WHERE l.warehouse_id = t.warehouse_id
+);
+
+CREATE UNIQUE INDEX mst_stock_product_location_key ON mst_stock (product_id, location_id);
+CREATE INDEX trx_stock_location_id_idx ON trx_stock (location_id);
+
+COMMIT;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS "trx_stock_in_transit_idx";
+ALTER TABLE "trx_stock" DROP COLUMN IF EXISTS "reference_id";
+ALTER TABLE "trx_stock" DROP COLUMN IF EXISTS "status";
+ALTER TABLE "trx_stock" DROP COLUMN IF EXISTS "destination_location_id";
+ALTER TABLE "trx_stock" DROP COLUMN IF EXISTS "destination_warehouse_id";
@@ -0,0 +1,12 @@
+BEGIN;
+
+-- Transfers record their destination on the source row; the destination row points back via reference_id
+ALTER TABLE trx_stock
+    ADD COLUMN destination_warehouse_id INT REFERENCES mst_warehouse(warehouse_id),
+    ADD COLUMN destination_location_id INT REFERENCES mst_location(location_id),
+    ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'COMPLETED',
+    ADD COLUMN reference_id INT REFERENCES trx_stock(transaction_id);
+
+CREATE INDEX trx_stock_in_transit_idx ON trx_stock (transaction_id) WHERE status = 'IN_TRANSIT';
+
+COMMIT;
@@ -0,0 +1,7 @@
+DROP TRIGGER IF EXISTS "trx_stock_append_only" ON "trx_stock";
+DROP FUNCTION IF EXISTS prevent_trx_stock_mutation();
+DROP INDEX IF EXISTS "trx_stock_product_location_idx";
+DELETE FROM "trx_stock" WHERE transaction_type = 'OPENING';
+UPDATE "trx_stock" SET quantity = balance_after WHERE transaction_type IN ('IN', 'OUT');
+UPDATE "trx_stock" SET quantity = ABS(quantity) WHERE transaction_type = 'TRANSFER';
+ALTER TABLE "trx_stock" DROP COLUMN IF EXISTS "balance_after";
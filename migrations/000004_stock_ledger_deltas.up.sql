@@ -0,0 +1,85 @@
+BEGIN;
+
+-- trx_stock becomes an append-only ledger: quantity is the signed movement and
+-- balance_after is the location balance once the movement is applied
+ALTER TABLE trx_stock ADD COLUMN balance_after INT;
+
+-- IN/OUT rows used to store the resulting balance in quantity, while transfer rows
+-- stored the moved quantity. Replay every product/location series in posting order
+-- to turn both into signed deltas. The first IN/OUT row of a series has no known
+-- previous balance, so any opening stock it contained is treated as part of it.
+DO $$
+DECLARE
+    r RECORD;
+    running BIGINT := 0;
+    current_product INT;
+    current_location INT;
+    delta BIGINT;
+BEGIN
+    FOR r IN
+        SELECT transaction_id, product_id, location_id, transaction_type, quantity, reference_id
+        FROM trx_stock
+        ORDER BY product_id, location_id, transaction_id
+    LOOP
+        IF r.product_id IS DISTINCT FROM current_product OR r.location_id IS DISTINCT FROM current_location THEN
+            running := 0;
+            current_product := r.product_id;
+            current_location := r.location_id;
+        END IF;
+
+        IF r.transaction_type = 'TRANSFER' THEN
+            -- The shipping leg has no reference_id, the receiving leg points at it
+            delta := CASE WHEN r.reference_id IS NULL THEN -r.quantity ELSE r.quantity END;
+        ELSE
+            delta := r.quantity - running;
+        END IF;
+
+        running := running + delta;
+        UPDATE trx_stock SET quantity = delta, balance_after = running WHERE transaction_id = r.transaction_id;
+    END LOOP;
+END $$;
+
+-- Balances that were seeded straight into mst_stock never went through the ledger;
+-- post the difference as an opening entry so the ledger sums to mst_stock
+INSERT INTO trx_stock (product_id, warehouse_id, location_id, transaction_type, quantity, balance_after)
+SELECT s.product_id, s.warehouse_id, s.location_id, 'OPENING', s.stock_quantity - COALESCE(l.total, 0), s.stock_quantity
+FROM mst_stock s
+LEFT JOIN (
+    SELECT product_id, location_id, SUM(quantity) AS total
+    FROM trx_stock
+    GROUP BY product_id, location_id
+) l ON l.product_id = s.product_id AND l.location_id = s.location_id
+WHERE s.stock_quantity <> COALESCE(l.total, 0);
+
+ALTER TABLE trx_stock ALTER COLUMN balance_after SET NOT NULL;
+
+CREATE INDEX trx_stock_product_location_idx ON trx_stock (product_id, location_id, transaction_id);
+
+-- Posted movements are immutable; only workflow columns such as status may change
+CREATE OR REPLACE FUNCTION prevent_trx_stock_mutation()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        RAISE EXCEPTION 'trx_stock is an append-only ledger';
+    END IF;
+
+    IF NEW.product_id IS DISTINCT FROM OLD.product_id
+        OR NEW.warehouse_id IS DISTINCT FROM OLD.warehouse_id
+        OR NEW.location_id IS DISTINCT FROM OLD.location_id
+        OR NEW.transaction_type IS DISTINCT FROM OLD.transaction_type
+        OR NEW.quantity IS DISTINCT FROM OLD.quantity
+        OR NEW.balance_after IS DISTINCT FROM OLD.balance_after
+        OR NEW.transaction_date IS DISTINCT FROM OLD.transaction_date THEN
+        RAISE EXCEPTION 'trx_stock movement % cannot be changed once posted', OLD.transaction_id;
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trx_stock_append_only
+BEFORE UPDATE OR DELETE ON trx_stock
+FOR EACH ROW
+EXECUTE FUNCTION prevent_trx_stock_mutation();
+
+COMMIT;
@@ -0,0 +1,4 @@
+ALTER TABLE "mst_stock" ALTER COLUMN "stock_quantity" DROP DEFAULT;
+DELETE FROM "mst_stock" WHERE stock_quantity = 0;
+ALTER TABLE "mst_stock" DROP CONSTRAINT IF EXISTS "mst_stock_stock_quantity_check";
+ALTER TABLE "mst_stock" ADD CONSTRAINT "mst_stock_stock_quantity_check" CHECK (stock_quantity > 0);
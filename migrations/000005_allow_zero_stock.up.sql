@@ -0,0 +1,8 @@
+BEGIN;
+
+-- A location may be emptied completely; rows are created with a zero balance on first receipt
+ALTER TABLE mst_stock DROP CONSTRAINT IF EXISTS mst_stock_stock_quantity_check;
+ALTER TABLE mst_stock ADD CONSTRAINT mst_stock_stock_quantity_check CHECK (stock_quantity >= 0);
+ALTER TABLE mst_stock ALTER COLUMN stock_quantity SET DEFAULT 0;
+
+COMMIT;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "trx_reservation";
+ALTER TABLE "mst_stock" DROP CONSTRAINT IF EXISTS "mst_stock_reserved_quantity_check";
+ALTER TABLE "mst_stock" DROP COLUMN IF EXISTS "reserved_quantity";
@@ -0,0 +1,34 @@
+BEGIN;
+
+-- Stock held for orders that have not been picked yet
+ALTER TABLE mst_stock ADD COLUMN reserved_quantity INT NOT NULL DEFAULT 0;
+ALTER TABLE mst_stock ADD CONSTRAINT mst_stock_reserved_quantity_check CHECK (reserved_quantity >= 0 AND reserved_quantity <= stock_quantity);
+
+-- Reservations
+CREATE TABLE trx_reservation (
+    reservation_id SERIAL PRIMARY KEY,
+    product_id INT NOT NULL,
+    warehouse_id INT NOT NULL,
+    location_id INT NOT NULL,
+    quantity INT NOT NULL CHECK (quantity > 0),
+    status VARCHAR(20) NOT NULL DEFAULT 'ACTIVE', -- ACTIVE, CONFIRMED, RELEASED or EXPIRED
+    reference VARCHAR(255),                       -- Order number in the calling system
+    expires_at TIMESTAMP NOT NULL,
+    transaction_id INT,                           -- OUT movement posted on confirmation
+    created_by INT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (product_id) REFERENCES mst_product(product_id),
+    FOREIGN KEY (warehouse_id) REFERENCES mst_warehouse(warehouse_id),
+    FOREIGN KEY (location_id) REFERENCES mst_location(location_id),
+    FOREIGN KEY (transaction_id) REFERENCES trx_stock(transaction_id)
+);
+
+CREATE INDEX trx_reservation_active_expires_at_idx ON trx_reservation (expires_at) WHERE status = 'ACTIVE';
+
+CREATE TRIGGER update_trx_reservation_updated_at
+BEFORE UPDATE ON trx_reservation
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
+
+COMMIT;
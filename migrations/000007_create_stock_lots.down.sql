@@ -0,0 +1,24 @@
+CREATE OR REPLACE FUNCTION prevent_trx_stock_mutation()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        RAISE EXCEPTION 'trx_stock is an append-only ledger';
+    END IF;
+
+    IF NEW.product_id IS DISTINCT FROM OLD.product_id
+        OR NEW.warehouse_id IS DISTINCT FROM OLD.warehouse_id
+        OR NEW.location_id IS DISTINCT FROM OLD.location_id
+        OR NEW.transaction_type IS DISTINCT FROM OLD.transaction_type
+        OR NEW.quantity IS DISTINCT FROM OLD.quantity
+        OR NEW.balance_after IS DISTINCT FROM OLD.balance_after
+        OR NEW.transaction_date IS DISTINCT FROM OLD.transaction_date THEN
+        RAISE EXCEPTION 'trx_stock movement % cannot be changed once posted', OLD.transaction_id;
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TABLE IF EXISTS "mst_stock_lot";
+ALTER TABLE "trx_stock" DROP COLUMN IF EXISTS "expiry_date";
+ALTER TABLE "trx_stock" DROP COLUMN IF EXISTS "lot_number";
@@ -0,0 +1,56 @@
+BEGIN;
+
+-- Lot number and best-before date of each movement
+ALTER TABLE trx_stock ADD COLUMN lot_number VARCHAR(50);
+ALTER TABLE trx_stock ADD COLUMN expiry_date DATE;
+
+-- Lot balances per location. Stock received without a lot number is the part
+-- of mst_stock.stock_quantity that is not covered by any lot.
+CREATE TABLE mst_stock_lot (
+    lot_id SERIAL PRIMARY KEY,
+    product_id INT NOT NULL,
+    warehouse_id INT NOT NULL,
+    location_id INT NOT NULL,
+    lot_number VARCHAR(50) NOT NULL,
+    expiry_date DATE,
+    quantity INT NOT NULL DEFAULT 0 CHECK (quantity >= 0),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (product_id) REFERENCES mst_product(product_id),
+    FOREIGN KEY (warehouse_id) REFERENCES mst_warehouse(warehouse_id),
+    FOREIGN KEY (location_id) REFERENCES mst_location(location_id)
+);
+
+CREATE UNIQUE INDEX mst_stock_lot_product_location_lot_key ON mst_stock_lot (product_id, location_id, lot_number);
+CREATE INDEX mst_stock_lot_warehouse_expiry_idx ON mst_stock_lot (warehouse_id, expiry_date) WHERE quantity > 0;
+
+CREATE TRIGGER update_mst_stock_lot_updated_at
+BEFORE UPDATE ON mst_stock_lot
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
+
+-- Lot details are part of the posted movement
+CREATE OR REPLACE FUNCTION prevent_trx_stock_mutation()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        RAISE EXCEPTION 'trx_stock is an append-only ledger';
+    END IF;
+
+    IF NEW.product_id IS DISTINCT FROM OLD.product_id
+        OR NEW.warehouse_id IS DISTINCT FROM OLD.warehouse_id
+        OR NEW.location_id IS DISTINCT FROM OLD.location_id
+        OR NEW.transaction_type IS DISTINCT FROM OLD.transaction_type
+        OR NEW.quantity IS DISTINCT FROM OLD.quantity
+        OR NEW.balance_after IS DISTINCT FROM OLD.balance_after
+        OR NEW.transaction_date IS DISTINCT FROM OLD.transaction_date
+        OR NEW.lot_number IS DISTINCT FROM OLD.lot_number
+        OR NEW.expiry_date IS DISTINCT FROM OLD.expiry_date THEN
+        RAISE EXCEPTION 'trx_stock movement % cannot be changed once posted', OLD.transaction_id;
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+COMMIT;
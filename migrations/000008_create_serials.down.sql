@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "trx_stock_serial";
+DROP TABLE IF EXISTS "mst_serial";
+ALTER TABLE "mst_product" DROP COLUMN IF EXISTS "is_serialized";
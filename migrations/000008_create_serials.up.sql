@@ -0,0 +1,41 @@
+BEGIN;
+
+-- Products tracked per unit
+ALTER TABLE mst_product ADD COLUMN is_serialized BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Serialized units and where they currently are
+CREATE TABLE mst_serial (
+    serial_id SERIAL PRIMARY KEY,
+    product_id INT NOT NULL,
+    serial_number VARCHAR(100) NOT NULL,
+    status VARCHAR(20) NOT NULL,  -- IN_STOCK, IN_TRANSIT or SHIPPED
+    warehouse_id INT,             -- Current place, empty unless IN_STOCK
+    location_id INT,
+    lot_number VARCHAR(50),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (product_id) REFERENCES mst_product(product_id),
+    FOREIGN KEY (warehouse_id) REFERENCES mst_warehouse(warehouse_id),
+    FOREIGN KEY (location_id) REFERENCES mst_location(location_id)
+);
+
+CREATE UNIQUE INDEX mst_serial_product_serial_number_key ON mst_serial (product_id, serial_number);
+CREATE INDEX mst_serial_serial_number_idx ON mst_serial (serial_number);
+
+CREATE TRIGGER update_mst_serial_updated_at
+BEFORE UPDATE ON mst_serial
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
+
+-- Units moved by each ledger row
+CREATE TABLE trx_stock_serial (
+    transaction_id INT NOT NULL,
+    serial_id INT NOT NULL,
+    PRIMARY KEY (transaction_id, serial_id),
+    FOREIGN KEY (transaction_id) REFERENCES trx_stock(transaction_id),
+    FOREIGN KEY (serial_id) REFERENCES mst_serial(serial_id)
+);
+
+CREATE INDEX trx_stock_serial_serial_id_idx ON trx_stock_serial (serial_id);
+
+COMMIT;
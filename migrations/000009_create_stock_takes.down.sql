@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "trx_stock_take_line";
+DROP TABLE IF EXISTS "trx_stock_take";
@@ -0,0 +1,41 @@
+BEGIN;
+
+-- Physical count sessions
+CREATE TABLE trx_stock_take (
+    stock_take_id SERIAL PRIMARY KEY,
+    warehouse_id INT NOT NULL,
+    location_ids INT[],                         -- Locations counted, empty for the whole warehouse
+    status VARCHAR(20) NOT NULL DEFAULT 'OPEN', -- OPEN, APPROVED or CANCELLED
+    created_by INT,
+    approved_by INT,
+    approved_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (warehouse_id) REFERENCES mst_warehouse(warehouse_id)
+);
+
+CREATE TRIGGER update_trx_stock_take_updated_at
+BEFORE UPDATE ON trx_stock_take
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
+
+-- Expected quantities frozen when the session is opened, and what was counted
+CREATE TABLE trx_stock_take_line (
+    line_id SERIAL PRIMARY KEY,
+    stock_take_id INT NOT NULL,
+    product_id INT NOT NULL,
+    location_id INT NOT NULL,
+    expected_quantity INT NOT NULL,
+    counted_quantity INT CHECK (counted_quantity >= 0),
+    counted_by INT,
+    counted_at TIMESTAMP,
+    transaction_id INT,                         -- ADJUSTMENT posted on approval
+    FOREIGN KEY (stock_take_id) REFERENCES trx_stock_take(stock_take_id),
+    FOREIGN KEY (product_id) REFERENCES mst_product(product_id),
+    FOREIGN KEY (location_id) REFERENCES mst_location(location_id),
+    FOREIGN KEY (transaction_id) REFERENCES trx_stock(transaction_id)
+);
+
+CREATE UNIQUE INDEX trx_stock_take_line_product_location_key ON trx_stock_take_line (stock_take_id, product_id, location_id);
+
+COMMIT;
@@ -0,0 +1,29 @@
+CREATE OR REPLACE FUNCTION prevent_trx_stock_mutation()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        RAISE EXCEPTION 'trx_stock is an append-only ledger';
+    END IF;
+
+    IF NEW.product_id IS DISTINCT FROM OLD.product_id
+        OR NEW.warehouse_id IS DISTINCT FROM OLD.warehouse_id
+        OR NEW.location_id IS DISTINCT FROM OLD.location_id
+        OR NEW.transaction_type IS DISTINCT FROM OLD.transaction_type
+        OR NEW.quantity IS DISTINCT FROM OLD.quantity
+        OR NEW.balance_after IS DISTINCT FROM OLD.balance_after
+        OR NEW.transaction_date IS DISTINCT FROM OLD.transaction_date
+        OR NEW.lot_number IS DISTINCT FROM OLD.lot_number
+        OR NEW.expiry_date IS DISTINCT FROM OLD.expiry_date
+        OR NEW.reason_code IS DISTINCT FROM OLD.reason_code
+        OR NEW.notes IS DISTINCT FROM OLD.notes
+        OR NEW.reference_document IS DISTINCT FROM OLD.reference_document THEN
+        RAISE EXCEPTION 'trx_stock movement % cannot be changed once posted', OLD.transaction_id;
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+UPDATE "trx_stock" SET status = 'COMPLETED' WHERE status = 'REVERSED';
+DROP INDEX IF EXISTS "trx_stock_reversal_of_id_key";
+ALTER TABLE "trx_stock" DROP COLUMN IF EXISTS "reversal_of_id";
@@ -0,0 +1,17 @@
+BEGIN;
+
+-- Responses of create requests sent with an Idempotency-Key, replayed on retries
+CREATE TABLE trx_idempotency_key (
+    user_id INT NOT NULL,
+    idempotency_key VARCHAR(255) NOT NULL,
+    request_fingerprint VARCHAR(64) NOT NULL, -- SHA-256 of method, path and body
+    status_code INT,                          -- Empty while the first request is running
+    response_body BYTEA,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, idempotency_key),
+    FOREIGN KEY (user_id) REFERENCES mst_users(user_id)
+);
+
+CREATE INDEX trx_idempotency_key_created_at_idx ON trx_idempotency_key (created_at);
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP INDEX IF EXISTS trx_stock_transaction_date_idx;
+DROP TABLE IF EXISTS "trx_stock_snapshot";
+
+COMMIT;
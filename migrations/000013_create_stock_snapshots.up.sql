@@ -0,0 +1,17 @@
+BEGIN;
+
+-- Ledger balances per product and location as of snapshot_at, so that
+-- point-in-time queries only have to add up the ledger rows after it
+CREATE TABLE trx_stock_snapshot (
+    snapshot_at TIMESTAMP NOT NULL,
+    product_id INT NOT NULL,
+    location_id INT NOT NULL,   -- 0 for ledger rows without a location
+    quantity INT NOT NULL,      -- Sum of trx_stock.quantity dated before snapshot_at
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (snapshot_at, product_id, location_id),
+    FOREIGN KEY (product_id) REFERENCES mst_product(product_id)
+);
+
+CREATE INDEX trx_stock_transaction_date_idx ON trx_stock (transaction_date);
+
+COMMIT;
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS "mst_reorder_point";
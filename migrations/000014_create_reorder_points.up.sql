@@ -0,0 +1,24 @@
+BEGIN;
+
+-- Stock levels kept per product and warehouse
+CREATE TABLE mst_reorder_point (
+    product_id INT NOT NULL,
+    warehouse_id INT NOT NULL,
+    min_quantity INT NOT NULL CHECK (min_quantity >= 0),          -- Reorder when available stock falls below
+    max_quantity INT NOT NULL DEFAULT 0 CHECK (max_quantity >= 0), -- Most stock to keep, 0 for no limit
+    reorder_quantity INT NOT NULL CHECK (reorder_quantity > 0),
+    alerted_at TIMESTAMP,                                         -- Set while a low-stock alert is outstanding
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (product_id, warehouse_id),
+    CHECK (max_quantity = 0 OR max_quantity >= min_quantity),
+    FOREIGN KEY (product_id) REFERENCES mst_product(product_id),
+    FOREIGN KEY (warehouse_id) REFERENCES mst_warehouse(warehouse_id)
+);
+
+CREATE TRIGGER update_mst_reorder_point_updated_at
+BEFORE UPDATE ON mst_reorder_point
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
+
+COMMIT;
@@ -0,0 +1,53 @@
+BEGIN;
+
+-- Stock is kept and posted to the ledger in the product's base unit
+ALTER TABLE mst_product
+    ADD COLUMN base_unit VARCHAR(20) NOT NULL DEFAULT 'PCS',
+    ADD COLUMN purchase_unit VARCHAR(20), -- Unit bought in, empty for the base unit
+    ADD COLUMN sales_unit VARCHAR(20);    -- Unit sold in, empty for the base unit
+
+-- The unit and quantity a movement was entered in, before it was posted in base units
+ALTER TABLE trx_stock
+    ADD COLUMN unit_code VARCHAR(20),
+    ADD COLUMN unit_quantity BIGINT;
+
+CREATE OR REPLACE FUNCTION prevent_trx_stock_mutation()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        RAISE EXCEPTION 'trx_stock is an append-only ledger';
+    END IF;
+
+    IF NEW.product_id IS DISTINCT FROM OLD.product_id
+        OR NEW.warehouse_id IS DISTINCT FROM OLD.warehouse_id
+        OR NEW.location_id IS DISTINCT FROM OLD.location_id
+        OR NEW.transaction_type IS DISTINCT FROM OLD.transaction_type
+        OR NEW.quantity IS DISTINCT FROM OLD.quantity
+        OR NEW.balance_after IS DISTINCT FROM OLD.balance_after
+        OR NEW.transaction_date IS DISTINCT FROM OLD.transaction_date
+        OR NEW.lot_number IS DISTINCT FROM OLD.lot_number
+        OR NEW.expiry_date IS DISTINCT FROM OLD.expiry_date
+        OR NEW.reason_code IS DISTINCT FROM OLD.reason_code
+        OR NEW.notes IS DISTINCT FROM OLD.notes
+        OR NEW.reference_document IS DISTINCT FROM OLD.reference_document
+        OR NEW.reversal_of_id IS DISTINCT FROM OLD.reversal_of_id
+        OR NEW.unit_code IS DISTINCT FROM OLD.unit_code
+        OR NEW.unit_quantity IS DISTINCT FROM OLD.unit_quantity THEN
+        RAISE EXCEPTION 'trx_stock movement % cannot be changed once posted', OLD.transaction_id;
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Other units a product is counted in, e.g. a SACK of 50 KG
+CREATE TABLE mst_product_unit (
+    product_id INT NOT NULL,
+    unit_code VARCHAR(20) NOT NULL,
+    factor INT NOT NULL CHECK (factor > 0), -- Base units in one of this unit
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (product_id, unit_code),
+    FOREIGN KEY (product_id) REFERENCES mst_product(product_id)
+);
+
+COMMIT;
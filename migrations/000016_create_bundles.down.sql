@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE IF EXISTS "mst_bundle_component";
+ALTER TABLE mst_product DROP COLUMN IF EXISTS is_bundle;
+
+COMMIT;
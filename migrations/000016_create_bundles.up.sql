@@ -0,0 +1,18 @@
+BEGIN;
+
+ALTER TABLE mst_product ADD COLUMN is_bundle BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Bill of materials of bundle products
+CREATE TABLE mst_bundle_component (
+    bundle_id INT NOT NULL,
+    component_id INT NOT NULL,
+    quantity INT NOT NULL CHECK (quantity > 0), -- Component base units in one bundle
+    PRIMARY KEY (bundle_id, component_id),
+    CHECK (bundle_id <> component_id),
+    FOREIGN KEY (bundle_id) REFERENCES mst_product(product_id),
+    FOREIGN KEY (component_id) REFERENCES mst_product(product_id)
+);
+
+CREATE INDEX mst_bundle_component_component_id_idx ON mst_bundle_component (component_id);
+
+COMMIT;
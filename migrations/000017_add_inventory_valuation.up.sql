@@ -0,0 +1,75 @@
+BEGIN;
+
+-- How stock leaving a warehouse is costed: oldest cost layers first, or at the
+-- moving average cost of the warehouse's stock
+ALTER TABLE mst_warehouse ADD COLUMN costing_method VARCHAR(16) NOT NULL DEFAULT 'FIFO'
+    CHECK (costing_method IN ('FIFO', 'AVERAGE'));
+
+-- Cost per unit of the movement and its signed effect on the stock value
+ALTER TABLE trx_stock
+    ADD COLUMN unit_cost NUMERIC(15,4),
+    ADD COLUMN cost_amount NUMERIC(15,4);
+
+-- Quantity and book value of a product in a warehouse
+CREATE TABLE mst_stock_cost (
+    product_id INT NOT NULL,
+    warehouse_id INT NOT NULL,
+    quantity INT NOT NULL DEFAULT 0,
+    stock_value NUMERIC(15,4) NOT NULL DEFAULT 0,
+    PRIMARY KEY (product_id, warehouse_id),
+    FOREIGN KEY (product_id) REFERENCES mst_product(product_id),
+    FOREIGN KEY (warehouse_id) REFERENCES mst_warehouse(warehouse_id)
+);
+
+-- Receipts not yet consumed, taken oldest first
+CREATE TABLE mst_cost_layer (
+    layer_id SERIAL PRIMARY KEY,
+    product_id INT NOT NULL,
+    warehouse_id INT NOT NULL,
+    unit_cost NUMERIC(15,4) NOT NULL,
+    remaining_quantity INT NOT NULL CHECK (remaining_quantity >= 0),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (product_id, warehouse_id) REFERENCES mst_stock_cost(product_id, warehouse_id)
+);
+
+CREATE INDEX mst_cost_layer_open_idx ON mst_cost_layer (product_id, warehouse_id, layer_id) WHERE remaining_quantity > 0;
+
+-- Stock on hand before costing was introduced has no known cost
+INSERT INTO mst_stock_cost (product_id, warehouse_id, quantity)
+SELECT product_id, warehouse_id, SUM(stock_quantity) FROM mst_stock GROUP BY product_id, warehouse_id;
+
+INSERT INTO mst_cost_layer (product_id, warehouse_id, unit_cost, remaining_quantity)
+SELECT product_id, warehouse_id, 0, quantity FROM mst_stock_cost WHERE quantity > 0;
+
+CREATE OR REPLACE FUNCTION prevent_trx_stock_mutation()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        RAISE EXCEPTION 'trx_stock is an append-only ledger';
+    END IF;
+
+    IF NEW.product_id IS DISTINCT FROM OLD.product_id
+        OR NEW.warehouse_id IS DISTINCT FROM OLD.warehouse_id
+        OR NEW.location_id IS DISTINCT FROM OLD.location_id
+        OR NEW.transaction_type IS DISTINCT FROM OLD.transaction_type
+        OR NEW.quantity IS DISTINCT FROM OLD.quantity
+        OR NEW.balance_after IS DISTINCT FROM OLD.balance_after
+        OR NEW.transaction_date IS DISTINCT FROM OLD.transaction_date
+        OR NEW.lot_number IS DISTINCT FROM OLD.lot_number
+        OR NEW.expiry_date IS DISTINCT FROM OLD.expiry_date
+        OR NEW.reason_code IS DISTINCT FROM OLD.reason_code
+        OR NEW.notes IS DISTINCT FROM OLD.notes
+        OR NEW.reference_document IS DISTINCT FROM OLD.reference_document
+        OR NEW.reversal_of_id IS DISTINCT FROM OLD.reversal_of_id
+        OR NEW.unit_code IS DISTINCT FROM OLD.unit_code
+        OR NEW.unit_quantity IS DISTINCT FROM OLD.unit_quantity
+        OR NEW.unit_cost IS DISTINCT FROM OLD.unit_cost
+        OR NEW.cost_amount IS DISTINCT FROM OLD.cost_amount THEN
+        RAISE EXCEPTION 'trx_stock movement % cannot be changed once posted', OLD.transaction_id;
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+COMMIT;
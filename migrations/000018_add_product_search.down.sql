@@ -0,0 +1,10 @@
+BEGIN;
+
+DROP INDEX IF EXISTS "mst_product_updated_at_idx";
+DROP INDEX IF EXISTS "mst_product_created_at_idx";
+DROP INDEX IF EXISTS "mst_product_price_idx";
+DROP INDEX IF EXISTS "mst_product_sku_trgm_idx";
+DROP INDEX IF EXISTS "mst_product_description_trgm_idx";
+DROP INDEX IF EXISTS "mst_product_name_trgm_idx";
+
+COMMIT;
@@ -0,0 +1,14 @@
+BEGIN;
+
+-- Trigram indexes serve the substring searches of the product listing
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX mst_product_name_trgm_idx ON mst_product USING GIN (product_name gin_trgm_ops);
+CREATE INDEX mst_product_description_trgm_idx ON mst_product USING GIN (description gin_trgm_ops);
+CREATE INDEX mst_product_sku_trgm_idx ON mst_product USING GIN (sku gin_trgm_ops);
+
+CREATE INDEX mst_product_price_idx ON mst_product (price);
+CREATE INDEX mst_product_created_at_idx ON mst_product (created_at);
+CREATE INDEX mst_product_updated_at_idx ON mst_product (updated_at);
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE mst_product DROP COLUMN IF EXISTS category_id;
+DROP TABLE IF EXISTS "mst_category";
+
+COMMIT;
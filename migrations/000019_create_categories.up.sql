@@ -0,0 +1,21 @@
+BEGIN;
+
+-- Product category tree; root categories have no parent
+CREATE TABLE mst_category (
+    category_id SERIAL PRIMARY KEY,
+    category_name VARCHAR(255) NOT NULL,
+    parent_id INT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (parent_id <> category_id),
+    FOREIGN KEY (parent_id) REFERENCES mst_category(category_id)
+);
+
+-- Sibling categories have distinct names
+CREATE UNIQUE INDEX mst_category_parent_name_idx ON mst_category (COALESCE(parent_id, 0), LOWER(category_name));
+
+ALTER TABLE mst_product ADD COLUMN category_id INT REFERENCES mst_category(category_id);
+
+CREATE INDEX mst_product_category_id_idx ON mst_product (category_id);
+
+COMMIT;
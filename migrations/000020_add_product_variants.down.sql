@@ -0,0 +1,8 @@
+BEGIN;
+
+ALTER TABLE mst_product DROP CONSTRAINT IF EXISTS mst_product_parent_id_check;
+ALTER TABLE mst_product DROP COLUMN IF EXISTS attributes;
+ALTER TABLE mst_product DROP COLUMN IF EXISTS variant_attributes;
+ALTER TABLE mst_product DROP COLUMN IF EXISTS parent_id;
+
+COMMIT;
@@ -0,0 +1,15 @@
+BEGIN;
+
+-- A parent product names the attributes its variants differ in; each variant
+-- is a product of its own under the parent, with a value for every attribute
+ALTER TABLE mst_product ADD COLUMN parent_id INT REFERENCES mst_product(product_id);
+ALTER TABLE mst_product ADD COLUMN variant_attributes TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE mst_product ADD COLUMN attributes JSONB;
+ALTER TABLE mst_product ADD CONSTRAINT mst_product_parent_id_check CHECK (parent_id <> product_id);
+
+CREATE INDEX mst_product_parent_id_idx ON mst_product (parent_id);
+
+-- Variants of a parent differ in at least one attribute
+CREATE UNIQUE INDEX mst_product_parent_attributes_idx ON mst_product (parent_id, attributes) WHERE parent_id IS NOT NULL;
+
+COMMIT;
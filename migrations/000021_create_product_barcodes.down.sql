@@ -0,0 +1,5 @@
+BEGIN;
+
+DROP TABLE IF EXISTS "mst_product_barcode";
+
+COMMIT;
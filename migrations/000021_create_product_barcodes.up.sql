@@ -0,0 +1,14 @@
+BEGIN;
+
+-- Barcodes products are known by; a barcode identifies one product only
+CREATE TABLE mst_product_barcode (
+    barcode VARCHAR(48) PRIMARY KEY,
+    product_id INT NOT NULL,
+    barcode_type VARCHAR(10) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (product_id) REFERENCES mst_product(product_id)
+);
+
+CREATE INDEX mst_product_barcode_product_id_idx ON mst_product_barcode (product_id);
+
+COMMIT;
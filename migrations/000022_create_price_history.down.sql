@@ -0,0 +1,5 @@
+BEGIN;
+
+DROP TABLE IF EXISTS "trx_product_price";
+
+COMMIT;
@@ -0,0 +1,25 @@
+BEGIN;
+
+-- Every price a product has had or is scheduled to have. A row takes effect at
+-- effective_from; applied_at is set once it has been written to mst_product.
+-- The times are instants, so scheduled changes sent in UTC compare correctly
+-- with CURRENT_TIMESTAMP whatever the session time zone.
+CREATE TABLE trx_product_price (
+    price_id SERIAL PRIMARY KEY,
+    product_id INT NOT NULL,
+    price NUMERIC(10, 2) NOT NULL,
+    effective_from TIMESTAMPTZ NOT NULL,
+    applied_at TIMESTAMPTZ, -- NULL while the change is scheduled
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (product_id) REFERENCES mst_product(product_id)
+);
+
+CREATE INDEX trx_product_price_product_id_effective_from_idx ON trx_product_price (product_id, effective_from);
+CREATE INDEX trx_product_price_scheduled_idx ON trx_product_price (effective_from) WHERE applied_at IS NULL;
+
+-- The current prices start the history. A product's price may have changed at
+-- any edit, so it is only known to hold since the last one
+INSERT INTO trx_product_price (product_id, price, effective_from, applied_at)
+SELECT product_id, price, COALESCE(updated_at, created_at, CURRENT_TIMESTAMP), COALESCE(updated_at, created_at, CURRENT_TIMESTAMP) FROM mst_product;
+
+COMMIT;
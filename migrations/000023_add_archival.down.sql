@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE mst_location DROP COLUMN IF EXISTS archived_at;
+ALTER TABLE mst_warehouse DROP COLUMN IF EXISTS archived_at;
+ALTER TABLE mst_product DROP COLUMN IF EXISTS archived_at;
+
+COMMIT;
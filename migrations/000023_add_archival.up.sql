@@ -0,0 +1,9 @@
+BEGIN;
+
+-- Archived rows are kept for the stock history that references them, but are
+-- left out of listings and take no new stock
+ALTER TABLE mst_product ADD COLUMN archived_at TIMESTAMP;
+ALTER TABLE mst_warehouse ADD COLUMN archived_at TIMESTAMP;
+ALTER TABLE mst_location ADD COLUMN archived_at TIMESTAMP;
+
+COMMIT;
@@ -0,0 +1,258 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Barcode types a product can be registered under. EAN-13 and UPC-A codes must
+// carry a valid check digit; CODE128 takes any other code printed for the
+// product, such as an in-house label.
+const (
+	BarcodeEAN13   = "EAN13"
+	BarcodeUPCA    = "UPCA"
+	BarcodeCode128 = "CODE128"
+)
+
+// maxBarcodeLength is the longest code the registry holds.
+const maxBarcodeLength = 48
+
+// gs1GroupSeparator ends a variable-length element in a scanned GS1-128 code.
+const gs1GroupSeparator = "\x1d"
+
+// ProductBarcode is one of the barcodes a product is known by. A barcode
+// belongs to one product only.
+type ProductBarcode struct {
+	Barcode   string    `json:"barcode"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BarcodeScan is what a scanned code resolves to: the product and, for a
+// GS1-128 label, the lot and expiry date it carries.
+type BarcodeScan struct {
+	Barcode    string  `json:"barcode"`
+	Product    Product `json:"product"`
+	LotNumber  string  `json:"lot_number,omitempty"`
+	ExpiryDate *Date   `json:"expiry_date,omitempty"`
+}
+
+// GS1Label holds the application identifiers of a GS1-128 code this service
+// uses: (01) or (02) GTIN, (10) lot and (17) expiry date.
+type GS1Label struct {
+	GTIN       string
+	LotNumber  string
+	ExpiryDate *Date
+}
+
+// Validate checks the barcode against its type, guessing the type from the
+// code when it is empty: 13 digits are EAN-13, 12 digits UPC-A and anything
+// else CODE128.
+func (b *ProductBarcode) Validate() error {
+	if b.Type == "" {
+		switch {
+		case len(b.Barcode) == 13 && isDigits(b.Barcode):
+			b.Type = BarcodeEAN13
+		case len(b.Barcode) == 12 && isDigits(b.Barcode):
+			b.Type = BarcodeUPCA
+		default:
+			b.Type = BarcodeCode128
+		}
+	}
+
+	switch b.Type {
+	case BarcodeEAN13, BarcodeUPCA:
+		length := 13
+		if b.Type == BarcodeUPCA {
+			length = 12
+		}
+		if len(b.Barcode) != length || !isDigits(b.Barcode) {
+			return fmt.Errorf("%w: %s must be %d digits", ErrInvalidBarcode, b.Type, length)
+		}
+		if !validCheckDigit(b.Barcode) {
+			return fmt.Errorf("%w: %s has a wrong check digit", ErrInvalidBarcode, b.Barcode)
+		}
+	case BarcodeCode128:
+		if b.Barcode == "" || len(b.Barcode) > maxBarcodeLength {
+			return fmt.Errorf("%w: barcode must be 1 to %d characters", ErrInvalidBarcode, maxBarcodeLength)
+		}
+		// Codes are looked up by path, so they cannot hold a slash
+		for _, r := range b.Barcode {
+			if r <= ' ' || r > '~' || r == '/' {
+				return fmt.Errorf("%w: %q holds a character that cannot be scanned", ErrInvalidBarcode, b.Barcode)
+			}
+		}
+	default:
+		return fmt.Errorf("%w: unknown type %s", ErrInvalidBarcode, b.Type)
+	}
+
+	return nil
+}
+
+// IsGS1 reports whether a scanned code is a GS1-128 label: written with its
+// application identifiers in brackets, prefixed with the ]C1 symbology
+// identifier or holding group separators.
+func IsGS1(code string) bool {
+	return strings.HasPrefix(code, "(") || strings.HasPrefix(code, "]C1") || strings.Contains(code, gs1GroupSeparator)
+}
+
+// gs1Lengths gives the length of the data of the application identifiers
+// understood, 0 for variable-length data of at most 20 characters. Those read
+// but not used are there so a label carrying them still parses.
+var gs1Lengths = map[string]int{
+	"00": 18, // SSCC
+	"01": 14, // GTIN
+	"02": 14, // GTIN of contained items
+	"10": 0,  // Lot
+	"11": 6,  // Production date
+	"13": 6,  // Packaging date
+	"15": 6,  // Best before date
+	"17": 6,  // Expiry date
+	"21": 0,  // Serial number
+}
+
+// ParseGS1 reads the GTIN, lot and expiry date from a GS1-128 code, written
+// either as scanned or in the human readable form with brackets.
+func ParseGS1(code string) (GS1Label, error) {
+	elements, err := splitGS1(code)
+	if err != nil {
+		return GS1Label{}, err
+	}
+
+	var label GS1Label
+	for _, element := range elements {
+		ai, data := element[0], element[1]
+		length := gs1Lengths[ai]
+		if (length > 0 && (len(data) != length || !isDigits(data))) || (length == 0 && (data == "" || len(data) > 20)) {
+			return GS1Label{}, fmt.Errorf("%w: malformed (%s) %q", ErrInvalidBarcode, ai, data)
+		}
+
+		switch ai {
+		case "01", "02":
+			if !validCheckDigit(data) {
+				return GS1Label{}, fmt.Errorf("%w: GTIN %s has a wrong check digit", ErrInvalidBarcode, data)
+			}
+			label.GTIN = data
+		case "10":
+			label.LotNumber = data
+		case "17":
+			expiry, err := parseGS1Date(data)
+			if err != nil {
+				return GS1Label{}, err
+			}
+			label.ExpiryDate = &expiry
+		}
+	}
+
+	if label.GTIN == "" {
+		return GS1Label{}, fmt.Errorf("%w: GS1-128 code has no GTIN", ErrInvalidBarcode)
+	}
+	return label, nil
+}
+
+// GTINCandidates lists the forms a GTIN may be registered under: as given,
+// and without the leading zeros that pad an EAN-13 or UPC-A to 14 digits.
+func GTINCandidates(gtin string) []string {
+	candidates := []string{gtin}
+	if !isDigits(gtin) {
+		return candidates
+	}
+	for _, length := range []int{13, 12} {
+		if len(gtin) > length && strings.Trim(gtin[:len(gtin)-length], "0") == "" {
+			candidates = append(candidates, gtin[len(gtin)-length:])
+		}
+	}
+	return candidates
+}
+
+// splitGS1 splits a GS1-128 code into application identifier and data pairs.
+func splitGS1(code string) ([][2]string, error) {
+	var elements [][2]string
+
+	if strings.HasPrefix(code, "(") {
+		for _, part := range strings.Split(code[1:], "(") {
+			ai, data, ok := strings.Cut(part, ")")
+			if !ok {
+				return nil, fmt.Errorf("%w: unclosed application identifier in %q", ErrInvalidBarcode, code)
+			}
+			if _, known := gs1Lengths[ai]; !known {
+				return nil, fmt.Errorf("%w: unsupported application identifier (%s)", ErrInvalidBarcode, ai)
+			}
+			elements = append(elements, [2]string{ai, data})
+		}
+		return elements, nil
+	}
+
+	rest := strings.TrimPrefix(code, "]C1")
+	for rest != "" {
+		if len(rest) < 2 {
+			return nil, fmt.Errorf("%w: truncated GS1-128 code %q", ErrInvalidBarcode, code)
+		}
+		ai := rest[:2]
+		length, known := gs1Lengths[ai]
+		if !known {
+			return nil, fmt.Errorf("%w: unsupported application identifier (%s)", ErrInvalidBarcode, ai)
+		}
+		rest = rest[2:]
+
+		var data string
+		if length > 0 {
+			if len(rest) < length {
+				return nil, fmt.Errorf("%w: truncated GS1-128 code %q", ErrInvalidBarcode, code)
+			}
+			data, rest = rest[:length], rest[length:]
+		} else {
+			data, rest, _ = strings.Cut(rest, gs1GroupSeparator)
+		}
+		rest = strings.TrimPrefix(rest, gs1GroupSeparator)
+		elements = append(elements, [2]string{ai, data})
+	}
+
+	return elements, nil
+}
+
+// parseGS1Date reads a YYMMDD date, where day 00 stands for the last day of
+// the month.
+func parseGS1Date(data string) (Date, error) {
+	date, err := time.Parse("060102", data[:4]+"01")
+	if err != nil {
+		return Date{}, fmt.Errorf("%w: invalid date %s", ErrInvalidBarcode, data)
+	}
+
+	if data[4:] == "00" {
+		return Date{date.AddDate(0, 1, -1)}, nil
+	}
+	date, err = time.Parse("060102", data)
+	if err != nil {
+		return Date{}, fmt.Errorf("%w: invalid date %s", ErrInvalidBarcode, data)
+	}
+	return Date{date}, nil
+}
+
+// validCheckDigit checks the last digit of a GS1 number (EAN-13, UPC-A or
+// GTIN-14): weighting the other digits 3 and 1 from the right, their sum plus
+// the check digit is a multiple of 10.
+func validCheckDigit(code string) bool {
+	sum := 0
+	for i := len(code) - 2; i >= 0; i-- {
+		digit := int(code[i] - '0')
+		if (len(code)-2-i)%2 == 0 {
+			digit *= 3
+		}
+		sum += digit
+	}
+	return (sum+int(code[len(code)-1]-'0'))%10 == 0
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,35 @@
+package model
+
+// BundleComponent is a line of a bundle's bill of materials: one bundle is
+// made of Quantity base units of the component.
+type BundleComponent struct {
+	ComponentID int64  `json:"component_id"`
+	ProductName string `json:"product_name,omitempty"`
+	SKU         string `json:"sku,omitempty"`
+	Quantity    int64  `json:"quantity"`
+}
+
+// BundleAvailability is how many of a bundle can be shipped: the finished
+// bundles in stock plus those the component stock at each location is enough
+// to make.
+type BundleAvailability struct {
+	ProductID      int64                   `json:"product_id"`
+	AssembledStock int64                   `json:"assembled_stock"`
+	BuildableStock int64                   `json:"buildable_stock"`
+	AvailableStock int64                   `json:"available_stock"`
+	Components     []ComponentAvailability `json:"components"`
+}
+
+// ComponentAvailability is the available stock of a bundle component.
+type ComponentAvailability struct {
+	BundleComponent
+	AvailableStock int64 `json:"available_stock"`
+}
+
+// LocationStock is the stock of a product at a location that is not held by
+// reservations.
+type LocationStock struct {
+	ProductID      int64
+	LocationID     int64
+	AvailableStock int64
+}
@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// Category is a node of the product category tree. Root categories have no
+// ParentID. Children holds the categories below it when a tree is read.
+type Category struct {
+	CategoryID   int64      `json:"category_id"`
+	CategoryName string     `json:"category_name" validate:"required"`
+	ParentID     int64      `json:"parent_id,omitempty"`
+	Children     []Category `json:"children,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// CategoryStock is the stock of a category's products rolled up with that of
+// every category below it.
+type CategoryStock struct {
+	CategoryID     int64  `json:"category_id"`
+	CategoryName   string `json:"category_name"`
+	ParentID       int64  `json:"parent_id,omitempty"`
+	TotalStock     int64  `json:"total_stock"`
+	ReservedStock  int64  `json:"reserved_stock"`
+	AvailableStock int64  `json:"available_stock"`
+}
@@ -0,0 +1,183 @@
+package model
+
+import "errors"
+
+// ErrLocationRequired is returned for a stock movement that gives no location_id.
+// Stock is kept per location, so a warehouse alone does not say where it goes.
+var ErrLocationRequired = errors.New("location_id is required")
+
+// ErrLocationNotFound is returned for a location that does not exist.
+var ErrLocationNotFound = errors.New("location not found")
+
+// ErrInvalidMovement is returned for a stock movement whose own fields do not
+// add up, such as a quantity that is not positive or serial numbers that do not
+// match it.
+var ErrInvalidMovement = errors.New("invalid stock movement")
+
+// ErrInsufficientStock is returned when a movement would take a stock balance below zero.
+var ErrInsufficientStock = errors.New("insufficient stock")
+
+// ErrTransferNotFound is returned for a transfer that does not exist, or for a
+// transaction that is not a transfer or only a later leg of one.
+var ErrTransferNotFound = errors.New("transfer not found")
+
+// ErrTransferNotInTransit is returned when receiving a transfer that was
+// completed when shipped or has already been received.
+var ErrTransferNotInTransit = errors.New("transfer is not in transit")
+
+// ErrReservationNotFound is returned for a reservation that does not exist.
+var ErrReservationNotFound = errors.New("reservation not found")
+
+// ErrReservationNotActive is returned when confirming or releasing a
+// reservation that was already confirmed, released or has expired.
+var ErrReservationNotActive = errors.New("reservation is not active")
+
+// ErrSerialNotAvailable is returned when a serial number cannot take part in a
+// movement, e.g. it is already in stock on receipt or not at the source location.
+var ErrSerialNotAvailable = errors.New("serial number not available")
+
+// ErrSerialNotFound is returned when no unit has the serial number looked up.
+var ErrSerialNotFound = errors.New("serial number not found")
+
+// ErrStockTakeNotOpen is returned when counting, approving or cancelling a
+// stock take that was already approved or cancelled.
+var ErrStockTakeNotOpen = errors.New("stock take is not open")
+
+// ErrStockTakeNotFound is returned when a stock take does not exist or belongs
+// to another user's warehouse.
+var ErrStockTakeNotFound = errors.New("stock take not found")
+
+// ErrSerializedVariance is returned when approving a stock take that counted a
+// serialized product differently than expected. Serialized stock is only
+// adjusted by serial number, so such a line has to be settled with an
+// ADJUSTMENT and counted again before the stock take can be approved.
+var ErrSerializedVariance = errors.New("serialized stock must be adjusted by serial number")
+
+// ErrInvalidReasonCode is returned when an adjustment has no reason code or one
+// that is not configured.
+var ErrInvalidReasonCode = errors.New("invalid adjustment reason code")
+
+// ErrTransactionReversed is returned when reversing a movement that was
+// already reversed.
+var ErrTransactionReversed = errors.New("transaction already reversed")
+
+// ErrTransactionNotReversible is returned when reversing a movement whose type
+// cannot be undone by a single compensating entry, such as a transfer.
+var ErrTransactionNotReversible = errors.New("transaction cannot be reversed")
+
+// ErrTransactionNotFound is returned when no ledger row has the transaction id.
+var ErrTransactionNotFound = errors.New("transaction not found")
+
+// ErrIdempotencyKeyMismatch is returned when an Idempotency-Key is reused with
+// a different request.
+var ErrIdempotencyKeyMismatch = errors.New("idempotency key reused with a different request")
+
+// ErrIdempotencyKeyInProgress is returned when a request is retried while the
+// first request with the same Idempotency-Key is still running.
+var ErrIdempotencyKeyInProgress = errors.New("request with this idempotency key is still in progress")
+
+// ErrInvalidBatchSize is returned when a batch is empty or has too many lines.
+var ErrInvalidBatchSize = errors.New("invalid batch size")
+
+// ErrReorderPointNotFound is returned when a product has no reorder point in
+// the warehouse.
+var ErrReorderPointNotFound = errors.New("reorder point not found")
+
+// ErrInvalidReorderPoint is returned when reorder point levels are inconsistent.
+var ErrInvalidReorderPoint = errors.New("invalid reorder point")
+
+// ErrUnknownUnit is returned when a quantity is given in a unit the product
+// has no conversion for.
+var ErrUnknownUnit = errors.New("unknown unit of measure")
+
+// ErrInvalidQuantity is returned for a quantity that is out of range once
+// converted to the product's base unit.
+var ErrInvalidQuantity = errors.New("invalid quantity")
+
+// ErrInvalidUnit is returned when a unit of measure cannot be set up as asked.
+var ErrInvalidUnit = errors.New("invalid unit of measure")
+
+// ErrInvalidBundle is returned when a bill of materials cannot be set up as
+// asked.
+var ErrInvalidBundle = errors.New("invalid bundle")
+
+// ErrNotBundle is returned when a bundle operation is asked of a product that
+// has no components.
+var ErrNotBundle = errors.New("product is not a bundle")
+
+// ErrInvalidCostingMethod is returned for a costing method other than FIFO or
+// AVERAGE.
+var ErrInvalidCostingMethod = errors.New("invalid costing method")
+
+// ErrCostingMethodLocked is returned when a warehouse's costing method is
+// changed while it holds stock valued under the old one.
+var ErrCostingMethodLocked = errors.New("costing method cannot change while the warehouse holds stock")
+
+// ErrSerializationLocked is returned when a product is made serialized, or no
+// longer serialized, while it has stock on hand or in transit.
+var ErrSerializationLocked = errors.New("serial tracking cannot change while the product holds stock")
+
+// ErrInvalidUnitCost is returned for a negative unit cost, or one given on a
+// movement that takes stock out.
+var ErrInvalidUnitCost = errors.New("invalid unit cost")
+
+// ErrWarehouseNotFound is returned for a warehouse that does not exist or
+// belongs to another user.
+var ErrWarehouseNotFound = errors.New("warehouse not found")
+
+// ErrInvalidProductQuery is returned for a product listing with an unknown
+// sort field, an empty range or a page out of bounds.
+var ErrInvalidProductQuery = errors.New("invalid product query")
+
+// ErrInvalidPagination is returned for a malformed or mismatched cursor or a
+// page size out of bounds.
+var ErrInvalidPagination = errors.New("invalid pagination")
+
+// ErrCategoryNotFound is returned for a category that does not exist.
+var ErrCategoryNotFound = errors.New("category not found")
+
+// ErrInvalidCategory is returned for a category without a name, named like one
+// of its siblings, or placed under a missing parent or one of its own
+// descendants.
+var ErrInvalidCategory = errors.New("invalid category")
+
+// ErrCategoryInUse is returned when deleting a category that still has
+// products or categories below it.
+var ErrCategoryInUse = errors.New("category in use")
+
+// ErrInvalidVariant is returned for a variant whose parent does not take
+// variants, or whose attributes do not match the parent's or another
+// variant's.
+var ErrInvalidVariant = errors.New("invalid variant")
+
+// ErrVariantParent is returned for a stock movement, reservation, count,
+// reorder point or bundle of a product whose stock is kept by its variants.
+var ErrVariantParent = errors.New("stock is kept per variant")
+
+// ErrInvalidBarcode is returned for a barcode that does not fit its type, such
+// as an EAN-13 with a wrong check digit, or a GS1-128 code that cannot be read.
+var ErrInvalidBarcode = errors.New("invalid barcode")
+
+// ErrBarcodeNotFound is returned when no product is registered under a
+// barcode.
+var ErrBarcodeNotFound = errors.New("barcode not found")
+
+// ErrBarcodeInUse is returned when registering a barcode that already belongs
+// to a product.
+var ErrBarcodeInUse = errors.New("barcode already registered")
+
+// ErrInvalidPriceChange is returned for a scheduled price change with a
+// negative price or an effective date that is not in the future.
+var ErrInvalidPriceChange = errors.New("invalid price change")
+
+// ErrPriceNotFound is returned when a product had no price at the time asked,
+// or has no scheduled change with the id given.
+var ErrPriceNotFound = errors.New("price not found")
+
+// ErrProductNotFound is returned for a product that does not exist.
+var ErrProductNotFound = errors.New("product not found")
+
+// ErrArchived is returned for stock brought into, or transferred of, an
+// archived product or location, and for a location added to or restored in an
+// archived warehouse.
+var ErrArchived = errors.New("archived")
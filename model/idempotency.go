@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// IdempotencyRecord is a create request made with an Idempotency-Key and, once
+// it has finished, the response to replay when it is retried. StatusCode is
+// zero while the first request is still running.
+type IdempotencyRecord struct {
+	UserID             int64
+	Key                string
+	RequestFingerprint string
+	StatusCode         int
+	ResponseBody       []byte
+	CreatedAt          time.Time
+}
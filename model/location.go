@@ -2,16 +2,23 @@ package model
 
 import "time"
 
+// Warehouse is a user's warehouse. Archiving a warehouse archives its
+// locations along with it.
 type Warehouse struct {
-	WarehouseID   int64     `json:"warehouse_id"`
-	WarehouseName string    `json:"warehouse_name"`
-	UserID        int64     `json:"user_id"`
-	CreatedAt     time.Time `json:"created_at"`
+	WarehouseID   int64         `json:"warehouse_id"`
+	WarehouseName string        `json:"warehouse_name"`
+	UserID        int64         `json:"user_id"`
+	CostingMethod CostingMethod `json:"costing_method"`
+	ArchivedAt    *time.Time    `json:"archived_at,omitempty"`
+	CreatedAt     time.Time     `json:"created_at"`
 }
 
+// Location is a place in a warehouse where stock is kept. An archived
+// location takes no new stock.
 type Location struct {
-	LocationID   int64     `json:"location_id"`
-	LocationName string    `json:"location_name"`
-	WarehouseID  int64     `json:"warehouse_id"`
-	CreatedAt    time.Time `json:"created_at"`
+	LocationID   int64      `json:"location_id"`
+	LocationName string     `json:"location_name"`
+	WarehouseID  int64      `json:"warehouse_id"`
+	ArchivedAt   *time.Time `json:"archived_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
 }
@@ -0,0 +1,14 @@
+package model
+
+// StockLot is the balance of one production lot at a location.
+type StockLot struct {
+	LotID       int64  `json:"lot_id"`
+	ProductID   int64  `json:"product_id"`
+	ProductName string `json:"product_name"`
+	SKU         string `json:"sku"`
+	WarehouseID int64  `json:"warehouse_id"`
+	LocationID  int64  `json:"location_id"`
+	LotNumber   string `json:"lot_number"`
+	ExpiryDate  *Date  `json:"expiry_date,omitempty"`
+	Quantity    int64  `json:"quantity"`
+}
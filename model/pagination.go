@@ -0,0 +1,61 @@
+package model
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+// CursorPagination asks for up to Limit items following the position encoded
+// in Cursor, or the first items when Cursor is empty.
+type CursorPagination struct {
+	Cursor string
+	Limit  int32
+}
+
+// Cursor is a position in a listing: the item with ID whose sort field holds
+// Key. Items are taken after it, or before it when Backward is set. Sort names
+// the order the cursor was made for.
+type Cursor struct {
+	ID       int64  `json:"id"`
+	Key      string `json:"k,omitempty"`
+	Sort     string `json:"s,omitempty"`
+	Backward bool   `json:"b,omitempty"`
+}
+
+// IsZero reports whether the cursor is the start of the listing.
+func (c Cursor) IsZero() bool {
+	return c.ID == 0
+}
+
+// Encode turns the cursor into the opaque string handed to clients.
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor reads a cursor made by Encode. The empty string is the start of
+// the listing.
+func DecodeCursor(s string) (Cursor, error) {
+	if s == "" {
+		return Cursor{}, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("%w: %s", ErrInvalidPagination, err.Error())
+	}
+
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID <= 0 {
+		return Cursor{}, fmt.Errorf("%w: malformed cursor", ErrInvalidPagination)
+	}
+	return cursor, nil
+}
+
+// PageCursors lead to the pages either side of a page of results. A cursor is
+// empty when there is nothing on that side.
+type PageCursors struct {
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// ProductPrice is an entry in a product's price history: Price took, or will
+// take, effect at EffectiveFrom. A scheduled change has no AppliedAt until the
+// price job writes it to the product.
+type ProductPrice struct {
+	PriceID       int64      `json:"price_id"`
+	ProductID     int64      `json:"product_id"`
+	Price         float64    `json:"price"`
+	EffectiveFrom time.Time  `json:"effective_from"`
+	AppliedAt     *time.Time `json:"applied_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
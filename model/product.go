@@ -2,19 +2,121 @@ package model
 
 import "time"
 
+// DefaultBaseUnit is the base unit of products created without one.
+const DefaultBaseUnit = "PCS"
+
+// Product is a catalogue item. IsSerialized products are tracked per unit and
+// their stock movements must list serial numbers. IsSerialized is always set on
+// a product read back; an edit leaving it nil keeps the product as it is.
+//
+// An IsBundle product is made of other products, its Components. Shipping it
+// takes finished bundles first and the components of the rest.
+//
+// Stock is kept in BaseUnit, which is fixed when the product is created.
+// PurchaseUnit and SalesUnit name the units the product is usually bought and
+// sold in; empty means the base unit. Units lists the conversions configured
+// for the product, and a new product may be created with them.
+//
+// Barcodes lists the codes the product can be scanned by.
+//
+// An archived product, one with ArchivedAt set, is left out of listings and
+// takes no new stock; what it holds can still be taken out.
+//
+// A product belongs to at most one category, CategoryID.
+//
+// A product with VariantAttributes, such as size or color, is the parent of
+// Variants: products of their own under ParentID, each with its own SKU, price
+// and stock and with Attributes giving its value of every variant attribute.
+// The parent itself holds no stock. Which family a product belongs to, and the
+// part it plays in it, is fixed when the product is created.
 type Product struct {
-	ProductID   int64     `json:"product_id"`
-	ProductName string    `json:"product_name" validate:"required"`
-	Description string    `json:"description,omitempty"`
-	Price       float64   `json:"price" validate:"required"`
-	SKU         string    `json:"sku" validate:"required,unique"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ProductID         int64             `json:"product_id"`
+	ProductName       string            `json:"product_name" validate:"required"`
+	Description       string            `json:"description,omitempty"`
+	Price             float64           `json:"price" validate:"required"`
+	SKU               string            `json:"sku" validate:"required,unique"`
+	IsSerialized      *bool             `json:"is_serialized"`
+	IsBundle          bool              `json:"is_bundle"`
+	BaseUnit          string            `json:"base_unit"`
+	PurchaseUnit      string            `json:"purchase_unit,omitempty"`
+	SalesUnit         string            `json:"sales_unit,omitempty"`
+	CategoryID        int64             `json:"category_id,omitempty"`
+	ParentID          int64             `json:"parent_id,omitempty"`
+	VariantAttributes []string          `json:"variant_attributes,omitempty"`
+	Attributes        map[string]string `json:"attributes,omitempty"`
+	Variants          []Product         `json:"variants,omitempty"`
+	Units             []ProductUnit     `json:"units,omitempty"`
+	Barcodes          []ProductBarcode  `json:"barcodes,omitempty"`
+	Components        []BundleComponent `json:"components,omitempty"`
+	ArchivedAt        *time.Time        `json:"archived_at,omitempty"`
+	CreatedAt         time.Time         `json:"created_at"`
+	UpdatedAt         time.Time         `json:"updated_at"`
+}
+
+// Serialized reports whether the product is tracked per unit.
+func (p Product) Serialized() bool {
+	return p.IsSerialized != nil && *p.IsSerialized
+}
+
+// HasVariants reports whether the product is the parent of variants.
+func (p Product) HasVariants() bool {
+	return len(p.VariantAttributes) > 0
+}
+
+// ProductUnit converts a unit of a product to its base unit: one UnitCode is
+// Factor base units.
+type ProductUnit struct {
+	UnitCode string `json:"unit_code"`
+	Factor   int64  `json:"factor"`
 }
 
+// ProductStock reports on-hand stock (TotalStock), the part of it held by
+// active reservations and what is left to promise.
 type ProductStock struct {
-	ProductID   int64  `json:"product_id"`
-	TotalStock  int64  `json:"total_stock"`
-	ProductName string `json:"product_name"`
-	SKU         string `json:"sku"`
+	ProductID      int64  `json:"product_id"`
+	TotalStock     int64  `json:"total_stock"`
+	ReservedStock  int64  `json:"reserved_stock"`
+	AvailableStock int64  `json:"available_stock"`
+	ProductName    string `json:"product_name"`
+	SKU            string `json:"sku"`
+	CategoryID     int64  `json:"category_id,omitempty"`
+	ParentID       int64  `json:"parent_id,omitempty"`
+}
+
+// ProductSortFields are the fields products can be sorted by.
+var ProductSortFields = map[string]bool{
+	"product_id":   true,
+	"product_name": true,
+	"price":        true,
+	"sku":          true,
+	"created_at":   true,
+	"updated_at":   true,
+}
+
+// ProductFilter narrows and orders the products listed. Search matches part of
+// the name, description or SKU; ranges include their bounds. CategoryID takes
+// the products of a category and of all categories below it. Archived
+// products are only listed with IncludeArchived. Empty fields do not filter,
+// and products are sorted by product_id when SortBy is empty.
+type ProductFilter struct {
+	Search          string
+	CategoryID      int64
+	IncludeArchived bool
+	MinPrice        *float64
+	MaxPrice        *float64
+	CreatedFrom     *time.Time
+	CreatedTo       *time.Time
+	UpdatedFrom     *time.Time
+	UpdatedTo       *time.Time
+	SortBy          string
+	Descending      bool
+}
+
+// ProductPage is one page of the products matching a filter and, for a page
+// found by number, how many match it in total.
+type ProductPage struct {
+	Products []Product `json:"products"`
+	Total    *int64    `json:"total,omitempty"`
+	Page     int32     `json:"page,omitempty"`
+	Limit    int32     `json:"limit"`
 }
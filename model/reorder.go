@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+// ReorderPoint holds the stock levels kept for a product in a warehouse. When
+// available stock falls below MinQuantity, ReorderQuantity should be ordered;
+// MaxQuantity caps how much is kept, 0 for no cap.
+type ReorderPoint struct {
+	ProductID       int64     `json:"product_id"`
+	WarehouseID     int64     `json:"warehouse_id"`
+	MinQuantity     int64     `json:"min_quantity"`
+	MaxQuantity     int64     `json:"max_quantity"`
+	ReorderQuantity int64     `json:"reorder_quantity"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// LowStockItem is a product whose available stock in a warehouse is below its
+// reorder point. SuggestedQuantity tops it up to MaxQuantity when one is set,
+// and is ReorderQuantity otherwise.
+type LowStockItem struct {
+	ProductID         int64  `json:"product_id"`
+	ProductName       string `json:"product_name"`
+	SKU               string `json:"sku"`
+	WarehouseID       int64  `json:"warehouse_id"`
+	AvailableStock    int64  `json:"available_stock"`
+	MinQuantity       int64  `json:"min_quantity"`
+	MaxQuantity       int64  `json:"max_quantity"`
+	ReorderQuantity   int64  `json:"reorder_quantity"`
+	SuggestedQuantity int64  `json:"suggested_quantity"`
+}
@@ -0,0 +1,35 @@
+package model
+
+import "time"
+
+type ReservationStatus string
+
+const (
+	ReservationActive    = ReservationStatus("ACTIVE")
+	ReservationConfirmed = ReservationStatus("CONFIRMED")
+	ReservationReleased  = ReservationStatus("RELEASED")
+	ReservationExpired   = ReservationStatus("EXPIRED")
+)
+
+// Reservation holds stock at a location for an order until it is confirmed
+// into an OUT transaction, released or expires.
+type Reservation struct {
+	ReservationID int64             `json:"reservation_id"`
+	ProductID     int64             `json:"product_id"`
+	WarehouseID   int64             `json:"warehouse_id"`
+	LocationID    int64             `json:"location_id"`
+	Quantity      int64             `json:"quantity"`
+	Status        ReservationStatus `json:"status"`
+	Reference     string            `json:"reference,omitempty"`
+	ExpiresAt     time.Time         `json:"expires_at"`
+	TransactionID int64             `json:"transaction_id,omitempty"`
+	CreatedBy     int64             `json:"created_by"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+}
+
+// ReservationConfirmation is the optional body of a confirmation; reservations
+// of serialized products list the units shipped.
+type ReservationConfirmation struct {
+	SerialNumbers []string `json:"serial_numbers"`
+}
@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+type SerialStatus string
+
+const (
+	SerialInStock   = SerialStatus("IN_STOCK")
+	SerialInTransit = SerialStatus("IN_TRANSIT")
+	SerialShipped   = SerialStatus("SHIPPED")
+)
+
+// Serial is one unit of a serialized product. WarehouseID and LocationID are
+// where the unit currently is and are empty unless it is in stock.
+type Serial struct {
+	SerialID     int64              `json:"serial_id"`
+	ProductID    int64              `json:"product_id"`
+	SerialNumber string             `json:"serial_number"`
+	Status       SerialStatus       `json:"status"`
+	WarehouseID  int64              `json:"warehouse_id,omitempty"`
+	LocationID   int64              `json:"location_id,omitempty"`
+	LotNumber    string             `json:"lot_number,omitempty"`
+	CreatedAt    time.Time          `json:"created_at"`
+	UpdatedAt    time.Time          `json:"updated_at"`
+	Movements    []StockTransaction `json:"movements"`
+}
@@ -0,0 +1,48 @@
+package model
+
+import "time"
+
+type StockTakeStatus string
+
+const (
+	StockTakeOpen      = StockTakeStatus("OPEN")
+	StockTakeApproved  = StockTakeStatus("APPROVED")
+	StockTakeCancelled = StockTakeStatus("CANCELLED")
+)
+
+// StockTake is a physical count of a warehouse, or of some of its locations
+// when LocationIDs is set. Expected quantities are frozen when it is opened;
+// approving it posts the variances as ADJUSTMENT transactions.
+type StockTake struct {
+	StockTakeID int64           `json:"stock_take_id"`
+	WarehouseID int64           `json:"warehouse_id"`
+	LocationIDs []int64         `json:"location_ids,omitempty"`
+	Status      StockTakeStatus `json:"status"`
+	CreatedBy   int64           `json:"created_by"`
+	ApprovedBy  int64           `json:"approved_by,omitempty"`
+	ApprovedAt  *time.Time      `json:"approved_at,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	Lines       []StockTakeLine `json:"lines"`
+}
+
+// StockTakeLine is one product at one location of a stock take. CountedQuantity
+// and Variance stay empty until the line is counted.
+type StockTakeLine struct {
+	LineID           int64  `json:"line_id"`
+	ProductID        int64  `json:"product_id"`
+	ProductName      string `json:"product_name"`
+	SKU              string `json:"sku"`
+	LocationID       int64  `json:"location_id"`
+	ExpectedQuantity int64  `json:"expected_quantity"`
+	CountedQuantity  *int64 `json:"counted_quantity"`
+	Variance         *int64 `json:"variance"`
+	TransactionID    int64  `json:"transaction_id,omitempty"`
+}
+
+// StockTakeCount is a counted quantity submitted for a stock take.
+type StockTakeCount struct {
+	ProductID       int64 `json:"product_id"`
+	LocationID      int64 `json:"location_id"`
+	CountedQuantity int64 `json:"counted_quantity"`
+}
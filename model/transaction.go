@@ -1,20 +1,118 @@
 package model
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 type TransactionType string
 
 const (
-	StockIn  = TransactionType("IN")
-	StockOut = TransactionType("OUT")
+	StockIn         = TransactionType("IN")
+	StockOut        = TransactionType("OUT")
+	StockTransfer   = TransactionType("TRANSFER")
+	StockOpening    = TransactionType("OPENING")
+	StockAdjustment = TransactionType("ADJUSTMENT")
+	StockReversal   = TransactionType("REVERSAL")
+	StockAssembly   = TransactionType("ASSEMBLY")
+)
+
+type TransactionStatus string
+
+const (
+	TransactionCompleted = TransactionStatus("COMPLETED")
+	TransactionInTransit = TransactionStatus("IN_TRANSIT")
+	TransactionReversed  = TransactionStatus("REVERSED")
 )
 
+// StockTransaction is a movement in the trx_stock ledger. Requests carry a positive
+// Quantity; once posted, Quantity is the signed delta applied to the location and
+// BalanceAfter is the location balance that resulted from it.
+//
+// LotNumber and ExpiryDate tag stock received in lots. An OUT or TRANSFER without
+// a LotNumber is taken first-expired-first-out and posted as one row per lot.
+// Movements of serialized products list one SerialNumbers entry per unit.
+//
+// An ADJUSTMENT request carries a signed Quantity, negative to write stock off,
+// and must give one of the configured ReasonCode values. Notes and
+// ReferenceDocument are free text kept with the movement for audit.
+//
+// An OUT of a bundle lists the bundle's Components; the part of Quantity not
+// covered by finished bundles is taken out as components, in the same database
+// transaction. An ASSEMBLY turns components into finished bundles.
+//
+// An IN may give the UnitCost it was bought at; movements into stock without
+// one are valued at the warehouse's current average cost. Every movement
+// records the CostAmount by which it changed the warehouse's stock value, the
+// cost of goods for stock taken out.
+//
+// A request may give Quantity in any Unit configured for the product; it is
+// converted and posted in the product's base unit. Every row posted for it keeps
+// the Unit and the UnitQuantity it was entered as.
+//
+// A request may name the product by a scanned Barcode instead of ProductID. A
+// GS1-128 label also fills in LotNumber and ExpiryDate when they are not given.
+//
+// A REVERSAL row undoes the movement named by ReversalOfID, which is then
+// marked REVERSED.
 type StockTransaction struct {
-	TransactionID   int64           `json:"transaction_id"`
-	ProductID       int64           `json:"product_id"`
-	WarehouseID     int64           `json:"warehouse_id"`
-	TransactionType TransactionType `json:"transaction_type"`
-	Quantity        int64           `json:"quantity"`
-	TransactionDate time.Time       `json:"transaction_date"`
-	CreatedBy       int64           `json:"created_by"`
+	TransactionID          int64             `json:"transaction_id"`
+	ProductID              int64             `json:"product_id"`
+	Barcode                string            `json:"barcode,omitempty"`
+	WarehouseID            int64             `json:"warehouse_id"`
+	LocationID             int64             `json:"location_id"`
+	TransactionType        TransactionType   `json:"transaction_type"`
+	Quantity               int64             `json:"quantity"`
+	BalanceAfter           int64             `json:"balance_after"`
+	TransactionDate        time.Time         `json:"transaction_date"`
+	CreatedBy              int64             `json:"created_by"`
+	DestinationWarehouseID int64             `json:"destination_warehouse_id,omitempty"`
+	DestinationLocationID  int64             `json:"destination_location_id,omitempty"`
+	Status                 TransactionStatus `json:"status,omitempty"`
+	ReferenceID            int64             `json:"reference_id,omitempty"`
+	LotNumber              string            `json:"lot_number,omitempty"`
+	ExpiryDate             *Date             `json:"expiry_date,omitempty"`
+	SerialNumbers          []string          `json:"serial_numbers,omitempty"`
+	ReasonCode             string            `json:"reason_code,omitempty"`
+	Notes                  string            `json:"notes,omitempty"`
+	ReferenceDocument      string            `json:"reference_document,omitempty"`
+	ReversalOfID           int64             `json:"reversal_of_id,omitempty"`
+	Unit                   string            `json:"unit,omitempty"`
+	UnitQuantity           int64             `json:"unit_quantity,omitempty"`
+	UnitCost               *float64          `json:"unit_cost,omitempty"`
+	CostAmount             float64           `json:"cost_amount,omitempty"`
+	Components             []BundleComponent `json:"-"`
+}
+
+// AdjustmentReasonStockTake is the reason given to adjustments posted by an
+// approved stock take.
+const AdjustmentReasonStockTake = "STOCK_TAKE"
+
+// StockTransactionFilter narrows the ledger rows listed for a user. Empty
+// fields do not filter.
+type StockTransactionFilter struct {
+	CreatedBy   int64
+	ReasonCodes []string
+}
+
+// StockTransactionBatch is a set of IN, OUT and ADJUSTMENT lines posted all or
+// nothing. TransactionIDs holds, once posted, the first ledger row of each line.
+type StockTransactionBatch struct {
+	Lines          []StockTransaction `json:"lines"`
+	TransactionIDs []int64            `json:"transaction_ids,omitempty"`
+}
+
+// StockBatchLineError is why a line of a batch was rejected. Line counts from 1.
+type StockBatchLineError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// StockBatchError rejects a batch, listing every failing line.
+type StockBatchError struct {
+	Lines []StockBatchLineError
+}
+
+func (e *StockBatchError) Error() string {
+	return fmt.Sprintf("%d batch lines rejected", len(e.Lines))
 }
@@ -0,0 +1,40 @@
+package model
+
+import (
+	"math"
+	"time"
+)
+
+// CostingMethod decides the cost of stock leaving a warehouse.
+type CostingMethod string
+
+const (
+	// CostingFIFO takes the cost of the oldest receipts still in stock.
+	CostingFIFO = CostingMethod("FIFO")
+	// CostingAverage takes the moving average cost of the stock on hand.
+	CostingAverage = CostingMethod("AVERAGE")
+)
+
+// ValuationItem is the quantity and book value of a product in a warehouse.
+type ValuationItem struct {
+	ProductID   int64   `json:"product_id"`
+	ProductName string  `json:"product_name"`
+	SKU         string  `json:"sku"`
+	Quantity    int64   `json:"quantity"`
+	StockValue  float64 `json:"stock_value"`
+	UnitCost    float64 `json:"unit_cost"`
+}
+
+// InventoryValuation values the stock of a warehouse as of a point in time.
+type InventoryValuation struct {
+	WarehouseID   int64           `json:"warehouse_id"`
+	CostingMethod CostingMethod   `json:"costing_method"`
+	AsOf          time.Time       `json:"as_of"`
+	TotalValue    float64         `json:"total_value"`
+	Items         []ValuationItem `json:"items"`
+}
+
+// RoundCost rounds to the precision costs are stored with.
+func RoundCost(cost float64) float64 {
+	return math.Round(cost*10000) / 10000
+}
@@ -0,0 +1,27 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/budsx/retail-management/model"
+	"github.com/budsx/retail-management/utils"
+)
+
+type emailNotifier struct {
+	to     []string
+	logger utils.Interface
+}
+
+// NewEmailNotifier stands in for mail delivery: it writes the message it would
+// send to the log.
+func NewEmailNotifier(to []string, logger utils.Interface) Notifier {
+	return &emailNotifier{to: to, logger: logger}
+}
+
+func (n *emailNotifier) NotifyLowStock(ctx context.Context, item model.LowStockItem) error {
+	n.logger.Info(fmt.Sprintf("[EMAIL] To: %s Subject: Low stock %s (%s) in warehouse %d - %d available, reorder point %d, suggested order %d",
+		strings.Join(n.to, ", "), item.ProductName, item.SKU, item.WarehouseID, item.AvailableStock, item.MinQuantity, item.SuggestedQuantity))
+	return nil
+}
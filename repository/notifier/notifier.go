@@ -0,0 +1,40 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/budsx/retail-management/model"
+	"github.com/budsx/retail-management/utils"
+)
+
+// Notifier tells someone outside the service that stock is running low.
+type Notifier interface {
+	NotifyLowStock(ctx context.Context, item model.LowStockItem) error
+}
+
+// Config picks the notifier: "webhook" posts to WebhookURL, "email" writes the
+// mail it would send to EmailTo to the log, and anything else disables
+// notifications.
+type Config struct {
+	Kind       string
+	WebhookURL string
+	EmailTo    []string
+	Timeout    time.Duration
+}
+
+// New returns the notifier described by conf, or nil when it is disabled.
+func New(conf Config, logger utils.Interface) (Notifier, error) {
+	switch conf.Kind {
+	case "webhook":
+		if conf.WebhookURL == "" {
+			return nil, fmt.Errorf("webhook notifier needs a URL")
+		}
+		return NewWebhookNotifier(conf.WebhookURL, conf.Timeout), nil
+	case "email":
+		return NewEmailNotifier(conf.EmailTo, logger), nil
+	default:
+		return nil, nil
+	}
+}
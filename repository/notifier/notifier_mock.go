@@ -0,0 +1,50 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: notifier.go
+
+// Package notifier is a generated GoMock package.
+package notifier
+
+import (
+	context "context"
+	reflect "reflect"
+
+	model "github.com/budsx/retail-management/model"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockNotifier is a mock of Notifier interface.
+type MockNotifier struct {
+	ctrl     *gomock.Controller
+	recorder *MockNotifierMockRecorder
+}
+
+// MockNotifierMockRecorder is the mock recorder for MockNotifier.
+type MockNotifierMockRecorder struct {
+	mock *MockNotifier
+}
+
+// NewMockNotifier creates a new mock instance.
+func NewMockNotifier(ctrl *gomock.Controller) *MockNotifier {
+	mock := &MockNotifier{ctrl: ctrl}
+	mock.recorder = &MockNotifierMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockNotifier) EXPECT() *MockNotifierMockRecorder {
+	return m.recorder
+}
+
+// NotifyLowStock mocks base method.
+func (m *MockNotifier) NotifyLowStock(ctx context.Context, item model.LowStockItem) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NotifyLowStock", ctx, item)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// NotifyLowStock indicates an expected call of NotifyLowStock.
+func (mr *MockNotifierMockRecorder) NotifyLowStock(ctx, item interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NotifyLowStock", reflect.TypeOf((*MockNotifier)(nil).NotifyLowStock), ctx, item)
+}
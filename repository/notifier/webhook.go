@@ -0,0 +1,51 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/budsx/retail-management/model"
+)
+
+type webhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookNotifier posts each alert as JSON to url.
+func NewWebhookNotifier(url string, timeout time.Duration) Notifier {
+	return &webhookNotifier{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+type lowStockEvent struct {
+	Event string             `json:"event"`
+	Item  model.LowStockItem `json:"item"`
+}
+
+func (n *webhookNotifier) NotifyLowStock(ctx context.Context, item model.LowStockItem) error {
+	body, err := json.Marshal(lowStockEvent{Event: "low_stock", Item: item})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("webhook answered %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/budsx/retail-management/model"
+	"github.com/lib/pq"
+)
+
+func (rw *dbReadWriter) ReadProductBarcodes(ctx context.Context, productID int64) ([]model.ProductBarcode, error) {
+	selectBarcodes := `SELECT barcode, barcode_type, created_at FROM mst_product_barcode WHERE product_id = $1 ORDER BY created_at, barcode`
+
+	rows, err := rw.db.QueryContext(ctx, selectBarcodes, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	barcodes := []model.ProductBarcode{}
+	for rows.Next() {
+		var barcode model.ProductBarcode
+		if err := rows.Scan(&barcode.Barcode, &barcode.Type, &barcode.CreatedAt); err != nil {
+			return nil, err
+		}
+		barcodes = append(barcodes, barcode)
+	}
+
+	return barcodes, rows.Err()
+}
+
+// WriteProductBarcode registers a barcode for the product. A barcode already
+// registered, for this product or another, is ErrBarcodeInUse.
+func (rw *dbReadWriter) WriteProductBarcode(ctx context.Context, productID int64, barcode model.ProductBarcode) error {
+	insertBarcode := `INSERT INTO mst_product_barcode (barcode, product_id, barcode_type, created_at) 
+		VALUES ($1, $2, $3, CURRENT_TIMESTAMP)`
+
+	_, err := rw.db.ExecContext(ctx, insertBarcode, barcode.Barcode, productID, barcode.Type)
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return fmt.Errorf("%w: %s", model.ErrBarcodeInUse, barcode.Barcode)
+	}
+	return err
+}
+
+func (rw *dbReadWriter) DeleteProductBarcode(ctx context.Context, productID int64, barcode string) error {
+	deleteBarcode := `DELETE FROM mst_product_barcode WHERE product_id = $1 AND barcode = $2`
+
+	result, err := rw.db.ExecContext(ctx, deleteBarcode, productID, barcode)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: %s for product %d", model.ErrBarcodeNotFound, barcode, productID)
+	}
+
+	return nil
+}
+
+// ReadProductIDByBarcode returns the product registered under any of the
+// barcodes, which are the forms one scanned code may be registered in.
+func (rw *dbReadWriter) ReadProductIDByBarcode(ctx context.Context, barcodes []string) (int64, error) {
+	selectProductID := `SELECT product_id FROM mst_product_barcode WHERE barcode = ANY($1) ORDER BY LENGTH(barcode) DESC LIMIT 1`
+
+	var productID int64
+	err := rw.db.QueryRowContext(ctx, selectProductID, pq.Array(barcodes)).Scan(&productID)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("%w: %s", model.ErrBarcodeNotFound, barcodes[0])
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return productID, nil
+}
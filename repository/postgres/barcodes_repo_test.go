@@ -0,0 +1,110 @@
+package postgres
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/budsx/retail-management/model"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_WriteProductBarcode(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	insertBarcode := regexp.QuoteMeta(`INSERT INTO mst_product_barcode (barcode, product_id, barcode_type, created_at) VALUES ($1, $2, $3, CURRENT_TIMESTAMP)`)
+	barcode := model.ProductBarcode{Barcode: "8992761111113", Type: model.BarcodeEAN13}
+	rw := &dbReadWriter{db: db}
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectExec(insertBarcode).
+			WithArgs("8992761111113", 4, model.BarcodeEAN13).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := rw.WriteProductBarcode(context.Background(), 4, barcode)
+		assert.NoError(t, err)
+	})
+
+	t.Run("already registered", func(t *testing.T) {
+		mock.ExpectExec(insertBarcode).
+			WithArgs("8992761111113", 4, model.BarcodeEAN13).
+			WillReturnError(&pq.Error{Code: "23505"})
+
+		err := rw.WriteProductBarcode(context.Background(), 4, barcode)
+		assert.ErrorIs(t, err, model.ErrBarcodeInUse)
+	})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func Test_DeleteProductBarcode(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	deleteBarcode := regexp.QuoteMeta(`DELETE FROM mst_product_barcode WHERE product_id = $1 AND barcode = $2`)
+	rw := &dbReadWriter{db: db}
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectExec(deleteBarcode).WithArgs(4, "8992761111113").WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := rw.DeleteProductBarcode(context.Background(), 4, "8992761111113")
+		assert.NoError(t, err)
+	})
+
+	t.Run("not registered for the product", func(t *testing.T) {
+		mock.ExpectExec(deleteBarcode).WithArgs(4, "8992761111113").WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := rw.DeleteProductBarcode(context.Background(), 4, "8992761111113")
+		assert.ErrorIs(t, err, model.ErrBarcodeNotFound)
+	})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func Test_ReadProductIDByBarcode(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	selectProductID := regexp.QuoteMeta(`SELECT product_id FROM mst_product_barcode WHERE barcode = ANY($1) ORDER BY LENGTH(barcode) DESC LIMIT 1`)
+	barcodes := []string{"08992761111113", "8992761111113"}
+	rw := &dbReadWriter{db: db}
+
+	t.Run("registered", func(t *testing.T) {
+		mock.ExpectQuery(selectProductID).
+			WithArgs(pq.Array(barcodes)).
+			WillReturnRows(sqlmock.NewRows([]string{"product_id"}).AddRow(4))
+
+		got, err := rw.ReadProductIDByBarcode(context.Background(), barcodes)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(4), got)
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		mock.ExpectQuery(selectProductID).
+			WithArgs(pq.Array(barcodes)).
+			WillReturnRows(sqlmock.NewRows([]string{"product_id"}))
+
+		_, err := rw.ReadProductIDByBarcode(context.Background(), barcodes)
+		assert.ErrorIs(t, err, model.ErrBarcodeNotFound)
+	})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,150 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/budsx/retail-management/model"
+	"github.com/lib/pq"
+)
+
+func (rw *dbReadWriter) ReadBundleComponents(ctx context.Context, bundleID int64) ([]model.BundleComponent, error) {
+	selectComponents := `SELECT c.component_id, p.product_name, p.sku, c.quantity 
+		FROM mst_bundle_component c 
+		INNER JOIN mst_product p ON p.product_id = c.component_id 
+		WHERE c.bundle_id = $1 
+		ORDER BY c.component_id`
+
+	rows, err := rw.db.QueryContext(ctx, selectComponents, bundleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	components := []model.BundleComponent{}
+	for rows.Next() {
+		var component model.BundleComponent
+		if err := rows.Scan(&component.ComponentID, &component.ProductName, &component.SKU, &component.Quantity); err != nil {
+			return nil, err
+		}
+		components = append(components, component)
+	}
+
+	return components, rows.Err()
+}
+
+// SetBundleComponents replaces the bundle's bill of materials. An empty list
+// turns the product back into a plain one. Bundles do not nest: a product that
+// is a component of another bundle cannot become one, and a bundle cannot be a
+// component. Neither a bundle nor its components may be serialized.
+func (rw *dbReadWriter) SetBundleComponents(ctx context.Context, bundleID int64, components []model.BundleComponent) error {
+	deleteComponents := `DELETE FROM mst_bundle_component WHERE bundle_id = $1`
+	insertComponent := `INSERT INTO mst_bundle_component (bundle_id, component_id, quantity) VALUES ($1, $2, $3)`
+	updateProduct := `UPDATE mst_product SET is_bundle = $1, updated_at = CURRENT_TIMESTAMP WHERE product_id = $2`
+
+	tx, err := rw.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if len(components) > 0 {
+		err = lockBundleProducts(ctx, tx, bundleID, components)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, deleteComponents, bundleID)
+	if err != nil {
+		return err
+	}
+
+	for _, component := range components {
+		_, err = tx.ExecContext(ctx, insertComponent, bundleID, component.ComponentID, component.Quantity)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, updateProduct, len(components) > 0, bundleID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// lockBundleProducts locks the bundle and its components in product order and
+// checks that they can be put together. Editing a product takes the same row
+// lock, so a product cannot be serialized while it is being made a component.
+func lockBundleProducts(ctx context.Context, tx *sql.Tx, bundleID int64, components []model.BundleComponent) error {
+	lockProducts := `SELECT p.product_id, p.is_serialized, p.is_bundle, 
+		EXISTS (SELECT 1 FROM mst_bundle_component c WHERE c.component_id = p.product_id AND c.bundle_id <> $2) 
+		FROM mst_product p WHERE p.product_id = ANY($1) ORDER BY p.product_id FOR UPDATE`
+
+	productIDs := []int64{bundleID}
+	for _, component := range components {
+		productIDs = append(productIDs, component.ComponentID)
+	}
+
+	rows, err := tx.QueryContext(ctx, lockProducts, pq.Array(productIDs), bundleID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	found := 0
+	for rows.Next() {
+		var productID int64
+		var serialized, bundle, component bool
+		if err := rows.Scan(&productID, &serialized, &bundle, &component); err != nil {
+			return err
+		}
+		found++
+
+		if productID == bundleID && (serialized || component) {
+			return fmt.Errorf("%w: product %d is serialized or a component of another bundle", model.ErrInvalidBundle, productID)
+		}
+		if productID != bundleID && (serialized || bundle) {
+			return fmt.Errorf("%w: product %d is a bundle or serialized", model.ErrInvalidBundle, productID)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if found != len(productIDs) {
+		return fmt.Errorf("%w: bundle %d or one of its components not found", model.ErrInvalidBundle, bundleID)
+	}
+
+	return nil
+}
+
+// GetBundleStock returns the available stock of the bundle and of each of its
+// components per location, limited to one location unless locationID is 0.
+func (rw *dbReadWriter) GetBundleStock(ctx context.Context, bundleID, locationID int64) ([]model.LocationStock, error) {
+	selectStock := `SELECT product_id, COALESCE(location_id, 0), stock_quantity - reserved_quantity 
+		FROM mst_stock 
+		WHERE (product_id = $1 OR product_id IN (SELECT component_id FROM mst_bundle_component WHERE bundle_id = $1)) 
+		AND ($2 = 0 OR location_id = $2) 
+		ORDER BY location_id, product_id`
+
+	rows, err := rw.db.QueryContext(ctx, selectStock, bundleID, locationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stocks := []model.LocationStock{}
+	for rows.Next() {
+		var stock model.LocationStock
+		if err := rows.Scan(&stock.ProductID, &stock.LocationID, &stock.AvailableStock); err != nil {
+			return nil, err
+		}
+		stocks = append(stocks, stock)
+	}
+
+	return stocks, rows.Err()
+}
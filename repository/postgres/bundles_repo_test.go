@@ -0,0 +1,240 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/budsx/retail-management/model"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CreateStockTransaction_Bundle(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	lockStock := regexp.QuoteMeta(`SELECT location_id, stock_quantity, reserved_quantity FROM mst_stock WHERE product_id = $1 AND location_id = ANY($2) ORDER BY location_id FOR UPDATE`)
+	lockLots := regexp.QuoteMeta(`SELECT lot_id, lot_number, expiry_date, quantity FROM mst_stock_lot WHERE product_id = $1 AND location_id = $2 AND quantity > 0 ORDER BY expiry_date NULLS LAST, lot_id FOR UPDATE`)
+	lotColumns := []string{"lot_id", "lot_number", "expiry_date", "quantity"}
+	stockColumns := []string{"location_id", "stock_quantity", "reserved_quantity"}
+
+	bundleOut := model.StockTransaction{
+		ProductID:       10,
+		WarehouseID:     1,
+		LocationID:      2,
+		TransactionType: model.StockOut,
+		Quantity:        3,
+		CreatedBy:       1,
+		Components: []model.BundleComponent{
+			{ComponentID: 1, Quantity: 2},
+			{ComponentID: 2, Quantity: 1},
+		},
+	}
+
+	expectLocks := func(mock sqlmock.Sqlmock, bundle, first, second int64) {
+		for _, stock := range []struct{ productID, onHand int64 }{{1, first}, {2, second}, {10, bundle}} {
+			mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO mst_stock`)).
+				WithArgs(stock.productID, pq.Array([]int64{2})).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectQuery(lockStock).
+				WithArgs(stock.productID, pq.Array([]int64{2})).
+				WillReturnRows(sqlmock.NewRows(stockColumns).AddRow(2, stock.onHand, 0))
+			expectStockCostLock(mock, stock.productID, 2)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		mockSetup func(mock sqlmock.Sqlmock)
+		wantErr   error
+	}{
+		{
+			name: "Finished bundles first, components for the rest",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				expectLocks(mock, 1, 10, 5)
+				mock.ExpectQuery(lockLots).WithArgs(10, 2).WillReturnRows(sqlmock.NewRows(lotColumns))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE mst_stock`)).
+					WithArgs(0, 10, 2).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				expectUncostedMovement(mock, 10, 1, 1, -1)
+				mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO trx_stock`)).
+					WithArgs(10, 1, 2, "OUT", -1, 0, 1, "", nil, "", "", "", 0, 0.0, 0.0, "", 0).
+					WillReturnRows(sqlmock.NewRows([]string{"transaction_id"}).AddRow(7))
+				mock.ExpectQuery(lockLots).WithArgs(1, 2).WillReturnRows(sqlmock.NewRows(lotColumns))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE mst_stock`)).
+					WithArgs(6, 1, 2).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				expectUncostedMovement(mock, 1, 1, 10, -4)
+				mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO trx_stock`)).
+					WithArgs(1, 1, 2, "OUT", -4, 6, 1, "", nil, "", "Bundle 10", "", 0, 0.0, 0.0, "", 0).
+					WillReturnRows(sqlmock.NewRows([]string{"transaction_id"}).AddRow(8))
+				mock.ExpectQuery(lockLots).WithArgs(2, 2).WillReturnRows(sqlmock.NewRows(lotColumns))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE mst_stock`)).
+					WithArgs(3, 2, 2).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				expectUncostedMovement(mock, 2, 1, 5, -2)
+				mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO trx_stock`)).
+					WithArgs(2, 1, 2, "OUT", -2, 3, 1, "", nil, "", "Bundle 10", "", 0, 0.0, 0.0, "", 0).
+					WillReturnRows(sqlmock.NewRows([]string{"transaction_id"}).AddRow(9))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "Short component rolls back the whole bundle",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				expectLocks(mock, 0, 10, 2)
+				mock.ExpectQuery(lockLots).WithArgs(1, 2).WillReturnRows(sqlmock.NewRows(lotColumns))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE mst_stock`)).
+					WithArgs(4, 1, 2).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				expectUncostedMovement(mock, 1, 1, 10, -6)
+				mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO trx_stock`)).
+					WithArgs(1, 1, 2, "OUT", -6, 4, 1, "", nil, "", "Bundle 10", "", 0, 0.0, 0.0, "", 0).
+					WillReturnRows(sqlmock.NewRows([]string{"transaction_id"}).AddRow(8))
+				mock.ExpectQuery(lockLots).WithArgs(2, 2).WillReturnRows(sqlmock.NewRows(lotColumns))
+				mock.ExpectRollback()
+			},
+			wantErr: model.ErrInsufficientStock,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rw := &dbReadWriter{db: db}
+			tt.mockSetup(mock)
+
+			err := rw.CreateStockTransaction(context.Background(), bundleOut)
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "got %v", err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func Test_AssembleBundle(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	lockStock := regexp.QuoteMeta(`SELECT location_id, stock_quantity, reserved_quantity FROM mst_stock WHERE product_id = $1 AND location_id = ANY($2) ORDER BY location_id FOR UPDATE`)
+	lockLots := regexp.QuoteMeta(`SELECT lot_id, lot_number, expiry_date, quantity FROM mst_stock_lot WHERE product_id = $1 AND location_id = $2 AND quantity > 0 ORDER BY expiry_date NULLS LAST, lot_id FOR UPDATE`)
+
+	mock.ExpectBegin()
+	for _, stock := range []struct{ productID, onHand int64 }{{1, 10}, {10, 0}} {
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO mst_stock`)).
+			WithArgs(stock.productID, pq.Array([]int64{2})).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(lockStock).
+			WithArgs(stock.productID, pq.Array([]int64{2})).
+			WillReturnRows(sqlmock.NewRows([]string{"location_id", "stock_quantity", "reserved_quantity"}).AddRow(2, stock.onHand, 0))
+		expectStockCostLock(mock, stock.productID, 2)
+	}
+	mock.ExpectQuery(lockLots).WithArgs(1, 2).WillReturnRows(sqlmock.NewRows([]string{"lot_id", "lot_number", "expiry_date", "quantity"}))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE mst_stock`)).
+		WithArgs(4, 1, 2).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectUncostedMovement(mock, 1, 1, 10, -6)
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO trx_stock`)).
+		WithArgs(1, 1, 2, "ASSEMBLY", -6, 4, 1, "", nil, "", "", "", 0, 0.0, 0.0, "", 0).
+		WillReturnRows(sqlmock.NewRows([]string{"transaction_id"}).AddRow(11))
+	expectReceivable(mock, 10, 2)
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE mst_stock`)).
+		WithArgs(3, 10, 2).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectUncostedMovement(mock, 10, 1, 0, 3)
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO trx_stock`)).
+		WithArgs(10, 1, 2, "ASSEMBLY", 3, 3, 1, "", nil, "", "", "", 0, 0.0, 0.0, "", 0).
+		WillReturnRows(sqlmock.NewRows([]string{"transaction_id"}).AddRow(12))
+	mock.ExpectCommit()
+
+	rw := &dbReadWriter{db: db}
+	transactionID, err := rw.AssembleBundle(context.Background(), model.StockTransaction{
+		ProductID:       10,
+		WarehouseID:     1,
+		LocationID:      2,
+		TransactionType: model.StockAssembly,
+		Quantity:        3,
+		CreatedBy:       1,
+		Components:      []model.BundleComponent{{ComponentID: 1, Quantity: 2}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(12), transactionID)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func Test_SetBundleComponents(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	lockProducts := regexp.QuoteMeta(`SELECT p.product_id, p.is_serialized, p.is_bundle, EXISTS (SELECT 1 FROM mst_bundle_component c WHERE c.component_id = p.product_id AND c.bundle_id <> $2) FROM mst_product p WHERE p.product_id = ANY($1) ORDER BY p.product_id FOR UPDATE`)
+	productColumns := []string{"product_id", "is_serialized", "is_bundle", "component"}
+	rw := &dbReadWriter{db: db}
+
+	t.Run("components are replaced", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockProducts).
+			WithArgs(pq.Array([]int64{10, 1}), 10).
+			WillReturnRows(sqlmock.NewRows(productColumns).AddRow(1, false, false, true).AddRow(10, false, true, false))
+		mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM mst_bundle_component WHERE bundle_id = $1`)).
+			WithArgs(10).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO mst_bundle_component (bundle_id, component_id, quantity) VALUES ($1, $2, $3)`)).
+			WithArgs(10, 1, 2).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE mst_product SET is_bundle = $1, updated_at = CURRENT_TIMESTAMP WHERE product_id = $2`)).
+			WithArgs(true, 10).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := rw.SetBundleComponents(context.Background(), 10, []model.BundleComponent{{ComponentID: 1, Quantity: 2}})
+		assert.NoError(t, err)
+	})
+
+	t.Run("a component of another bundle cannot become one", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockProducts).
+			WithArgs(pq.Array([]int64{10, 1}), 10).
+			WillReturnRows(sqlmock.NewRows(productColumns).AddRow(1, false, false, false).AddRow(10, false, false, true))
+		mock.ExpectRollback()
+
+		err := rw.SetBundleComponents(context.Background(), 10, []model.BundleComponent{{ComponentID: 1, Quantity: 2}})
+		assert.ErrorIs(t, err, model.ErrInvalidBundle)
+	})
+
+	t.Run("a bundle cannot be a component", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockProducts).
+			WithArgs(pq.Array([]int64{10, 1}), 10).
+			WillReturnRows(sqlmock.NewRows(productColumns).AddRow(1, false, true, false).AddRow(10, false, false, false))
+		mock.ExpectRollback()
+
+		err := rw.SetBundleComponents(context.Background(), 10, []model.BundleComponent{{ComponentID: 1, Quantity: 2}})
+		assert.ErrorIs(t, err, model.ErrInvalidBundle)
+	})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
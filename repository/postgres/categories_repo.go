@@ -0,0 +1,180 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/budsx/retail-management/model"
+	"github.com/lib/pq"
+)
+
+const categoryColumns = `category_id, category_name, COALESCE(parent_id, 0), created_at, updated_at`
+
+func scanCategory(row rowScanner) (model.Category, error) {
+	var category model.Category
+	err := row.Scan(&category.CategoryID, &category.CategoryName, &category.ParentID, &category.CreatedAt, &category.UpdatedAt)
+	return category, err
+}
+
+// duplicateCategory turns the unique violation of a category named like one of
+// its siblings into ErrInvalidCategory.
+func duplicateCategory(err error, name string) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return fmt.Errorf("%w: a sibling category is already named %q", model.ErrInvalidCategory, name)
+	}
+	return err
+}
+
+// WriteCategory creates a category under its ParentID, or at the root when
+// it has none, and returns its ID.
+func (rw *dbReadWriter) WriteCategory(ctx context.Context, category model.Category) (int64, error) {
+	insertCategory := `INSERT INTO mst_category (category_name, parent_id, created_at, updated_at)
+		VALUES ($1, NULLIF($2, 0), CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+		RETURNING category_id`
+
+	var categoryID int64
+	err := rw.db.QueryRowContext(ctx, insertCategory, category.CategoryName, category.ParentID).Scan(&categoryID)
+	if err != nil {
+		return 0, duplicateCategory(err, category.CategoryName)
+	}
+
+	return categoryID, nil
+}
+
+func (rw *dbReadWriter) ReadCategoryByID(ctx context.Context, categoryID int64) (model.Category, error) {
+	selectCategory := `SELECT ` + categoryColumns + ` FROM mst_category WHERE category_id = $1`
+
+	category, err := scanCategory(rw.db.QueryRowContext(ctx, selectCategory, categoryID))
+	if err == sql.ErrNoRows {
+		return category, fmt.Errorf("%w: %d", model.ErrCategoryNotFound, categoryID)
+	}
+
+	return category, err
+}
+
+// ReadCategories lists every category, parents before the categories created
+// after them.
+func (rw *dbReadWriter) ReadCategories(ctx context.Context) ([]model.Category, error) {
+	selectCategories := `SELECT ` + categoryColumns + ` FROM mst_category ORDER BY category_id`
+
+	rows, err := rw.db.QueryContext(ctx, selectCategories)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := []model.Category{}
+	for rows.Next() {
+		category, err := scanCategory(rows)
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+
+	return categories, rows.Err()
+}
+
+// UpdateCategory renames a category and moves it under its ParentID. The
+// category and the ancestors of its new parent are locked, so concurrent moves
+// cannot close a loop in the tree.
+func (rw *dbReadWriter) UpdateCategory(ctx context.Context, category model.Category) error {
+	lockCategory := `SELECT category_id FROM mst_category WHERE category_id = $1 FOR UPDATE`
+
+	lockAncestors := `WITH RECURSIVE ancestors AS (
+			SELECT category_id, parent_id FROM mst_category WHERE category_id = $1
+			UNION ALL
+			SELECT c.category_id, c.parent_id FROM mst_category as c INNER JOIN ancestors as a ON c.category_id = a.parent_id
+		)
+		SELECT c.category_id FROM mst_category as c INNER JOIN ancestors as a ON c.category_id = a.category_id
+		FOR UPDATE OF c`
+
+	updateCategory := `UPDATE mst_category
+		SET category_name = $1, parent_id = NULLIF($2, 0), updated_at = CURRENT_TIMESTAMP
+		WHERE category_id = $3`
+
+	tx, err := rw.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var categoryID int64
+	err = tx.QueryRowContext(ctx, lockCategory, category.CategoryID).Scan(&categoryID)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("%w: %d", model.ErrCategoryNotFound, category.CategoryID)
+	}
+	if err != nil {
+		return err
+	}
+
+	if category.ParentID != 0 {
+		rows, err := tx.QueryContext(ctx, lockAncestors, category.ParentID)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		found := false
+		for rows.Next() {
+			var ancestorID int64
+			if err := rows.Scan(&ancestorID); err != nil {
+				return err
+			}
+			if ancestorID == category.CategoryID {
+				return fmt.Errorf("%w: category %d cannot be moved below itself", model.ErrInvalidCategory, category.CategoryID)
+			}
+			found = true
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if !found {
+			return fmt.Errorf("%w: parent category %d not found", model.ErrInvalidCategory, category.ParentID)
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, updateCategory, category.CategoryName, category.ParentID, category.CategoryID)
+	if err != nil {
+		return duplicateCategory(err, category.CategoryName)
+	}
+
+	return tx.Commit()
+}
+
+// DeleteCategory deletes a category that has no products or categories below
+// it.
+func (rw *dbReadWriter) DeleteCategory(ctx context.Context, categoryID int64) error {
+	deleteCategory := `DELETE FROM mst_category WHERE category_id = $1
+		AND NOT EXISTS (SELECT 1 FROM mst_category WHERE parent_id = $1)
+		AND NOT EXISTS (SELECT 1 FROM mst_product WHERE category_id = $1)`
+
+	selectExists := `SELECT EXISTS (SELECT 1 FROM mst_category WHERE category_id = $1)`
+
+	result, err := rw.db.ExecContext(ctx, deleteCategory, categoryID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected > 0 {
+		return nil
+	}
+
+	var exists bool
+	err = rw.db.QueryRowContext(ctx, selectExists, categoryID).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("%w: %d", model.ErrCategoryNotFound, categoryID)
+	}
+
+	return fmt.Errorf("%w: category %d still has products or subcategories", model.ErrCategoryInUse, categoryID)
+}
@@ -0,0 +1,223 @@
+package postgres
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/budsx/retail-management/model"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_WriteCategory(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	insertCategory := regexp.QuoteMeta(`INSERT INTO mst_category (category_name, parent_id, created_at, updated_at) VALUES ($1, NULLIF($2, 0), CURRENT_TIMESTAMP, CURRENT_TIMESTAMP) RETURNING category_id`)
+	rw := &dbReadWriter{db: db}
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectQuery(insertCategory).
+			WithArgs("Minuman", 1).
+			WillReturnRows(sqlmock.NewRows([]string{"category_id"}).AddRow(4))
+
+		got, err := rw.WriteCategory(context.Background(), model.Category{CategoryName: "Minuman", ParentID: 1})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(4), got)
+	})
+
+	t.Run("sibling with the same name", func(t *testing.T) {
+		mock.ExpectQuery(insertCategory).
+			WithArgs("Minuman", 1).
+			WillReturnError(&pq.Error{Code: "23505"})
+
+		_, err := rw.WriteCategory(context.Background(), model.Category{CategoryName: "Minuman", ParentID: 1})
+		assert.ErrorIs(t, err, model.ErrInvalidCategory)
+	})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func Test_ReadCategories(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	fixedTime := time.Now()
+	rows := sqlmock.NewRows([]string{"category_id", "category_name", "parent_id", "created_at", "updated_at"}).
+		AddRow(1, "Sembako", 0, fixedTime, fixedTime).
+		AddRow(2, "Beras", 1, fixedTime, fixedTime)
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT category_id, category_name, COALESCE(parent_id, 0), created_at, updated_at FROM mst_category ORDER BY category_id`)).
+		WillReturnRows(rows)
+
+	rw := &dbReadWriter{db: db}
+	got, err := rw.ReadCategories(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []model.Category{
+		{CategoryID: 1, CategoryName: "Sembako", CreatedAt: fixedTime, UpdatedAt: fixedTime},
+		{CategoryID: 2, CategoryName: "Beras", ParentID: 1, CreatedAt: fixedTime, UpdatedAt: fixedTime},
+	}, got)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func Test_UpdateCategory(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	lockCategory := regexp.QuoteMeta(`SELECT category_id FROM mst_category WHERE category_id = $1 FOR UPDATE`)
+	lockAncestors := regexp.QuoteMeta(`WITH RECURSIVE ancestors AS (`)
+	updateCategory := regexp.QuoteMeta(`UPDATE mst_category SET category_name = $1, parent_id = NULLIF($2, 0), updated_at = CURRENT_TIMESTAMP WHERE category_id = $3`)
+
+	tests := []struct {
+		name      string
+		category  model.Category
+		mockSetup func(sqlmock.Sqlmock)
+		wantErr   error
+	}{
+		{
+			name:     "moved under another branch",
+			category: model.Category{CategoryID: 2, CategoryName: "Beras", ParentID: 5},
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(lockCategory).WithArgs(2).WillReturnRows(sqlmock.NewRows([]string{"category_id"}).AddRow(2))
+				mock.ExpectQuery(lockAncestors).WithArgs(5).WillReturnRows(sqlmock.NewRows([]string{"category_id"}).AddRow(5).AddRow(3))
+				mock.ExpectExec(updateCategory).WithArgs("Beras", 5, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:     "moved to the root",
+			category: model.Category{CategoryID: 2, CategoryName: "Beras"},
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(lockCategory).WithArgs(2).WillReturnRows(sqlmock.NewRows([]string{"category_id"}).AddRow(2))
+				mock.ExpectExec(updateCategory).WithArgs("Beras", 0, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:     "moved below its own descendant",
+			category: model.Category{CategoryID: 1, CategoryName: "Sembako", ParentID: 2},
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(lockCategory).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"category_id"}).AddRow(1))
+				mock.ExpectQuery(lockAncestors).WithArgs(2).WillReturnRows(sqlmock.NewRows([]string{"category_id"}).AddRow(2).AddRow(1))
+				mock.ExpectRollback()
+			},
+			wantErr: model.ErrInvalidCategory,
+		},
+		{
+			name:     "unknown parent",
+			category: model.Category{CategoryID: 2, CategoryName: "Beras", ParentID: 99},
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(lockCategory).WithArgs(2).WillReturnRows(sqlmock.NewRows([]string{"category_id"}).AddRow(2))
+				mock.ExpectQuery(lockAncestors).WithArgs(99).WillReturnRows(sqlmock.NewRows([]string{"category_id"}))
+				mock.ExpectRollback()
+			},
+			wantErr: model.ErrInvalidCategory,
+		},
+		{
+			name:     "category not found",
+			category: model.Category{CategoryID: 99, CategoryName: "Beras"},
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(lockCategory).WithArgs(99).WillReturnRows(sqlmock.NewRows([]string{"category_id"}))
+				mock.ExpectRollback()
+			},
+			wantErr: model.ErrCategoryNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rw := &dbReadWriter{db: db}
+			tt.mockSetup(mock)
+
+			err := rw.UpdateCategory(context.Background(), tt.category)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func Test_DeleteCategory(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	deleteCategory := regexp.QuoteMeta(`DELETE FROM mst_category WHERE category_id = $1`)
+	selectExists := regexp.QuoteMeta(`SELECT EXISTS (SELECT 1 FROM mst_category WHERE category_id = $1)`)
+
+	tests := []struct {
+		name      string
+		mockSetup func(sqlmock.Sqlmock)
+		wantErr   error
+	}{
+		{
+			name: "success",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(deleteCategory).WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name: "still has products or subcategories",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(deleteCategory).WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(selectExists).WithArgs(3).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+			},
+			wantErr: model.ErrCategoryInUse,
+		},
+		{
+			name: "not found",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(deleteCategory).WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(selectExists).WithArgs(3).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			},
+			wantErr: model.ErrCategoryNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rw := &dbReadWriter{db: db}
+			tt.mockSetup(mock)
+
+			err := rw.DeleteCategory(context.Background(), 3)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
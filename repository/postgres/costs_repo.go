@@ -0,0 +1,211 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/budsx/retail-management/model"
+	"github.com/lib/pq"
+)
+
+// costPosition is a product's quantity and book value in a warehouse.
+type costPosition struct {
+	quantity int64
+	value    float64
+	method   model.CostingMethod
+}
+
+// averageCost is the value of one unit of the position.
+func (p costPosition) averageCost() float64 {
+	if p.quantity <= 0 {
+		return 0
+	}
+	return p.value / float64(p.quantity)
+}
+
+// movementCost is what a movement did to its warehouse's stock value.
+type movementCost struct {
+	unitCost float64
+	amount   float64
+}
+
+// lockStockCost locks the cost positions of the product in the warehouses of
+// the given locations, creating missing ones. The caller must already hold the
+// product's stock rows at those locations; positions are locked in warehouse
+// order after them.
+func lockStockCost(ctx context.Context, tx *sql.Tx, productID int64, locationIDs []int64) error {
+	// The no-op update takes the row lock on positions that already exist
+	lockPositions := `INSERT INTO mst_stock_cost (product_id, warehouse_id) 
+		SELECT DISTINCT $1::int, warehouse_id FROM mst_location WHERE location_id = ANY($2) ORDER BY warehouse_id 
+		ON CONFLICT (product_id, warehouse_id) DO UPDATE SET quantity = mst_stock_cost.quantity`
+
+	_, err := tx.ExecContext(ctx, lockPositions, productID, pq.Array(locationIDs))
+	return err
+}
+
+// postMovementCost values a movement of delta units of the product in the
+// movement's warehouse, locked with lockStockCost, and books it to the
+// warehouse's cost position and layers. Stock coming in is valued at the
+// movement's UnitCost, or the position's average cost when it has none; stock
+// going out at the cost given by the warehouse's costing method. A reversal
+// taking a receipt back out leaves at the UnitCost the receipt came in at.
+func postMovementCost(ctx context.Context, tx *sql.Tx, transaction model.StockTransaction, delta int64) (movementCost, error) {
+	selectPosition := `SELECT c.quantity, c.stock_value, w.costing_method FROM mst_stock_cost c 
+		INNER JOIN mst_warehouse w ON w.warehouse_id = c.warehouse_id 
+		WHERE c.product_id = $1 AND c.warehouse_id = $2`
+	insertLayer := `INSERT INTO mst_cost_layer (product_id, warehouse_id, unit_cost, remaining_quantity) VALUES ($1, $2, $3, $4)`
+	updatePosition := `UPDATE mst_stock_cost SET quantity = $1, stock_value = $2 WHERE product_id = $3 AND warehouse_id = $4`
+
+	var position costPosition
+	err := tx.QueryRowContext(ctx, selectPosition, transaction.ProductID, transaction.WarehouseID).
+		Scan(&position.quantity, &position.value, &position.method)
+	if err != nil {
+		return movementCost{}, err
+	}
+
+	var cost movementCost
+	if delta > 0 {
+		cost.unitCost = model.RoundCost(position.averageCost())
+		if transaction.UnitCost != nil {
+			cost.unitCost = *transaction.UnitCost
+		}
+		cost.amount = model.RoundCost(float64(delta) * cost.unitCost)
+
+		_, err = tx.ExecContext(ctx, insertLayer, transaction.ProductID, transaction.WarehouseID, cost.unitCost, delta)
+		if err != nil {
+			return movementCost{}, err
+		}
+	} else if transaction.ReversalOfID != 0 && transaction.UnitCost != nil {
+		quantity := -delta
+		cost.unitCost = *transaction.UnitCost
+		cost.amount = -model.RoundCost(float64(quantity) * cost.unitCost)
+
+		err = releaseCostLayers(ctx, tx, transaction.ProductID, transaction.WarehouseID, cost.unitCost, quantity)
+		if err != nil {
+			return movementCost{}, err
+		}
+	} else {
+		quantity := -delta
+		fifoCost, err := consumeCostLayers(ctx, tx, transaction.ProductID, transaction.WarehouseID, quantity)
+		if err != nil {
+			return movementCost{}, err
+		}
+
+		cost.amount = -fifoCost
+		if position.method == model.CostingAverage {
+			cost.amount = -position.value
+			if quantity < position.quantity {
+				cost.amount = -model.RoundCost(float64(quantity) * position.averageCost())
+			}
+		}
+		cost.unitCost = model.RoundCost(-cost.amount / float64(quantity))
+	}
+
+	_, err = tx.ExecContext(ctx, updatePosition, position.quantity+delta, model.RoundCost(position.value+cost.amount), transaction.ProductID, transaction.WarehouseID)
+	if err != nil {
+		return movementCost{}, err
+	}
+
+	return cost, nil
+}
+
+// consumeCostLayers takes quantity out of the product's oldest cost layers in
+// the warehouse and returns what it cost. Layers are only touched by holders
+// of the position lock. Stock beyond the recorded layers is free.
+func consumeCostLayers(ctx context.Context, tx *sql.Tx, productID, warehouseID, quantity int64) (float64, error) {
+	selectLayers := `SELECT layer_id, unit_cost, remaining_quantity FROM mst_cost_layer 
+		WHERE product_id = $1 AND warehouse_id = $2 AND remaining_quantity > 0 
+		ORDER BY layer_id`
+
+	return takeCostLayers(ctx, tx, quantity, selectLayers, productID, warehouseID)
+}
+
+// releaseCostLayers takes back out the layer a reversed receipt added at
+// unitCost. The newest layers at that cost go first; should the receipt's
+// stock already have been partly consumed, the rest comes off the newest
+// layers so the older ones stay next in line.
+func releaseCostLayers(ctx context.Context, tx *sql.Tx, productID, warehouseID int64, unitCost float64, quantity int64) error {
+	selectLayers := `SELECT layer_id, unit_cost, remaining_quantity FROM mst_cost_layer 
+		WHERE product_id = $1 AND warehouse_id = $2 AND remaining_quantity > 0 
+		ORDER BY unit_cost = $3 DESC, layer_id DESC`
+
+	_, err := takeCostLayers(ctx, tx, quantity, selectLayers, productID, warehouseID, unitCost)
+	return err
+}
+
+// takeCostLayers takes quantity out of the open layers selectLayers returns,
+// in its order, and returns what it cost.
+func takeCostLayers(ctx context.Context, tx *sql.Tx, quantity int64, selectLayers string, args ...interface{}) (float64, error) {
+	updateLayers := `UPDATE mst_cost_layer AS l SET remaining_quantity = l.remaining_quantity - t.taken 
+		FROM UNNEST($1::int[], $2::int[]) AS t(layer_id, taken) WHERE l.layer_id = t.layer_id`
+
+	rows, err := tx.QueryContext(ctx, selectLayers, args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var layerIDs, taken []int64
+	var cost float64
+	for quantity > 0 && rows.Next() {
+		var layerID, remaining int64
+		var unitCost float64
+		if err := rows.Scan(&layerID, &unitCost, &remaining); err != nil {
+			return 0, err
+		}
+
+		take := remaining
+		if take > quantity {
+			take = quantity
+		}
+		layerIDs = append(layerIDs, layerID)
+		taken = append(taken, take)
+		cost += float64(take) * unitCost
+		quantity -= take
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	rows.Close()
+
+	if len(layerIDs) == 0 {
+		return 0, nil
+	}
+
+	_, err = tx.ExecContext(ctx, updateLayers, pq.Array(layerIDs), pq.Array(taken))
+	if err != nil {
+		return 0, err
+	}
+
+	return model.RoundCost(cost), nil
+}
+
+// GetInventoryValuation adds up the ledger of a warehouse up to asOf into the
+// quantity and book value of each product that has either.
+func (rw *dbReadWriter) GetInventoryValuation(ctx context.Context, warehouseID int64, asOf time.Time) ([]model.ValuationItem, error) {
+	selectValuation := `SELECT s.product_id, p.product_name, p.sku, SUM(s.quantity), COALESCE(SUM(s.cost_amount), 0) 
+		FROM trx_stock s 
+		INNER JOIN mst_product p ON p.product_id = s.product_id 
+		WHERE s.warehouse_id = $1 AND s.transaction_date <= $2 
+		GROUP BY s.product_id, p.product_name, p.sku 
+		HAVING SUM(s.quantity) <> 0 OR COALESCE(SUM(s.cost_amount), 0) <> 0 
+		ORDER BY s.product_id`
+
+	rows, err := rw.db.QueryContext(ctx, selectValuation, warehouseID, asOf)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []model.ValuationItem{}
+	for rows.Next() {
+		var item model.ValuationItem
+		if err := rows.Scan(&item.ProductID, &item.ProductName, &item.SKU, &item.Quantity, &item.StockValue); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
@@ -0,0 +1,250 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/budsx/retail-management/model"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	lockStockCostQuery  = regexp.QuoteMeta(`INSERT INTO mst_stock_cost (product_id, warehouse_id) SELECT DISTINCT $1::int, warehouse_id FROM mst_location WHERE location_id = ANY($2) ORDER BY warehouse_id ON CONFLICT (product_id, warehouse_id) DO UPDATE SET quantity = mst_stock_cost.quantity`)
+	selectPositionQuery = regexp.QuoteMeta(`SELECT c.quantity, c.stock_value, w.costing_method FROM mst_stock_cost c INNER JOIN mst_warehouse w ON w.warehouse_id = c.warehouse_id WHERE c.product_id = $1 AND c.warehouse_id = $2`)
+	insertLayerQuery    = regexp.QuoteMeta(`INSERT INTO mst_cost_layer (product_id, warehouse_id, unit_cost, remaining_quantity) VALUES ($1, $2, $3, $4)`)
+	selectLayersQuery   = regexp.QuoteMeta(`SELECT layer_id, unit_cost, remaining_quantity FROM mst_cost_layer WHERE product_id = $1 AND warehouse_id = $2 AND remaining_quantity > 0 ORDER BY layer_id`)
+	releaseLayersQuery  = regexp.QuoteMeta(`SELECT layer_id, unit_cost, remaining_quantity FROM mst_cost_layer WHERE product_id = $1 AND warehouse_id = $2 AND remaining_quantity > 0 ORDER BY unit_cost = $3 DESC, layer_id DESC`)
+	updateLayersQuery   = regexp.QuoteMeta(`UPDATE mst_cost_layer AS l SET remaining_quantity = l.remaining_quantity - t.taken FROM UNNEST($1::int[], $2::int[]) AS t(layer_id, taken) WHERE l.layer_id = t.layer_id`)
+	updatePositionQuery = regexp.QuoteMeta(`UPDATE mst_stock_cost SET quantity = $1, stock_value = $2 WHERE product_id = $3 AND warehouse_id = $4`)
+)
+
+// expectStockCostLock expects the product's cost positions at the locations to
+// be locked after its stock rows.
+func expectStockCostLock(mock sqlmock.Sqlmock, productID int64, locationIDs ...int64) {
+	mock.ExpectExec(lockStockCostQuery).
+		WithArgs(productID, pq.Array(locationIDs)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+// expectUncostedMovement expects delta units to be booked to a FIFO position
+// of onHand units that carries no cost.
+func expectUncostedMovement(mock sqlmock.Sqlmock, productID, warehouseID, onHand, delta int64) {
+	mock.ExpectQuery(selectPositionQuery).
+		WithArgs(productID, warehouseID).
+		WillReturnRows(sqlmock.NewRows([]string{"quantity", "stock_value", "costing_method"}).AddRow(onHand, 0.0, "FIFO"))
+	if delta > 0 {
+		mock.ExpectExec(insertLayerQuery).
+			WithArgs(productID, warehouseID, 0.0, delta).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+	} else {
+		mock.ExpectQuery(selectLayersQuery).
+			WithArgs(productID, warehouseID).
+			WillReturnRows(sqlmock.NewRows([]string{"layer_id", "unit_cost", "remaining_quantity"}))
+	}
+	mock.ExpectExec(updatePositionQuery).
+		WithArgs(onHand+delta, 0.0, productID, warehouseID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func Test_postMovementCost(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	positionColumns := []string{"quantity", "stock_value", "costing_method"}
+	layerColumns := []string{"layer_id", "unit_cost", "remaining_quantity"}
+	unitCost := 12.5
+
+	tests := []struct {
+		name        string
+		transaction model.StockTransaction
+		delta       int64
+		mockSetup   func(mock sqlmock.Sqlmock)
+		want        movementCost
+	}{
+		{
+			name:        "Receipt at its unit cost",
+			transaction: model.StockTransaction{ProductID: 1, WarehouseID: 1, UnitCost: &unitCost},
+			delta:       4,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(selectPositionQuery).
+					WithArgs(1, 1).
+					WillReturnRows(sqlmock.NewRows(positionColumns).AddRow(6, 60.0, "FIFO"))
+				mock.ExpectExec(insertLayerQuery).
+					WithArgs(1, 1, 12.5, 4).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(updatePositionQuery).
+					WithArgs(10, 110.0, 1, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			want: movementCost{unitCost: 12.5, amount: 50},
+		},
+		{
+			name:        "Receipt without a cost takes the average",
+			transaction: model.StockTransaction{ProductID: 1, WarehouseID: 1},
+			delta:       2,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(selectPositionQuery).
+					WithArgs(1, 1).
+					WillReturnRows(sqlmock.NewRows(positionColumns).AddRow(3, 10.0, "AVERAGE"))
+				mock.ExpectExec(insertLayerQuery).
+					WithArgs(1, 1, 3.3333, 2).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(updatePositionQuery).
+					WithArgs(5, 16.6666, 1, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			want: movementCost{unitCost: 3.3333, amount: 6.6666},
+		},
+		{
+			name:        "FIFO takes the oldest layers first",
+			transaction: model.StockTransaction{ProductID: 1, WarehouseID: 1},
+			delta:       -5,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(selectPositionQuery).
+					WithArgs(1, 1).
+					WillReturnRows(sqlmock.NewRows(positionColumns).AddRow(10, 80.0, "FIFO"))
+				mock.ExpectQuery(selectLayersQuery).
+					WithArgs(1, 1).
+					WillReturnRows(sqlmock.NewRows(layerColumns).AddRow(7, 5.0, 3).AddRow(8, 10.0, 4).AddRow(9, 10.0, 3))
+				mock.ExpectExec(updateLayersQuery).
+					WithArgs(pq.Array([]int64{7, 8}), pq.Array([]int64{3, 2})).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec(updatePositionQuery).
+					WithArgs(5, 45.0, 1, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			want: movementCost{unitCost: 7, amount: -35},
+		},
+		{
+			name:        "Moving average ignores the layer costs",
+			transaction: model.StockTransaction{ProductID: 1, WarehouseID: 1},
+			delta:       -5,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(selectPositionQuery).
+					WithArgs(1, 1).
+					WillReturnRows(sqlmock.NewRows(positionColumns).AddRow(10, 80.0, "AVERAGE"))
+				mock.ExpectQuery(selectLayersQuery).
+					WithArgs(1, 1).
+					WillReturnRows(sqlmock.NewRows(layerColumns).AddRow(7, 5.0, 3).AddRow(8, 10.0, 7))
+				mock.ExpectExec(updateLayersQuery).
+					WithArgs(pq.Array([]int64{7, 8}), pq.Array([]int64{3, 2})).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec(updatePositionQuery).
+					WithArgs(5, 40.0, 1, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			want: movementCost{unitCost: 8, amount: -40},
+		},
+		{
+			name:        "Reversed receipt leaves at the cost it came in at",
+			transaction: model.StockTransaction{ProductID: 1, WarehouseID: 1, ReversalOfID: 5, UnitCost: &unitCost},
+			delta:       -4,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(selectPositionQuery).
+					WithArgs(1, 1).
+					WillReturnRows(sqlmock.NewRows(positionColumns).AddRow(10, 80.0, "FIFO"))
+				mock.ExpectQuery(releaseLayersQuery).
+					WithArgs(1, 1, 12.5).
+					WillReturnRows(sqlmock.NewRows(layerColumns).AddRow(9, 12.5, 4).AddRow(8, 5.0, 6))
+				mock.ExpectExec(updateLayersQuery).
+					WithArgs(pq.Array([]int64{9}), pq.Array([]int64{4})).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(updatePositionQuery).
+					WithArgs(6, 30.0, 1, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			want: movementCost{unitCost: 12.5, amount: -50},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock.ExpectBegin()
+			tt.mockSetup(mock)
+			mock.ExpectRollback()
+
+			tx, err := db.Begin()
+			if err != nil {
+				t.Fatalf("failed to begin: %v", err)
+			}
+
+			got, err := postMovementCost(context.Background(), tx, tt.transaction, tt.delta)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			tx.Rollback()
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func Test_GetInventoryValuation(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	asOf := time.Date(2024, 3, 31, 23, 59, 59, 0, time.UTC)
+	selectValuation := regexp.QuoteMeta(`SELECT s.product_id, p.product_name, p.sku, SUM(s.quantity), COALESCE(SUM(s.cost_amount), 0) FROM trx_stock s`)
+
+	tests := []struct {
+		name      string
+		mockSetup func(mock sqlmock.Sqlmock)
+		want      []model.ValuationItem
+		wantErr   bool
+	}{
+		{
+			name: "Successfully value warehouse",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(selectValuation).
+					WithArgs(1, asOf).
+					WillReturnRows(sqlmock.NewRows([]string{"product_id", "product_name", "sku", "quantity", "stock_value"}).
+						AddRow(1, "Beras", "BRS-5", 10, 650.0).
+						AddRow(2, "Minyak", "MNY-1", 4, 72.5))
+			},
+			want: []model.ValuationItem{
+				{ProductID: 1, ProductName: "Beras", SKU: "BRS-5", Quantity: 10, StockValue: 650},
+				{ProductID: 2, ProductName: "Minyak", SKU: "MNY-1", Quantity: 4, StockValue: 72.5},
+			},
+		},
+		{
+			name: "Database error",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(selectValuation).
+					WithArgs(1, asOf).
+					WillReturnError(sql.ErrConnDone)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rw := &dbReadWriter{db: db}
+			tt.mockSetup(mock)
+
+			got, err := rw.GetInventoryValuation(context.Background(), 1, asOf)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/budsx/retail-management/model"
+)
+
+// CreateIdempotencyKey claims a key for a request and reports whether it was
+// free. A key still unfinished after lease was abandoned by a request that
+// never completed, and is taken over by the same request sent again; any other
+// key that is already taken is left untouched.
+func (rw *dbReadWriter) CreateIdempotencyKey(ctx context.Context, record model.IdempotencyRecord, lease time.Duration) (bool, error) {
+	insertKey := `INSERT INTO trx_idempotency_key (user_id, idempotency_key, request_fingerprint) 
+		VALUES ($1, $2, $3) 
+		ON CONFLICT (user_id, idempotency_key) DO UPDATE SET created_at = NOW() 
+		WHERE trx_idempotency_key.status_code IS NULL 
+		AND trx_idempotency_key.request_fingerprint = EXCLUDED.request_fingerprint 
+		AND trx_idempotency_key.created_at <= NOW() - $4 * INTERVAL '1 second'`
+
+	result, err := rw.db.ExecContext(ctx, insertKey, record.UserID, record.Key, record.RequestFingerprint, int64(lease.Seconds()))
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected == 1, nil
+}
+
+func (rw *dbReadWriter) ReadIdempotencyKey(ctx context.Context, userID int64, key string) (model.IdempotencyRecord, error) {
+	query := `SELECT user_id, idempotency_key, request_fingerprint, COALESCE(status_code, 0), response_body, created_at 
+		FROM trx_idempotency_key WHERE user_id = $1 AND idempotency_key = $2`
+
+	var record model.IdempotencyRecord
+	err := rw.db.QueryRowContext(ctx, query, userID, key).Scan(
+		&record.UserID,
+		&record.Key,
+		&record.RequestFingerprint,
+		&record.StatusCode,
+		&record.ResponseBody,
+		&record.CreatedAt,
+	)
+	return record, err
+}
+
+// SaveIdempotentResponse stores the response of the request that claimed the key.
+func (rw *dbReadWriter) SaveIdempotentResponse(ctx context.Context, record model.IdempotencyRecord) error {
+	updateKey := `UPDATE trx_idempotency_key SET status_code = $1, response_body = $2 WHERE user_id = $3 AND idempotency_key = $4`
+
+	_, err := rw.db.ExecContext(ctx, updateKey, record.StatusCode, record.ResponseBody, record.UserID, record.Key)
+	return err
+}
+
+// DeleteIdempotencyKey frees a key so that the request can be tried again.
+func (rw *dbReadWriter) DeleteIdempotencyKey(ctx context.Context, userID int64, key string) error {
+	deleteKey := `DELETE FROM trx_idempotency_key WHERE user_id = $1 AND idempotency_key = $2`
+
+	_, err := rw.db.ExecContext(ctx, deleteKey, userID, key)
+	return err
+}
+
+// DeleteExpiredIdempotencyKeys forgets keys older than ttl and returns how many
+// were removed.
+func (rw *dbReadWriter) DeleteExpiredIdempotencyKeys(ctx context.Context, ttl time.Duration) (int64, error) {
+	deleteKeys := `DELETE FROM trx_idempotency_key WHERE created_at <= NOW() - $1 * INTERVAL '1 second'`
+
+	result, err := rw.db.ExecContext(ctx, deleteKeys, int64(ttl.Seconds()))
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
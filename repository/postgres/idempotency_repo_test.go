@@ -0,0 +1,123 @@
+package postgres
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/budsx/retail-management/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CreateIdempotencyKey(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	insertKey := regexp.QuoteMeta(`INSERT INTO trx_idempotency_key (user_id, idempotency_key, request_fingerprint) VALUES ($1, $2, $3) ON CONFLICT (user_id, idempotency_key) DO UPDATE SET created_at = NOW() WHERE trx_idempotency_key.status_code IS NULL AND trx_idempotency_key.request_fingerprint = EXCLUDED.request_fingerprint AND trx_idempotency_key.created_at <= NOW() - $4 * INTERVAL '1 second'`)
+	record := model.IdempotencyRecord{UserID: 3, Key: "order-1", RequestFingerprint: "abc"}
+
+	tests := []struct {
+		name      string
+		mockSetup func(sqlmock.Sqlmock)
+		want      bool
+	}{
+		{
+			name: "new key",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(insertKey).
+					WithArgs(3, "order-1", "abc", 60).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			want: true,
+		},
+		{
+			name: "abandoned key taken over",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(insertKey).
+					WithArgs(3, "order-1", "abc", 60).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			want: true,
+		},
+		{
+			name: "key already used",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(insertKey).
+					WithArgs(3, "order-1", "abc", 60).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rw := &dbReadWriter{db: db}
+			tt.mockSetup(mock)
+
+			got, err := rw.CreateIdempotencyKey(context.Background(), record, time.Minute)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func Test_ReadIdempotencyKey(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	fixedTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT user_id, idempotency_key, request_fingerprint, COALESCE(status_code, 0), response_body, created_at FROM trx_idempotency_key WHERE user_id = $1 AND idempotency_key = $2`)).
+		WithArgs(3, "order-1").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "idempotency_key", "request_fingerprint", "status_code", "response_body", "created_at"}).
+			AddRow(3, "order-1", "abc", 201, []byte(`{"message":"success"}`), fixedTime))
+
+	rw := &dbReadWriter{db: db}
+	got, err := rw.ReadIdempotencyKey(context.Background(), 3, "order-1")
+	assert.NoError(t, err)
+	assert.Equal(t, model.IdempotencyRecord{
+		UserID:             3,
+		Key:                "order-1",
+		RequestFingerprint: "abc",
+		StatusCode:         201,
+		ResponseBody:       []byte(`{"message":"success"}`),
+		CreatedAt:          fixedTime,
+	}, got)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func Test_DeleteExpiredIdempotencyKeys(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM trx_idempotency_key WHERE created_at <= NOW() - $1 * INTERVAL '1 second'`)).
+		WithArgs(86400).
+		WillReturnResult(sqlmock.NewResult(0, 4))
+
+	rw := &dbReadWriter{db: db}
+	got, err := rw.DeleteExpiredIdempotencyKeys(context.Background(), 24*time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), got)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
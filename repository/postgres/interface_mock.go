@@ -7,6 +7,7 @@ package postgres
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	model "github.com/budsx/retail-management/model"
 	gomock "github.com/golang/mock/gomock"
@@ -35,6 +36,79 @@ func (m *MockPostgresRepository) EXPECT() *MockPostgresRepositoryMockRecorder {
 	return m.recorder
 }
 
+// ApplyScheduledPrices mocks base method.
+func (m *MockPostgresRepository) ApplyScheduledPrices(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApplyScheduledPrices", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ApplyScheduledPrices indicates an expected call of ApplyScheduledPrices.
+func (mr *MockPostgresRepositoryMockRecorder) ApplyScheduledPrices(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApplyScheduledPrices", reflect.TypeOf((*MockPostgresRepository)(nil).ApplyScheduledPrices), ctx)
+}
+
+// ApproveStockTake mocks base method.
+func (m *MockPostgresRepository) ApproveStockTake(ctx context.Context, stockTakeID, approvedBy int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApproveStockTake", ctx, stockTakeID, approvedBy)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ApproveStockTake indicates an expected call of ApproveStockTake.
+func (mr *MockPostgresRepositoryMockRecorder) ApproveStockTake(ctx, stockTakeID, approvedBy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApproveStockTake", reflect.TypeOf((*MockPostgresRepository)(nil).ApproveStockTake), ctx, stockTakeID, approvedBy)
+}
+
+// AssembleBundle mocks base method.
+func (m *MockPostgresRepository) AssembleBundle(ctx context.Context, assembly model.StockTransaction) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AssembleBundle", ctx, assembly)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AssembleBundle indicates an expected call of AssembleBundle.
+func (mr *MockPostgresRepositoryMockRecorder) AssembleBundle(ctx, assembly interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AssembleBundle", reflect.TypeOf((*MockPostgresRepository)(nil).AssembleBundle), ctx, assembly)
+}
+
+// CancelStockTake mocks base method.
+func (m *MockPostgresRepository) CancelStockTake(ctx context.Context, stockTakeID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelStockTake", ctx, stockTakeID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CancelStockTake indicates an expected call of CancelStockTake.
+func (mr *MockPostgresRepositoryMockRecorder) CancelStockTake(ctx, stockTakeID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelStockTake", reflect.TypeOf((*MockPostgresRepository)(nil).CancelStockTake), ctx, stockTakeID)
+}
+
+// ClaimLowStockAlerts mocks base method.
+func (m *MockPostgresRepository) ClaimLowStockAlerts(ctx context.Context, productID, warehouseID int64) ([]model.LowStockItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimLowStockAlerts", ctx, productID, warehouseID)
+	ret0, _ := ret[0].([]model.LowStockItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimLowStockAlerts indicates an expected call of ClaimLowStockAlerts.
+func (mr *MockPostgresRepositoryMockRecorder) ClaimLowStockAlerts(ctx, productID, warehouseID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimLowStockAlerts", reflect.TypeOf((*MockPostgresRepository)(nil).ClaimLowStockAlerts), ctx, productID, warehouseID)
+}
+
 // Close mocks base method.
 func (m *MockPostgresRepository) Close() error {
 	m.ctrl.T.Helper()
@@ -49,6 +123,97 @@ func (mr *MockPostgresRepositoryMockRecorder) Close() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockPostgresRepository)(nil).Close))
 }
 
+// ConfirmReservation mocks base method.
+func (m *MockPostgresRepository) ConfirmReservation(ctx context.Context, reservationID, confirmedBy int64, serialNumbers []string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmReservation", ctx, reservationID, confirmedBy, serialNumbers)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConfirmReservation indicates an expected call of ConfirmReservation.
+func (mr *MockPostgresRepositoryMockRecorder) ConfirmReservation(ctx, reservationID, confirmedBy, serialNumbers interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmReservation", reflect.TypeOf((*MockPostgresRepository)(nil).ConfirmReservation), ctx, reservationID, confirmedBy, serialNumbers)
+}
+
+// CountProducts mocks base method.
+func (m *MockPostgresRepository) CountProducts(ctx context.Context, filter model.ProductFilter) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountProducts", ctx, filter)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountProducts indicates an expected call of CountProducts.
+func (mr *MockPostgresRepositoryMockRecorder) CountProducts(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountProducts", reflect.TypeOf((*MockPostgresRepository)(nil).CountProducts), ctx, filter)
+}
+
+// CreateIdempotencyKey mocks base method.
+func (m *MockPostgresRepository) CreateIdempotencyKey(ctx context.Context, record model.IdempotencyRecord, lease time.Duration) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateIdempotencyKey", ctx, record, lease)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateIdempotencyKey indicates an expected call of CreateIdempotencyKey.
+func (mr *MockPostgresRepositoryMockRecorder) CreateIdempotencyKey(ctx, record, lease interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateIdempotencyKey", reflect.TypeOf((*MockPostgresRepository)(nil).CreateIdempotencyKey), ctx, record, lease)
+}
+
+// CreateReservation mocks base method.
+func (m *MockPostgresRepository) CreateReservation(ctx context.Context, reservation model.Reservation, ttl time.Duration) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateReservation", ctx, reservation, ttl)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateReservation indicates an expected call of CreateReservation.
+func (mr *MockPostgresRepositoryMockRecorder) CreateReservation(ctx, reservation, ttl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateReservation", reflect.TypeOf((*MockPostgresRepository)(nil).CreateReservation), ctx, reservation, ttl)
+}
+
+// CreateStockSnapshot mocks base method.
+func (m *MockPostgresRepository) CreateStockSnapshot(ctx context.Context) (time.Time, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateStockSnapshot", ctx)
+	ret0, _ := ret[0].(time.Time)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CreateStockSnapshot indicates an expected call of CreateStockSnapshot.
+func (mr *MockPostgresRepositoryMockRecorder) CreateStockSnapshot(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateStockSnapshot", reflect.TypeOf((*MockPostgresRepository)(nil).CreateStockSnapshot), ctx)
+}
+
+// CreateStockTake mocks base method.
+func (m *MockPostgresRepository) CreateStockTake(ctx context.Context, stockTake model.StockTake) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateStockTake", ctx, stockTake)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateStockTake indicates an expected call of CreateStockTake.
+func (mr *MockPostgresRepositoryMockRecorder) CreateStockTake(ctx, stockTake interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateStockTake", reflect.TypeOf((*MockPostgresRepository)(nil).CreateStockTake), ctx, stockTake)
+}
+
 // CreateStockTransaction mocks base method.
 func (m *MockPostgresRepository) CreateStockTransaction(arg0 context.Context, arg1 model.StockTransaction) error {
 	m.ctrl.T.Helper()
@@ -63,18 +228,223 @@ func (mr *MockPostgresRepositoryMockRecorder) CreateStockTransaction(arg0, arg1
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateStockTransaction", reflect.TypeOf((*MockPostgresRepository)(nil).CreateStockTransaction), arg0, arg1)
 }
 
-// DeleteLocationByUserID mocks base method.
-func (m *MockPostgresRepository) DeleteLocationByUserID(ctx context.Context, userID, locationID int64) error {
+// CreateStockTransactionBatch mocks base method.
+func (m *MockPostgresRepository) CreateStockTransactionBatch(ctx context.Context, lines []model.StockTransaction) ([]int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteLocationByUserID", ctx, userID, locationID)
+	ret := m.ctrl.Call(m, "CreateStockTransactionBatch", ctx, lines)
+	ret0, _ := ret[0].([]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateStockTransactionBatch indicates an expected call of CreateStockTransactionBatch.
+func (mr *MockPostgresRepositoryMockRecorder) CreateStockTransactionBatch(ctx, lines interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateStockTransactionBatch", reflect.TypeOf((*MockPostgresRepository)(nil).CreateStockTransactionBatch), ctx, lines)
+}
+
+// CreateStockTransfer mocks base method.
+func (m *MockPostgresRepository) CreateStockTransfer(ctx context.Context, transfer model.StockTransaction) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateStockTransfer", ctx, transfer)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateStockTransfer indicates an expected call of CreateStockTransfer.
+func (mr *MockPostgresRepositoryMockRecorder) CreateStockTransfer(ctx, transfer interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateStockTransfer", reflect.TypeOf((*MockPostgresRepository)(nil).CreateStockTransfer), ctx, transfer)
+}
+
+// DeleteCategory mocks base method.
+func (m *MockPostgresRepository) DeleteCategory(ctx context.Context, categoryID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCategory", ctx, categoryID)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// DeleteLocationByUserID indicates an expected call of DeleteLocationByUserID.
-func (mr *MockPostgresRepositoryMockRecorder) DeleteLocationByUserID(ctx, userID, locationID interface{}) *gomock.Call {
+// DeleteCategory indicates an expected call of DeleteCategory.
+func (mr *MockPostgresRepositoryMockRecorder) DeleteCategory(ctx, categoryID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteLocationByUserID", reflect.TypeOf((*MockPostgresRepository)(nil).DeleteLocationByUserID), ctx, userID, locationID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCategory", reflect.TypeOf((*MockPostgresRepository)(nil).DeleteCategory), ctx, categoryID)
+}
+
+// DeleteExpiredIdempotencyKeys mocks base method.
+func (m *MockPostgresRepository) DeleteExpiredIdempotencyKeys(ctx context.Context, ttl time.Duration) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredIdempotencyKeys", ctx, ttl)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpiredIdempotencyKeys indicates an expected call of DeleteExpiredIdempotencyKeys.
+func (mr *MockPostgresRepositoryMockRecorder) DeleteExpiredIdempotencyKeys(ctx, ttl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredIdempotencyKeys", reflect.TypeOf((*MockPostgresRepository)(nil).DeleteExpiredIdempotencyKeys), ctx, ttl)
+}
+
+// DeleteIdempotencyKey mocks base method.
+func (m *MockPostgresRepository) DeleteIdempotencyKey(ctx context.Context, userID int64, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteIdempotencyKey", ctx, userID, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteIdempotencyKey indicates an expected call of DeleteIdempotencyKey.
+func (mr *MockPostgresRepositoryMockRecorder) DeleteIdempotencyKey(ctx, userID, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteIdempotencyKey", reflect.TypeOf((*MockPostgresRepository)(nil).DeleteIdempotencyKey), ctx, userID, key)
+}
+
+// DeletePriceChange mocks base method.
+func (m *MockPostgresRepository) DeletePriceChange(ctx context.Context, productID, priceID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePriceChange", ctx, productID, priceID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePriceChange indicates an expected call of DeletePriceChange.
+func (mr *MockPostgresRepositoryMockRecorder) DeletePriceChange(ctx, productID, priceID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePriceChange", reflect.TypeOf((*MockPostgresRepository)(nil).DeletePriceChange), ctx, productID, priceID)
+}
+
+// DeleteProductBarcode mocks base method.
+func (m *MockPostgresRepository) DeleteProductBarcode(ctx context.Context, productID int64, barcode string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteProductBarcode", ctx, productID, barcode)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteProductBarcode indicates an expected call of DeleteProductBarcode.
+func (mr *MockPostgresRepositoryMockRecorder) DeleteProductBarcode(ctx, productID, barcode interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteProductBarcode", reflect.TypeOf((*MockPostgresRepository)(nil).DeleteProductBarcode), ctx, productID, barcode)
+}
+
+// DeleteProductUnit mocks base method.
+func (m *MockPostgresRepository) DeleteProductUnit(ctx context.Context, productID int64, unitCode string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteProductUnit", ctx, productID, unitCode)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteProductUnit indicates an expected call of DeleteProductUnit.
+func (mr *MockPostgresRepositoryMockRecorder) DeleteProductUnit(ctx, productID, unitCode interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteProductUnit", reflect.TypeOf((*MockPostgresRepository)(nil).DeleteProductUnit), ctx, productID, unitCode)
+}
+
+// DeleteReorderPoint mocks base method.
+func (m *MockPostgresRepository) DeleteReorderPoint(ctx context.Context, productID, warehouseID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteReorderPoint", ctx, productID, warehouseID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteReorderPoint indicates an expected call of DeleteReorderPoint.
+func (mr *MockPostgresRepositoryMockRecorder) DeleteReorderPoint(ctx, productID, warehouseID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteReorderPoint", reflect.TypeOf((*MockPostgresRepository)(nil).DeleteReorderPoint), ctx, productID, warehouseID)
+}
+
+// ExpireReservations mocks base method.
+func (m *MockPostgresRepository) ExpireReservations(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpireReservations", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExpireReservations indicates an expected call of ExpireReservations.
+func (mr *MockPostgresRepositoryMockRecorder) ExpireReservations(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpireReservations", reflect.TypeOf((*MockPostgresRepository)(nil).ExpireReservations), ctx)
+}
+
+// GetBundleStock mocks base method.
+func (m *MockPostgresRepository) GetBundleStock(ctx context.Context, bundleID, locationID int64) ([]model.LocationStock, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBundleStock", ctx, bundleID, locationID)
+	ret0, _ := ret[0].([]model.LocationStock)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBundleStock indicates an expected call of GetBundleStock.
+func (mr *MockPostgresRepositoryMockRecorder) GetBundleStock(ctx, bundleID, locationID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBundleStock", reflect.TypeOf((*MockPostgresRepository)(nil).GetBundleStock), ctx, bundleID, locationID)
+}
+
+// GetExpiringLots mocks base method.
+func (m *MockPostgresRepository) GetExpiringLots(ctx context.Context, warehouseID int64, days int) ([]model.StockLot, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExpiringLots", ctx, warehouseID, days)
+	ret0, _ := ret[0].([]model.StockLot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExpiringLots indicates an expected call of GetExpiringLots.
+func (mr *MockPostgresRepositoryMockRecorder) GetExpiringLots(ctx, warehouseID, days interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExpiringLots", reflect.TypeOf((*MockPostgresRepository)(nil).GetExpiringLots), ctx, warehouseID, days)
+}
+
+// GetInventoryValuation mocks base method.
+func (m *MockPostgresRepository) GetInventoryValuation(ctx context.Context, warehouseID int64, asOf time.Time) ([]model.ValuationItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInventoryValuation", ctx, warehouseID, asOf)
+	ret0, _ := ret[0].([]model.ValuationItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetInventoryValuation indicates an expected call of GetInventoryValuation.
+func (mr *MockPostgresRepositoryMockRecorder) GetInventoryValuation(ctx, warehouseID, asOf interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInventoryValuation", reflect.TypeOf((*MockPostgresRepository)(nil).GetInventoryValuation), ctx, warehouseID, asOf)
+}
+
+// GetLowStockItems mocks base method.
+func (m *MockPostgresRepository) GetLowStockItems(ctx context.Context, userID, warehouseID int64) ([]model.LowStockItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLowStockItems", ctx, userID, warehouseID)
+	ret0, _ := ret[0].([]model.LowStockItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLowStockItems indicates an expected call of GetLowStockItems.
+func (mr *MockPostgresRepositoryMockRecorder) GetLowStockItems(ctx, userID, warehouseID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLowStockItems", reflect.TypeOf((*MockPostgresRepository)(nil).GetLowStockItems), ctx, userID, warehouseID)
+}
+
+// GetSerialMovements mocks base method.
+func (m *MockPostgresRepository) GetSerialMovements(ctx context.Context, serialID int64) ([]model.StockTransaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSerialMovements", ctx, serialID)
+	ret0, _ := ret[0].([]model.StockTransaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSerialMovements indicates an expected call of GetSerialMovements.
+func (mr *MockPostgresRepositoryMockRecorder) GetSerialMovements(ctx, serialID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSerialMovements", reflect.TypeOf((*MockPostgresRepository)(nil).GetSerialMovements), ctx, serialID)
 }
 
 // GetStockTransactionByID mocks base method.
@@ -93,18 +463,18 @@ func (mr *MockPostgresRepositoryMockRecorder) GetStockTransactionByID(ctx, trans
 }
 
 // GetStockTransactions mocks base method.
-func (m *MockPostgresRepository) GetStockTransactions(arg0 context.Context, arg1 int64) ([]model.StockTransaction, error) {
+func (m *MockPostgresRepository) GetStockTransactions(ctx context.Context, filter model.StockTransactionFilter, cursor model.Cursor, limit int32) ([]model.StockTransaction, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetStockTransactions", arg0, arg1)
+	ret := m.ctrl.Call(m, "GetStockTransactions", ctx, filter, cursor, limit)
 	ret0, _ := ret[0].([]model.StockTransaction)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetStockTransactions indicates an expected call of GetStockTransactions.
-func (mr *MockPostgresRepositoryMockRecorder) GetStockTransactions(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockPostgresRepositoryMockRecorder) GetStockTransactions(ctx, filter, cursor, limit interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStockTransactions", reflect.TypeOf((*MockPostgresRepository)(nil).GetStockTransactions), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStockTransactions", reflect.TypeOf((*MockPostgresRepository)(nil).GetStockTransactions), ctx, filter, cursor, limit)
 }
 
 // GetTotalStockByLocation mocks base method.
@@ -122,6 +492,36 @@ func (mr *MockPostgresRepositoryMockRecorder) GetTotalStockByLocation(arg0, arg1
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTotalStockByLocation", reflect.TypeOf((*MockPostgresRepository)(nil).GetTotalStockByLocation), arg0, arg1)
 }
 
+// GetTotalStockByLocationAsOf mocks base method.
+func (m *MockPostgresRepository) GetTotalStockByLocationAsOf(ctx context.Context, locationID int64, asOf time.Time) ([]model.ProductStock, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTotalStockByLocationAsOf", ctx, locationID, asOf)
+	ret0, _ := ret[0].([]model.ProductStock)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTotalStockByLocationAsOf indicates an expected call of GetTotalStockByLocationAsOf.
+func (mr *MockPostgresRepositoryMockRecorder) GetTotalStockByLocationAsOf(ctx, locationID, asOf interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTotalStockByLocationAsOf", reflect.TypeOf((*MockPostgresRepository)(nil).GetTotalStockByLocationAsOf), ctx, locationID, asOf)
+}
+
+// GetTotalStockByProductAndLocation mocks base method.
+func (m *MockPostgresRepository) GetTotalStockByProductAndLocation(ctx context.Context, productID, locationID int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTotalStockByProductAndLocation", ctx, productID, locationID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTotalStockByProductAndLocation indicates an expected call of GetTotalStockByProductAndLocation.
+func (mr *MockPostgresRepositoryMockRecorder) GetTotalStockByProductAndLocation(ctx, productID, locationID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTotalStockByProductAndLocation", reflect.TypeOf((*MockPostgresRepository)(nil).GetTotalStockByProductAndLocation), ctx, productID, locationID)
+}
+
 // GetTotalStockByProductAndWarehouse mocks base method.
 func (m *MockPostgresRepository) GetTotalStockByProductAndWarehouse(arg0 context.Context, arg1, arg2 int64) (int64, error) {
 	m.ctrl.T.Helper()
@@ -152,6 +552,21 @@ func (mr *MockPostgresRepositoryMockRecorder) GetTotalStocks(ctx interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTotalStocks", reflect.TypeOf((*MockPostgresRepository)(nil).GetTotalStocks), ctx)
 }
 
+// GetTotalStocksAsOf mocks base method.
+func (m *MockPostgresRepository) GetTotalStocksAsOf(ctx context.Context, asOf time.Time) ([]model.ProductStock, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTotalStocksAsOf", ctx, asOf)
+	ret0, _ := ret[0].([]model.ProductStock)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTotalStocksAsOf indicates an expected call of GetTotalStocksAsOf.
+func (mr *MockPostgresRepositoryMockRecorder) GetTotalStocksAsOf(ctx, asOf interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTotalStocksAsOf", reflect.TypeOf((*MockPostgresRepository)(nil).GetTotalStocksAsOf), ctx, asOf)
+}
+
 // GetUserByUsername mocks base method.
 func (m *MockPostgresRepository) GetUserByUsername(ctx context.Context, username string) (model.User, error) {
 	m.ctrl.T.Helper()
@@ -167,6 +582,66 @@ func (mr *MockPostgresRepositoryMockRecorder) GetUserByUsername(ctx, username in
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByUsername", reflect.TypeOf((*MockPostgresRepository)(nil).GetUserByUsername), ctx, username)
 }
 
+// ReadBundleComponents mocks base method.
+func (m *MockPostgresRepository) ReadBundleComponents(ctx context.Context, bundleID int64) ([]model.BundleComponent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadBundleComponents", ctx, bundleID)
+	ret0, _ := ret[0].([]model.BundleComponent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadBundleComponents indicates an expected call of ReadBundleComponents.
+func (mr *MockPostgresRepositoryMockRecorder) ReadBundleComponents(ctx, bundleID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadBundleComponents", reflect.TypeOf((*MockPostgresRepository)(nil).ReadBundleComponents), ctx, bundleID)
+}
+
+// ReadCategories mocks base method.
+func (m *MockPostgresRepository) ReadCategories(ctx context.Context) ([]model.Category, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadCategories", ctx)
+	ret0, _ := ret[0].([]model.Category)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadCategories indicates an expected call of ReadCategories.
+func (mr *MockPostgresRepositoryMockRecorder) ReadCategories(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadCategories", reflect.TypeOf((*MockPostgresRepository)(nil).ReadCategories), ctx)
+}
+
+// ReadCategoryByID mocks base method.
+func (m *MockPostgresRepository) ReadCategoryByID(ctx context.Context, categoryID int64) (model.Category, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadCategoryByID", ctx, categoryID)
+	ret0, _ := ret[0].(model.Category)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadCategoryByID indicates an expected call of ReadCategoryByID.
+func (mr *MockPostgresRepositoryMockRecorder) ReadCategoryByID(ctx, categoryID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadCategoryByID", reflect.TypeOf((*MockPostgresRepository)(nil).ReadCategoryByID), ctx, categoryID)
+}
+
+// ReadIdempotencyKey mocks base method.
+func (m *MockPostgresRepository) ReadIdempotencyKey(ctx context.Context, userID int64, key string) (model.IdempotencyRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadIdempotencyKey", ctx, userID, key)
+	ret0, _ := ret[0].(model.IdempotencyRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadIdempotencyKey indicates an expected call of ReadIdempotencyKey.
+func (mr *MockPostgresRepositoryMockRecorder) ReadIdempotencyKey(ctx, userID, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadIdempotencyKey", reflect.TypeOf((*MockPostgresRepository)(nil).ReadIdempotencyKey), ctx, userID, key)
+}
+
 // ReadLocationByID mocks base method.
 func (m *MockPostgresRepository) ReadLocationByID(ctx context.Context, locationID int64) (model.Location, error) {
 	m.ctrl.T.Helper()
@@ -182,6 +657,51 @@ func (mr *MockPostgresRepositoryMockRecorder) ReadLocationByID(ctx, locationID i
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadLocationByID", reflect.TypeOf((*MockPostgresRepository)(nil).ReadLocationByID), ctx, locationID)
 }
 
+// ReadLocationsByWarehouse mocks base method.
+func (m *MockPostgresRepository) ReadLocationsByWarehouse(ctx context.Context, warehouseID int64, includeArchived bool, cursor model.Cursor, limit int32) ([]model.Location, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadLocationsByWarehouse", ctx, warehouseID, includeArchived, cursor, limit)
+	ret0, _ := ret[0].([]model.Location)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadLocationsByWarehouse indicates an expected call of ReadLocationsByWarehouse.
+func (mr *MockPostgresRepositoryMockRecorder) ReadLocationsByWarehouse(ctx, warehouseID, includeArchived, cursor, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadLocationsByWarehouse", reflect.TypeOf((*MockPostgresRepository)(nil).ReadLocationsByWarehouse), ctx, warehouseID, includeArchived, cursor, limit)
+}
+
+// ReadPriceAt mocks base method.
+func (m *MockPostgresRepository) ReadPriceAt(ctx context.Context, productID int64, at time.Time) (model.ProductPrice, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadPriceAt", ctx, productID, at)
+	ret0, _ := ret[0].(model.ProductPrice)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadPriceAt indicates an expected call of ReadPriceAt.
+func (mr *MockPostgresRepositoryMockRecorder) ReadPriceAt(ctx, productID, at interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadPriceAt", reflect.TypeOf((*MockPostgresRepository)(nil).ReadPriceAt), ctx, productID, at)
+}
+
+// ReadProductBarcodes mocks base method.
+func (m *MockPostgresRepository) ReadProductBarcodes(ctx context.Context, productID int64) ([]model.ProductBarcode, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadProductBarcodes", ctx, productID)
+	ret0, _ := ret[0].([]model.ProductBarcode)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadProductBarcodes indicates an expected call of ReadProductBarcodes.
+func (mr *MockPostgresRepositoryMockRecorder) ReadProductBarcodes(ctx, productID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadProductBarcodes", reflect.TypeOf((*MockPostgresRepository)(nil).ReadProductBarcodes), ctx, productID)
+}
+
 // ReadProductByID mocks base method.
 func (m *MockPostgresRepository) ReadProductByID(arg0 context.Context, arg1 int64) (model.Product, error) {
 	m.ctrl.T.Helper()
@@ -197,19 +717,169 @@ func (mr *MockPostgresRepositoryMockRecorder) ReadProductByID(arg0, arg1 interfa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadProductByID", reflect.TypeOf((*MockPostgresRepository)(nil).ReadProductByID), arg0, arg1)
 }
 
+// ReadProductIDByBarcode mocks base method.
+func (m *MockPostgresRepository) ReadProductIDByBarcode(ctx context.Context, barcodes []string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadProductIDByBarcode", ctx, barcodes)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadProductIDByBarcode indicates an expected call of ReadProductIDByBarcode.
+func (mr *MockPostgresRepositoryMockRecorder) ReadProductIDByBarcode(ctx, barcodes interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadProductIDByBarcode", reflect.TypeOf((*MockPostgresRepository)(nil).ReadProductIDByBarcode), ctx, barcodes)
+}
+
+// ReadProductPrices mocks base method.
+func (m *MockPostgresRepository) ReadProductPrices(ctx context.Context, productID int64) ([]model.ProductPrice, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadProductPrices", ctx, productID)
+	ret0, _ := ret[0].([]model.ProductPrice)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadProductPrices indicates an expected call of ReadProductPrices.
+func (mr *MockPostgresRepositoryMockRecorder) ReadProductPrices(ctx, productID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadProductPrices", reflect.TypeOf((*MockPostgresRepository)(nil).ReadProductPrices), ctx, productID)
+}
+
+// ReadProductUnits mocks base method.
+func (m *MockPostgresRepository) ReadProductUnits(ctx context.Context, productID int64) ([]model.ProductUnit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadProductUnits", ctx, productID)
+	ret0, _ := ret[0].([]model.ProductUnit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadProductUnits indicates an expected call of ReadProductUnits.
+func (mr *MockPostgresRepositoryMockRecorder) ReadProductUnits(ctx, productID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadProductUnits", reflect.TypeOf((*MockPostgresRepository)(nil).ReadProductUnits), ctx, productID)
+}
+
+// ReadProductsByCursor mocks base method.
+func (m *MockPostgresRepository) ReadProductsByCursor(ctx context.Context, filter model.ProductFilter, cursor model.Cursor, limit int32) ([]model.Product, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadProductsByCursor", ctx, filter, cursor, limit)
+	ret0, _ := ret[0].([]model.Product)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadProductsByCursor indicates an expected call of ReadProductsByCursor.
+func (mr *MockPostgresRepositoryMockRecorder) ReadProductsByCursor(ctx, filter, cursor, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadProductsByCursor", reflect.TypeOf((*MockPostgresRepository)(nil).ReadProductsByCursor), ctx, filter, cursor, limit)
+}
+
+// ReadProductsByIDs mocks base method.
+func (m *MockPostgresRepository) ReadProductsByIDs(ctx context.Context, productIDs []int64) ([]model.Product, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadProductsByIDs", ctx, productIDs)
+	ret0, _ := ret[0].([]model.Product)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadProductsByIDs indicates an expected call of ReadProductsByIDs.
+func (mr *MockPostgresRepositoryMockRecorder) ReadProductsByIDs(ctx, productIDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadProductsByIDs", reflect.TypeOf((*MockPostgresRepository)(nil).ReadProductsByIDs), ctx, productIDs)
+}
+
 // ReadProductsWithPagination mocks base method.
-func (m *MockPostgresRepository) ReadProductsWithPagination(arg0 context.Context, arg1, arg2 int32) ([]model.Product, error) {
+func (m *MockPostgresRepository) ReadProductsWithPagination(ctx context.Context, filter model.ProductFilter, limit, offset int32) ([]model.Product, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ReadProductsWithPagination", arg0, arg1, arg2)
+	ret := m.ctrl.Call(m, "ReadProductsWithPagination", ctx, filter, limit, offset)
 	ret0, _ := ret[0].([]model.Product)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ReadProductsWithPagination indicates an expected call of ReadProductsWithPagination.
-func (mr *MockPostgresRepositoryMockRecorder) ReadProductsWithPagination(arg0, arg1, arg2 interface{}) *gomock.Call {
+func (mr *MockPostgresRepositoryMockRecorder) ReadProductsWithPagination(ctx, filter, limit, offset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadProductsWithPagination", reflect.TypeOf((*MockPostgresRepository)(nil).ReadProductsWithPagination), ctx, filter, limit, offset)
+}
+
+// ReadReorderPointsByWarehouse mocks base method.
+func (m *MockPostgresRepository) ReadReorderPointsByWarehouse(ctx context.Context, warehouseID int64) ([]model.ReorderPoint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadReorderPointsByWarehouse", ctx, warehouseID)
+	ret0, _ := ret[0].([]model.ReorderPoint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadReorderPointsByWarehouse indicates an expected call of ReadReorderPointsByWarehouse.
+func (mr *MockPostgresRepositoryMockRecorder) ReadReorderPointsByWarehouse(ctx, warehouseID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadProductsWithPagination", reflect.TypeOf((*MockPostgresRepository)(nil).ReadProductsWithPagination), arg0, arg1, arg2)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadReorderPointsByWarehouse", reflect.TypeOf((*MockPostgresRepository)(nil).ReadReorderPointsByWarehouse), ctx, warehouseID)
+}
+
+// ReadReservationByID mocks base method.
+func (m *MockPostgresRepository) ReadReservationByID(ctx context.Context, reservationID int64) (model.Reservation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadReservationByID", ctx, reservationID)
+	ret0, _ := ret[0].(model.Reservation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadReservationByID indicates an expected call of ReadReservationByID.
+func (mr *MockPostgresRepositoryMockRecorder) ReadReservationByID(ctx, reservationID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadReservationByID", reflect.TypeOf((*MockPostgresRepository)(nil).ReadReservationByID), ctx, reservationID)
+}
+
+// ReadSerialsByNumber mocks base method.
+func (m *MockPostgresRepository) ReadSerialsByNumber(ctx context.Context, serialNumber string) ([]model.Serial, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadSerialsByNumber", ctx, serialNumber)
+	ret0, _ := ret[0].([]model.Serial)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadSerialsByNumber indicates an expected call of ReadSerialsByNumber.
+func (mr *MockPostgresRepositoryMockRecorder) ReadSerialsByNumber(ctx, serialNumber interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadSerialsByNumber", reflect.TypeOf((*MockPostgresRepository)(nil).ReadSerialsByNumber), ctx, serialNumber)
+}
+
+// ReadStockTakeByID mocks base method.
+func (m *MockPostgresRepository) ReadStockTakeByID(ctx context.Context, stockTakeID int64) (model.StockTake, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadStockTakeByID", ctx, stockTakeID)
+	ret0, _ := ret[0].(model.StockTake)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadStockTakeByID indicates an expected call of ReadStockTakeByID.
+func (mr *MockPostgresRepositoryMockRecorder) ReadStockTakeByID(ctx, stockTakeID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadStockTakeByID", reflect.TypeOf((*MockPostgresRepository)(nil).ReadStockTakeByID), ctx, stockTakeID)
+}
+
+// ReadUnitFactor mocks base method.
+func (m *MockPostgresRepository) ReadUnitFactor(ctx context.Context, productID int64, unitCode string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadUnitFactor", ctx, productID, unitCode)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadUnitFactor indicates an expected call of ReadUnitFactor.
+func (mr *MockPostgresRepositoryMockRecorder) ReadUnitFactor(ctx, productID, unitCode interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadUnitFactor", reflect.TypeOf((*MockPostgresRepository)(nil).ReadUnitFactor), ctx, productID, unitCode)
 }
 
 // ReadWarehouseByID mocks base method.
@@ -228,18 +898,46 @@ func (mr *MockPostgresRepositoryMockRecorder) ReadWarehouseByID(ctx, warehouseID
 }
 
 // ReadWarehousesByUserID mocks base method.
-func (m *MockPostgresRepository) ReadWarehousesByUserID(ctx context.Context, userID int64) ([]model.Warehouse, error) {
+func (m *MockPostgresRepository) ReadWarehousesByUserID(ctx context.Context, userID int64, includeArchived bool, cursor model.Cursor, limit int32) ([]model.Warehouse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ReadWarehousesByUserID", ctx, userID)
+	ret := m.ctrl.Call(m, "ReadWarehousesByUserID", ctx, userID, includeArchived, cursor, limit)
 	ret0, _ := ret[0].([]model.Warehouse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ReadWarehousesByUserID indicates an expected call of ReadWarehousesByUserID.
-func (mr *MockPostgresRepositoryMockRecorder) ReadWarehousesByUserID(ctx, userID interface{}) *gomock.Call {
+func (mr *MockPostgresRepositoryMockRecorder) ReadWarehousesByUserID(ctx, userID, includeArchived, cursor, limit interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadWarehousesByUserID", reflect.TypeOf((*MockPostgresRepository)(nil).ReadWarehousesByUserID), ctx, userID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadWarehousesByUserID", reflect.TypeOf((*MockPostgresRepository)(nil).ReadWarehousesByUserID), ctx, userID, includeArchived, cursor, limit)
+}
+
+// ReceiveStockTransfer mocks base method.
+func (m *MockPostgresRepository) ReceiveStockTransfer(ctx context.Context, transactionID, receivedBy int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReceiveStockTransfer", ctx, transactionID, receivedBy)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReceiveStockTransfer indicates an expected call of ReceiveStockTransfer.
+func (mr *MockPostgresRepositoryMockRecorder) ReceiveStockTransfer(ctx, transactionID, receivedBy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReceiveStockTransfer", reflect.TypeOf((*MockPostgresRepository)(nil).ReceiveStockTransfer), ctx, transactionID, receivedBy)
+}
+
+// RecordStockTakeCounts mocks base method.
+func (m *MockPostgresRepository) RecordStockTakeCounts(ctx context.Context, stockTakeID, countedBy int64, counts []model.StockTakeCount) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordStockTakeCounts", ctx, stockTakeID, countedBy, counts)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordStockTakeCounts indicates an expected call of RecordStockTakeCounts.
+func (mr *MockPostgresRepositoryMockRecorder) RecordStockTakeCounts(ctx, stockTakeID, countedBy, counts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordStockTakeCounts", reflect.TypeOf((*MockPostgresRepository)(nil).RecordStockTakeCounts), ctx, stockTakeID, countedBy, counts)
 }
 
 // RegisterUser mocks base method.
@@ -256,6 +954,133 @@ func (mr *MockPostgresRepositoryMockRecorder) RegisterUser(arg0, arg1 interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterUser", reflect.TypeOf((*MockPostgresRepository)(nil).RegisterUser), arg0, arg1)
 }
 
+// ReleaseLowStockAlert mocks base method.
+func (m *MockPostgresRepository) ReleaseLowStockAlert(ctx context.Context, productID, warehouseID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseLowStockAlert", ctx, productID, warehouseID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseLowStockAlert indicates an expected call of ReleaseLowStockAlert.
+func (mr *MockPostgresRepositoryMockRecorder) ReleaseLowStockAlert(ctx, productID, warehouseID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseLowStockAlert", reflect.TypeOf((*MockPostgresRepository)(nil).ReleaseLowStockAlert), ctx, productID, warehouseID)
+}
+
+// ReleaseReservation mocks base method.
+func (m *MockPostgresRepository) ReleaseReservation(ctx context.Context, reservationID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseReservation", ctx, reservationID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseReservation indicates an expected call of ReleaseReservation.
+func (mr *MockPostgresRepositoryMockRecorder) ReleaseReservation(ctx, reservationID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseReservation", reflect.TypeOf((*MockPostgresRepository)(nil).ReleaseReservation), ctx, reservationID)
+}
+
+// ReverseStockTransaction mocks base method.
+func (m *MockPostgresRepository) ReverseStockTransaction(ctx context.Context, transactionID, reversedBy int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReverseStockTransaction", ctx, transactionID, reversedBy)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReverseStockTransaction indicates an expected call of ReverseStockTransaction.
+func (mr *MockPostgresRepositoryMockRecorder) ReverseStockTransaction(ctx, transactionID, reversedBy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReverseStockTransaction", reflect.TypeOf((*MockPostgresRepository)(nil).ReverseStockTransaction), ctx, transactionID, reversedBy)
+}
+
+// SaveIdempotentResponse mocks base method.
+func (m *MockPostgresRepository) SaveIdempotentResponse(ctx context.Context, record model.IdempotencyRecord) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveIdempotentResponse", ctx, record)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveIdempotentResponse indicates an expected call of SaveIdempotentResponse.
+func (mr *MockPostgresRepositoryMockRecorder) SaveIdempotentResponse(ctx, record interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveIdempotentResponse", reflect.TypeOf((*MockPostgresRepository)(nil).SaveIdempotentResponse), ctx, record)
+}
+
+// SetBundleComponents mocks base method.
+func (m *MockPostgresRepository) SetBundleComponents(ctx context.Context, bundleID int64, components []model.BundleComponent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetBundleComponents", ctx, bundleID, components)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetBundleComponents indicates an expected call of SetBundleComponents.
+func (mr *MockPostgresRepositoryMockRecorder) SetBundleComponents(ctx, bundleID, components interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetBundleComponents", reflect.TypeOf((*MockPostgresRepository)(nil).SetBundleComponents), ctx, bundleID, components)
+}
+
+// SetLocationArchived mocks base method.
+func (m *MockPostgresRepository) SetLocationArchived(ctx context.Context, userID, locationID int64, archived bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetLocationArchived", ctx, userID, locationID, archived)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetLocationArchived indicates an expected call of SetLocationArchived.
+func (mr *MockPostgresRepositoryMockRecorder) SetLocationArchived(ctx, userID, locationID, archived interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetLocationArchived", reflect.TypeOf((*MockPostgresRepository)(nil).SetLocationArchived), ctx, userID, locationID, archived)
+}
+
+// SetProductArchived mocks base method.
+func (m *MockPostgresRepository) SetProductArchived(ctx context.Context, productID int64, archived bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetProductArchived", ctx, productID, archived)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetProductArchived indicates an expected call of SetProductArchived.
+func (mr *MockPostgresRepositoryMockRecorder) SetProductArchived(ctx, productID, archived interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetProductArchived", reflect.TypeOf((*MockPostgresRepository)(nil).SetProductArchived), ctx, productID, archived)
+}
+
+// SetWarehouseArchived mocks base method.
+func (m *MockPostgresRepository) SetWarehouseArchived(ctx context.Context, userID, warehouseID int64, archived bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetWarehouseArchived", ctx, userID, warehouseID, archived)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetWarehouseArchived indicates an expected call of SetWarehouseArchived.
+func (mr *MockPostgresRepositoryMockRecorder) SetWarehouseArchived(ctx, userID, warehouseID, archived interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetWarehouseArchived", reflect.TypeOf((*MockPostgresRepository)(nil).SetWarehouseArchived), ctx, userID, warehouseID, archived)
+}
+
+// UpdateCategory mocks base method.
+func (m *MockPostgresRepository) UpdateCategory(ctx context.Context, category model.Category) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateCategory", ctx, category)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateCategory indicates an expected call of UpdateCategory.
+func (mr *MockPostgresRepositoryMockRecorder) UpdateCategory(ctx, category interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCategory", reflect.TypeOf((*MockPostgresRepository)(nil).UpdateCategory), ctx, category)
+}
+
 // UpdateLocation mocks base method.
 func (m *MockPostgresRepository) UpdateLocation(ctx context.Context, location model.Location) error {
 	m.ctrl.T.Helper()
@@ -298,6 +1123,49 @@ func (mr *MockPostgresRepositoryMockRecorder) UpdateWarehouse(ctx, warehouse int
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWarehouse", reflect.TypeOf((*MockPostgresRepository)(nil).UpdateWarehouse), ctx, warehouse)
 }
 
+// UpsertProductUnit mocks base method.
+func (m *MockPostgresRepository) UpsertProductUnit(ctx context.Context, productID int64, unit model.ProductUnit) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpsertProductUnit", ctx, productID, unit)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpsertProductUnit indicates an expected call of UpsertProductUnit.
+func (mr *MockPostgresRepositoryMockRecorder) UpsertProductUnit(ctx, productID, unit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertProductUnit", reflect.TypeOf((*MockPostgresRepository)(nil).UpsertProductUnit), ctx, productID, unit)
+}
+
+// UpsertReorderPoint mocks base method.
+func (m *MockPostgresRepository) UpsertReorderPoint(ctx context.Context, reorderPoint model.ReorderPoint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpsertReorderPoint", ctx, reorderPoint)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpsertReorderPoint indicates an expected call of UpsertReorderPoint.
+func (mr *MockPostgresRepositoryMockRecorder) UpsertReorderPoint(ctx, reorderPoint interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertReorderPoint", reflect.TypeOf((*MockPostgresRepository)(nil).UpsertReorderPoint), ctx, reorderPoint)
+}
+
+// WriteCategory mocks base method.
+func (m *MockPostgresRepository) WriteCategory(ctx context.Context, category model.Category) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteCategory", ctx, category)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WriteCategory indicates an expected call of WriteCategory.
+func (mr *MockPostgresRepositoryMockRecorder) WriteCategory(ctx, category interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteCategory", reflect.TypeOf((*MockPostgresRepository)(nil).WriteCategory), ctx, category)
+}
+
 // WriteLocation mocks base method.
 func (m *MockPostgresRepository) WriteLocation(ctx context.Context, location model.Location) error {
 	m.ctrl.T.Helper()
@@ -312,6 +1180,21 @@ func (mr *MockPostgresRepositoryMockRecorder) WriteLocation(ctx, location interf
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteLocation", reflect.TypeOf((*MockPostgresRepository)(nil).WriteLocation), ctx, location)
 }
 
+// WritePriceChange mocks base method.
+func (m *MockPostgresRepository) WritePriceChange(ctx context.Context, price model.ProductPrice) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WritePriceChange", ctx, price)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WritePriceChange indicates an expected call of WritePriceChange.
+func (mr *MockPostgresRepositoryMockRecorder) WritePriceChange(ctx, price interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WritePriceChange", reflect.TypeOf((*MockPostgresRepository)(nil).WritePriceChange), ctx, price)
+}
+
 // WriteProduct mocks base method.
 func (m *MockPostgresRepository) WriteProduct(arg0 context.Context, arg1 model.Product) error {
 	m.ctrl.T.Helper()
@@ -326,6 +1209,20 @@ func (mr *MockPostgresRepositoryMockRecorder) WriteProduct(arg0, arg1 interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteProduct", reflect.TypeOf((*MockPostgresRepository)(nil).WriteProduct), arg0, arg1)
 }
 
+// WriteProductBarcode mocks base method.
+func (m *MockPostgresRepository) WriteProductBarcode(ctx context.Context, productID int64, barcode model.ProductBarcode) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteProductBarcode", ctx, productID, barcode)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WriteProductBarcode indicates an expected call of WriteProductBarcode.
+func (mr *MockPostgresRepositoryMockRecorder) WriteProductBarcode(ctx, productID, barcode interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteProductBarcode", reflect.TypeOf((*MockPostgresRepository)(nil).WriteProductBarcode), ctx, productID, barcode)
+}
+
 // WriteWarehouse mocks base method.
 func (m *MockPostgresRepository) WriteWarehouse(ctx context.Context, warehouse model.Warehouse) error {
 	m.ctrl.T.Helper()
@@ -3,15 +3,40 @@ package postgres
 import (
 	"context"
 	"io"
+	"time"
 
 	"github.com/budsx/retail-management/model"
 )
 
 type PostgresRepository interface {
 	ReadProductByID(context.Context, int64) (model.Product, error)
-	ReadProductsWithPagination(context.Context, int32, int32) ([]model.Product, error)
+	ReadProductsByIDs(ctx context.Context, productIDs []int64) ([]model.Product, error)
+	ReadProductsWithPagination(ctx context.Context, filter model.ProductFilter, limit, offset int32) ([]model.Product, error)
+	ReadProductsByCursor(ctx context.Context, filter model.ProductFilter, cursor model.Cursor, limit int32) ([]model.Product, error)
+	CountProducts(ctx context.Context, filter model.ProductFilter) (int64, error)
 	UpdateProductByID(context.Context, model.Product) error
+	SetProductArchived(ctx context.Context, productID int64, archived bool) error
 	WriteProduct(context.Context, model.Product) error
+	ReadProductUnits(ctx context.Context, productID int64) ([]model.ProductUnit, error)
+	UpsertProductUnit(ctx context.Context, productID int64, unit model.ProductUnit) error
+	DeleteProductUnit(ctx context.Context, productID int64, unitCode string) error
+	ReadUnitFactor(ctx context.Context, productID int64, unitCode string) (int64, error)
+	ReadProductBarcodes(ctx context.Context, productID int64) ([]model.ProductBarcode, error)
+	WriteProductBarcode(ctx context.Context, productID int64, barcode model.ProductBarcode) error
+	DeleteProductBarcode(ctx context.Context, productID int64, barcode string) error
+	ReadProductIDByBarcode(ctx context.Context, barcodes []string) (int64, error)
+	ReadProductPrices(ctx context.Context, productID int64) ([]model.ProductPrice, error)
+	ReadPriceAt(ctx context.Context, productID int64, at time.Time) (model.ProductPrice, error)
+	WritePriceChange(ctx context.Context, price model.ProductPrice) (int64, error)
+	DeletePriceChange(ctx context.Context, productID, priceID int64) error
+	ApplyScheduledPrices(ctx context.Context) (int64, error)
+
+	// Category
+	WriteCategory(ctx context.Context, category model.Category) (int64, error)
+	ReadCategoryByID(ctx context.Context, categoryID int64) (model.Category, error)
+	ReadCategories(ctx context.Context) ([]model.Category, error)
+	UpdateCategory(ctx context.Context, category model.Category) error
+	DeleteCategory(ctx context.Context, categoryID int64) error
 
 	// User
 	RegisterUser(context.Context, model.User) error
@@ -21,18 +46,71 @@ type PostgresRepository interface {
 	WriteLocation(ctx context.Context, location model.Location) error
 	UpdateLocation(ctx context.Context, location model.Location) error
 	ReadLocationByID(ctx context.Context, locationID int64) (model.Location, error)
-	DeleteLocationByUserID(ctx context.Context, userID, locationID int64) error
+	SetLocationArchived(ctx context.Context, userID, locationID int64, archived bool) error
+	SetWarehouseArchived(ctx context.Context, userID, warehouseID int64, archived bool) error
 	WriteWarehouse(ctx context.Context, warehouse model.Warehouse) error
 	UpdateWarehouse(ctx context.Context, warehouse model.Warehouse) error
-	ReadWarehousesByUserID(ctx context.Context, userID int64) ([]model.Warehouse, error)
+	ReadWarehousesByUserID(ctx context.Context, userID int64, includeArchived bool, cursor model.Cursor, limit int32) ([]model.Warehouse, error)
+	ReadLocationsByWarehouse(ctx context.Context, warehouseID int64, includeArchived bool, cursor model.Cursor, limit int32) ([]model.Location, error)
 	ReadWarehouseByID(ctx context.Context, warehouseID int64) (model.Warehouse, error)
 
 	CreateStockTransaction(context.Context, model.StockTransaction) error
+	CreateStockTransactionBatch(ctx context.Context, lines []model.StockTransaction) ([]int64, error)
 	GetTotalStockByProductAndWarehouse(context.Context, int64, int64) (int64, error)
-	GetStockTransactions(context.Context, int64) ([]model.StockTransaction, error)
+	GetTotalStockByProductAndLocation(ctx context.Context, productID, locationID int64) (int64, error)
+	GetStockTransactions(ctx context.Context, filter model.StockTransactionFilter, cursor model.Cursor, limit int32) ([]model.StockTransaction, error)
 	GetStockTransactionByID(ctx context.Context, transactionID int64) (model.StockTransaction, error)
 	GetTotalStocks(ctx context.Context) ([]model.ProductStock, error)
 	GetTotalStockByLocation(context.Context, int64) ([]model.ProductStock, error)
+	GetTotalStocksAsOf(ctx context.Context, asOf time.Time) ([]model.ProductStock, error)
+	GetTotalStockByLocationAsOf(ctx context.Context, locationID int64, asOf time.Time) ([]model.ProductStock, error)
+	CreateStockSnapshot(ctx context.Context) (time.Time, int64, error)
+	GetExpiringLots(ctx context.Context, warehouseID int64, days int) ([]model.StockLot, error)
+	CreateStockTransfer(ctx context.Context, transfer model.StockTransaction) (int64, error)
+	ReceiveStockTransfer(ctx context.Context, transactionID, receivedBy int64) error
+	ReverseStockTransaction(ctx context.Context, transactionID, reversedBy int64) (int64, error)
+
+	// Reservation
+	CreateReservation(ctx context.Context, reservation model.Reservation, ttl time.Duration) (int64, error)
+	ReadReservationByID(ctx context.Context, reservationID int64) (model.Reservation, error)
+	ConfirmReservation(ctx context.Context, reservationID, confirmedBy int64, serialNumbers []string) (int64, error)
+	ReleaseReservation(ctx context.Context, reservationID int64) error
+	ExpireReservations(ctx context.Context) (int64, error)
+
+	// Stock take
+	CreateStockTake(ctx context.Context, stockTake model.StockTake) (int64, error)
+	ReadStockTakeByID(ctx context.Context, stockTakeID int64) (model.StockTake, error)
+	RecordStockTakeCounts(ctx context.Context, stockTakeID, countedBy int64, counts []model.StockTakeCount) error
+	ApproveStockTake(ctx context.Context, stockTakeID, approvedBy int64) error
+	CancelStockTake(ctx context.Context, stockTakeID int64) error
+
+	// Serial
+	ReadSerialsByNumber(ctx context.Context, serialNumber string) ([]model.Serial, error)
+	GetSerialMovements(ctx context.Context, serialID int64) ([]model.StockTransaction, error)
+
+	// Reorder point
+	UpsertReorderPoint(ctx context.Context, reorderPoint model.ReorderPoint) error
+	ReadReorderPointsByWarehouse(ctx context.Context, warehouseID int64) ([]model.ReorderPoint, error)
+	DeleteReorderPoint(ctx context.Context, productID, warehouseID int64) error
+	GetLowStockItems(ctx context.Context, userID, warehouseID int64) ([]model.LowStockItem, error)
+	ClaimLowStockAlerts(ctx context.Context, productID, warehouseID int64) ([]model.LowStockItem, error)
+	ReleaseLowStockAlert(ctx context.Context, productID, warehouseID int64) error
+
+	// Bundle
+	ReadBundleComponents(ctx context.Context, bundleID int64) ([]model.BundleComponent, error)
+	SetBundleComponents(ctx context.Context, bundleID int64, components []model.BundleComponent) error
+	GetBundleStock(ctx context.Context, bundleID, locationID int64) ([]model.LocationStock, error)
+	AssembleBundle(ctx context.Context, assembly model.StockTransaction) (int64, error)
+
+	// Valuation
+	GetInventoryValuation(ctx context.Context, warehouseID int64, asOf time.Time) ([]model.ValuationItem, error)
+
+	// Idempotency
+	CreateIdempotencyKey(ctx context.Context, record model.IdempotencyRecord, lease time.Duration) (bool, error)
+	ReadIdempotencyKey(ctx context.Context, userID int64, key string) (model.IdempotencyRecord, error)
+	SaveIdempotentResponse(ctx context.Context, record model.IdempotencyRecord) error
+	DeleteIdempotencyKey(ctx context.Context, userID int64, key string) error
+	DeleteExpiredIdempotencyKeys(ctx context.Context, ttl time.Duration) (int64, error)
 
 	io.Closer
 }
@@ -2,17 +2,22 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/budsx/retail-management/model"
 )
 
 func (rw *dbReadWriter) ReadWarehouseByID(ctx context.Context, warehouseID int64) (model.Warehouse, error) {
-	selectWarehouseByID := `SELECT warehouse_id, warehouse_name, user_id, created_at 
+	selectWarehouseByID := `SELECT warehouse_id, warehouse_name, user_id, costing_method, archived_at, created_at 
 							FROM mst_warehouse WHERE warehouse_id = $1`
 
 	var warehouse model.Warehouse
-	err := rw.db.QueryRowContext(ctx, selectWarehouseByID, warehouseID).Scan(&warehouse.WarehouseID, &warehouse.WarehouseName, &warehouse.UserID, &warehouse.CreatedAt)
+	err := rw.db.QueryRowContext(ctx, selectWarehouseByID, warehouseID).Scan(&warehouse.WarehouseID, &warehouse.WarehouseName, &warehouse.UserID, &warehouse.CostingMethod, &warehouse.ArchivedAt, &warehouse.CreatedAt)
+	if err == sql.ErrNoRows {
+		return model.Warehouse{}, fmt.Errorf("%w: %d", model.ErrWarehouseNotFound, warehouseID)
+	}
 	if err != nil {
 		return model.Warehouse{}, err
 	}
@@ -21,9 +26,9 @@ func (rw *dbReadWriter) ReadWarehouseByID(ctx context.Context, warehouseID int64
 }
 
 func (rw *dbReadWriter) WriteWarehouse(ctx context.Context, warehouse model.Warehouse) error {
-	insertWarehouse := `INSERT INTO mst_warehouse (warehouse_name, user_id, created_at) VALUES ($1, $2, CURRENT_TIMESTAMP)`
+	insertWarehouse := `INSERT INTO mst_warehouse (warehouse_name, user_id, costing_method, created_at) VALUES ($1, $2, $3, CURRENT_TIMESTAMP)`
 
-	_, err := rw.db.ExecContext(ctx, insertWarehouse, warehouse.WarehouseName, warehouse.UserID)
+	_, err := rw.db.ExecContext(ctx, insertWarehouse, warehouse.WarehouseName, warehouse.UserID, warehouse.CostingMethod)
 	if err != nil {
 		return err
 	}
@@ -31,15 +36,35 @@ func (rw *dbReadWriter) WriteWarehouse(ctx context.Context, warehouse model.Ware
 	return nil
 }
 
+// UpdateWarehouse renames a warehouse and sets its costing method. The method
+// cannot change while the warehouse holds stock, whose cost layers and
+// positions were booked under the old one.
 func (rw *dbReadWriter) UpdateWarehouse(ctx context.Context, warehouse model.Warehouse) error {
-	updateWarehouse := `UPDATE mst_warehouse SET warehouse_name = $1 WHERE warehouse_id = $2`
+	updateWarehouse := `UPDATE mst_warehouse SET warehouse_name = $1, costing_method = $2 WHERE warehouse_id = $3 
+		AND (costing_method = $2 OR NOT EXISTS (SELECT 1 FROM mst_stock_cost WHERE warehouse_id = $3 AND quantity <> 0))`
+
+	selectExists := `SELECT EXISTS (SELECT 1 FROM mst_warehouse WHERE warehouse_id = $1)`
 
-	_, err := rw.db.ExecContext(ctx, updateWarehouse, warehouse.WarehouseName, warehouse.WarehouseID)
+	result, err := rw.db.ExecContext(ctx, updateWarehouse, warehouse.WarehouseName, warehouse.CostingMethod, warehouse.WarehouseID)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected > 0 {
+		return nil
+	}
+
+	var exists bool
+	err = rw.db.QueryRowContext(ctx, selectExists, warehouse.WarehouseID).Scan(&exists)
+	if err != nil || !exists {
+		return err
+	}
+
+	return fmt.Errorf("%w: warehouse %d", model.ErrCostingMethodLocked, warehouse.WarehouseID)
 }
 
 func (rw *dbReadWriter) WriteLocation(ctx context.Context, location model.Location) error {
@@ -54,11 +79,14 @@ func (rw *dbReadWriter) WriteLocation(ctx context.Context, location model.Locati
 }
 
 func (rw *dbReadWriter) ReadLocationByID(ctx context.Context, locationID int64) (model.Location, error) {
-	selectLocationByID := `SELECT location_id, location_name, warehouse_id, created_at 
+	selectLocationByID := `SELECT location_id, location_name, warehouse_id, archived_at, created_at 
 						   FROM mst_location WHERE location_id = $1`
 
 	var location model.Location
-	err := rw.db.QueryRowContext(ctx, selectLocationByID, locationID).Scan(&location.LocationID, &location.LocationName, &location.WarehouseID, &location.CreatedAt)
+	err := rw.db.QueryRowContext(ctx, selectLocationByID, locationID).Scan(&location.LocationID, &location.LocationName, &location.WarehouseID, &location.ArchivedAt, &location.CreatedAt)
+	if err == sql.ErrNoRows {
+		return model.Location{}, fmt.Errorf("%w: %d", model.ErrLocationNotFound, locationID)
+	}
 	if err != nil {
 		return model.Location{}, err
 	}
@@ -77,36 +105,94 @@ func (rw *dbReadWriter) UpdateLocation(ctx context.Context, location model.Locat
 	return nil
 }
 
-func (rw *dbReadWriter) DeleteLocationByUserID(ctx context.Context, userID, locationID int64) error {
-	selectLocation := `
-		SELECT l.location_id
-		FROM mst_location l
-		INNER JOIN mst_warehouse w ON l.warehouse_id = w.warehouse_id
-		WHERE l.location_id = $1 AND w.user_id = $2`
-
-	var locationIDCheck int64
-	err := rw.db.QueryRowContext(ctx, selectLocation, locationID, userID).Scan(&locationIDCheck)
+// SetLocationArchived archives one of the user's locations, or restores it
+// when archived is false. Locations are archived rather than deleted as the
+// stock ledger still refers to them.
+func (rw *dbReadWriter) SetLocationArchived(ctx context.Context, userID, locationID int64, archived bool) error {
+	updateLocation := `
+		UPDATE mst_location l
+		SET archived_at = CASE WHEN $1 THEN COALESCE(l.archived_at, CURRENT_TIMESTAMP) END
+		FROM mst_warehouse w
+		WHERE l.warehouse_id = w.warehouse_id AND l.location_id = $2 AND w.user_id = $3`
+
+	result, err := rw.db.ExecContext(ctx, updateLocation, archived, locationID, userID)
 	if err != nil {
-		return fmt.Errorf("unauthorized or location not found")
+		return fmt.Errorf("failed to archive location: %w", err)
 	}
 
-	deleteLocation := `DELETE FROM mst_location WHERE location_id = $1`
-	_, err = rw.db.ExecContext(ctx, deleteLocation, locationID)
+	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("failed to delete location: %v", err)
+		return err
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: %d", model.ErrLocationNotFound, locationID)
 	}
 
 	return nil
 }
 
-func (rw *dbReadWriter) ReadWarehousesByUserID(ctx context.Context, userID int64) ([]model.Warehouse, error) {
+// SetWarehouseArchived archives one of the user's warehouses together with
+// its locations, or restores it when archived is false. Restoring brings back
+// the locations archived with the warehouse, leaving those archived before it.
+func (rw *dbReadWriter) SetWarehouseArchived(ctx context.Context, userID, warehouseID int64, archived bool) error {
+	lockWarehouse := `SELECT archived_at FROM mst_warehouse WHERE warehouse_id = $1 AND user_id = $2 FOR UPDATE`
+
+	archiveWarehouse := `UPDATE mst_warehouse SET archived_at = CURRENT_TIMESTAMP WHERE warehouse_id = $1`
+	archiveLocations := `UPDATE mst_location SET archived_at = CURRENT_TIMESTAMP WHERE warehouse_id = $1 AND archived_at IS NULL`
+
+	restoreLocations := `UPDATE mst_location SET archived_at = NULL 
+		WHERE warehouse_id = $1 AND archived_at = (SELECT archived_at FROM mst_warehouse WHERE warehouse_id = $1)`
+	restoreWarehouse := `UPDATE mst_warehouse SET archived_at = NULL WHERE warehouse_id = $1`
+
+	tx, err := rw.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var archivedAt *time.Time
+	err = tx.QueryRowContext(ctx, lockWarehouse, warehouseID, userID).Scan(&archivedAt)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("%w: %d", model.ErrWarehouseNotFound, warehouseID)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Nothing to do when the warehouse is already as asked
+	if archived == (archivedAt != nil) {
+		return nil
+	}
+
+	// CURRENT_TIMESTAMP is the start of the transaction, so the warehouse and
+	// its locations are archived at the same time
+	statements := []string{archiveWarehouse, archiveLocations}
+	if !archived {
+		statements = []string{restoreLocations, restoreWarehouse}
+	}
+	for _, statement := range statements {
+		_, err = tx.ExecContext(ctx, statement, warehouseID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ReadWarehousesByUserID lists up to limit of the user's warehouses that
+// follow cursor, in the order they were created. Archived warehouses are only
+// listed with includeArchived.
+func (rw *dbReadWriter) ReadWarehousesByUserID(ctx context.Context, userID int64, includeArchived bool, cursor model.Cursor, limit int32) ([]model.Warehouse, error) {
 	warehouses := make([]model.Warehouse, 0)
 
-	selectWarehouseByUser := `SELECT warehouse_id, warehouse_name, user_id, created_at 
+	keyset, order := idKeyset("warehouse_id", false, cursor, 3)
+	selectWarehouseByUser := `SELECT warehouse_id, warehouse_name, user_id, costing_method, archived_at, created_at 
 	          FROM mst_warehouse 
-	          WHERE user_id = $1`
+	          WHERE user_id = $1 AND ($2::bool OR archived_at IS NULL)` + keyset + order + ` 
+	          LIMIT $4`
 
-	rows, err := rw.db.QueryContext(ctx, selectWarehouseByUser, userID)
+	rows, err := rw.db.QueryContext(ctx, selectWarehouseByUser, userID, includeArchived, cursor.ID, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -114,7 +200,7 @@ func (rw *dbReadWriter) ReadWarehousesByUserID(ctx context.Context, userID int64
 
 	for rows.Next() {
 		var warehouse model.Warehouse
-		err := rows.Scan(&warehouse.WarehouseID, &warehouse.WarehouseName, &warehouse.UserID, &warehouse.CreatedAt)
+		err := rows.Scan(&warehouse.WarehouseID, &warehouse.WarehouseName, &warehouse.UserID, &warehouse.CostingMethod, &warehouse.ArchivedAt, &warehouse.CreatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -125,5 +211,45 @@ func (rw *dbReadWriter) ReadWarehousesByUserID(ctx context.Context, userID int64
 		return nil, err
 	}
 
+	if cursor.Backward {
+		reverseRows(warehouses)
+	}
 	return warehouses, nil
 }
+
+// ReadLocationsByWarehouse lists up to limit of the warehouse's locations that
+// follow cursor, in the order they were created. Archived locations are only
+// listed with includeArchived.
+func (rw *dbReadWriter) ReadLocationsByWarehouse(ctx context.Context, warehouseID int64, includeArchived bool, cursor model.Cursor, limit int32) ([]model.Location, error) {
+	locations := make([]model.Location, 0)
+
+	keyset, order := idKeyset("location_id", false, cursor, 3)
+	selectLocations := `SELECT location_id, location_name, warehouse_id, archived_at, created_at 
+	          FROM mst_location 
+	          WHERE warehouse_id = $1 AND ($2::bool OR archived_at IS NULL)` + keyset + order + ` 
+	          LIMIT $4`
+
+	rows, err := rw.db.QueryContext(ctx, selectLocations, warehouseID, includeArchived, cursor.ID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var location model.Location
+		err := rows.Scan(&location.LocationID, &location.LocationName, &location.WarehouseID, &location.ArchivedAt, &location.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		locations = append(locations, location)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if cursor.Backward {
+		reverseRows(locations)
+	}
+	return locations, nil
+}
@@ -33,9 +33,9 @@ func Test_ReadWarehouseByID(t *testing.T) {
 			name:        "success",
 			warehouseID: 1,
 			mock: func(mock sqlmock.Sqlmock) {
-				rows := sqlmock.NewRows([]string{"warehouse_id", "warehouse_name", "user_id", "created_at"}).
-					AddRow(1, "Test Warehouse", 1, fixedTime)
-				mock.ExpectQuery(regexp.QuoteMeta(`SELECT warehouse_id, warehouse_name, user_id, created_at FROM mst_warehouse WHERE warehouse_id = $1`)).
+				rows := sqlmock.NewRows([]string{"warehouse_id", "warehouse_name", "user_id", "costing_method", "archived_at", "created_at"}).
+					AddRow(1, "Test Warehouse", 1, "FIFO", nil, fixedTime)
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT warehouse_id, warehouse_name, user_id, costing_method, archived_at, created_at FROM mst_warehouse WHERE warehouse_id = $1`)).
 					WithArgs(1).
 					WillReturnRows(rows)
 			},
@@ -43,6 +43,7 @@ func Test_ReadWarehouseByID(t *testing.T) {
 				WarehouseID:   1,
 				WarehouseName: "Test Warehouse",
 				UserID:        1,
+				CostingMethod: model.CostingFIFO,
 				CreatedAt:     fixedTime,
 			},
 			wantErr: false,
@@ -51,7 +52,7 @@ func Test_ReadWarehouseByID(t *testing.T) {
 			name:        "not found",
 			warehouseID: 999,
 			mock: func(mock sqlmock.Sqlmock) {
-				mock.ExpectQuery(regexp.QuoteMeta(`SELECT warehouse_id, warehouse_name, user_id, created_at FROM mst_warehouse WHERE warehouse_id = $1`)).
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT warehouse_id, warehouse_name, user_id, costing_method, archived_at, created_at FROM mst_warehouse WHERE warehouse_id = $1`)).
 					WithArgs(999).
 					WillReturnError(sql.ErrNoRows)
 			},
@@ -94,10 +95,11 @@ func Test_WriteWarehouse(t *testing.T) {
 			warehouse: model.Warehouse{
 				WarehouseName: "New Warehouse",
 				UserID:        1,
+				CostingMethod: model.CostingFIFO,
 			},
 			mock: func(mock sqlmock.Sqlmock) {
-				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO mst_warehouse (warehouse_name, user_id, created_at) VALUES ($1, $2, CURRENT_TIMESTAMP)`)).
-					WithArgs("New Warehouse", int64(1)).
+				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO mst_warehouse (warehouse_name, user_id, costing_method, created_at) VALUES ($1, $2, $3, CURRENT_TIMESTAMP)`)).
+					WithArgs("New Warehouse", int64(1), model.CostingFIFO).
 					WillReturnResult(sqlmock.NewResult(1, 1))
 			},
 			wantErr: false,
@@ -139,9 +141,9 @@ func Test_ReadLocationByID(t *testing.T) {
 			name:       "success",
 			locationID: 1,
 			mock: func(mock sqlmock.Sqlmock) {
-				rows := sqlmock.NewRows([]string{"location_id", "location_name", "warehouse_id", "created_at"}).
-					AddRow(1, "Test Location", 1, fixedTime)
-				mock.ExpectQuery(regexp.QuoteMeta(`SELECT location_id, location_name, warehouse_id, created_at FROM mst_location WHERE location_id = $1`)).
+				rows := sqlmock.NewRows([]string{"location_id", "location_name", "warehouse_id", "archived_at", "created_at"}).
+					AddRow(1, "Test Location", 1, nil, fixedTime)
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT location_id, location_name, warehouse_id, archived_at, created_at FROM mst_location WHERE location_id = $1`)).
 					WithArgs(1).
 					WillReturnRows(rows)
 			},
@@ -153,6 +155,16 @@ func Test_ReadLocationByID(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name:       "not found",
+			locationID: 9,
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT location_id, location_name, warehouse_id, archived_at, created_at FROM mst_location WHERE location_id = $1`)).
+					WithArgs(9).
+					WillReturnError(sql.ErrNoRows)
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -162,7 +174,7 @@ func Test_ReadLocationByID(t *testing.T) {
 
 			got, err := rw.ReadLocationByID(context.Background(), tt.locationID)
 			if tt.wantErr {
-				assert.Error(t, err)
+				assert.ErrorIs(t, err, model.ErrLocationNotFound)
 				return
 			}
 			assert.NoError(t, err)
@@ -171,32 +183,44 @@ func Test_ReadLocationByID(t *testing.T) {
 	}
 }
 
-func Test_DeleteLocationByUserID(t *testing.T) {
+func Test_SetLocationArchived(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
 		t.Fatalf("failed to create mock: %v", err)
 	}
 	defer db.Close()
 
+	updateLocation := regexp.QuoteMeta(`UPDATE mst_location l SET archived_at = CASE WHEN $1 THEN COALESCE(l.archived_at, CURRENT_TIMESTAMP) END FROM mst_warehouse w WHERE l.warehouse_id = w.warehouse_id AND l.location_id = $2 AND w.user_id = $3`)
+
 	tests := []struct {
 		name       string
 		userID     int64
 		locationID int64
+		archived   bool
 		mock       func(sqlmock.Sqlmock)
 		wantErr    bool
 		errMsg     string
 	}{
 		{
-			name:       "success",
+			name:       "archive",
 			userID:     1,
 			locationID: 1,
+			archived:   true,
 			mock: func(mock sqlmock.Sqlmock) {
-				mock.ExpectQuery(regexp.QuoteMeta(`SELECT l.location_id FROM mst_location l INNER JOIN mst_warehouse w`)).
-					WithArgs(1, 1).
-					WillReturnRows(sqlmock.NewRows([]string{"location_id"}).AddRow(1))
-
-				mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM mst_location WHERE location_id = $1`)).
-					WithArgs(1).
+				mock.ExpectExec(updateLocation).
+					WithArgs(true, 1, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			wantErr: false,
+		},
+		{
+			name:       "restore",
+			userID:     1,
+			locationID: 1,
+			archived:   false,
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(updateLocation).
+					WithArgs(false, 1, 1).
 					WillReturnResult(sqlmock.NewResult(0, 1))
 			},
 			wantErr: false,
@@ -205,13 +229,14 @@ func Test_DeleteLocationByUserID(t *testing.T) {
 			name:       "unauthorized",
 			userID:     2,
 			locationID: 1,
+			archived:   true,
 			mock: func(mock sqlmock.Sqlmock) {
-				mock.ExpectQuery(regexp.QuoteMeta(`SELECT l.location_id FROM mst_location l INNER JOIN mst_warehouse w`)).
-					WithArgs(1, 2).
-					WillReturnError(sql.ErrNoRows)
+				mock.ExpectExec(updateLocation).
+					WithArgs(true, 1, 2).
+					WillReturnResult(sqlmock.NewResult(0, 0))
 			},
 			wantErr: true,
-			errMsg:  "unauthorized or location not found",
+			errMsg:  "location not found: 1",
 		},
 	}
 
@@ -220,7 +245,7 @@ func Test_DeleteLocationByUserID(t *testing.T) {
 			rw := &dbReadWriter{db: db}
 			tt.mock(mock)
 
-			err := rw.DeleteLocationByUserID(context.Background(), tt.userID, tt.locationID)
+			err := rw.SetLocationArchived(context.Background(), tt.userID, tt.locationID, tt.archived)
 			if tt.wantErr {
 				assert.Error(t, err)
 				if tt.errMsg != "" {
@@ -233,6 +258,69 @@ func Test_DeleteLocationByUserID(t *testing.T) {
 	}
 }
 
+func Test_SetWarehouseArchived(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	lockWarehouse := regexp.QuoteMeta(`SELECT archived_at FROM mst_warehouse WHERE warehouse_id = $1 AND user_id = $2 FOR UPDATE`)
+	archivedAt := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	rw := &dbReadWriter{db: db}
+
+	t.Run("archive with its locations", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockWarehouse).WithArgs(1, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"archived_at"}).AddRow(nil))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE mst_warehouse SET archived_at = CURRENT_TIMESTAMP WHERE warehouse_id = $1`)).
+			WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE mst_location SET archived_at = CURRENT_TIMESTAMP WHERE warehouse_id = $1 AND archived_at IS NULL`)).
+			WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectCommit()
+
+		err := rw.SetWarehouseArchived(context.Background(), 1, 1, true)
+		assert.NoError(t, err)
+	})
+
+	t.Run("restore with the locations archived along", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockWarehouse).WithArgs(1, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"archived_at"}).AddRow(archivedAt))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE mst_location SET archived_at = NULL WHERE warehouse_id = $1 AND archived_at = (SELECT archived_at FROM mst_warehouse WHERE warehouse_id = $1)`)).
+			WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE mst_warehouse SET archived_at = NULL WHERE warehouse_id = $1`)).
+			WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := rw.SetWarehouseArchived(context.Background(), 1, 1, false)
+		assert.NoError(t, err)
+	})
+
+	t.Run("already archived", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockWarehouse).WithArgs(1, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"archived_at"}).AddRow(archivedAt))
+		mock.ExpectRollback()
+
+		err := rw.SetWarehouseArchived(context.Background(), 1, 1, true)
+		assert.NoError(t, err)
+	})
+
+	t.Run("another user's warehouse", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockWarehouse).WithArgs(1, 2).WillReturnError(sql.ErrNoRows)
+		mock.ExpectRollback()
+
+		err := rw.SetWarehouseArchived(context.Background(), 2, 1, true)
+		assert.ErrorIs(t, err, model.ErrWarehouseNotFound)
+	})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
 func Test_ReadWarehousesByUserID(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -253,11 +341,11 @@ func Test_ReadWarehousesByUserID(t *testing.T) {
 			name:   "success with multiple warehouses",
 			userID: 1,
 			mock: func(mock sqlmock.Sqlmock) {
-				rows := sqlmock.NewRows([]string{"warehouse_id", "warehouse_name", "user_id", "created_at"}).
-					AddRow(1, "Warehouse 1", 1, fixedTime).
-					AddRow(2, "Warehouse 2", 1, fixedTime)
-				mock.ExpectQuery(regexp.QuoteMeta(`SELECT warehouse_id, warehouse_name, user_id, created_at FROM mst_warehouse WHERE user_id = $1`)).
-					WithArgs(1).
+				rows := sqlmock.NewRows([]string{"warehouse_id", "warehouse_name", "user_id", "costing_method", "archived_at", "created_at"}).
+					AddRow(1, "Warehouse 1", 1, "FIFO", nil, fixedTime).
+					AddRow(2, "Warehouse 2", 1, "AVERAGE", nil, fixedTime)
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT warehouse_id, warehouse_name, user_id, costing_method, archived_at, created_at FROM mst_warehouse WHERE user_id = $1 AND ($2::bool OR archived_at IS NULL)`)).
+					WithArgs(1, false, 0, 10).
 					WillReturnRows(rows)
 			},
 			want: []model.Warehouse{
@@ -265,12 +353,14 @@ func Test_ReadWarehousesByUserID(t *testing.T) {
 					WarehouseID:   1,
 					WarehouseName: "Warehouse 1",
 					UserID:        1,
+					CostingMethod: model.CostingFIFO,
 					CreatedAt:     fixedTime,
 				},
 				{
 					WarehouseID:   2,
 					WarehouseName: "Warehouse 2",
 					UserID:        1,
+					CostingMethod: model.CostingAverage,
 					CreatedAt:     fixedTime,
 				},
 			},
@@ -280,9 +370,9 @@ func Test_ReadWarehousesByUserID(t *testing.T) {
 			name:   "success with no warehouses",
 			userID: 2,
 			mock: func(mock sqlmock.Sqlmock) {
-				rows := sqlmock.NewRows([]string{"warehouse_id", "warehouse_name", "user_id", "created_at"})
-				mock.ExpectQuery(regexp.QuoteMeta(`SELECT warehouse_id, warehouse_name, user_id, created_at FROM mst_warehouse WHERE user_id = $1`)).
-					WithArgs(2).
+				rows := sqlmock.NewRows([]string{"warehouse_id", "warehouse_name", "user_id", "costing_method", "archived_at", "created_at"})
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT warehouse_id, warehouse_name, user_id, costing_method, archived_at, created_at FROM mst_warehouse WHERE user_id = $1 AND ($2::bool OR archived_at IS NULL)`)).
+					WithArgs(2, false, 0, 10).
 					WillReturnRows(rows)
 			},
 			want:    []model.Warehouse{},
@@ -292,8 +382,8 @@ func Test_ReadWarehousesByUserID(t *testing.T) {
 			name:   "database error",
 			userID: 3,
 			mock: func(mock sqlmock.Sqlmock) {
-				mock.ExpectQuery(regexp.QuoteMeta(`SELECT warehouse_id, warehouse_name, user_id, created_at FROM mst_warehouse WHERE user_id = $1`)).
-					WithArgs(3).
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT warehouse_id, warehouse_name, user_id, costing_method, archived_at, created_at FROM mst_warehouse WHERE user_id = $1 AND ($2::bool OR archived_at IS NULL)`)).
+					WithArgs(3, false, 0, 10).
 					WillReturnError(sql.ErrConnDone)
 			},
 			want:    nil,
@@ -303,10 +393,10 @@ func Test_ReadWarehousesByUserID(t *testing.T) {
 			name:   "error during row scan",
 			userID: 4,
 			mock: func(mock sqlmock.Sqlmock) {
-				rows := sqlmock.NewRows([]string{"warehouse_id", "warehouse_name", "user_id", "created_at"}).
-					AddRow("invalid", "Warehouse 1", 1, fixedTime) // warehouse_id as string will cause scan error
-				mock.ExpectQuery(regexp.QuoteMeta(`SELECT warehouse_id, warehouse_name, user_id, created_at FROM mst_warehouse WHERE user_id = $1`)).
-					WithArgs(4).
+				rows := sqlmock.NewRows([]string{"warehouse_id", "warehouse_name", "user_id", "costing_method", "archived_at", "created_at"}).
+					AddRow("invalid", "Warehouse 1", 1, "FIFO", nil, fixedTime) // warehouse_id as string will cause scan error
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT warehouse_id, warehouse_name, user_id, costing_method, archived_at, created_at FROM mst_warehouse WHERE user_id = $1 AND ($2::bool OR archived_at IS NULL)`)).
+					WithArgs(4, false, 0, 10).
 					WillReturnRows(rows)
 			},
 			want:    nil,
@@ -319,7 +409,7 @@ func Test_ReadWarehousesByUserID(t *testing.T) {
 			rw := &dbReadWriter{db: db}
 			tt.mock(mock)
 
-			got, err := rw.ReadWarehousesByUserID(context.Background(), tt.userID)
+			got, err := rw.ReadWarehousesByUserID(context.Background(), tt.userID, false, model.Cursor{}, 10)
 			if tt.wantErr {
 				assert.Error(t, err)
 				return
@@ -414,6 +504,9 @@ func Test_UpdateWarehouse(t *testing.T) {
 	}
 	defer db.Close()
 
+	updateWarehouse := regexp.QuoteMeta(`UPDATE mst_warehouse SET warehouse_name = $1, costing_method = $2 WHERE warehouse_id = $3 AND (costing_method = $2 OR NOT EXISTS (SELECT 1 FROM mst_stock_cost WHERE warehouse_id = $3 AND quantity <> 0))`)
+	selectExists := regexp.QuoteMeta(`SELECT EXISTS (SELECT 1 FROM mst_warehouse WHERE warehouse_id = $1)`)
+
 	tests := []struct {
 		name      string
 		warehouse model.Warehouse
@@ -425,10 +518,11 @@ func Test_UpdateWarehouse(t *testing.T) {
 			warehouse: model.Warehouse{
 				WarehouseID:   1,
 				WarehouseName: "Updated Warehouse",
+				CostingMethod: model.CostingFIFO,
 			},
 			mock: func(mock sqlmock.Sqlmock) {
-				mock.ExpectExec(regexp.QuoteMeta(`UPDATE mst_warehouse SET warehouse_name = $1 WHERE warehouse_id = $2`)).
-					WithArgs("Updated Warehouse", int64(1)).
+				mock.ExpectExec(updateWarehouse).
+					WithArgs("Updated Warehouse", model.CostingFIFO, int64(1)).
 					WillReturnResult(sqlmock.NewResult(0, 1))
 			},
 			wantErr: false,
@@ -438,23 +532,45 @@ func Test_UpdateWarehouse(t *testing.T) {
 			warehouse: model.Warehouse{
 				WarehouseID:   999,
 				WarehouseName: "Non-existent Warehouse",
+				CostingMethod: model.CostingFIFO,
 			},
 			mock: func(mock sqlmock.Sqlmock) {
-				mock.ExpectExec(regexp.QuoteMeta(`UPDATE mst_warehouse SET warehouse_name = $1 WHERE warehouse_id = $2`)).
-					WithArgs("Non-existent Warehouse", int64(999)).
+				mock.ExpectExec(updateWarehouse).
+					WithArgs("Non-existent Warehouse", model.CostingFIFO, int64(999)).
 					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(selectExists).
+					WithArgs(int64(999)).
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
 			},
 			wantErr: false,
 		},
+		{
+			name: "costing method changed while holding stock",
+			warehouse: model.Warehouse{
+				WarehouseID:   1,
+				WarehouseName: "Gudang Utama",
+				CostingMethod: model.CostingAverage,
+			},
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(updateWarehouse).
+					WithArgs("Gudang Utama", model.CostingAverage, int64(1)).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(selectExists).
+					WithArgs(int64(1)).
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+			},
+			wantErr: true,
+		},
 		{
 			name: "database error",
 			warehouse: model.Warehouse{
 				WarehouseID:   1,
 				WarehouseName: "Error Warehouse",
+				CostingMethod: model.CostingFIFO,
 			},
 			mock: func(mock sqlmock.Sqlmock) {
-				mock.ExpectExec(regexp.QuoteMeta(`UPDATE mst_warehouse SET warehouse_name = $1 WHERE warehouse_id = $2`)).
-					WithArgs("Error Warehouse", int64(1)).
+				mock.ExpectExec(updateWarehouse).
+					WithArgs("Error Warehouse", model.CostingFIFO, int64(1)).
 					WillReturnError(sql.ErrConnDone)
 			},
 			wantErr: true,
@@ -552,3 +668,100 @@ func Test_WriteLocation(t *testing.T) {
 		})
 	}
 }
+
+func Test_ReadLocationsByWarehouse(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	fixedTime := time.Now()
+	columns := []string{"location_id", "location_name", "warehouse_id", "archived_at", "created_at"}
+
+	tests := []struct {
+		name            string
+		includeArchived bool
+		cursor          model.Cursor
+		mock            func(sqlmock.Sqlmock)
+		want            []model.Location
+		wantErr         bool
+	}{
+		{
+			name:   "first page",
+			cursor: model.Cursor{},
+			mock: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows(columns).
+					AddRow(1, "A-01", 1, nil, fixedTime).
+					AddRow(2, "A-02", 1, nil, fixedTime)
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT location_id, location_name, warehouse_id, archived_at, created_at FROM mst_location WHERE warehouse_id = $1 AND ($2::bool OR archived_at IS NULL) AND ($3::bigint = 0 OR location_id > $3) ORDER BY location_id ASC LIMIT $4`)).
+					WithArgs(1, false, 0, 10).
+					WillReturnRows(rows)
+			},
+			want: []model.Location{
+				{LocationID: 1, LocationName: "A-01", WarehouseID: 1, CreatedAt: fixedTime},
+				{LocationID: 2, LocationName: "A-02", WarehouseID: 1, CreatedAt: fixedTime},
+			},
+		},
+		{
+			name:   "backward from a cursor",
+			cursor: model.Cursor{ID: 5, Backward: true},
+			mock: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows(columns).
+					AddRow(4, "A-04", 1, nil, fixedTime).
+					AddRow(3, "A-03", 1, nil, fixedTime)
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT location_id, location_name, warehouse_id, archived_at, created_at FROM mst_location WHERE warehouse_id = $1 AND ($2::bool OR archived_at IS NULL) AND ($3::bigint = 0 OR location_id < $3) ORDER BY location_id DESC LIMIT $4`)).
+					WithArgs(1, false, 5, 10).
+					WillReturnRows(rows)
+			},
+			want: []model.Location{
+				{LocationID: 3, LocationName: "A-03", WarehouseID: 1, CreatedAt: fixedTime},
+				{LocationID: 4, LocationName: "A-04", WarehouseID: 1, CreatedAt: fixedTime},
+			},
+		},
+		{
+			name:            "including archived",
+			includeArchived: true,
+			cursor:          model.Cursor{},
+			mock: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows(columns).
+					AddRow(1, "A-01", 1, fixedTime, fixedTime)
+				mock.ExpectQuery(regexp.QuoteMeta(`WHERE warehouse_id = $1 AND ($2::bool OR archived_at IS NULL)`)).
+					WithArgs(1, true, 0, 10).
+					WillReturnRows(rows)
+			},
+			want: []model.Location{
+				{LocationID: 1, LocationName: "A-01", WarehouseID: 1, ArchivedAt: &fixedTime, CreatedAt: fixedTime},
+			},
+		},
+		{
+			name:   "database error",
+			cursor: model.Cursor{},
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT location_id, location_name, warehouse_id, archived_at, created_at FROM mst_location`)).
+					WithArgs(1, false, 0, 10).
+					WillReturnError(sql.ErrConnDone)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rw := &dbReadWriter{db: db}
+			tt.mock(mock)
+
+			got, err := rw.ReadLocationsByWarehouse(context.Background(), 1, tt.includeArchived, tt.cursor, 10)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/budsx/retail-management/model"
+)
+
+// stockLot is a locked lot balance at one location.
+type stockLot struct {
+	lotID      int64
+	lotNumber  string
+	expiryDate *model.Date
+	quantity   int64
+}
+
+// lotPick is the part of an outgoing quantity taken from one lot. A pick with
+// a zero lotID comes from stock that was received without a lot number. For
+// serialized products serialIDs are the units picked.
+type lotPick struct {
+	lot       stockLot
+	quantity  int64
+	serialIDs []int64
+}
+
+// lockStockLots locks the product's non-empty lots at a location in
+// first-expired-first-out order. Callers must hold the location's stock row
+// lock first so that lot rows are always locked after it.
+func lockStockLots(ctx context.Context, tx *sql.Tx, productID, locationID int64) ([]stockLot, error) {
+	selectForUpdate := `SELECT lot_id, lot_number, expiry_date, quantity FROM mst_stock_lot 
+		WHERE product_id = $1 AND location_id = $2 AND quantity > 0 
+		ORDER BY expiry_date NULLS LAST, lot_id FOR UPDATE`
+
+	rows, err := tx.QueryContext(ctx, selectForUpdate, productID, locationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lots []stockLot
+	for rows.Next() {
+		var lot stockLot
+		if err := rows.Scan(&lot.lotID, &lot.lotNumber, &lot.expiryDate, &lot.quantity); err != nil {
+			return nil, err
+		}
+		lots = append(lots, lot)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return lots, nil
+}
+
+// pickLots decides which lots an outgoing quantity is taken from: the named lot
+// if lotNumber is set, otherwise lots in FEFO order followed by stock held
+// without a lot. The lots and the untracked stock add up to the location
+// balance, so anything beyond it ends up on the untracked pick where
+// updateLocationStock rejects it.
+func pickLots(lots []stockLot, lotNumber string, quantity int64) ([]lotPick, error) {
+	if lotNumber != "" {
+		for _, lot := range lots {
+			if lot.lotNumber != lotNumber {
+				continue
+			}
+			if lot.quantity < quantity {
+				return nil, fmt.Errorf("%w: lot %s has %d, requested %d", model.ErrInsufficientStock, lotNumber, lot.quantity, quantity)
+			}
+			return []lotPick{{lot: lot, quantity: quantity}}, nil
+		}
+		return nil, fmt.Errorf("%w: lot %s has no stock at this location", model.ErrInsufficientStock, lotNumber)
+	}
+
+	var picks []lotPick
+	remaining := quantity
+	for _, lot := range lots {
+		if remaining == 0 {
+			break
+		}
+		take := lot.quantity
+		if take > remaining {
+			take = remaining
+		}
+		picks = append(picks, lotPick{lot: lot, quantity: take})
+		remaining -= take
+	}
+
+	if remaining > 0 {
+		picks = append(picks, lotPick{quantity: remaining})
+	}
+
+	return picks, nil
+}
+
+func debitStockLot(ctx context.Context, tx *sql.Tx, lotID, quantity int64) error {
+	updateLot := `UPDATE mst_stock_lot SET quantity = quantity - $1 WHERE lot_id = $2`
+
+	_, err := tx.ExecContext(ctx, updateLot, quantity, lotID)
+	return err
+}
+
+// creditStockLot adds quantity to a lot at a location, creating the lot on its
+// first receipt there. The expiry date of an existing lot is kept.
+func creditStockLot(ctx context.Context, tx *sql.Tx, transaction model.StockTransaction, quantity int64) error {
+	upsertLot := `INSERT INTO mst_stock_lot (product_id, warehouse_id, location_id, lot_number, expiry_date, quantity) 
+		VALUES ($1, $2, $3, $4, $5, $6) 
+		ON CONFLICT (product_id, location_id, lot_number) 
+		DO UPDATE SET quantity = mst_stock_lot.quantity + EXCLUDED.quantity, expiry_date = COALESCE(mst_stock_lot.expiry_date, EXCLUDED.expiry_date)`
+
+	_, err := tx.ExecContext(ctx, upsertLot,
+		transaction.ProductID,
+		transaction.WarehouseID,
+		transaction.LocationID,
+		transaction.LotNumber,
+		transaction.ExpiryDate,
+		quantity,
+	)
+	return err
+}
+
+// GetExpiringLots lists the lots in a warehouse that expire within days from
+// today, including lots that have already expired, soonest first.
+func (rw *dbReadWriter) GetExpiringLots(ctx context.Context, warehouseID int64, days int) ([]model.StockLot, error) {
+	query := `SELECT l.lot_id, l.product_id, m.product_name, m.sku, l.warehouse_id, l.location_id, l.lot_number, l.expiry_date, l.quantity
+	          FROM mst_stock_lot as l INNER JOIN mst_product as m ON l.product_id = m.product_id
+	          WHERE l.warehouse_id = $1 AND l.quantity > 0 AND l.expiry_date <= CURRENT_DATE + $2::int
+	          ORDER BY l.expiry_date, l.location_id, l.product_id`
+
+	rows, err := rw.db.QueryContext(ctx, query, warehouseID, days)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lots := []model.StockLot{}
+	for rows.Next() {
+		var lot model.StockLot
+		err := rows.Scan(&lot.LotID, &lot.ProductID, &lot.ProductName, &lot.SKU, &lot.WarehouseID, &lot.LocationID, &lot.LotNumber, &lot.ExpiryDate, &lot.Quantity)
+		if err != nil {
+			return nil, err
+		}
+		lots = append(lots, lot)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return lots, nil
+}
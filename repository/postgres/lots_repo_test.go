@@ -0,0 +1,143 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/budsx/retail-management/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_pickLots(t *testing.T) {
+	early := model.NewDate(2025, time.January, 10)
+	late := model.NewDate(2025, time.March, 1)
+	lots := []stockLot{
+		{lotID: 1, lotNumber: "LOT-A", expiryDate: &early, quantity: 5},
+		{lotID: 2, lotNumber: "LOT-B", expiryDate: &late, quantity: 8},
+	}
+
+	tests := []struct {
+		name      string
+		lotNumber string
+		quantity  int64
+		want      []lotPick
+		wantErr   error
+	}{
+		{
+			name:     "earliest expiry is taken first",
+			quantity: 7,
+			want: []lotPick{
+				{lot: lots[0], quantity: 5},
+				{lot: lots[1], quantity: 2},
+			},
+		},
+		{
+			name:     "stock without a lot is taken last",
+			quantity: 16,
+			want: []lotPick{
+				{lot: lots[0], quantity: 5},
+				{lot: lots[1], quantity: 8},
+				{quantity: 3},
+			},
+		},
+		{
+			name:      "named lot",
+			lotNumber: "LOT-B",
+			quantity:  8,
+			want:      []lotPick{{lot: lots[1], quantity: 8}},
+		},
+		{
+			name:      "named lot is short",
+			lotNumber: "LOT-A",
+			quantity:  6,
+			wantErr:   model.ErrInsufficientStock,
+		},
+		{
+			name:      "unknown lot",
+			lotNumber: "LOT-Z",
+			quantity:  1,
+			wantErr:   model.ErrInsufficientStock,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := pickLots(lots, tt.lotNumber, tt.quantity)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_GetExpiringLots(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	query := regexp.QuoteMeta(`SELECT l.lot_id, l.product_id, m.product_name, m.sku, l.warehouse_id, l.location_id, l.lot_number, l.expiry_date, l.quantity FROM mst_stock_lot as l INNER JOIN mst_product as m ON l.product_id = m.product_id WHERE l.warehouse_id = $1 AND l.quantity > 0 AND l.expiry_date <= CURRENT_DATE + $2::int ORDER BY l.expiry_date, l.location_id, l.product_id`)
+	expiry := model.NewDate(2025, time.January, 10)
+
+	tests := []struct {
+		name      string
+		mockSetup func(sqlmock.Sqlmock)
+		want      []model.StockLot
+		wantErr   bool
+	}{
+		{
+			name: "lots expiring within the window",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{
+					"lot_id", "product_id", "product_name", "sku", "warehouse_id", "location_id", "lot_number", "expiry_date", "quantity",
+				}).AddRow(4, 1, "Kopi Arabica", "KOPI-001", 1, 2, "LOT-A", expiry.Time, 12)
+				mock.ExpectQuery(query).WithArgs(1, 30).WillReturnRows(rows)
+			},
+			want: []model.StockLot{{
+				LotID:       4,
+				ProductID:   1,
+				ProductName: "Kopi Arabica",
+				SKU:         "KOPI-001",
+				WarehouseID: 1,
+				LocationID:  2,
+				LotNumber:   "LOT-A",
+				ExpiryDate:  &expiry,
+				Quantity:    12,
+			}},
+		},
+		{
+			name: "database error",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(query).WithArgs(1, 30).WillReturnError(sql.ErrConnDone)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rw := &dbReadWriter{db: db}
+			tt.mockSetup(mock)
+
+			got, err := rw.GetExpiringLots(context.Background(), 1, 30)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package postgres
+
+import (
+	"fmt"
+
+	"github.com/budsx/retail-management/model"
+)
+
+// idKeyset is the condition and order of a listing sorted by its id column that
+// continue past cursor, the cursor's id bound as $n. Walking backward reads the
+// listing in reverse, for reverseRows to put right.
+func idKeyset(column string, descending bool, cursor model.Cursor, n int) (string, string) {
+	if cursor.Backward {
+		descending = !descending
+	}
+
+	op, direction := ">", "ASC"
+	if descending {
+		op, direction = "<", "DESC"
+	}
+
+	param := fmt.Sprintf("$%d", n)
+	condition := fmt.Sprintf(` AND (%s::bigint = 0 OR %s %s %s)`, param, column, op, param)
+	return condition, ` ORDER BY ` + column + ` ` + direction
+}
+
+// reverseRows puts rows read backward from a cursor back in listing order.
+func reverseRows[T any](rows []T) {
+	for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+		rows[i], rows[j] = rows[j], rows[i]
+	}
+}
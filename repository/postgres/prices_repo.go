@@ -0,0 +1,119 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/budsx/retail-management/model"
+)
+
+const priceColumns = `price_id, product_id, price, effective_from, applied_at, created_at`
+
+func scanPrice(row rowScanner) (model.ProductPrice, error) {
+	var price model.ProductPrice
+	err := row.Scan(&price.PriceID, &price.ProductID, &price.Price, &price.EffectiveFrom, &price.AppliedAt, &price.CreatedAt)
+	return price, err
+}
+
+// ReadProductPrices returns the price history of a product, scheduled changes
+// included, oldest first.
+func (rw *dbReadWriter) ReadProductPrices(ctx context.Context, productID int64) ([]model.ProductPrice, error) {
+	selectPrices := `SELECT ` + priceColumns + ` FROM trx_product_price WHERE product_id = $1 ORDER BY effective_from, price_id`
+
+	rows, err := rw.db.QueryContext(ctx, selectPrices, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	prices := []model.ProductPrice{}
+	for rows.Next() {
+		price, err := scanPrice(rows)
+		if err != nil {
+			return nil, err
+		}
+		prices = append(prices, price)
+	}
+
+	return prices, rows.Err()
+}
+
+// ReadPriceAt returns the price of a product in effect at the given time: the
+// latest change effective by then, whether or not the price job has applied it
+// yet.
+func (rw *dbReadWriter) ReadPriceAt(ctx context.Context, productID int64, at time.Time) (model.ProductPrice, error) {
+	selectPrice := `SELECT ` + priceColumns + ` FROM trx_product_price 
+		WHERE product_id = $1 AND effective_from <= $2 
+		ORDER BY effective_from DESC, price_id DESC 
+		LIMIT 1`
+
+	price, err := scanPrice(rw.db.QueryRowContext(ctx, selectPrice, productID, at))
+	if err == sql.ErrNoRows {
+		return price, fmt.Errorf("%w: product %d had no price at %s", model.ErrPriceNotFound, productID, at.Format(time.RFC3339))
+	}
+
+	return price, err
+}
+
+// WritePriceChange schedules a price change and returns its ID.
+func (rw *dbReadWriter) WritePriceChange(ctx context.Context, price model.ProductPrice) (int64, error) {
+	insertPrice := `INSERT INTO trx_product_price (product_id, price, effective_from, created_at) 
+		VALUES ($1, $2, $3, CURRENT_TIMESTAMP) 
+		RETURNING price_id`
+
+	var priceID int64
+	err := rw.db.QueryRowContext(ctx, insertPrice, price.ProductID, price.Price, price.EffectiveFrom).Scan(&priceID)
+	return priceID, err
+}
+
+// DeletePriceChange cancels a price change that has neither been applied nor
+// taken effect yet.
+func (rw *dbReadWriter) DeletePriceChange(ctx context.Context, productID, priceID int64) error {
+	deletePrice := `DELETE FROM trx_product_price WHERE price_id = $1 AND product_id = $2 AND applied_at IS NULL AND effective_from > CURRENT_TIMESTAMP`
+
+	result, err := rw.db.ExecContext(ctx, deletePrice, priceID, productID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: product %d has no scheduled change %d", model.ErrPriceNotFound, productID, priceID)
+	}
+
+	return nil
+}
+
+// ApplyScheduledPrices writes the price changes effective by now to their
+// products, the latest one winning when several are due, and returns how many
+// products were repriced. A due change that a later price already applied to
+// the product supersedes, such as one set directly on the product while the
+// change waited for the job, is marked applied without repricing. Marking the
+// changes applied and repricing happen in one statement, so a change is never
+// applied twice.
+func (rw *dbReadWriter) ApplyScheduledPrices(ctx context.Context) (int64, error) {
+	applyPrices := `WITH due AS (
+			UPDATE trx_product_price SET applied_at = CURRENT_TIMESTAMP
+			WHERE applied_at IS NULL AND effective_from <= CURRENT_TIMESTAMP
+			RETURNING price_id, product_id, price, effective_from
+		), latest AS (
+			SELECT DISTINCT ON (product_id) product_id, price, effective_from FROM due
+			ORDER BY product_id, effective_from DESC, price_id DESC
+		)
+		UPDATE mst_product as p SET price = latest.price, updated_at = CURRENT_TIMESTAMP
+		FROM latest WHERE p.product_id = latest.product_id 
+		AND NOT EXISTS (SELECT 1 FROM trx_product_price a WHERE a.product_id = latest.product_id 
+			AND a.applied_at IS NOT NULL AND a.effective_from > latest.effective_from)`
+
+	result, err := rw.db.ExecContext(ctx, applyPrices)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
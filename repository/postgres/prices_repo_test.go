@@ -0,0 +1,110 @@
+package postgres
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/budsx/retail-management/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ReadPriceAt(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	selectPrice := regexp.QuoteMeta(`SELECT price_id, product_id, price, effective_from, applied_at, created_at FROM trx_product_price WHERE product_id = $1 AND effective_from <= $2 ORDER BY effective_from DESC, price_id DESC LIMIT 1`)
+	columns := []string{"price_id", "product_id", "price", "effective_from", "applied_at", "created_at"}
+	at := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	effectiveFrom := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	rw := &dbReadWriter{db: db}
+
+	t.Run("applied change", func(t *testing.T) {
+		mock.ExpectQuery(selectPrice).
+			WithArgs(4, at).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(7, 4, 72500.0, effectiveFrom, effectiveFrom, effectiveFrom))
+
+		got, err := rw.ReadPriceAt(context.Background(), 4, at)
+		assert.NoError(t, err)
+		assert.Equal(t, model.ProductPrice{PriceID: 7, ProductID: 4, Price: 72500, EffectiveFrom: effectiveFrom, AppliedAt: &effectiveFrom, CreatedAt: effectiveFrom}, got)
+	})
+
+	t.Run("change not applied yet", func(t *testing.T) {
+		mock.ExpectQuery(selectPrice).
+			WithArgs(4, at).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(8, 4, 75000.0, effectiveFrom, nil, effectiveFrom))
+
+		got, err := rw.ReadPriceAt(context.Background(), 4, at)
+		assert.NoError(t, err)
+		assert.Nil(t, got.AppliedAt)
+		assert.Equal(t, 75000.0, got.Price)
+	})
+
+	t.Run("before the product had a price", func(t *testing.T) {
+		mock.ExpectQuery(selectPrice).
+			WithArgs(4, at).
+			WillReturnRows(sqlmock.NewRows(columns))
+
+		_, err := rw.ReadPriceAt(context.Background(), 4, at)
+		assert.ErrorIs(t, err, model.ErrPriceNotFound)
+	})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func Test_DeletePriceChange(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	deletePrice := regexp.QuoteMeta(`DELETE FROM trx_product_price WHERE price_id = $1 AND product_id = $2 AND applied_at IS NULL AND effective_from > CURRENT_TIMESTAMP`)
+	rw := &dbReadWriter{db: db}
+
+	t.Run("scheduled change", func(t *testing.T) {
+		mock.ExpectExec(deletePrice).WithArgs(8, 4).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := rw.DeletePriceChange(context.Background(), 4, 8)
+		assert.NoError(t, err)
+	})
+
+	t.Run("already applied", func(t *testing.T) {
+		mock.ExpectExec(deletePrice).WithArgs(7, 4).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := rw.DeletePriceChange(context.Background(), 4, 7)
+		assert.ErrorIs(t, err, model.ErrPriceNotFound)
+	})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func Test_ApplyScheduledPrices(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE trx_product_price SET applied_at = CURRENT_TIMESTAMP WHERE applied_at IS NULL AND effective_from <= CURRENT_TIMESTAMP`) +
+		`.*` + regexp.QuoteMeta(`AND NOT EXISTS (SELECT 1 FROM trx_product_price a WHERE a.product_id = latest.product_id AND a.applied_at IS NOT NULL AND a.effective_from > latest.effective_from)`)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	rw := &dbReadWriter{db: db}
+	got, err := rw.ApplyScheduledPrices(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), got)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
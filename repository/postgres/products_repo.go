@@ -3,43 +3,192 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/budsx/retail-management/model"
+	"github.com/lib/pq"
 )
 
-func (rw *dbReadWriter) ReadProductByID(ctx context.Context, req int64) (model.Product, error) {
-	selectProductByID := `SELECT product_id, product_name, description, price, sku, created_at, updated_at 
-	FROM mst_product 
-	WHERE product_id = $1`
+const productColumns = `product_id, product_name, description, price, sku, is_serialized, is_bundle, base_unit, COALESCE(purchase_unit, ''), COALESCE(sales_unit, ''), COALESCE(category_id, 0), COALESCE(parent_id, 0), variant_attributes, COALESCE(attributes, '{}'), archived_at, created_at, updated_at`
 
+func scanProduct(row rowScanner) (model.Product, error) {
 	var product model.Product
-	err := rw.db.QueryRowContext(ctx, selectProductByID, req).Scan(
+	var attributes []byte
+	err := row.Scan(
 		&product.ProductID,
 		&product.ProductName,
 		&product.Description,
 		&product.Price,
 		&product.SKU,
+		&product.IsSerialized,
+		&product.IsBundle,
+		&product.BaseUnit,
+		&product.PurchaseUnit,
+		&product.SalesUnit,
+		&product.CategoryID,
+		&product.ParentID,
+		pq.Array(&product.VariantAttributes),
+		&attributes,
+		&product.ArchivedAt,
 		&product.CreatedAt,
 		&product.UpdatedAt,
 	)
+	if err != nil {
+		return product, err
+	}
+
+	if len(product.VariantAttributes) == 0 {
+		product.VariantAttributes = nil
+	}
+	err = json.Unmarshal(attributes, &product.Attributes)
+	if len(product.Attributes) == 0 {
+		product.Attributes = nil
+	}
+	return product, err
+}
+
+// variantColumns binds the variant fields of a product for a write.
+func variantColumns(product model.Product) (interface{}, interface{}, error) {
+	variantAttributes := product.VariantAttributes
+	if variantAttributes == nil {
+		variantAttributes = []string{}
+	}
+
+	if len(product.Attributes) == 0 {
+		return pq.Array(variantAttributes), nil, nil
+	}
+	attributes, err := json.Marshal(product.Attributes)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pq.Array(variantAttributes), string(attributes), nil
+}
+
+func (rw *dbReadWriter) ReadProductByID(ctx context.Context, req int64) (model.Product, error) {
+	selectProductByID := `SELECT ` + productColumns + ` 
+	FROM mst_product 
+	WHERE product_id = $1`
 
+	product, err := scanProduct(rw.db.QueryRowContext(ctx, selectProductByID, req))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return product, fmt.Errorf("product with id %d not found", req)
+			return product, fmt.Errorf("%w: %d", model.ErrProductNotFound, req)
 		}
 		return product, err
 	}
 
+	if product.HasVariants() {
+		product.Variants, err = rw.readProducts(ctx, `SELECT `+productColumns+` FROM mst_product WHERE parent_id = $1 ORDER BY product_id`, req)
+		if err != nil {
+			return product, err
+		}
+	}
+
 	return product, nil
 }
 
-func (rw *dbReadWriter) ReadProductsWithPagination(ctx context.Context, limit int32, offset int32) ([]model.Product, error) {
-	selectProductsWithPagination := `SELECT product_id, product_name, description, price, sku, created_at, updated_at 
-		FROM mst_product ORDER BY product_id
-		LIMIT $1 OFFSET $2`
+// ReadProductsByIDs reads the products with the given IDs, without their
+// variants. IDs that do not exist are left out.
+func (rw *dbReadWriter) ReadProductsByIDs(ctx context.Context, productIDs []int64) ([]model.Product, error) {
+	selectProducts := `SELECT ` + productColumns + ` FROM mst_product WHERE product_id = ANY($1) ORDER BY product_id`
+
+	return rw.readProducts(ctx, selectProducts, pq.Array(productIDs))
+}
+
+func (rw *dbReadWriter) readProducts(ctx context.Context, query string, args ...interface{}) ([]model.Product, error) {
+	rows, err := rw.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := []model.Product{}
+	for rows.Next() {
+		product, err := scanProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, product)
+	}
+
+	return products, rows.Err()
+}
+
+// productFilterClause selects the products matching a filter whose values are
+// bound as $1 to $9 by productFilterArgs. A category takes in its whole subtree.
+// Archived products are left out unless the filter includes them.
+const productFilterClause = ` WHERE ($1::text = '' OR product_name ILIKE $1 OR description ILIKE $1 OR sku ILIKE $1) 
+	AND ($2::numeric IS NULL OR price >= $2) AND ($3::numeric IS NULL OR price <= $3) 
+	AND ($4::timestamp IS NULL OR created_at >= $4) AND ($5::timestamp IS NULL OR created_at <= $5) 
+	AND ($6::timestamp IS NULL OR updated_at >= $6) AND ($7::timestamp IS NULL OR updated_at <= $7) 
+	AND ($8::int = 0 OR category_id IN (
+		WITH RECURSIVE subtree AS (
+			SELECT category_id FROM mst_category WHERE category_id = $8 
+			UNION ALL 
+			SELECT c.category_id FROM mst_category as c INNER JOIN subtree as s ON c.parent_id = s.category_id
+		) SELECT category_id FROM subtree)) 
+	AND ($9::bool OR archived_at IS NULL)`
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func productFilterArgs(filter model.ProductFilter) []interface{} {
+	pattern := ""
+	if filter.Search != "" {
+		pattern = "%" + likeEscaper.Replace(filter.Search) + "%"
+	}
+
+	return []interface{}{
+		pattern,
+		filter.MinPrice,
+		filter.MaxPrice,
+		filter.CreatedFrom,
+		filter.CreatedTo,
+		filter.UpdatedFrom,
+		filter.UpdatedTo,
+		filter.CategoryID,
+		filter.IncludeArchived,
+	}
+}
+
+// productOrder is the ORDER BY of a product listing, product_id breaking ties
+// so that pages do not overlap.
+func productOrder(filter model.ProductFilter) (string, error) {
+	sortBy := filter.SortBy
+	if sortBy == "" {
+		sortBy = "product_id"
+	}
+	if !model.ProductSortFields[sortBy] {
+		return "", fmt.Errorf("%w: cannot sort by %q", model.ErrInvalidProductQuery, sortBy)
+	}
 
-	rows, err := rw.db.QueryContext(ctx, selectProductsWithPagination, limit, offset)
+	direction := " ASC"
+	if filter.Descending {
+		direction = " DESC"
+	}
+
+	order := ` ORDER BY ` + sortBy + direction
+	if sortBy != "product_id" {
+		order += `, product_id` + direction
+	}
+	return order, nil
+}
+
+// ReadProductsWithPagination lists a page of the products matching filter.
+func (rw *dbReadWriter) ReadProductsWithPagination(ctx context.Context, filter model.ProductFilter, limit int32, offset int32) ([]model.Product, error) {
+	order, err := productOrder(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	selectProductsWithPagination := `SELECT ` + productColumns + ` 
+		FROM mst_product` + productFilterClause + order + ` 
+		LIMIT $10 OFFSET $11`
+
+	args := append(productFilterArgs(filter), limit, offset)
+	rows, err := rw.db.QueryContext(ctx, selectProductsWithPagination, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -48,16 +197,8 @@ func (rw *dbReadWriter) ReadProductsWithPagination(ctx context.Context, limit in
 	products := []model.Product{}
 
 	for rows.Next() {
-		var product model.Product
-		if err := rows.Scan(
-			&product.ProductID,
-			&product.ProductName,
-			&product.Description,
-			&product.Price,
-			&product.SKU,
-			&product.CreatedAt,
-			&product.UpdatedAt,
-		); err != nil {
+		product, err := scanProduct(rows)
+		if err != nil {
 			return nil, err
 		}
 		products = append(products, product)
@@ -70,18 +211,163 @@ func (rw *dbReadWriter) ReadProductsWithPagination(ctx context.Context, limit in
 	return products, nil
 }
 
+// productSortTypes are the SQL types of the sort fields other than product_id,
+// which cursor keys are cast to.
+var productSortTypes = map[string]string{
+	"product_name": "text",
+	"sku":          "text",
+	"price":        "numeric",
+	"created_at":   "timestamp",
+	"updated_at":   "timestamp",
+}
+
+// ReadProductsByCursor lists up to limit products matching filter that follow
+// cursor in the listing order. The cursor's Key holds its product's value of
+// the sort field.
+func (rw *dbReadWriter) ReadProductsByCursor(ctx context.Context, filter model.ProductFilter, cursor model.Cursor, limit int32) ([]model.Product, error) {
+	sortBy := filter.SortBy
+	if sortBy == "" {
+		sortBy = "product_id"
+	}
+
+	// Walking backward reads the listing in reverse
+	descending := filter.Descending != cursor.Backward
+	order, err := productOrder(model.ProductFilter{SortBy: sortBy, Descending: descending})
+	if err != nil {
+		return nil, err
+	}
+
+	op := ">"
+	if descending {
+		op = "<"
+	}
+
+	args := append(productFilterArgs(filter), cursor.ID)
+	id := fmt.Sprintf("$%d", len(args))
+	keyset := ` AND (` + id + `::bigint = 0 OR product_id ` + op + ` ` + id + `)`
+	if sortBy != "product_id" {
+		var key interface{}
+		if !cursor.IsZero() {
+			key = cursor.Key
+		}
+		args = append(args, key)
+		keyset = fmt.Sprintf(` AND (%s::bigint = 0 OR (%s, product_id) %s ($%d::%s, %s))`, id, sortBy, op, len(args), productSortTypes[sortBy], id)
+	}
+	args = append(args, limit)
+
+	selectProducts := fmt.Sprintf(`SELECT `+productColumns+` 
+		FROM mst_product`+productFilterClause+keyset+order+` 
+		LIMIT $%d`, len(args))
+
+	rows, err := rw.db.QueryContext(ctx, selectProducts, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := []model.Product{}
+	for rows.Next() {
+		product, err := scanProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, product)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if cursor.Backward {
+		reverseRows(products)
+	}
+	return products, nil
+}
+
+// CountProducts counts the products matching filter.
+func (rw *dbReadWriter) CountProducts(ctx context.Context, filter model.ProductFilter) (int64, error) {
+	countProducts := `SELECT COUNT(*) FROM mst_product` + productFilterClause
+
+	var total int64
+	err := rw.db.QueryRowContext(ctx, countProducts, productFilterArgs(filter)...).Scan(&total)
+	return total, err
+}
+
+// UpdateProductByID updates a product. The base unit cannot be changed once
+// stock has been posted in it, so it is left as it is. A new price is added to
+// the price history, effective at once. IsSerialized is only changed when it
+// is given, never while the product has stock on hand or in transit, and a
+// bundle or a bundle component cannot be serialized.
 func (rw *dbReadWriter) UpdateProductByID(ctx context.Context, product model.Product) error {
+	lockProduct := `SELECT price, is_serialized, 
+			is_bundle OR EXISTS (SELECT 1 FROM mst_bundle_component WHERE component_id = $1), 
+			EXISTS (SELECT 1 FROM mst_stock WHERE product_id = $1 AND stock_quantity <> 0) 
+				OR EXISTS (SELECT 1 FROM trx_stock WHERE product_id = $1 AND status = $2) 
+		FROM mst_product WHERE product_id = $1 FOR UPDATE`
+
 	updateProduct := `UPDATE mst_product 
-		SET product_name = $1, description = $2, price = $3, updated_at = CURRENT_TIMESTAMP 
-		WHERE product_id = $4`
+		SET product_name = $1, description = $2, price = $3, is_serialized = COALESCE($4, is_serialized), purchase_unit = NULLIF($5, ''), sales_unit = NULLIF($6, ''), category_id = NULLIF($7, 0), updated_at = CURRENT_TIMESTAMP 
+		WHERE product_id = $8`
+
+	insertPrice := `INSERT INTO trx_product_price (product_id, price, effective_from, applied_at, created_at) 
+		VALUES ($1, $2, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)`
+
+	tx, err := rw.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var price float64
+	var serialized, bundled, held bool
+	err = tx.QueryRowContext(ctx, lockProduct, product.ProductID, model.TransactionInTransit).Scan(&price, &serialized, &bundled, &held)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("%w: %d", model.ErrProductNotFound, product.ProductID)
+	}
+	if err != nil {
+		return err
+	}
+
+	if product.IsSerialized != nil && *product.IsSerialized != serialized {
+		if bundled && *product.IsSerialized {
+			return fmt.Errorf("%w: product %d is a bundle or a bundle component and cannot be serialized", model.ErrInvalidBundle, product.ProductID)
+		}
+		if held {
+			return fmt.Errorf("%w: product %d", model.ErrSerializationLocked, product.ProductID)
+		}
+	}
 
-	result, err := rw.db.ExecContext(ctx, updateProduct,
+	_, err = tx.ExecContext(ctx, updateProduct,
 		product.ProductName,
 		product.Description,
 		product.Price,
+		product.IsSerialized,
+		product.PurchaseUnit,
+		product.SalesUnit,
+		product.CategoryID,
 		product.ProductID,
 	)
+	if err != nil {
+		return err
+	}
 
+	if product.Price != price {
+		_, err = tx.ExecContext(ctx, insertPrice, product.ProductID, product.Price)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// SetProductArchived archives a product, or restores it when archived is
+// false. Archiving an archived product keeps the time it was first archived.
+func (rw *dbReadWriter) SetProductArchived(ctx context.Context, productID int64, archived bool) error {
+	updateProduct := `UPDATE mst_product 
+		SET archived_at = CASE WHEN $1 THEN COALESCE(archived_at, CURRENT_TIMESTAMP) END, updated_at = CURRENT_TIMESTAMP 
+		WHERE product_id = $2`
+
+	result, err := rw.db.ExecContext(ctx, updateProduct, archived, productID)
 	if err != nil {
 		return err
 	}
@@ -90,28 +376,131 @@ func (rw *dbReadWriter) UpdateProductByID(ctx context.Context, product model.Pro
 	if err != nil {
 		return err
 	}
-
 	if rowsAffected == 0 {
-		return fmt.Errorf("product with id %d not found", product.ProductID)
+		return fmt.Errorf("%w: %d", model.ErrProductNotFound, productID)
 	}
 
 	return nil
 }
 
+// WriteProduct creates a product with the units it is counted in and starts its
+// price history with its price.
 func (rw *dbReadWriter) WriteProduct(ctx context.Context, product model.Product) error {
-	insertProduct := `INSERT INTO mst_product (product_name, description, price, sku, created_at, updated_at) 
-		VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)`
+	insertProduct := `WITH product AS (
+			INSERT INTO mst_product (product_name, description, price, sku, is_serialized, base_unit, purchase_unit, sales_unit, category_id, parent_id, variant_attributes, attributes, created_at, updated_at) 
+			VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), NULLIF($8, ''), NULLIF($9, 0), NULLIF($10, 0), $11, $12::jsonb, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP) 
+			RETURNING product_id, price
+		), units AS (
+			INSERT INTO mst_product_unit (product_id, unit_code, factor) 
+			SELECT product_id, u.unit_code, u.factor FROM product, UNNEST($13::text[], $14::int[]) AS u(unit_code, factor)
+		)
+		INSERT INTO trx_product_price (product_id, price, effective_from, applied_at, created_at) 
+		SELECT product_id, price, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP FROM product`
+
+	variantAttributes, attributes, err := variantColumns(product)
+	if err != nil {
+		return err
+	}
 
-	_, err := rw.db.ExecContext(ctx, insertProduct,
+	unitCodes := make([]string, len(product.Units))
+	factors := make([]int64, len(product.Units))
+	for i, unit := range product.Units {
+		unitCodes[i] = unit.UnitCode
+		factors[i] = unit.Factor
+	}
+
+	_, err = rw.db.ExecContext(ctx, insertProduct,
 		product.ProductName,
 		product.Description,
 		product.Price,
 		product.SKU,
+		product.Serialized(),
+		product.BaseUnit,
+		product.PurchaseUnit,
+		product.SalesUnit,
+		product.CategoryID,
+		product.ParentID,
+		variantAttributes,
+		attributes,
+		pq.Array(unitCodes),
+		pq.Array(factors),
 	)
 
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Constraint == "mst_product_parent_attributes_idx" {
+		return fmt.Errorf("%w: another variant of product %d has the same attributes", model.ErrInvalidVariant, product.ParentID)
+	}
 	if err != nil {
 		return err
 	}
 
 	return nil
 }
+
+func (rw *dbReadWriter) ReadProductUnits(ctx context.Context, productID int64) ([]model.ProductUnit, error) {
+	selectUnits := `SELECT unit_code, factor FROM mst_product_unit WHERE product_id = $1 ORDER BY factor, unit_code`
+
+	rows, err := rw.db.QueryContext(ctx, selectUnits, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	units := []model.ProductUnit{}
+	for rows.Next() {
+		var unit model.ProductUnit
+		if err := rows.Scan(&unit.UnitCode, &unit.Factor); err != nil {
+			return nil, err
+		}
+		units = append(units, unit)
+	}
+
+	return units, rows.Err()
+}
+
+func (rw *dbReadWriter) UpsertProductUnit(ctx context.Context, productID int64, unit model.ProductUnit) error {
+	upsertUnit := `INSERT INTO mst_product_unit (product_id, unit_code, factor) VALUES ($1, $2, $3) 
+		ON CONFLICT (product_id, unit_code) DO UPDATE SET factor = EXCLUDED.factor`
+
+	_, err := rw.db.ExecContext(ctx, upsertUnit, productID, unit.UnitCode, unit.Factor)
+	return err
+}
+
+func (rw *dbReadWriter) DeleteProductUnit(ctx context.Context, productID int64, unitCode string) error {
+	deleteUnit := `DELETE FROM mst_product_unit WHERE product_id = $1 AND unit_code = $2`
+
+	result, err := rw.db.ExecContext(ctx, deleteUnit, productID, unitCode)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: %s for product %d", model.ErrUnknownUnit, unitCode, productID)
+	}
+
+	return nil
+}
+
+// ReadUnitFactor returns how many base units of the product one unitCode is,
+// 1 for the base unit itself.
+func (rw *dbReadWriter) ReadUnitFactor(ctx context.Context, productID int64, unitCode string) (int64, error) {
+	selectFactor := `SELECT 1 FROM mst_product WHERE product_id = $1 AND base_unit = $2 
+		UNION ALL 
+		SELECT factor FROM mst_product_unit WHERE product_id = $1 AND unit_code = $2 
+		LIMIT 1`
+
+	var factor int64
+	err := rw.db.QueryRowContext(ctx, selectFactor, productID, unitCode).Scan(&factor)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("%w: %s for product %d", model.ErrUnknownUnit, unitCode, productID)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return factor, nil
+}
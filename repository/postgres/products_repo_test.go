@@ -10,6 +10,7 @@ import (
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/budsx/retail-management/model"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -21,6 +22,7 @@ func Test_ReadProductByID(t *testing.T) {
 	defer db.Close()
 
 	fixedTime := time.Now()
+	notSerialized := false
 
 	tests := []struct {
 		name    string
@@ -35,21 +37,56 @@ func Test_ReadProductByID(t *testing.T) {
 			id:   1,
 			mock: func(mock sqlmock.Sqlmock) {
 				rows := sqlmock.NewRows([]string{
-					"product_id", "product_name", "description", "price", "sku", "created_at", "updated_at",
-				}).AddRow(1, "Test Product", "Description", 100.0, "SKU123", fixedTime, fixedTime)
+					"product_id", "product_name", "description", "price", "sku", "is_serialized", "is_bundle", "base_unit", "purchase_unit", "sales_unit", "category_id", "parent_id", "variant_attributes", "attributes", "archived_at", "created_at", "updated_at",
+				}).AddRow(1, "Test Product", "Description", 100.0, "SKU123", false, false, "PCS", "", "", 0, 0, "{}", "{}", nil, fixedTime, fixedTime)
 
-				mock.ExpectQuery(regexp.QuoteMeta(`SELECT product_id, product_name, description, price, sku, created_at, updated_at FROM mst_product WHERE product_id = $1`)).
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT product_id, product_name, description, price, sku, is_serialized, is_bundle, base_unit, COALESCE(purchase_unit, ''), COALESCE(sales_unit, ''), COALESCE(category_id, 0), COALESCE(parent_id, 0), variant_attributes, COALESCE(attributes, '{}'), archived_at, created_at, updated_at FROM mst_product WHERE product_id = $1`)).
 					WithArgs(1).
 					WillReturnRows(rows)
 			},
 			want: model.Product{
-				ProductID:   1,
-				ProductName: "Test Product",
-				Description: "Description",
-				Price:       100.0,
-				SKU:         "SKU123",
-				CreatedAt:   fixedTime,
-				UpdatedAt:   fixedTime,
+				ProductID:    1,
+				ProductName:  "Test Product",
+				Description:  "Description",
+				Price:        100.0,
+				SKU:          "SKU123",
+				IsSerialized: &notSerialized,
+				BaseUnit:     "PCS",
+				CreatedAt:    fixedTime,
+				UpdatedAt:    fixedTime,
+			},
+			wantErr: false,
+		},
+		{
+			name: "Parent product with its variants",
+			id:   10,
+			mock: func(mock sqlmock.Sqlmock) {
+				columns := []string{
+					"product_id", "product_name", "description", "price", "sku", "is_serialized", "is_bundle", "base_unit", "purchase_unit", "sales_unit", "category_id", "parent_id", "variant_attributes", "attributes", "archived_at", "created_at", "updated_at",
+				}
+				mock.ExpectQuery(regexp.QuoteMeta(`FROM mst_product WHERE product_id = $1`)).
+					WithArgs(10).
+					WillReturnRows(sqlmock.NewRows(columns).
+						AddRow(10, "Teh Melati", "", 0.0, "TEH", false, false, "PCS", "", "", 0, 0, "{size}", "{}", nil, fixedTime, fixedTime))
+				mock.ExpectQuery(regexp.QuoteMeta(`FROM mst_product WHERE parent_id = $1 ORDER BY product_id`)).
+					WithArgs(10).
+					WillReturnRows(sqlmock.NewRows(columns).
+						AddRow(11, "Teh Melati 25g", "", 5000.0, "TEH-25", false, false, "PCS", "", "", 0, 10, "{}", `{"size": "25g"}`, nil, fixedTime, fixedTime).
+						AddRow(12, "Teh Melati 100g", "", 18000.0, "TEH-100", false, false, "PCS", "", "", 0, 10, "{}", `{"size": "100g"}`, nil, fixedTime, fixedTime))
+			},
+			want: model.Product{
+				ProductID:         10,
+				ProductName:       "Teh Melati",
+				SKU:               "TEH",
+				IsSerialized:      &notSerialized,
+				BaseUnit:          "PCS",
+				VariantAttributes: []string{"size"},
+				Variants: []model.Product{
+					{ProductID: 11, ProductName: "Teh Melati 25g", Price: 5000, SKU: "TEH-25", IsSerialized: &notSerialized, BaseUnit: "PCS", ParentID: 10, Attributes: map[string]string{"size": "25g"}, CreatedAt: fixedTime, UpdatedAt: fixedTime},
+					{ProductID: 12, ProductName: "Teh Melati 100g", Price: 18000, SKU: "TEH-100", IsSerialized: &notSerialized, BaseUnit: "PCS", ParentID: 10, Attributes: map[string]string{"size": "100g"}, CreatedAt: fixedTime, UpdatedAt: fixedTime},
+				},
+				CreatedAt: fixedTime,
+				UpdatedAt: fixedTime,
 			},
 			wantErr: false,
 		},
@@ -57,13 +94,13 @@ func Test_ReadProductByID(t *testing.T) {
 			name: "Product not found",
 			id:   999,
 			mock: func(mock sqlmock.Sqlmock) {
-				mock.ExpectQuery(regexp.QuoteMeta(`SELECT product_id, product_name, description, price, sku, created_at, updated_at FROM mst_product WHERE product_id = $1`)).
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT product_id, product_name, description, price, sku, is_serialized, is_bundle, base_unit, COALESCE(purchase_unit, ''), COALESCE(sales_unit, ''), COALESCE(category_id, 0), COALESCE(parent_id, 0), variant_attributes, COALESCE(attributes, '{}'), archived_at, created_at, updated_at FROM mst_product WHERE product_id = $1`)).
 					WithArgs(999).
 					WillReturnError(sql.ErrNoRows)
 			},
 			want:    model.Product{},
 			wantErr: true,
-			errMsg:  "product with id 999 not found",
+			errMsg:  "product not found: 999",
 		},
 	}
 
@@ -94,9 +131,14 @@ func Test_ReadProductsWithPagination(t *testing.T) {
 	defer db.Close()
 
 	fixedTime := time.Now()
+	notSerialized := false
+	selectProducts := regexp.QuoteMeta(`SELECT product_id, product_name, description, price, sku, is_serialized, is_bundle, base_unit, COALESCE(purchase_unit, ''), COALESCE(sales_unit, ''), COALESCE(category_id, 0), COALESCE(parent_id, 0), variant_attributes, COALESCE(attributes, '{}'), archived_at, created_at, updated_at FROM mst_product WHERE ($1::text = '' OR product_name ILIKE $1 OR description ILIKE $1 OR sku ILIKE $1)`)
+	minPrice := 150.0
+	createdTo := time.Date(2024, 1, 31, 23, 59, 59, 0, time.UTC)
 
 	tests := []struct {
 		name    string
+		filter  model.ProductFilter
 		limit   int32
 		offset  int32
 		mock    func(sqlmock.Sqlmock)
@@ -109,33 +151,37 @@ func Test_ReadProductsWithPagination(t *testing.T) {
 			offset: 0,
 			mock: func(mock sqlmock.Sqlmock) {
 				rows := sqlmock.NewRows([]string{
-					"product_id", "product_name", "description", "price", "sku", "created_at", "updated_at",
+					"product_id", "product_name", "description", "price", "sku", "is_serialized", "is_bundle", "base_unit", "purchase_unit", "sales_unit", "category_id", "parent_id", "variant_attributes", "attributes", "archived_at", "created_at", "updated_at",
 				}).
-					AddRow(1, "Product 1", "Desc 1", 100.0, "SKU1", fixedTime, fixedTime).
-					AddRow(2, "Product 2", "Desc 2", 200.0, "SKU2", fixedTime, fixedTime)
+					AddRow(1, "Product 1", "Desc 1", 100.0, "SKU1", false, false, "PCS", "", "", 0, 0, "{}", "{}", nil, fixedTime, fixedTime).
+					AddRow(2, "Product 2", "Desc 2", 200.0, "SKU2", false, false, "PCS", "", "", 0, 0, "{}", "{}", nil, fixedTime, fixedTime)
 
-				mock.ExpectQuery(regexp.QuoteMeta(`SELECT product_id, product_name, description, price, sku, created_at, updated_at FROM mst_product ORDER BY product_id LIMIT $1 OFFSET $2`)).
-					WithArgs(int32(10), int32(0)).
+				mock.ExpectQuery(selectProducts + `.* ORDER BY product_id ASC LIMIT \$10 OFFSET \$11`).
+					WithArgs("", nil, nil, nil, nil, nil, nil, int64(0), false, int32(10), int32(0)).
 					WillReturnRows(rows)
 			},
 			want: []model.Product{
 				{
-					ProductID:   1,
-					ProductName: "Product 1",
-					Description: "Desc 1",
-					Price:       100.0,
-					SKU:         "SKU1",
-					CreatedAt:   fixedTime,
-					UpdatedAt:   fixedTime,
+					ProductID:    1,
+					ProductName:  "Product 1",
+					Description:  "Desc 1",
+					Price:        100.0,
+					SKU:          "SKU1",
+					IsSerialized: &notSerialized,
+					BaseUnit:     "PCS",
+					CreatedAt:    fixedTime,
+					UpdatedAt:    fixedTime,
 				},
 				{
-					ProductID:   2,
-					ProductName: "Product 2",
-					Description: "Desc 2",
-					Price:       200.0,
-					SKU:         "SKU2",
-					CreatedAt:   fixedTime,
-					UpdatedAt:   fixedTime,
+					ProductID:    2,
+					ProductName:  "Product 2",
+					Description:  "Desc 2",
+					Price:        200.0,
+					SKU:          "SKU2",
+					IsSerialized: &notSerialized,
+					BaseUnit:     "PCS",
+					CreatedAt:    fixedTime,
+					UpdatedAt:    fixedTime,
 				},
 			},
 			wantErr: false,
@@ -146,15 +192,58 @@ func Test_ReadProductsWithPagination(t *testing.T) {
 			offset: 100,
 			mock: func(mock sqlmock.Sqlmock) {
 				rows := sqlmock.NewRows([]string{
-					"product_id", "product_name", "description", "price", "sku", "created_at", "updated_at",
+					"product_id", "product_name", "description", "price", "sku", "is_serialized", "is_bundle", "base_unit", "purchase_unit", "sales_unit", "category_id", "parent_id", "variant_attributes", "attributes", "archived_at", "created_at", "updated_at",
 				})
-				mock.ExpectQuery(regexp.QuoteMeta(`SELECT product_id, product_name, description, price, sku, created_at, updated_at FROM mst_product ORDER BY product_id LIMIT $1 OFFSET $2`)).
-					WithArgs(int32(10), int32(100)).
+				mock.ExpectQuery(selectProducts + `.* ORDER BY product_id ASC LIMIT \$10 OFFSET \$11`).
+					WithArgs("", nil, nil, nil, nil, nil, nil, int64(0), false, int32(10), int32(100)).
 					WillReturnRows(rows)
 			},
 			want:    []model.Product{},
 			wantErr: false,
 		},
+		{
+			name: "Search and sort",
+			filter: model.ProductFilter{
+				Search:     "50%_off",
+				MinPrice:   &minPrice,
+				CreatedTo:  &createdTo,
+				SortBy:     "price",
+				Descending: true,
+			},
+			limit:  10,
+			offset: 0,
+			mock: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{
+					"product_id", "product_name", "description", "price", "sku", "is_serialized", "is_bundle", "base_unit", "purchase_unit", "sales_unit", "category_id", "parent_id", "variant_attributes", "attributes", "archived_at", "created_at", "updated_at",
+				}).
+					AddRow(2, "Product 2", "Desc 2", 200.0, "SKU2", false, false, "PCS", "", "", 0, 0, "{}", "{}", nil, fixedTime, fixedTime)
+				mock.ExpectQuery(selectProducts + `.* ORDER BY price DESC, product_id DESC LIMIT \$10 OFFSET \$11`).
+					WithArgs(`%50\%\_off%`, 150.0, nil, nil, createdTo, nil, nil, int64(0), false, int32(10), int32(0)).
+					WillReturnRows(rows)
+			},
+			want: []model.Product{
+				{
+					ProductID:    2,
+					ProductName:  "Product 2",
+					Description:  "Desc 2",
+					Price:        200.0,
+					SKU:          "SKU2",
+					IsSerialized: &notSerialized,
+					BaseUnit:     "PCS",
+					CreatedAt:    fixedTime,
+					UpdatedAt:    fixedTime,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name:    "Unknown sort field",
+			filter:  model.ProductFilter{SortBy: "password"},
+			limit:   10,
+			offset:  0,
+			mock:    func(mock sqlmock.Sqlmock) {},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -162,7 +251,7 @@ func Test_ReadProductsWithPagination(t *testing.T) {
 			rw := &dbReadWriter{db: db}
 			tt.mock(mock)
 
-			got, err := rw.ReadProductsWithPagination(context.Background(), tt.limit, tt.offset)
+			got, err := rw.ReadProductsWithPagination(context.Background(), tt.filter, tt.limit, tt.offset)
 			if tt.wantErr {
 				assert.Error(t, err)
 				return
@@ -173,6 +262,119 @@ func Test_ReadProductsWithPagination(t *testing.T) {
 	}
 }
 
+func Test_ReadProductsByCursor(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	fixedTime := time.Now()
+	columns := []string{
+		"product_id", "product_name", "description", "price", "sku", "is_serialized", "is_bundle", "base_unit", "purchase_unit", "sales_unit", "category_id", "parent_id", "variant_attributes", "attributes", "archived_at", "created_at", "updated_at",
+	}
+
+	tests := []struct {
+		name    string
+		filter  model.ProductFilter
+		cursor  model.Cursor
+		mock    func(sqlmock.Sqlmock)
+		wantIDs []int64
+		wantErr bool
+	}{
+		{
+			name: "first page by id",
+			mock: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows(columns).
+					AddRow(1, "Product 1", "", 100.0, "SKU1", false, false, "PCS", "", "", 0, 0, "{}", "{}", nil, fixedTime, fixedTime).
+					AddRow(2, "Product 2", "", 200.0, "SKU2", false, false, "PCS", "", "", 0, 0, "{}", "{}", nil, fixedTime, fixedTime)
+				mock.ExpectQuery(regexp.QuoteMeta(`AND ($10::bigint = 0 OR product_id > $10) ORDER BY product_id ASC LIMIT $11`)).
+					WithArgs("", nil, nil, nil, nil, nil, nil, 0, false, 0, 3).
+					WillReturnRows(rows)
+			},
+			wantIDs: []int64{1, 2},
+		},
+		{
+			name:   "by price after a cursor",
+			filter: model.ProductFilter{SortBy: "price", Descending: true},
+			cursor: model.Cursor{ID: 7, Key: "150"},
+			mock: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows(columns).
+					AddRow(3, "Product 3", "", 120.0, "SKU3", false, false, "PCS", "", "", 0, 0, "{}", "{}", nil, fixedTime, fixedTime)
+				mock.ExpectQuery(regexp.QuoteMeta(`AND ($10::bigint = 0 OR (price, product_id) < ($11::numeric, $10)) ORDER BY price DESC, product_id DESC LIMIT $12`)).
+					WithArgs("", nil, nil, nil, nil, nil, nil, 0, false, 7, "150", 3).
+					WillReturnRows(rows)
+			},
+			wantIDs: []int64{3},
+		},
+		{
+			name:   "backward by price",
+			filter: model.ProductFilter{SortBy: "price"},
+			cursor: model.Cursor{ID: 7, Key: "150", Backward: true},
+			mock: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows(columns).
+					AddRow(5, "Product 5", "", 140.0, "SKU5", false, false, "PCS", "", "", 0, 0, "{}", "{}", nil, fixedTime, fixedTime).
+					AddRow(4, "Product 4", "", 130.0, "SKU4", false, false, "PCS", "", "", 0, 0, "{}", "{}", nil, fixedTime, fixedTime)
+				mock.ExpectQuery(regexp.QuoteMeta(`AND ($10::bigint = 0 OR (price, product_id) < ($11::numeric, $10)) ORDER BY price DESC, product_id DESC LIMIT $12`)).
+					WithArgs("", nil, nil, nil, nil, nil, nil, 0, false, 7, "150", 3).
+					WillReturnRows(rows)
+			},
+			wantIDs: []int64{4, 5},
+		},
+		{
+			name:    "unknown sort field",
+			filter:  model.ProductFilter{SortBy: "stock"},
+			mock:    func(mock sqlmock.Sqlmock) {},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rw := &dbReadWriter{db: db}
+			tt.mock(mock)
+
+			got, err := rw.ReadProductsByCursor(context.Background(), tt.filter, tt.cursor, 3)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			var ids []int64
+			for _, product := range got {
+				ids = append(ids, product.ProductID)
+			}
+			assert.Equal(t, tt.wantIDs, ids)
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func Test_CountProducts(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	rw := &dbReadWriter{db: db}
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM mst_product WHERE ($1::text = '' OR product_name ILIKE $1`)).
+		WithArgs("%teh%", nil, nil, nil, nil, nil, nil, 3, false).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(42))
+
+	got, err := rw.CountProducts(context.Background(), model.ProductFilter{Search: "teh", CategoryID: 3})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), got)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
 func Test_UpdateProductByID(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -180,6 +382,12 @@ func Test_UpdateProductByID(t *testing.T) {
 	}
 	defer db.Close()
 
+	lockProduct := regexp.QuoteMeta(`SELECT price, is_serialized, is_bundle OR EXISTS (SELECT 1 FROM mst_bundle_component WHERE component_id = $1), EXISTS (SELECT 1 FROM mst_stock WHERE product_id = $1 AND stock_quantity <> 0) OR EXISTS (SELECT 1 FROM trx_stock WHERE product_id = $1 AND status = $2) FROM mst_product WHERE product_id = $1 FOR UPDATE`)
+	updateProduct := regexp.QuoteMeta(`UPDATE mst_product SET product_name = $1, description = $2, price = $3, is_serialized = COALESCE($4, is_serialized), purchase_unit = NULLIF($5, ''), sales_unit = NULLIF($6, ''), category_id = NULLIF($7, 0), updated_at = CURRENT_TIMESTAMP WHERE product_id = $8`)
+	insertPrice := regexp.QuoteMeta(`INSERT INTO trx_product_price (product_id, price, effective_from, applied_at, created_at) VALUES ($1, $2, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)`)
+	lockColumns := []string{"price", "is_serialized", "bundled", "held"}
+	serialized := true
+
 	tests := []struct {
 		name    string
 		product model.Product
@@ -196,9 +404,83 @@ func Test_UpdateProductByID(t *testing.T) {
 				Price:       150.0,
 			},
 			mock: func(mock sqlmock.Sqlmock) {
-				mock.ExpectExec(regexp.QuoteMeta(`UPDATE mst_product SET product_name = $1, description = $2, price = $3, updated_at = CURRENT_TIMESTAMP WHERE product_id = $4`)).
-					WithArgs("Updated Product", "Updated Description", 150.0, 1).
+				mock.ExpectBegin()
+				mock.ExpectQuery(lockProduct).WithArgs(1, model.TransactionInTransit).WillReturnRows(sqlmock.NewRows(lockColumns).AddRow(100.0, false, false, false))
+				mock.ExpectExec(updateProduct).
+					WithArgs("Updated Product", "Updated Description", 150.0, nil, "", "", 0, 1).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(insertPrice).WithArgs(1, 150.0).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+			wantErr: false,
+		},
+		{
+			name: "price unchanged",
+			product: model.Product{
+				ProductID:   1,
+				ProductName: "Updated Product",
+				Description: "Updated Description",
+				Price:       150.0,
+			},
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(lockProduct).WithArgs(1, model.TransactionInTransit).WillReturnRows(sqlmock.NewRows(lockColumns).AddRow(150.0, false, false, true))
+				mock.ExpectExec(updateProduct).
+					WithArgs("Updated Product", "Updated Description", 150.0, nil, "", "", 0, 1).
 					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+			wantErr: false,
+		},
+		{
+			name: "bundle component cannot be serialized",
+			product: model.Product{
+				ProductID:    1,
+				ProductName:  "Updated Product",
+				Description:  "Updated Description",
+				Price:        150.0,
+				IsSerialized: &serialized,
+			},
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(lockProduct).WithArgs(1, model.TransactionInTransit).WillReturnRows(sqlmock.NewRows(lockColumns).AddRow(150.0, false, true, false))
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+		{
+			name: "serial tracking cannot change while stock is held",
+			product: model.Product{
+				ProductID:    1,
+				ProductName:  "Updated Product",
+				Description:  "Updated Description",
+				Price:        150.0,
+				IsSerialized: &serialized,
+			},
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(lockProduct).WithArgs(1, model.TransactionInTransit).WillReturnRows(sqlmock.NewRows(lockColumns).AddRow(150.0, false, false, true))
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+			errMsg:  "serial tracking cannot change while the product holds stock: product 1",
+		},
+		{
+			name: "unchanged serial tracking while stock is held",
+			product: model.Product{
+				ProductID:    1,
+				ProductName:  "Updated Product",
+				Description:  "Updated Description",
+				Price:        150.0,
+				IsSerialized: &serialized,
+			},
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(lockProduct).WithArgs(1, model.TransactionInTransit).WillReturnRows(sqlmock.NewRows(lockColumns).AddRow(150.0, true, false, true))
+				mock.ExpectExec(updateProduct).
+					WithArgs("Updated Product", "Updated Description", 150.0, true, "", "", 0, 1).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
 			},
 			wantErr: false,
 		},
@@ -211,12 +493,12 @@ func Test_UpdateProductByID(t *testing.T) {
 				Price:       150.0,
 			},
 			mock: func(mock sqlmock.Sqlmock) {
-				mock.ExpectExec(regexp.QuoteMeta(`UPDATE mst_product SET product_name = $1, description = $2, price = $3, updated_at = CURRENT_TIMESTAMP WHERE product_id = $4`)).
-					WithArgs("Updated Product", "Updated Description", 150.0, 999).
-					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectBegin()
+				mock.ExpectQuery(lockProduct).WithArgs(999, model.TransactionInTransit).WillReturnRows(sqlmock.NewRows(lockColumns))
+				mock.ExpectRollback()
 			},
 			wantErr: true,
-			errMsg:  "product with id 999 not found",
+			errMsg:  "product not found: 999",
 		},
 	}
 
@@ -258,14 +540,67 @@ func Test_WriteProduct(t *testing.T) {
 				Description: "New Description",
 				Price:       100.0,
 				SKU:         "SKU123",
+				BaseUnit:    "PCS",
 			},
 			mock: func(mock sqlmock.Sqlmock) {
-				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO mst_product (product_name, description, price, sku, created_at, updated_at) VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)`)).
-					WithArgs("New Product", "New Description", 100.0, "SKU123").
+				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO mst_product (product_name, description, price, sku, is_serialized, base_unit, purchase_unit, sales_unit, category_id, parent_id, variant_attributes, attributes, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), NULLIF($8, ''), NULLIF($9, 0), NULLIF($10, 0), $11, $12::jsonb, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)`)).
+					WithArgs("New Product", "New Description", 100.0, "SKU123", false, "PCS", "", "", 0, 0, pq.Array([]string{}), nil, pq.Array([]string{}), pq.Array([]int64{})).
 					WillReturnResult(sqlmock.NewResult(1, 1))
 			},
 			wantErr: false,
 		},
+		{
+			name: "bought and sold in other units",
+			product: model.Product{
+				ProductName:  "Beras 1kg",
+				Price:        15000,
+				SKU:          "BRS-1",
+				BaseUnit:     "PCS",
+				PurchaseUnit: "SACK",
+				SalesUnit:    "PCS",
+				Units:        []model.ProductUnit{{UnitCode: "SACK", Factor: 25}},
+			},
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO mst_product_unit (product_id, unit_code, factor) SELECT product_id, u.unit_code, u.factor FROM product, UNNEST($13::text[], $14::int[]) AS u(unit_code, factor)`)).
+					WithArgs("Beras 1kg", "", 15000.0, "BRS-1", false, "PCS", "SACK", "PCS", 0, 0, pq.Array([]string{}), nil, pq.Array([]string{"SACK"}), pq.Array([]int64{25})).
+					WillReturnResult(sqlmock.NewResult(12, 1))
+			},
+			wantErr: false,
+		},
+		{
+			name: "variant",
+			product: model.Product{
+				ProductName: "Teh Melati 25g",
+				Price:       5000,
+				SKU:         "TEH-25",
+				BaseUnit:    "PCS",
+				ParentID:    10,
+				Attributes:  map[string]string{"size": "25g"},
+			},
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO mst_product`)).
+					WithArgs("Teh Melati 25g", "", 5000.0, "TEH-25", false, "PCS", "", "", 0, 10, pq.Array([]string{}), `{"size":"25g"}`, pq.Array([]string{}), pq.Array([]int64{})).
+					WillReturnResult(sqlmock.NewResult(11, 1))
+			},
+			wantErr: false,
+		},
+		{
+			name: "variant with the attributes of another",
+			product: model.Product{
+				ProductName: "Teh Melati 25g",
+				Price:       5000,
+				SKU:         "TEH-25B",
+				BaseUnit:    "PCS",
+				ParentID:    10,
+				Attributes:  map[string]string{"size": "25g"},
+			},
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO mst_product`)).
+					WithArgs("Teh Melati 25g", "", 5000.0, "TEH-25B", false, "PCS", "", "", 0, 10, pq.Array([]string{}), `{"size":"25g"}`, pq.Array([]string{}), pq.Array([]int64{})).
+					WillReturnError(&pq.Error{Code: "23505", Constraint: "mst_product_parent_attributes_idx"})
+			},
+			wantErr: true,
+		},
 		{
 			name: "duplicate SKU error",
 			product: model.Product{
@@ -273,10 +608,11 @@ func Test_WriteProduct(t *testing.T) {
 				Description: "New Description",
 				Price:       100.0,
 				SKU:         "SKU123",
+				BaseUnit:    "PCS",
 			},
 			mock: func(mock sqlmock.Sqlmock) {
-				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO mst_product (product_name, description, price, sku, created_at, updated_at) VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)`)).
-					WithArgs("New Product", "New Description", 100.0, "SKU123").
+				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO mst_product (product_name, description, price, sku, is_serialized, base_unit, purchase_unit, sales_unit, category_id, parent_id, variant_attributes, attributes, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), NULLIF($8, ''), NULLIF($9, 0), NULLIF($10, 0), $11, $12::jsonb, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)`)).
+					WithArgs("New Product", "New Description", 100.0, "SKU123", false, "PCS", "", "", 0, 0, pq.Array([]string{}), nil, pq.Array([]string{}), pq.Array([]int64{})).
 					WillReturnError(fmt.Errorf("duplicate key value violates unique constraint"))
 			},
 			wantErr: true,
@@ -297,3 +633,97 @@ func Test_WriteProduct(t *testing.T) {
 		})
 	}
 }
+
+func Test_ReadUnitFactor(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	selectFactor := regexp.QuoteMeta(`SELECT 1 FROM mst_product WHERE product_id = $1 AND base_unit = $2 UNION ALL SELECT factor FROM mst_product_unit WHERE product_id = $1 AND unit_code = $2 LIMIT 1`)
+
+	tests := []struct {
+		name     string
+		unitCode string
+		mock     func(sqlmock.Sqlmock)
+		want     int64
+		wantErr  error
+	}{
+		{
+			name:     "configured unit",
+			unitCode: "SACK",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(selectFactor).
+					WithArgs(4, "SACK").
+					WillReturnRows(sqlmock.NewRows([]string{"factor"}).AddRow(50))
+			},
+			want: 50,
+		},
+		{
+			name:     "unknown unit",
+			unitCode: "BOX",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(selectFactor).
+					WithArgs(4, "BOX").
+					WillReturnRows(sqlmock.NewRows([]string{"factor"}))
+			},
+			wantErr: model.ErrUnknownUnit,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rw := &dbReadWriter{db: db}
+			tt.mock(mock)
+
+			got, err := rw.ReadUnitFactor(context.Background(), 4, tt.unitCode)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func Test_SetProductArchived(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	updateProduct := regexp.QuoteMeta(`UPDATE mst_product SET archived_at = CASE WHEN $1 THEN COALESCE(archived_at, CURRENT_TIMESTAMP) END, updated_at = CURRENT_TIMESTAMP WHERE product_id = $2`)
+	rw := &dbReadWriter{db: db}
+
+	t.Run("archive", func(t *testing.T) {
+		mock.ExpectExec(updateProduct).WithArgs(true, 4).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := rw.SetProductArchived(context.Background(), 4, true)
+		assert.NoError(t, err)
+	})
+
+	t.Run("restore", func(t *testing.T) {
+		mock.ExpectExec(updateProduct).WithArgs(false, 4).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := rw.SetProductArchived(context.Background(), 4, false)
+		assert.NoError(t, err)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mock.ExpectExec(updateProduct).WithArgs(true, 999).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := rw.SetProductArchived(context.Background(), 999, true)
+		assert.ErrorIs(t, err, model.ErrProductNotFound)
+	})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,185 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/budsx/retail-management/model"
+)
+
+// reorderAvailability is the stock available, on hand less reserved, for each
+// reorder point matching product $1 and warehouse $2 (0 for any).
+const reorderAvailability = `SELECT r.product_id, r.warehouse_id, m.product_name, m.sku, 
+		COALESCE(SUM(s.stock_quantity - s.reserved_quantity), 0) as available 
+		FROM mst_reorder_point as r 
+		INNER JOIN mst_product as m ON r.product_id = m.product_id 
+		LEFT JOIN mst_stock as s ON r.product_id = s.product_id AND r.warehouse_id = s.warehouse_id 
+		WHERE ($1 = 0 OR r.product_id = $1) AND ($2 = 0 OR r.warehouse_id = $2) 
+		GROUP BY r.product_id, r.warehouse_id, m.product_name, m.sku`
+
+func (rw *dbReadWriter) UpsertReorderPoint(ctx context.Context, reorderPoint model.ReorderPoint) error {
+	upsertReorderPoint := `INSERT INTO mst_reorder_point (product_id, warehouse_id, min_quantity, max_quantity, reorder_quantity) 
+		VALUES ($1, $2, $3, $4, $5) 
+		ON CONFLICT (product_id, warehouse_id) DO UPDATE 
+		SET min_quantity = EXCLUDED.min_quantity, max_quantity = EXCLUDED.max_quantity, reorder_quantity = EXCLUDED.reorder_quantity`
+
+	_, err := rw.db.ExecContext(ctx, upsertReorderPoint,
+		reorderPoint.ProductID,
+		reorderPoint.WarehouseID,
+		reorderPoint.MinQuantity,
+		reorderPoint.MaxQuantity,
+		reorderPoint.ReorderQuantity,
+	)
+	return err
+}
+
+func (rw *dbReadWriter) ReadReorderPointsByWarehouse(ctx context.Context, warehouseID int64) ([]model.ReorderPoint, error) {
+	query := `SELECT product_id, warehouse_id, min_quantity, max_quantity, reorder_quantity, updated_at 
+		FROM mst_reorder_point WHERE warehouse_id = $1 ORDER BY product_id`
+
+	rows, err := rw.db.QueryContext(ctx, query, warehouseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reorderPoints := []model.ReorderPoint{}
+	for rows.Next() {
+		var reorderPoint model.ReorderPoint
+		err := rows.Scan(
+			&reorderPoint.ProductID,
+			&reorderPoint.WarehouseID,
+			&reorderPoint.MinQuantity,
+			&reorderPoint.MaxQuantity,
+			&reorderPoint.ReorderQuantity,
+			&reorderPoint.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		reorderPoints = append(reorderPoints, reorderPoint)
+	}
+
+	return reorderPoints, rows.Err()
+}
+
+func (rw *dbReadWriter) DeleteReorderPoint(ctx context.Context, productID, warehouseID int64) error {
+	deleteReorderPoint := `DELETE FROM mst_reorder_point WHERE product_id = $1 AND warehouse_id = $2`
+
+	result, err := rw.db.ExecContext(ctx, deleteReorderPoint, productID, warehouseID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: product %d in warehouse %d", model.ErrReorderPointNotFound, productID, warehouseID)
+	}
+
+	return nil
+}
+
+// GetLowStockItems lists the products below their reorder point in the
+// user's warehouses, or in one of them when warehouseID is set.
+func (rw *dbReadWriter) GetLowStockItems(ctx context.Context, userID, warehouseID int64) ([]model.LowStockItem, error) {
+	query := `SELECT a.product_id, a.product_name, a.sku, a.warehouse_id, a.available, r.min_quantity, r.max_quantity, r.reorder_quantity 
+		FROM (` + reorderAvailability + `) as a 
+		INNER JOIN mst_reorder_point as r ON a.product_id = r.product_id AND a.warehouse_id = r.warehouse_id 
+		INNER JOIN mst_warehouse as w ON a.warehouse_id = w.warehouse_id 
+		WHERE w.user_id = $3 AND a.available < r.min_quantity 
+		ORDER BY a.warehouse_id, a.product_id`
+
+	rows, err := rw.db.QueryContext(ctx, query, 0, warehouseID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanLowStockItems(rows)
+}
+
+// ClaimLowStockAlerts returns the reorder points matching productID and
+// warehouseID (0 for any) that have fallen below their minimum since they were
+// last alerted, and marks them alerted. Points that have recovered are reset
+// so that the next drop alerts again.
+func (rw *dbReadWriter) ClaimLowStockAlerts(ctx context.Context, productID, warehouseID int64) ([]model.LowStockItem, error) {
+	resetRecovered := `UPDATE mst_reorder_point as r SET alerted_at = NULL 
+		FROM (` + reorderAvailability + `) as a 
+		WHERE r.product_id = a.product_id AND r.warehouse_id = a.warehouse_id 
+		AND r.alerted_at IS NOT NULL AND a.available >= r.min_quantity`
+
+	claimAlerts := `UPDATE mst_reorder_point as r SET alerted_at = CURRENT_TIMESTAMP 
+		FROM (` + reorderAvailability + `) as a 
+		WHERE r.product_id = a.product_id AND r.warehouse_id = a.warehouse_id 
+		AND r.alerted_at IS NULL AND a.available < r.min_quantity 
+		RETURNING a.product_id, a.product_name, a.sku, a.warehouse_id, a.available, r.min_quantity, r.max_quantity, r.reorder_quantity`
+
+	tx, err := rw.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, resetRecovered, productID, warehouseID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryContext(ctx, claimAlerts, productID, warehouseID)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := scanLowStockItems(rows)
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return items, tx.Commit()
+}
+
+// ReleaseLowStockAlert gives back a claimed alert that could not be delivered,
+// so that the next check claims it again.
+func (rw *dbReadWriter) ReleaseLowStockAlert(ctx context.Context, productID, warehouseID int64) error {
+	releaseAlert := `UPDATE mst_reorder_point SET alerted_at = NULL WHERE product_id = $1 AND warehouse_id = $2`
+
+	_, err := rw.db.ExecContext(ctx, releaseAlert, productID, warehouseID)
+	return err
+}
+
+func scanLowStockItems(rows *sql.Rows) ([]model.LowStockItem, error) {
+	items := []model.LowStockItem{}
+	for rows.Next() {
+		var item model.LowStockItem
+		err := rows.Scan(
+			&item.ProductID,
+			&item.ProductName,
+			&item.SKU,
+			&item.WarehouseID,
+			&item.AvailableStock,
+			&item.MinQuantity,
+			&item.MaxQuantity,
+			&item.ReorderQuantity,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		item.SuggestedQuantity = item.ReorderQuantity
+		if item.MaxQuantity > 0 {
+			item.SuggestedQuantity = item.MaxQuantity - item.AvailableStock
+		}
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
@@ -0,0 +1,112 @@
+package postgres
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/budsx/retail-management/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ClaimLowStockAlerts(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	columns := []string{"product_id", "product_name", "sku", "warehouse_id", "available", "min_quantity", "max_quantity", "reorder_quantity"}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE mst_reorder_point as r SET alerted_at = NULL`)).
+		WithArgs(1, 2).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta(`UPDATE mst_reorder_point as r SET alerted_at = CURRENT_TIMESTAMP`)).
+		WithArgs(1, 2).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "Kopi Arabika", "KOP-001", 2, 4, 10, 50, 40))
+	mock.ExpectCommit()
+
+	rw := &dbReadWriter{db: db}
+	got, err := rw.ClaimLowStockAlerts(context.Background(), 1, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []model.LowStockItem{{
+		ProductID:         1,
+		ProductName:       "Kopi Arabika",
+		SKU:               "KOP-001",
+		WarehouseID:       2,
+		AvailableStock:    4,
+		MinQuantity:       10,
+		MaxQuantity:       50,
+		ReorderQuantity:   40,
+		SuggestedQuantity: 46,
+	}}, got)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func Test_ReleaseLowStockAlert(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE mst_reorder_point SET alerted_at = NULL WHERE product_id = $1 AND warehouse_id = $2`)).
+		WithArgs(1, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	rw := &dbReadWriter{db: db}
+	assert.NoError(t, rw.ReleaseLowStockAlert(context.Background(), 1, 2))
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func Test_GetLowStockItems(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	columns := []string{"product_id", "product_name", "sku", "warehouse_id", "available", "min_quantity", "max_quantity", "reorder_quantity"}
+
+	mock.ExpectQuery(regexp.QuoteMeta(`WHERE w.user_id = $3 AND a.available < r.min_quantity ORDER BY a.warehouse_id, a.product_id`)).
+		WithArgs(0, 0, 3).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "Kopi Arabika", "KOP-001", 2, 4, 10, 0, 40))
+
+	rw := &dbReadWriter{db: db}
+	got, err := rw.GetLowStockItems(context.Background(), 3, 0)
+	assert.NoError(t, err)
+	assert.Len(t, got, 1)
+	assert.Equal(t, int64(40), got[0].SuggestedQuantity)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func Test_DeleteReorderPoint(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM mst_reorder_point WHERE product_id = $1 AND warehouse_id = $2`)).
+		WithArgs(1, 2).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	rw := &dbReadWriter{db: db}
+	err = rw.DeleteReorderPoint(context.Background(), 1, 2)
+	assert.ErrorIs(t, err, model.ErrReorderPointNotFound)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
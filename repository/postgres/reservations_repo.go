@@ -0,0 +1,257 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/budsx/retail-management/model"
+)
+
+// reservationColumns reports an active reservation past its expiry as expired
+// even before ExpireReservations has swept it.
+const reservationColumns = `reservation_id, product_id, warehouse_id, location_id, quantity, 
+	CASE WHEN status = 'ACTIVE' AND expires_at <= NOW() THEN 'EXPIRED' ELSE status END, COALESCE(reference, ''), expires_at, COALESCE(transaction_id, 0), COALESCE(created_by, 0), created_at, updated_at`
+
+func scanReservation(row rowScanner) (model.Reservation, error) {
+	var reservation model.Reservation
+	err := row.Scan(
+		&reservation.ReservationID,
+		&reservation.ProductID,
+		&reservation.WarehouseID,
+		&reservation.LocationID,
+		&reservation.Quantity,
+		&reservation.Status,
+		&reservation.Reference,
+		&reservation.ExpiresAt,
+		&reservation.TransactionID,
+		&reservation.CreatedBy,
+		&reservation.CreatedAt,
+		&reservation.UpdatedAt,
+	)
+	return reservation, err
+}
+
+// CreateReservation holds quantity out of the available stock at a location
+// until it is confirmed, released or ttl has passed.
+func (rw *dbReadWriter) CreateReservation(ctx context.Context, reservation model.Reservation, ttl time.Duration) (int64, error) {
+	insertReservation := `INSERT INTO trx_reservation (product_id, warehouse_id, location_id, quantity, status, reference, expires_at, created_by) 
+		VALUES ($1, $2, $3, $4, $5, $6, NOW() + $7 * INTERVAL '1 second', $8) RETURNING reservation_id`
+
+	tx, err := rw.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	stocks, err := lockLocationStock(ctx, tx, reservation.ProductID, reservation.LocationID)
+	if err != nil {
+		return 0, err
+	}
+
+	stock := stocks[reservation.LocationID]
+	if available := stock.onHand - stock.reserved; available < reservation.Quantity {
+		return 0, fmt.Errorf("%w: product %d at location %d has %d available, requested %d", model.ErrInsufficientStock, reservation.ProductID, reservation.LocationID, available, reservation.Quantity)
+	}
+
+	err = adjustReservedStock(ctx, tx, reservation.ProductID, reservation.LocationID, reservation.Quantity)
+	if err != nil {
+		return 0, err
+	}
+
+	var reservationID int64
+	err = tx.QueryRowContext(ctx, insertReservation,
+		reservation.ProductID,
+		reservation.WarehouseID,
+		reservation.LocationID,
+		reservation.Quantity,
+		model.ReservationActive,
+		reservation.Reference,
+		int64(ttl.Seconds()),
+		reservation.CreatedBy,
+	).Scan(&reservationID)
+	if err != nil {
+		return 0, err
+	}
+
+	return reservationID, tx.Commit()
+}
+
+func (rw *dbReadWriter) ReadReservationByID(ctx context.Context, reservationID int64) (model.Reservation, error) {
+	query := `SELECT ` + reservationColumns + ` FROM trx_reservation WHERE reservation_id = $1`
+
+	reservation, err := scanReservation(rw.db.QueryRowContext(ctx, query, reservationID))
+	if err == sql.ErrNoRows {
+		return reservation, fmt.Errorf("%w: %d", model.ErrReservationNotFound, reservationID)
+	}
+	return reservation, err
+}
+
+// ConfirmReservation turns an active reservation into an OUT movement, picked
+// FEFO across lots or by the serial numbers given, and returns the id of the
+// first posted transaction.
+func (rw *dbReadWriter) ConfirmReservation(ctx context.Context, reservationID, confirmedBy int64, serialNumbers []string) (int64, error) {
+	updateReservation := `UPDATE trx_reservation SET status = $1, transaction_id = $2 WHERE reservation_id = $3`
+
+	tx, err := rw.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	reservation, err := lockActiveReservation(ctx, tx, reservationID)
+	if err != nil {
+		return 0, err
+	}
+
+	stocks, err := lockLocationStock(ctx, tx, reservation.ProductID, reservation.LocationID)
+	if err != nil {
+		return 0, err
+	}
+
+	err = adjustReservedStock(ctx, tx, reservation.ProductID, reservation.LocationID, -reservation.Quantity)
+	if err != nil {
+		return 0, err
+	}
+
+	stock := stocks[reservation.LocationID]
+	stock.reserved -= reservation.Quantity
+
+	transactionID, err := postStockOut(ctx, tx, model.StockTransaction{
+		ProductID:       reservation.ProductID,
+		WarehouseID:     reservation.WarehouseID,
+		LocationID:      reservation.LocationID,
+		TransactionType: model.StockOut,
+		CreatedBy:       confirmedBy,
+		SerialNumbers:   serialNumbers,
+	}, &stock, reservation.Quantity)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.ExecContext(ctx, updateReservation, model.ReservationConfirmed, transactionID, reservationID)
+	if err != nil {
+		return 0, err
+	}
+
+	return transactionID, tx.Commit()
+}
+
+// ReleaseReservation gives the held quantity back to available stock.
+func (rw *dbReadWriter) ReleaseReservation(ctx context.Context, reservationID int64) error {
+	updateReservation := `UPDATE trx_reservation SET status = $1 WHERE reservation_id = $2`
+
+	tx, err := rw.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	reservation, err := lockActiveReservation(ctx, tx, reservationID)
+	if err != nil {
+		return err
+	}
+
+	_, err = lockLocationStock(ctx, tx, reservation.ProductID, reservation.LocationID)
+	if err != nil {
+		return err
+	}
+
+	err = adjustReservedStock(ctx, tx, reservation.ProductID, reservation.LocationID, -reservation.Quantity)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, updateReservation, model.ReservationReleased, reservationID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ExpireReservations marks every active reservation past its expiry as
+// expired, releases the held stock and returns how many were expired.
+func (rw *dbReadWriter) ExpireReservations(ctx context.Context) (int64, error) {
+	expireReservations := `UPDATE trx_reservation SET status = $1 
+		WHERE status = $2 AND expires_at <= NOW() 
+		RETURNING product_id, location_id, quantity`
+
+	tx, err := rw.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, expireReservations, model.ReservationExpired, model.ReservationActive)
+	if err != nil {
+		return 0, err
+	}
+
+	type stockKey struct{ productID, locationID int64 }
+	released := map[stockKey]int64{}
+	var expired int64
+	for rows.Next() {
+		var key stockKey
+		var quantity int64
+		if err := rows.Scan(&key.productID, &key.locationID, &quantity); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		released[key] += quantity
+		expired++
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	// Touch stock rows in the same order as lockLocationStock does
+	keys := make([]stockKey, 0, len(released))
+	for key := range released {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].productID != keys[j].productID {
+			return keys[i].productID < keys[j].productID
+		}
+		return keys[i].locationID < keys[j].locationID
+	})
+
+	for _, key := range keys {
+		err = adjustReservedStock(ctx, tx, key.productID, key.locationID, -released[key])
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return expired, tx.Commit()
+}
+
+func lockActiveReservation(ctx context.Context, tx *sql.Tx, reservationID int64) (model.Reservation, error) {
+	selectReservation := `SELECT ` + reservationColumns + ` FROM trx_reservation WHERE reservation_id = $1 FOR UPDATE`
+
+	reservation, err := scanReservation(tx.QueryRowContext(ctx, selectReservation, reservationID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return reservation, fmt.Errorf("%w: %d", model.ErrReservationNotFound, reservationID)
+		}
+		return reservation, err
+	}
+
+	if reservation.Status != model.ReservationActive {
+		return reservation, fmt.Errorf("%w: reservation %d is %s", model.ErrReservationNotActive, reservationID, reservation.Status)
+	}
+
+	return reservation, nil
+}
+
+func adjustReservedStock(ctx context.Context, tx *sql.Tx, productID, locationID, delta int64) error {
+	updateReserved := `UPDATE mst_stock SET reserved_quantity = reserved_quantity + $1 WHERE product_id = $2 AND location_id = $3`
+
+	_, err := tx.ExecContext(ctx, updateReserved, delta, productID, locationID)
+	return err
+}
@@ -0,0 +1,261 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/budsx/retail-management/model"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+var reservationRowColumns = []string{
+	"reservation_id", "product_id", "warehouse_id", "location_id", "quantity", "status",
+	"reference", "expires_at", "transaction_id", "created_by", "created_at", "updated_at",
+}
+
+func Test_CreateReservation(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	lockStock := regexp.QuoteMeta(`SELECT location_id, stock_quantity, reserved_quantity FROM mst_stock WHERE product_id = $1 AND location_id = ANY($2) ORDER BY location_id FOR UPDATE`)
+	reservation := model.Reservation{ProductID: 1, WarehouseID: 1, LocationID: 2, Quantity: 10, Reference: "SO-001", CreatedBy: 1}
+
+	tests := []struct {
+		name      string
+		mockSetup func(sqlmock.Sqlmock)
+		want      int64
+		wantErr   error
+	}{
+		{
+			name: "Reserve available stock",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO mst_stock`)).
+					WithArgs(1, pq.Array([]int64{2})).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(lockStock).
+					WithArgs(1, pq.Array([]int64{2})).
+					WillReturnRows(sqlmock.NewRows([]string{"location_id", "stock_quantity", "reserved_quantity"}).AddRow(2, 40, 30))
+				expectStockCostLock(mock, 1, 2)
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE mst_stock SET reserved_quantity = reserved_quantity + $1 WHERE product_id = $2 AND location_id = $3`)).
+					WithArgs(10, 1, 2).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO trx_reservation (product_id, warehouse_id, location_id, quantity, status, reference, expires_at, created_by) VALUES ($1, $2, $3, $4, $5, $6, NOW() + $7 * INTERVAL '1 second', $8) RETURNING reservation_id`)).
+					WithArgs(1, 1, 2, 10, model.ReservationActive, "SO-001", 1800, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"reservation_id"}).AddRow(7))
+				mock.ExpectCommit()
+			},
+			want: 7,
+		},
+		{
+			name: "Reserved stock is not available",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO mst_stock`)).
+					WithArgs(1, pq.Array([]int64{2})).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(lockStock).
+					WithArgs(1, pq.Array([]int64{2})).
+					WillReturnRows(sqlmock.NewRows([]string{"location_id", "stock_quantity", "reserved_quantity"}).AddRow(2, 40, 31))
+				expectStockCostLock(mock, 1, 2)
+				mock.ExpectRollback()
+			},
+			wantErr: model.ErrInsufficientStock,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rw := &dbReadWriter{db: db}
+			tt.mockSetup(mock)
+
+			got, err := rw.CreateReservation(context.Background(), reservation, 30*time.Minute)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func Test_ConfirmReservation(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	selectReservation := regexp.QuoteMeta(`SELECT ` + reservationColumns + ` FROM trx_reservation WHERE reservation_id = $1 FOR UPDATE`)
+	fixedTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		mockSetup func(sqlmock.Sqlmock)
+		want      int64
+		wantErr   error
+	}{
+		{
+			name: "Reserved quantity is posted as OUT",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(selectReservation).
+					WithArgs(7).
+					WillReturnRows(sqlmock.NewRows(reservationRowColumns).
+						AddRow(7, 1, 1, 2, 10, "ACTIVE", "SO-001", fixedTime, 0, 1, fixedTime, fixedTime))
+				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO mst_stock`)).
+					WithArgs(1, pq.Array([]int64{2})).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT location_id, stock_quantity, reserved_quantity FROM mst_stock`)).
+					WithArgs(1, pq.Array([]int64{2})).
+					WillReturnRows(sqlmock.NewRows([]string{"location_id", "stock_quantity", "reserved_quantity"}).AddRow(2, 40, 40))
+				expectStockCostLock(mock, 1, 2)
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE mst_stock SET reserved_quantity = reserved_quantity + $1`)).
+					WithArgs(-10, 1, 2).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT lot_id, lot_number, expiry_date, quantity FROM mst_stock_lot`)).
+					WithArgs(1, 2).
+					WillReturnRows(sqlmock.NewRows([]string{"lot_id", "lot_number", "expiry_date", "quantity"}))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE mst_stock SET stock_quantity = $1 WHERE product_id = $2 AND location_id = $3`)).
+					WithArgs(30, 1, 2).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				expectUncostedMovement(mock, 1, 1, 40, -10)
+				mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO trx_stock`)).
+					WithArgs(1, 1, 2, model.StockOut, -10, 30, 3, "", nil, "", "", "", 0, 0.0, 0.0, "", 0).
+					WillReturnRows(sqlmock.NewRows([]string{"transaction_id"}).AddRow(11))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE trx_reservation SET status = $1, transaction_id = $2 WHERE reservation_id = $3`)).
+					WithArgs(model.ReservationConfirmed, 11, 7).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+			want: 11,
+		},
+		{
+			name: "Expired reservation",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(selectReservation).
+					WithArgs(7).
+					WillReturnRows(sqlmock.NewRows(reservationRowColumns).
+						AddRow(7, 1, 1, 2, 10, "EXPIRED", "SO-001", fixedTime, 0, 1, fixedTime, fixedTime))
+				mock.ExpectRollback()
+			},
+			wantErr: model.ErrReservationNotActive,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rw := &dbReadWriter{db: db}
+			tt.mockSetup(mock)
+
+			got, err := rw.ConfirmReservation(context.Background(), 7, 3, nil)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func Test_ExpireReservations(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	rw := &dbReadWriter{db: db}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`UPDATE trx_reservation SET status = $1 WHERE status = $2 AND expires_at <= NOW() RETURNING product_id, location_id, quantity`)).
+		WithArgs(model.ReservationExpired, model.ReservationActive).
+		WillReturnRows(sqlmock.NewRows([]string{"product_id", "location_id", "quantity"}).
+			AddRow(2, 1, 4).
+			AddRow(1, 2, 5).
+			AddRow(1, 2, 3))
+	updateReserved := regexp.QuoteMeta(`UPDATE mst_stock SET reserved_quantity = reserved_quantity + $1 WHERE product_id = $2 AND location_id = $3`)
+	mock.ExpectExec(updateReserved).WithArgs(-8, 1, 2).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(updateReserved).WithArgs(-4, 2, 1).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	expired, err := rw.ExpireReservations(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), expired)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func Test_ReleaseReservation_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	rw := &dbReadWriter{db: db}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT ` + reservationColumns + ` FROM trx_reservation WHERE reservation_id = $1 FOR UPDATE`)).
+		WithArgs(9).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	err = rw.ReleaseReservation(context.Background(), 9)
+	assert.ErrorIs(t, err, model.ErrReservationNotFound)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func Test_ReadReservationByID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	rw := &dbReadWriter{db: db}
+	selectReservation := regexp.QuoteMeta(`SELECT ` + reservationColumns + ` FROM trx_reservation WHERE reservation_id = $1`)
+
+	t.Run("not found", func(t *testing.T) {
+		mock.ExpectQuery(selectReservation).WithArgs(9).WillReturnError(sql.ErrNoRows)
+
+		_, err := rw.ReadReservationByID(context.Background(), 9)
+		assert.ErrorIs(t, err, model.ErrReservationNotFound)
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		mock.ExpectQuery(selectReservation).WithArgs(9).WillReturnError(sql.ErrConnDone)
+
+		_, err := rw.ReadReservationByID(context.Background(), 9)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+		assert.NotErrorIs(t, err, model.ErrReservationNotFound)
+	})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,109 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/budsx/retail-management/model"
+)
+
+// ReverseStockTransaction posts a REVERSAL row that undoes a movement and marks
+// the movement REVERSED, returning the id of the new row. The compensating
+// entry moves the same lot and serial numbers back and has to pass the same
+// balance checks as any other movement.
+func (rw *dbReadWriter) ReverseStockTransaction(ctx context.Context, transactionID, reversedBy int64) (int64, error) {
+	selectForUpdate := `SELECT ` + stockTransactionColumns + ` FROM trx_stock WHERE transaction_id = $1 FOR UPDATE`
+
+	updateStatus := `UPDATE trx_stock SET status = $1 WHERE transaction_id = $2`
+
+	tx, err := rw.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	original, err := scanStockTransaction(tx.QueryRowContext(ctx, selectForUpdate, transactionID))
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("%w: %d", model.ErrTransactionNotFound, transactionID)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	switch original.TransactionType {
+	case model.StockIn, model.StockOut, model.StockAdjustment:
+	default:
+		return 0, fmt.Errorf("%w: transaction %d is a %s", model.ErrTransactionNotReversible, transactionID, original.TransactionType)
+	}
+
+	if original.Status == model.TransactionReversed {
+		return 0, fmt.Errorf("%w: transaction %d", model.ErrTransactionReversed, transactionID)
+	}
+
+	serialNumbers, err := selectLinkedSerialNumbers(ctx, tx, transactionID)
+	if err != nil {
+		return 0, err
+	}
+
+	stocks, err := lockLocationStock(ctx, tx, original.ProductID, original.LocationID)
+	if err != nil {
+		return 0, err
+	}
+
+	stock := stocks[original.LocationID]
+	reversal := model.StockTransaction{
+		ProductID:       original.ProductID,
+		WarehouseID:     original.WarehouseID,
+		LocationID:      original.LocationID,
+		TransactionType: model.StockReversal,
+		CreatedBy:       reversedBy,
+		LotNumber:       original.LotNumber,
+		ExpiryDate:      original.ExpiryDate,
+		SerialNumbers:   serialNumbers,
+		ReversalOfID:    transactionID,
+		UnitCost:        original.UnitCost,
+	}
+
+	var reversalID int64
+	if original.Quantity > 0 {
+		reversalID, err = postStockOut(ctx, tx, reversal, &stock, original.Quantity)
+	} else {
+		reversalID, err = postStockIn(ctx, tx, reversal, &stock, -original.Quantity)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.ExecContext(ctx, updateStatus, model.TransactionReversed, transactionID)
+	if err != nil {
+		return 0, err
+	}
+
+	return reversalID, tx.Commit()
+}
+
+// selectLinkedSerialNumbers returns the serial numbers of the units a ledger
+// row moved.
+func selectLinkedSerialNumbers(ctx context.Context, tx *sql.Tx, transactionID int64) ([]string, error) {
+	selectLinks := `SELECT s.serial_number FROM trx_stock_serial as l
+		INNER JOIN mst_serial as s ON l.serial_id = s.serial_id
+		WHERE l.transaction_id = $1 ORDER BY s.serial_id`
+
+	rows, err := tx.QueryContext(ctx, selectLinks, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var serialNumbers []string
+	for rows.Next() {
+		var serialNumber string
+		if err := rows.Scan(&serialNumber); err != nil {
+			return nil, err
+		}
+		serialNumbers = append(serialNumbers, serialNumber)
+	}
+
+	return serialNumbers, rows.Err()
+}
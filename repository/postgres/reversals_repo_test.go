@@ -0,0 +1,212 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/budsx/retail-management/model"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ReverseStockTransaction(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	columns := []string{
+		"transaction_id", "product_id", "warehouse_id", "location_id",
+		"transaction_type", "quantity", "balance_after", "transaction_date", "created_by",
+		"destination_warehouse_id", "destination_location_id", "status", "reference_id",
+		"lot_number", "expiry_date", "reason_code", "notes", "reference_document", "reversal_of_id", "unit_cost", "cost_amount", "unit_code", "unit_quantity",
+	}
+	selectOriginal := regexp.QuoteMeta(`SELECT ` + stockTransactionColumns + ` FROM trx_stock WHERE transaction_id = $1 FOR UPDATE`)
+	selectSerials := regexp.QuoteMeta(`SELECT s.serial_number FROM trx_stock_serial as l INNER JOIN mst_serial as s ON l.serial_id = s.serial_id WHERE l.transaction_id = $1 ORDER BY s.serial_id`)
+	lockStock := regexp.QuoteMeta(`SELECT location_id, stock_quantity, reserved_quantity FROM mst_stock WHERE product_id = $1 AND location_id = ANY($2) ORDER BY location_id FOR UPDATE`)
+	lockLots := regexp.QuoteMeta(`SELECT lot_id, lot_number, expiry_date, quantity FROM mst_stock_lot WHERE product_id = $1 AND location_id = $2 AND quantity > 0 ORDER BY expiry_date NULLS LAST, lot_id FOR UPDATE`)
+	fixedTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	expectLockStock := func(mock sqlmock.Sqlmock, onHand, reserved int) {
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO mst_stock`)).
+			WithArgs(1, pq.Array([]int64{2})).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(lockStock).
+			WithArgs(1, pq.Array([]int64{2})).
+			WillReturnRows(sqlmock.NewRows([]string{"location_id", "stock_quantity", "reserved_quantity"}).AddRow(2, onHand, reserved))
+		expectStockCostLock(mock, 1, 2)
+	}
+
+	tests := []struct {
+		name      string
+		mockSetup func(sqlmock.Sqlmock)
+		want      int64
+		wantErr   error
+	}{
+		{
+			name: "stock in is taken back out",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(selectOriginal).
+					WithArgs(5).
+					WillReturnRows(sqlmock.NewRows(columns).
+						AddRow(5, 1, 1, 2, "IN", 10, 50, fixedTime, 1, 0, 0, "COMPLETED", 0, "", nil, "", "", "", 0, nil, 0.0, "", 0))
+				mock.ExpectQuery(selectSerials).
+					WithArgs(5).
+					WillReturnRows(sqlmock.NewRows([]string{"serial_number"}))
+				expectLockStock(mock, 50, 0)
+				mock.ExpectQuery(lockLots).
+					WithArgs(1, 2).
+					WillReturnRows(sqlmock.NewRows([]string{"lot_id", "lot_number", "expiry_date", "quantity"}))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE mst_stock SET stock_quantity = $1 WHERE product_id = $2 AND location_id = $3`)).
+					WithArgs(40, 1, 2).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				expectUncostedMovement(mock, 1, 1, 50, -10)
+				mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO trx_stock`)).
+					WithArgs(1, 1, 2, model.StockReversal, -10, 40, 3, "", nil, "", "", "", 5, 0.0, 0.0, "", 0).
+					WillReturnRows(sqlmock.NewRows([]string{"transaction_id"}).AddRow(9))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE trx_stock SET status = $1 WHERE transaction_id = $2`)).
+					WithArgs(model.TransactionReversed, 5).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+			want: 9,
+		},
+		{
+			name: "stock out is put back",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(selectOriginal).
+					WithArgs(5).
+					WillReturnRows(sqlmock.NewRows(columns).
+						AddRow(5, 1, 1, 2, "OUT", -10, 30, fixedTime, 1, 0, 0, "COMPLETED", 0, "", nil, "", "", "", 0, nil, 0.0, "", 0))
+				mock.ExpectQuery(selectSerials).
+					WithArgs(5).
+					WillReturnRows(sqlmock.NewRows([]string{"serial_number"}))
+				expectLockStock(mock, 30, 0)
+				expectReceivable(mock, 1, 2)
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE mst_stock SET stock_quantity = $1 WHERE product_id = $2 AND location_id = $3`)).
+					WithArgs(40, 1, 2).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				expectUncostedMovement(mock, 1, 1, 30, 10)
+				mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO trx_stock`)).
+					WithArgs(1, 1, 2, model.StockReversal, 10, 40, 3, "", nil, "", "", "", 5, 0.0, 0.0, "", 0).
+					WillReturnRows(sqlmock.NewRows([]string{"transaction_id"}).AddRow(9))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE trx_stock SET status = $1 WHERE transaction_id = $2`)).
+					WithArgs(model.TransactionReversed, 5).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+			want: 9,
+		},
+		{
+			name: "stock out of an archived product is not put back",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(selectOriginal).
+					WithArgs(5).
+					WillReturnRows(sqlmock.NewRows(columns).
+						AddRow(5, 1, 1, 2, "OUT", -10, 30, fixedTime, 1, 0, 0, "COMPLETED", 0, "", nil, "", "", "", 0, nil, 0.0, "", 0))
+				mock.ExpectQuery(selectSerials).
+					WithArgs(5).
+					WillReturnRows(sqlmock.NewRows([]string{"serial_number"}))
+				expectLockStock(mock, 30, 0)
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT p.archived_at IS NOT NULL, l.archived_at IS NOT NULL FROM mst_product p, mst_location l`)).
+					WithArgs(1, 2).
+					WillReturnRows(sqlmock.NewRows([]string{"product_archived", "location_archived"}).AddRow(true, false))
+				mock.ExpectRollback()
+			},
+			wantErr: model.ErrArchived,
+		},
+		{
+			name: "stock already used cannot be taken back",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(selectOriginal).
+					WithArgs(5).
+					WillReturnRows(sqlmock.NewRows(columns).
+						AddRow(5, 1, 1, 2, "IN", 10, 50, fixedTime, 1, 0, 0, "COMPLETED", 0, "", nil, "", "", "", 0, nil, 0.0, "", 0))
+				mock.ExpectQuery(selectSerials).
+					WithArgs(5).
+					WillReturnRows(sqlmock.NewRows([]string{"serial_number"}))
+				expectLockStock(mock, 12, 4)
+				mock.ExpectQuery(lockLots).
+					WithArgs(1, 2).
+					WillReturnRows(sqlmock.NewRows([]string{"lot_id", "lot_number", "expiry_date", "quantity"}))
+				mock.ExpectRollback()
+			},
+			wantErr: model.ErrInsufficientStock,
+		},
+		{
+			name: "already reversed",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(selectOriginal).
+					WithArgs(5).
+					WillReturnRows(sqlmock.NewRows(columns).
+						AddRow(5, 1, 1, 2, "IN", 10, 50, fixedTime, 1, 0, 0, "REVERSED", 0, "", nil, "", "", "", 0, nil, 0.0, "", 0))
+				mock.ExpectRollback()
+			},
+			wantErr: model.ErrTransactionReversed,
+		},
+		{
+			name: "transfer",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(selectOriginal).
+					WithArgs(5).
+					WillReturnRows(sqlmock.NewRows(columns).
+						AddRow(5, 1, 1, 2, "TRANSFER", -5, 45, fixedTime, 1, 2, 3, "COMPLETED", 0, "", nil, "", "", "", 0, nil, 0.0, "", 0))
+				mock.ExpectRollback()
+			},
+			wantErr: model.ErrTransactionNotReversible,
+		},
+		{
+			name: "reversal of a reversal",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(selectOriginal).
+					WithArgs(5).
+					WillReturnRows(sqlmock.NewRows(columns).
+						AddRow(5, 1, 1, 2, "REVERSAL", -10, 40, fixedTime, 1, 0, 0, "COMPLETED", 0, "", nil, "", "", "", 4, nil, 0.0, "", 0))
+				mock.ExpectRollback()
+			},
+			wantErr: model.ErrTransactionNotReversible,
+		},
+		{
+			name: "not found",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(selectOriginal).
+					WithArgs(5).
+					WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
+			},
+			wantErr: model.ErrTransactionNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rw := &dbReadWriter{db: db}
+			tt.mockSetup(mock)
+
+			got, err := rw.ReverseStockTransaction(context.Background(), 5, 3)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
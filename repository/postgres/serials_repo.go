@@ -0,0 +1,230 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/budsx/retail-management/model"
+	"github.com/lib/pq"
+)
+
+// serialUnit is a locked unit of a serialized product.
+type serialUnit struct {
+	serialID     int64
+	serialNumber string
+	status       model.SerialStatus
+	locationID   int64
+	lotNumber    string
+}
+
+// lockSerialUnits locks the product's units with the given serial numbers and
+// checks that every one of them is in stock at the location. Units are locked
+// in serial_id order after the location's stock row.
+func lockSerialUnits(ctx context.Context, tx *sql.Tx, productID, locationID int64, serialNumbers []string) ([]serialUnit, error) {
+	selectForUpdate := `SELECT serial_id, serial_number, status, COALESCE(location_id, 0), COALESCE(lot_number, '') FROM mst_serial 
+		WHERE product_id = $1 AND serial_number = ANY($2) 
+		ORDER BY serial_id FOR UPDATE`
+
+	rows, err := tx.QueryContext(ctx, selectForUpdate, productID, pq.Array(serialNumbers))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	found := make(map[string]serialUnit, len(serialNumbers))
+	for rows.Next() {
+		var unit serialUnit
+		if err := rows.Scan(&unit.serialID, &unit.serialNumber, &unit.status, &unit.locationID, &unit.lotNumber); err != nil {
+			return nil, err
+		}
+		found[unit.serialNumber] = unit
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	units := make([]serialUnit, 0, len(serialNumbers))
+	for _, serialNumber := range serialNumbers {
+		unit, ok := found[serialNumber]
+		if !ok || unit.status != model.SerialInStock || unit.locationID != locationID {
+			return nil, fmt.Errorf("%w: %s is not in stock at location %d", model.ErrSerialNotAvailable, serialNumber, locationID)
+		}
+		units = append(units, unit)
+	}
+
+	return units, nil
+}
+
+// pickSerials groups the units leaving a location by the lot they were
+// received in, in the same order pickLots would take those lots.
+func pickSerials(lots []stockLot, units []serialUnit) ([]lotPick, error) {
+	byLot := map[string][]int64{}
+	for _, unit := range units {
+		byLot[unit.lotNumber] = append(byLot[unit.lotNumber], unit.serialID)
+	}
+
+	var picks []lotPick
+	for _, lot := range lots {
+		serialIDs, ok := byLot[lot.lotNumber]
+		if !ok {
+			continue
+		}
+		if int64(len(serialIDs)) > lot.quantity {
+			return nil, fmt.Errorf("%w: lot %s has %d, requested %d", model.ErrInsufficientStock, lot.lotNumber, lot.quantity, len(serialIDs))
+		}
+		picks = append(picks, lotPick{lot: lot, quantity: int64(len(serialIDs)), serialIDs: serialIDs})
+		delete(byLot, lot.lotNumber)
+	}
+
+	if serialIDs, ok := byLot[""]; ok {
+		picks = append(picks, lotPick{quantity: int64(len(serialIDs)), serialIDs: serialIDs})
+		delete(byLot, "")
+	}
+
+	for lotNumber := range byLot {
+		return nil, fmt.Errorf("%w: lot %s has no stock at this location", model.ErrInsufficientStock, lotNumber)
+	}
+
+	return picks, nil
+}
+
+// receiveSerialUnits puts the movement's serial numbers in stock at its
+// location and returns their ids. A unit shipped earlier may come back; one
+// that is still in stock or in transit cannot be received again.
+func receiveSerialUnits(ctx context.Context, tx *sql.Tx, transaction model.StockTransaction) ([]int64, error) {
+	upsertSerial := `INSERT INTO mst_serial (product_id, serial_number, status, warehouse_id, location_id, lot_number) 
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, '')) 
+		ON CONFLICT (product_id, serial_number) 
+		DO UPDATE SET status = EXCLUDED.status, warehouse_id = EXCLUDED.warehouse_id, location_id = EXCLUDED.location_id, lot_number = EXCLUDED.lot_number 
+		WHERE mst_serial.status = $7 
+		RETURNING serial_id`
+
+	serialIDs := make([]int64, 0, len(transaction.SerialNumbers))
+	for _, serialNumber := range transaction.SerialNumbers {
+		var serialID int64
+		err := tx.QueryRowContext(ctx, upsertSerial,
+			transaction.ProductID,
+			serialNumber,
+			model.SerialInStock,
+			transaction.WarehouseID,
+			transaction.LocationID,
+			transaction.LotNumber,
+			model.SerialShipped,
+		).Scan(&serialID)
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("%w: %s is already in stock", model.ErrSerialNotAvailable, serialNumber)
+		}
+		if err != nil {
+			return nil, err
+		}
+		serialIDs = append(serialIDs, serialID)
+	}
+
+	return serialIDs, nil
+}
+
+// moveSerialUnits records where units are now; a zero location means the
+// units are not in any location.
+func moveSerialUnits(ctx context.Context, tx *sql.Tx, serialIDs []int64, status model.SerialStatus, warehouseID, locationID int64) error {
+	updateSerials := `UPDATE mst_serial SET status = $1, warehouse_id = NULLIF($2, 0), location_id = NULLIF($3, 0) WHERE serial_id = ANY($4)`
+
+	_, err := tx.ExecContext(ctx, updateSerials, status, warehouseID, locationID, pq.Array(serialIDs))
+	return err
+}
+
+// linkSerialUnits records which units a ledger row moved.
+func linkSerialUnits(ctx context.Context, tx *sql.Tx, transactionID int64, serialIDs []int64) error {
+	insertLinks := `INSERT INTO trx_stock_serial (transaction_id, serial_id) SELECT $1, UNNEST($2::int[])`
+
+	_, err := tx.ExecContext(ctx, insertLinks, transactionID, pq.Array(serialIDs))
+	return err
+}
+
+// selectLinkedSerialUnits returns the ids of the units a ledger row moved.
+func selectLinkedSerialUnits(ctx context.Context, tx *sql.Tx, transactionID int64) ([]int64, error) {
+	selectLinks := `SELECT serial_id FROM trx_stock_serial WHERE transaction_id = $1 ORDER BY serial_id`
+
+	rows, err := tx.QueryContext(ctx, selectLinks, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var serialIDs []int64
+	for rows.Next() {
+		var serialID int64
+		if err := rows.Scan(&serialID); err != nil {
+			return nil, err
+		}
+		serialIDs = append(serialIDs, serialID)
+	}
+
+	return serialIDs, rows.Err()
+}
+
+func (rw *dbReadWriter) ReadSerialsByNumber(ctx context.Context, serialNumber string) ([]model.Serial, error) {
+	query := `SELECT serial_id, product_id, serial_number, status, COALESCE(warehouse_id, 0), COALESCE(location_id, 0), COALESCE(lot_number, ''), created_at, updated_at 
+		FROM mst_serial WHERE serial_number = $1 ORDER BY product_id`
+
+	rows, err := rw.db.QueryContext(ctx, query, serialNumber)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	serials := []model.Serial{}
+	for rows.Next() {
+		var serial model.Serial
+		err := rows.Scan(
+			&serial.SerialID,
+			&serial.ProductID,
+			&serial.SerialNumber,
+			&serial.Status,
+			&serial.WarehouseID,
+			&serial.LocationID,
+			&serial.LotNumber,
+			&serial.CreatedAt,
+			&serial.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		serials = append(serials, serial)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return serials, nil
+}
+
+// GetSerialMovements returns the ledger rows that moved a unit, oldest first.
+func (rw *dbReadWriter) GetSerialMovements(ctx context.Context, serialID int64) ([]model.StockTransaction, error) {
+	query := `SELECT ` + stockTransactionColumns + `
+		FROM trx_stock WHERE transaction_id IN (SELECT transaction_id FROM trx_stock_serial WHERE serial_id = $1) 
+		ORDER BY transaction_id`
+
+	rows, err := rw.db.QueryContext(ctx, query, serialID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	movements := []model.StockTransaction{}
+	for rows.Next() {
+		movement, err := scanStockTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		movements = append(movements, movement)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return movements, nil
+}
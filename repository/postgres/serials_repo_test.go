@@ -0,0 +1,133 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/budsx/retail-management/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_pickSerials(t *testing.T) {
+	early := model.NewDate(2025, time.January, 10)
+	late := model.NewDate(2025, time.March, 1)
+	lots := []stockLot{
+		{lotID: 1, lotNumber: "LOT-A", expiryDate: &early, quantity: 1},
+		{lotID: 2, lotNumber: "LOT-B", expiryDate: &late, quantity: 3},
+	}
+
+	tests := []struct {
+		name    string
+		units   []serialUnit
+		want    []lotPick
+		wantErr error
+	}{
+		{
+			name: "units are grouped by lot in picking order",
+			units: []serialUnit{
+				{serialID: 7, lotNumber: ""},
+				{serialID: 5, lotNumber: "LOT-B"},
+				{serialID: 3, lotNumber: "LOT-A"},
+				{serialID: 6, lotNumber: "LOT-B"},
+			},
+			want: []lotPick{
+				{lot: lots[0], quantity: 1, serialIDs: []int64{3}},
+				{lot: lots[1], quantity: 2, serialIDs: []int64{5, 6}},
+				{quantity: 1, serialIDs: []int64{7}},
+			},
+		},
+		{
+			name: "more units than the lot holds",
+			units: []serialUnit{
+				{serialID: 3, lotNumber: "LOT-A"},
+				{serialID: 4, lotNumber: "LOT-A"},
+			},
+			wantErr: model.ErrInsufficientStock,
+		},
+		{
+			name:    "lot without stock at the location",
+			units:   []serialUnit{{serialID: 9, lotNumber: "LOT-Z"}},
+			wantErr: model.ErrInsufficientStock,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := pickSerials(lots, tt.units)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_ReadSerialsByNumber(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	query := regexp.QuoteMeta(`SELECT serial_id, product_id, serial_number, status, COALESCE(warehouse_id, 0), COALESCE(location_id, 0), COALESCE(lot_number, ''), created_at, updated_at FROM mst_serial WHERE serial_number = $1 ORDER BY product_id`)
+	now := time.Now()
+
+	tests := []struct {
+		name      string
+		mockSetup func(sqlmock.Sqlmock)
+		want      []model.Serial
+		wantErr   bool
+	}{
+		{
+			name: "serial in stock",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{
+					"serial_id", "product_id", "serial_number", "status", "warehouse_id", "location_id", "lot_number", "created_at", "updated_at",
+				}).AddRow(3, 5, "SN-001", "IN_STOCK", 1, 2, "", now, now)
+				mock.ExpectQuery(query).WithArgs("SN-001").WillReturnRows(rows)
+			},
+			want: []model.Serial{{
+				SerialID:     3,
+				ProductID:    5,
+				SerialNumber: "SN-001",
+				Status:       model.SerialInStock,
+				WarehouseID:  1,
+				LocationID:   2,
+				CreatedAt:    now,
+				UpdatedAt:    now,
+			}},
+		},
+		{
+			name: "database error",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(query).WithArgs("SN-001").WillReturnError(sql.ErrConnDone)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rw := &dbReadWriter{db: db}
+			tt.mockSetup(mock)
+
+			got, err := rw.ReadSerialsByNumber(context.Background(), "SN-001")
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
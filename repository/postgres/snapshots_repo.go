@@ -0,0 +1,98 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/budsx/retail-management/model"
+)
+
+// stockAsOf adds up each product's ledger at the locations matching
+// $2 (0 for all) up to and including $1, starting from the latest snapshot at
+// or before $1 instead of the beginning of the ledger when there is one.
+const stockAsOf = `WITH snapshot AS (
+		SELECT MAX(snapshot_at) as snapshot_at FROM trx_stock_snapshot WHERE snapshot_at <= $1
+	)
+	SELECT product_id, SUM(quantity) as total_stock FROM (
+		SELECT product_id, quantity FROM trx_stock_snapshot
+		WHERE snapshot_at = (SELECT snapshot_at FROM snapshot) AND ($2 = 0 OR location_id = $2)
+		UNION ALL
+		SELECT product_id, quantity FROM trx_stock
+		WHERE transaction_date <= $1 AND ($2 = 0 OR location_id = $2)
+		AND transaction_date >= COALESCE((SELECT snapshot_at FROM snapshot), '-infinity')
+	) as movements GROUP BY product_id`
+
+// GetTotalStocksAsOf reports on-hand stock across all locations as it stood at
+// asOf. Reservations are not kept historically, so nothing is shown reserved.
+func (rw *dbReadWriter) GetTotalStocksAsOf(ctx context.Context, asOf time.Time) ([]model.ProductStock, error) {
+	return rw.readStockAsOf(ctx, asOf, 0)
+}
+
+// GetTotalStockByLocationAsOf is GetTotalStocksAsOf for a single location.
+func (rw *dbReadWriter) GetTotalStockByLocationAsOf(ctx context.Context, locationID int64, asOf time.Time) ([]model.ProductStock, error) {
+	return rw.readStockAsOf(ctx, asOf, locationID)
+}
+
+func (rw *dbReadWriter) readStockAsOf(ctx context.Context, asOf time.Time, locationID int64) ([]model.ProductStock, error) {
+	query := `SELECT m.product_id, l.total_stock, 0, m.product_name, m.sku, COALESCE(m.category_id, 0), COALESCE(m.parent_id, 0) 
+		FROM (` + stockAsOf + `) as l 
+		INNER JOIN mst_product as m ON l.product_id = m.product_id 
+		ORDER BY m.product_id`
+
+	rows, err := rw.db.QueryContext(ctx, query, asOf, locationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanProductStocks(rows)
+}
+
+// CreateStockSnapshot records every product and location balance as of the
+// start of the day, rolling the previous snapshot forward with the ledger rows
+// since. It returns the cutoff and the number of balances written, 0 when the
+// snapshot already exists. The cutoff is taken from the database clock in the
+// session time zone, which is what transaction_date defaults to, and trails it
+// by an hour so that movements dated just before midnight have committed.
+func (rw *dbReadWriter) CreateStockSnapshot(ctx context.Context) (time.Time, int64, error) {
+	selectCutoff := `SELECT date_trunc('day', LOCALTIMESTAMP - interval '1 hour')`
+
+	selectExists := `SELECT EXISTS (SELECT 1 FROM trx_stock_snapshot WHERE snapshot_at = $1)`
+
+	insertSnapshot := `WITH previous AS (
+			SELECT MAX(snapshot_at) as snapshot_at FROM trx_stock_snapshot WHERE snapshot_at < $1
+		)
+		INSERT INTO trx_stock_snapshot (snapshot_at, product_id, location_id, quantity) 
+		SELECT $1, product_id, location_id, SUM(quantity) FROM (
+			SELECT product_id, location_id, quantity FROM trx_stock_snapshot
+			WHERE snapshot_at = (SELECT snapshot_at FROM previous)
+			UNION ALL
+			SELECT product_id, COALESCE(location_id, 0), quantity FROM trx_stock
+			WHERE transaction_date < $1
+			AND transaction_date >= COALESCE((SELECT snapshot_at FROM previous), '-infinity')
+		) as movements GROUP BY product_id, location_id 
+		ON CONFLICT (snapshot_at, product_id, location_id) DO NOTHING`
+
+	var snapshotAt time.Time
+	err := rw.db.QueryRowContext(ctx, selectCutoff).Scan(&snapshotAt)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+
+	var exists bool
+	err = rw.db.QueryRowContext(ctx, selectExists, snapshotAt).Scan(&exists)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	if exists {
+		return snapshotAt, 0, nil
+	}
+
+	result, err := rw.db.ExecContext(ctx, insertSnapshot, snapshotAt)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+
+	created, err := result.RowsAffected()
+	return snapshotAt, created, err
+}
@@ -0,0 +1,104 @@
+package postgres
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/budsx/retail-management/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GetTotalStocksAsOf(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	monthEnd := time.Date(2024, time.January, 31, 23, 59, 59, 0, time.UTC)
+	columns := []string{"product_id", "total_stock", "reserved_stock", "product_name", "sku", "category_id", "parent_id"}
+
+	mock.ExpectQuery(regexp.QuoteMeta(`WITH snapshot AS ( SELECT MAX(snapshot_at) as snapshot_at FROM trx_stock_snapshot WHERE snapshot_at <= $1 )`)).
+		WithArgs(monthEnd, 0).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(1, 120, 0, "Kopi Arabika", "KOP-001", 2, 0))
+	mock.ExpectQuery(regexp.QuoteMeta(`AND ($2 = 0 OR location_id = $2)`)).
+		WithArgs(monthEnd, 2).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(1, 40, 0, "Kopi Arabika", "KOP-001", 2, 0))
+
+	rw := &dbReadWriter{db: db}
+	got, err := rw.GetTotalStocksAsOf(context.Background(), monthEnd)
+	assert.NoError(t, err)
+	assert.Equal(t, []model.ProductStock{{ProductID: 1, TotalStock: 120, AvailableStock: 120, ProductName: "Kopi Arabika", SKU: "KOP-001", CategoryID: 2}}, got)
+
+	got, err = rw.GetTotalStockByLocationAsOf(context.Background(), 2, monthEnd)
+	assert.NoError(t, err)
+	assert.Equal(t, []model.ProductStock{{ProductID: 1, TotalStock: 40, AvailableStock: 40, ProductName: "Kopi Arabika", SKU: "KOP-001", CategoryID: 2}}, got)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func Test_CreateStockSnapshot(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	selectCutoff := regexp.QuoteMeta(`SELECT date_trunc('day', LOCALTIMESTAMP - interval '1 hour')`)
+	selectExists := regexp.QuoteMeta(`SELECT EXISTS (SELECT 1 FROM trx_stock_snapshot WHERE snapshot_at = $1)`)
+	insertSnapshot := regexp.QuoteMeta(`INSERT INTO trx_stock_snapshot (snapshot_at, product_id, location_id, quantity)`)
+	midnight := time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		mockSetup func(sqlmock.Sqlmock)
+		want      int64
+	}{
+		{
+			name: "rolls the previous snapshot forward",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(selectCutoff).
+					WillReturnRows(sqlmock.NewRows([]string{"date_trunc"}).AddRow(midnight))
+				mock.ExpectQuery(selectExists).
+					WithArgs(midnight).
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+				mock.ExpectExec(insertSnapshot).
+					WithArgs(midnight).
+					WillReturnResult(sqlmock.NewResult(0, 12))
+			},
+			want: 12,
+		},
+		{
+			name: "snapshot already taken",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(selectCutoff).
+					WillReturnRows(sqlmock.NewRows([]string{"date_trunc"}).AddRow(midnight))
+				mock.ExpectQuery(selectExists).
+					WithArgs(midnight).
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+			},
+			want: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rw := &dbReadWriter{db: db}
+			tt.mockSetup(mock)
+
+			snapshotAt, got, err := rw.CreateStockSnapshot(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, midnight, snapshotAt)
+			assert.Equal(t, tt.want, got)
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,304 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+
+	"github.com/budsx/retail-management/model"
+	"github.com/lib/pq"
+)
+
+const stockTakeColumns = `stock_take_id, warehouse_id, location_ids, status, COALESCE(created_by, 0), COALESCE(approved_by, 0), approved_at, created_at, updated_at`
+
+func scanStockTake(row rowScanner) (model.StockTake, error) {
+	var stockTake model.StockTake
+	err := row.Scan(
+		&stockTake.StockTakeID,
+		&stockTake.WarehouseID,
+		pq.Array(&stockTake.LocationIDs),
+		&stockTake.Status,
+		&stockTake.CreatedBy,
+		&stockTake.ApprovedBy,
+		&stockTake.ApprovedAt,
+		&stockTake.CreatedAt,
+		&stockTake.UpdatedAt,
+	)
+	return stockTake, err
+}
+
+// CreateStockTake opens a count and freezes the expected quantity of every
+// product held at the counted locations.
+func (rw *dbReadWriter) CreateStockTake(ctx context.Context, stockTake model.StockTake) (int64, error) {
+	insertStockTake := `INSERT INTO trx_stock_take (warehouse_id, location_ids, status, created_by)
+		VALUES ($1, $2, $3, $4) RETURNING stock_take_id`
+
+	freezeExpected := `INSERT INTO trx_stock_take_line (stock_take_id, product_id, location_id, expected_quantity)
+		SELECT $1, product_id, location_id, stock_quantity FROM mst_stock
+		WHERE warehouse_id = $2 AND (COALESCE(CARDINALITY($3::int[]), 0) = 0 OR location_id = ANY($3))`
+
+	tx, err := rw.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var stockTakeID int64
+	err = tx.QueryRowContext(ctx, insertStockTake,
+		stockTake.WarehouseID,
+		pq.Array(stockTake.LocationIDs),
+		model.StockTakeOpen,
+		stockTake.CreatedBy,
+	).Scan(&stockTakeID)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.ExecContext(ctx, freezeExpected, stockTakeID, stockTake.WarehouseID, pq.Array(stockTake.LocationIDs))
+	if err != nil {
+		return 0, err
+	}
+
+	return stockTakeID, tx.Commit()
+}
+
+func (rw *dbReadWriter) ReadStockTakeByID(ctx context.Context, stockTakeID int64) (model.StockTake, error) {
+	query := `SELECT ` + stockTakeColumns + ` FROM trx_stock_take WHERE stock_take_id = $1`
+
+	selectLines := `SELECT l.line_id, l.product_id, m.product_name, m.sku, l.location_id, l.expected_quantity, l.counted_quantity, COALESCE(l.transaction_id, 0)
+		FROM trx_stock_take_line as l
+		INNER JOIN mst_product as m ON l.product_id = m.product_id
+		WHERE l.stock_take_id = $1
+		ORDER BY l.location_id, l.product_id`
+
+	stockTake, err := scanStockTake(rw.db.QueryRowContext(ctx, query, stockTakeID))
+	if err == sql.ErrNoRows {
+		return stockTake, fmt.Errorf("%w: %d", model.ErrStockTakeNotFound, stockTakeID)
+	}
+	if err != nil {
+		return stockTake, err
+	}
+
+	rows, err := rw.db.QueryContext(ctx, selectLines, stockTakeID)
+	if err != nil {
+		return stockTake, err
+	}
+	defer rows.Close()
+
+	stockTake.Lines = []model.StockTakeLine{}
+	for rows.Next() {
+		var line model.StockTakeLine
+		err := rows.Scan(
+			&line.LineID,
+			&line.ProductID,
+			&line.ProductName,
+			&line.SKU,
+			&line.LocationID,
+			&line.ExpectedQuantity,
+			&line.CountedQuantity,
+			&line.TransactionID,
+		)
+		if err != nil {
+			return stockTake, err
+		}
+		if line.CountedQuantity != nil {
+			variance := *line.CountedQuantity - line.ExpectedQuantity
+			line.Variance = &variance
+		}
+		stockTake.Lines = append(stockTake.Lines, line)
+	}
+
+	if err := rows.Err(); err != nil {
+		return stockTake, err
+	}
+
+	return stockTake, nil
+}
+
+// RecordStockTakeCounts stores counted quantities, replacing earlier counts of
+// the same product and location. A product that was not at a location when the
+// count was opened is expected at zero there.
+func (rw *dbReadWriter) RecordStockTakeCounts(ctx context.Context, stockTakeID, countedBy int64, counts []model.StockTakeCount) error {
+	upsertCount := `INSERT INTO trx_stock_take_line (stock_take_id, product_id, location_id, expected_quantity, counted_quantity, counted_by, counted_at)
+		SELECT $1, $2, location_id, 0, $4, $5, NOW() FROM mst_location
+		WHERE location_id = $3 AND warehouse_id = $6 AND (COALESCE(CARDINALITY($7::int[]), 0) = 0 OR location_id = ANY($7))
+		ON CONFLICT (stock_take_id, product_id, location_id)
+		DO UPDATE SET counted_quantity = EXCLUDED.counted_quantity, counted_by = EXCLUDED.counted_by, counted_at = EXCLUDED.counted_at`
+
+	tx, err := rw.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stockTake, err := lockOpenStockTake(ctx, tx, stockTakeID)
+	if err != nil {
+		return err
+	}
+
+	for _, count := range counts {
+		result, err := tx.ExecContext(ctx, upsertCount,
+			stockTakeID,
+			count.ProductID,
+			count.LocationID,
+			count.CountedQuantity,
+			countedBy,
+			stockTake.WarehouseID,
+			pq.Array(stockTake.LocationIDs),
+		)
+		if err != nil {
+			return err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rowsAffected == 0 {
+			return fmt.Errorf("%w: location %d is not part of stock take %d", model.ErrLocationNotFound, count.LocationID, stockTakeID)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// stockTakeVariance is a counted line whose count differs from the frozen
+// expected quantity.
+type stockTakeVariance struct {
+	lineID     int64
+	productID  int64
+	locationID int64
+	variance   int64
+}
+
+// ApproveStockTake posts every variance of an open stock take as an ADJUSTMENT
+// through the ledger and closes it. Variances are applied on top of the
+// current balance, so movements posted while counting are kept. Lines that
+// were never counted are left as they are. A variance of a serialized product
+// does not say which serial numbers were found or lost, so it refuses the
+// approval rather than let the serial register and the balance part ways.
+func (rw *dbReadWriter) ApproveStockTake(ctx context.Context, stockTakeID, approvedBy int64) error {
+	selectVariances := `SELECT l.line_id, l.product_id, l.location_id, l.counted_quantity - l.expected_quantity, p.is_serialized
+		FROM trx_stock_take_line as l
+		INNER JOIN mst_product as p ON l.product_id = p.product_id
+		WHERE l.stock_take_id = $1 AND l.counted_quantity IS NOT NULL AND l.counted_quantity <> l.expected_quantity
+		ORDER BY l.product_id, l.location_id`
+
+	updateLine := `UPDATE trx_stock_take_line SET transaction_id = $1 WHERE line_id = $2`
+
+	approveStockTake := `UPDATE trx_stock_take SET status = $1, approved_by = $2, approved_at = NOW() WHERE stock_take_id = $3`
+
+	tx, err := rw.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stockTake, err := lockOpenStockTake(ctx, tx, stockTakeID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := tx.QueryContext(ctx, selectVariances, stockTakeID)
+	if err != nil {
+		return err
+	}
+
+	var variances []stockTakeVariance
+	for rows.Next() {
+		var line stockTakeVariance
+		var serialized bool
+		if err := rows.Scan(&line.lineID, &line.productID, &line.locationID, &line.variance, &serialized); err != nil {
+			rows.Close()
+			return err
+		}
+		if serialized {
+			rows.Close()
+			return fmt.Errorf("%w: product %d at location %d is off by %d", model.ErrSerializedVariance, line.productID, line.locationID, line.variance)
+		}
+		variances = append(variances, line)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, line := range variances {
+		stocks, err := lockLocationStock(ctx, tx, line.productID, line.locationID)
+		if err != nil {
+			return err
+		}
+
+		stock := stocks[line.locationID]
+		adjustment := model.StockTransaction{
+			ProductID:         line.productID,
+			WarehouseID:       stockTake.WarehouseID,
+			LocationID:        line.locationID,
+			TransactionType:   model.StockAdjustment,
+			CreatedBy:         approvedBy,
+			ReasonCode:        model.AdjustmentReasonStockTake,
+			ReferenceDocument: strconv.FormatInt(stockTakeID, 10),
+		}
+
+		var transactionID int64
+		if line.variance > 0 {
+			transactionID, err = postStockIn(ctx, tx, adjustment, &stock, line.variance)
+		} else {
+			transactionID, err = postStockOut(ctx, tx, adjustment, &stock, -line.variance)
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, updateLine, transactionID, line.lineID)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, approveStockTake, model.StockTakeApproved, approvedBy, stockTakeID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (rw *dbReadWriter) CancelStockTake(ctx context.Context, stockTakeID int64) error {
+	cancelStockTake := `UPDATE trx_stock_take SET status = $1 WHERE stock_take_id = $2 AND status = $3`
+
+	result, err := rw.db.ExecContext(ctx, cancelStockTake, model.StockTakeCancelled, stockTakeID, model.StockTakeOpen)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: stock take %d", model.ErrStockTakeNotOpen, stockTakeID)
+	}
+
+	return nil
+}
+
+func lockOpenStockTake(ctx context.Context, tx *sql.Tx, stockTakeID int64) (model.StockTake, error) {
+	selectStockTake := `SELECT ` + stockTakeColumns + ` FROM trx_stock_take WHERE stock_take_id = $1 FOR UPDATE`
+
+	stockTake, err := scanStockTake(tx.QueryRowContext(ctx, selectStockTake, stockTakeID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return stockTake, fmt.Errorf("%w: %d", model.ErrStockTakeNotFound, stockTakeID)
+		}
+		return stockTake, err
+	}
+
+	if stockTake.Status != model.StockTakeOpen {
+		return stockTake, fmt.Errorf("%w: stock take %d is %s", model.ErrStockTakeNotOpen, stockTakeID, stockTake.Status)
+	}
+
+	return stockTake, nil
+}
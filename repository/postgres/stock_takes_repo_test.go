@@ -0,0 +1,319 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/budsx/retail-management/model"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+var stockTakeRowColumns = []string{"stock_take_id", "warehouse_id", "location_ids", "status", "created_by", "approved_by", "approved_at", "created_at", "updated_at"}
+
+func Test_RecordStockTakeCounts(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	selectStockTake := regexp.QuoteMeta(`SELECT ` + stockTakeColumns + ` FROM trx_stock_take WHERE stock_take_id = $1 FOR UPDATE`)
+	upsertCount := regexp.QuoteMeta(`INSERT INTO trx_stock_take_line (stock_take_id, product_id, location_id, expected_quantity, counted_quantity, counted_by, counted_at)`)
+	fixedTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	counts := []model.StockTakeCount{{ProductID: 1, LocationID: 2, CountedQuantity: 38}}
+
+	tests := []struct {
+		name      string
+		mockSetup func(sqlmock.Sqlmock)
+		wantErr   bool
+		errIs     error
+	}{
+		{
+			name: "count is stored",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(selectStockTake).
+					WithArgs(4).
+					WillReturnRows(sqlmock.NewRows(stockTakeRowColumns).
+						AddRow(4, 1, "{2}", "OPEN", 3, 0, nil, fixedTime, fixedTime))
+				mock.ExpectExec(upsertCount).
+					WithArgs(4, 1, 2, 38, 3, 1, pq.Array([]int64{2})).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "location outside the stock take",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(selectStockTake).
+					WithArgs(4).
+					WillReturnRows(sqlmock.NewRows(stockTakeRowColumns).
+						AddRow(4, 1, "{5}", "OPEN", 3, 0, nil, fixedTime, fixedTime))
+				mock.ExpectExec(upsertCount).
+					WithArgs(4, 1, 2, 38, 3, 1, pq.Array([]int64{5})).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+			errIs:   model.ErrLocationNotFound,
+		},
+		{
+			name: "stock take not found",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(selectStockTake).
+					WithArgs(4).
+					WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+			errIs:   model.ErrStockTakeNotFound,
+		},
+		{
+			name: "approved stock take",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(selectStockTake).
+					WithArgs(4).
+					WillReturnRows(sqlmock.NewRows(stockTakeRowColumns).
+						AddRow(4, 1, nil, "APPROVED", 3, 3, fixedTime, fixedTime, fixedTime))
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+			errIs:   model.ErrStockTakeNotOpen,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rw := &dbReadWriter{db: db}
+			tt.mockSetup(mock)
+
+			err := rw.RecordStockTakeCounts(context.Background(), 4, 3, counts)
+			if tt.wantErr {
+				assert.Error(t, err)
+				if tt.errIs != nil {
+					assert.ErrorIs(t, err, tt.errIs)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func Test_ApproveStockTake(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	selectStockTake := regexp.QuoteMeta(`SELECT ` + stockTakeColumns + ` FROM trx_stock_take WHERE stock_take_id = $1 FOR UPDATE`)
+	selectVariances := regexp.QuoteMeta(`SELECT l.line_id, l.product_id, l.location_id, l.counted_quantity - l.expected_quantity, p.is_serialized FROM trx_stock_take_line as l`)
+	varianceColumns := []string{"line_id", "product_id", "location_id", "variance", "is_serialized"}
+	fixedTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	expectLockStock := func(mock sqlmock.Sqlmock, productID, locationID, onHand, reserved int) {
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO mst_stock`)).
+			WithArgs(productID, pq.Array([]int64{int64(locationID)})).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT location_id, stock_quantity, reserved_quantity FROM mst_stock`)).
+			WithArgs(productID, pq.Array([]int64{int64(locationID)})).
+			WillReturnRows(sqlmock.NewRows([]string{"location_id", "stock_quantity", "reserved_quantity"}).AddRow(locationID, onHand, reserved))
+		expectStockCostLock(mock, int64(productID), int64(locationID))
+	}
+	expectMovement := func(mock sqlmock.Sqlmock, productID, locationID, delta, balance int, transactionID driver.Value) {
+		if delta > 0 {
+			expectReceivable(mock, int64(productID), int64(locationID))
+		}
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE mst_stock SET stock_quantity = $1 WHERE product_id = $2 AND location_id = $3`)).
+			WithArgs(balance, productID, locationID).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		expectUncostedMovement(mock, int64(productID), 1, int64(balance-delta), int64(delta))
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO trx_stock`)).
+			WithArgs(productID, 1, locationID, model.StockAdjustment, delta, balance, 3, "", nil, model.AdjustmentReasonStockTake, "", "4", 0, 0.0, 0.0, "", 0).
+			WillReturnRows(sqlmock.NewRows([]string{"transaction_id"}).AddRow(transactionID))
+	}
+
+	tests := []struct {
+		name      string
+		mockSetup func(sqlmock.Sqlmock)
+		errIs     error
+	}{
+		{
+			name: "variances are posted as adjustments",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(selectStockTake).
+					WithArgs(4).
+					WillReturnRows(sqlmock.NewRows(stockTakeRowColumns).
+						AddRow(4, 1, nil, "OPEN", 3, 0, nil, fixedTime, fixedTime))
+				mock.ExpectQuery(selectVariances).
+					WithArgs(4).
+					WillReturnRows(sqlmock.NewRows(varianceColumns).
+						AddRow(10, 1, 2, 3, false).
+						AddRow(11, 2, 2, -4, false))
+
+				expectLockStock(mock, 1, 2, 40, 0)
+				expectMovement(mock, 1, 2, 3, 43, 21)
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE trx_stock_take_line SET transaction_id = $1 WHERE line_id = $2`)).
+					WithArgs(21, 10).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+
+				expectLockStock(mock, 2, 2, 10, 0)
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT lot_id, lot_number, expiry_date, quantity FROM mst_stock_lot`)).
+					WithArgs(2, 2).
+					WillReturnRows(sqlmock.NewRows([]string{"lot_id", "lot_number", "expiry_date", "quantity"}))
+				expectMovement(mock, 2, 2, -4, 6, 22)
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE trx_stock_take_line SET transaction_id = $1 WHERE line_id = $2`)).
+					WithArgs(22, 11).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE trx_stock_take SET status = $1, approved_by = $2, approved_at = NOW() WHERE stock_take_id = $3`)).
+					WithArgs(model.StockTakeApproved, 3, 4).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "shortage would take reserved stock",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(selectStockTake).
+					WithArgs(4).
+					WillReturnRows(sqlmock.NewRows(stockTakeRowColumns).
+						AddRow(4, 1, nil, "OPEN", 3, 0, nil, fixedTime, fixedTime))
+				mock.ExpectQuery(selectVariances).
+					WithArgs(4).
+					WillReturnRows(sqlmock.NewRows(varianceColumns).
+						AddRow(11, 2, 2, -4, false))
+				expectLockStock(mock, 2, 2, 10, 8)
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT lot_id, lot_number, expiry_date, quantity FROM mst_stock_lot`)).
+					WithArgs(2, 2).
+					WillReturnRows(sqlmock.NewRows([]string{"lot_id", "lot_number", "expiry_date", "quantity"}))
+				mock.ExpectRollback()
+			},
+			errIs: model.ErrInsufficientStock,
+		},
+		{
+			name: "serialized product counted short",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(selectStockTake).
+					WithArgs(4).
+					WillReturnRows(sqlmock.NewRows(stockTakeRowColumns).
+						AddRow(4, 1, nil, "OPEN", 3, 0, nil, fixedTime, fixedTime))
+				mock.ExpectQuery(selectVariances).
+					WithArgs(4).
+					WillReturnRows(sqlmock.NewRows(varianceColumns).
+						AddRow(12, 5, 2, -1, true))
+				mock.ExpectRollback()
+			},
+			errIs: model.ErrSerializedVariance,
+		},
+		{
+			name: "cancelled stock take",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(selectStockTake).
+					WithArgs(4).
+					WillReturnRows(sqlmock.NewRows(stockTakeRowColumns).
+						AddRow(4, 1, nil, "CANCELLED", 3, 0, nil, fixedTime, fixedTime))
+				mock.ExpectRollback()
+			},
+			errIs: model.ErrStockTakeNotOpen,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rw := &dbReadWriter{db: db}
+			tt.mockSetup(mock)
+
+			err := rw.ApproveStockTake(context.Background(), 4, 3)
+			if tt.errIs != nil {
+				assert.ErrorIs(t, err, tt.errIs)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func Test_ReadStockTakeByID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	fixedTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT ` + stockTakeColumns + ` FROM trx_stock_take WHERE stock_take_id = $1`)).
+		WithArgs(4).
+		WillReturnRows(sqlmock.NewRows(stockTakeRowColumns).
+			AddRow(4, 1, "{2}", "OPEN", 3, 0, nil, fixedTime, fixedTime))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT l.line_id, l.product_id, m.product_name, m.sku, l.location_id, l.expected_quantity, l.counted_quantity, COALESCE(l.transaction_id, 0)`)).
+		WithArgs(4).
+		WillReturnRows(sqlmock.NewRows([]string{"line_id", "product_id", "product_name", "sku", "location_id", "expected_quantity", "counted_quantity", "transaction_id"}).
+			AddRow(10, 1, "Kopi Arabica", "KOPI-001", 2, 40, 43, 0).
+			AddRow(11, 2, "Teh Hijau", "TEH-001", 2, 10, nil, 0))
+
+	rw := &dbReadWriter{db: db}
+	got, err := rw.ReadStockTakeByID(context.Background(), 4)
+	assert.NoError(t, err)
+
+	counted, variance := int64(43), int64(3)
+	assert.Equal(t, model.StockTake{
+		StockTakeID: 4,
+		WarehouseID: 1,
+		LocationIDs: []int64{2},
+		Status:      model.StockTakeOpen,
+		CreatedBy:   3,
+		CreatedAt:   fixedTime,
+		UpdatedAt:   fixedTime,
+		Lines: []model.StockTakeLine{
+			{LineID: 10, ProductID: 1, ProductName: "Kopi Arabica", SKU: "KOPI-001", LocationID: 2, ExpectedQuantity: 40, CountedQuantity: &counted, Variance: &variance},
+			{LineID: 11, ProductID: 2, ProductName: "Teh Hijau", SKU: "TEH-001", LocationID: 2, ExpectedQuantity: 10},
+		},
+	}, got)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func Test_ReadStockTakeByID_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT ` + stockTakeColumns + ` FROM trx_stock_take WHERE stock_take_id = $1`)).
+		WithArgs(9).
+		WillReturnError(sql.ErrNoRows)
+
+	rw := &dbReadWriter{db: db}
+	_, err = rw.ReadStockTakeByID(context.Background(), 9)
+	assert.ErrorIs(t, err, model.ErrStockTakeNotFound)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
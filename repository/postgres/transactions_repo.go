@@ -2,28 +2,65 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
 
 	"github.com/budsx/retail-management/model"
+	"github.com/lib/pq"
 )
 
-func (rw *dbReadWriter) CreateStockTransaction(ctx context.Context, transaction model.StockTransaction) error {
-	stockAdjustment := `INSERT INTO trx_stock (product_id, warehouse_id, transaction_type, quantity, created_by) 
-              VALUES ($1, $2, $3, $4, $5)`
+const stockTransactionColumns = `transaction_id, product_id, warehouse_id, COALESCE(location_id, 0), transaction_type, quantity, balance_after, transaction_date, created_by, COALESCE(destination_warehouse_id, 0), COALESCE(destination_location_id, 0), status, COALESCE(reference_id, 0), COALESCE(lot_number, ''), expiry_date, COALESCE(reason_code, ''), COALESCE(notes, ''), COALESCE(reference_document, ''), COALESCE(reversal_of_id, 0), unit_cost, COALESCE(cost_amount, 0), COALESCE(unit_code, ''), COALESCE(unit_quantity, 0)`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
 
-	updateStock := `UPDATE mst_stock SET stock_quantity = $1 WHERE product_id = $2 AND warehouse_id = $3`
+func scanStockTransaction(row rowScanner) (model.StockTransaction, error) {
+	var transaction model.StockTransaction
+	err := row.Scan(
+		&transaction.TransactionID,
+		&transaction.ProductID,
+		&transaction.WarehouseID,
+		&transaction.LocationID,
+		&transaction.TransactionType,
+		&transaction.Quantity,
+		&transaction.BalanceAfter,
+		&transaction.TransactionDate,
+		&transaction.CreatedBy,
+		&transaction.DestinationWarehouseID,
+		&transaction.DestinationLocationID,
+		&transaction.Status,
+		&transaction.ReferenceID,
+		&transaction.LotNumber,
+		&transaction.ExpiryDate,
+		&transaction.ReasonCode,
+		&transaction.Notes,
+		&transaction.ReferenceDocument,
+		&transaction.ReversalOfID,
+		&transaction.UnitCost,
+		&transaction.CostAmount,
+		&transaction.Unit,
+		&transaction.UnitQuantity,
+	)
+	return transaction, err
+}
 
-	tx, err := rw.db.Begin()
+func (rw *dbReadWriter) CreateStockTransaction(ctx context.Context, transaction model.StockTransaction) error {
+	tx, err := rw.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
-	_, err = tx.Exec(stockAdjustment, transaction.ProductID, transaction.WarehouseID, transaction.TransactionType, transaction.Quantity, transaction.CreatedBy)
+	stocks, err := lockMovementStock(ctx, tx, []model.StockTransaction{transaction})
 	if err != nil {
 		return err
 	}
 
-	_, err = tx.Exec(updateStock, transaction.Quantity, transaction.ProductID, transaction.WarehouseID)
+	_, err = postStockTransaction(ctx, tx, transaction, stocks)
 	if err != nil {
 		return err
 	}
@@ -31,8 +68,519 @@ func (rw *dbReadWriter) CreateStockTransaction(ctx context.Context, transaction
 	return tx.Commit()
 }
 
+// CreateStockTransactionBatch posts the lines in one database transaction and
+// returns the id of each line's first ledger row. Each line is posted behind
+// its own savepoint so that all lines are tried; if any of them fails nothing
+// is committed and a *model.StockBatchError lists the failing lines.
+func (rw *dbReadWriter) CreateStockTransactionBatch(ctx context.Context, lines []model.StockTransaction) ([]int64, error) {
+	tx, err := rw.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	stocks, err := lockMovementStock(ctx, tx, lines)
+	if err != nil {
+		return nil, err
+	}
+
+	transactionIDs := make([]int64, len(lines))
+	var batchErr model.StockBatchError
+	for i, line := range lines {
+		_, err = tx.ExecContext(ctx, `SAVEPOINT batch_line`)
+		if err != nil {
+			return nil, err
+		}
+
+		transactionIDs[i], err = postStockTransaction(ctx, tx, line, stocks)
+		if err != nil {
+			if !isStockRejection(err) {
+				return nil, err
+			}
+			_, rollbackErr := tx.ExecContext(ctx, `ROLLBACK TO SAVEPOINT batch_line`)
+			if rollbackErr != nil {
+				return nil, rollbackErr
+			}
+			batchErr.Lines = append(batchErr.Lines, model.StockBatchLineError{Line: i + 1, Error: err.Error()})
+			continue
+		}
+
+		_, err = tx.ExecContext(ctx, `RELEASE SAVEPOINT batch_line`)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(batchErr.Lines) > 0 {
+		return nil, &batchErr
+	}
+
+	return transactionIDs, tx.Commit()
+}
+
+// isStockRejection reports whether a posting failed on the stock it found, such
+// as too little of it, rather than on the database.
+func isStockRejection(err error) bool {
+	return errors.Is(err, model.ErrInsufficientStock) ||
+		errors.Is(err, model.ErrInvalidQuantity) ||
+		errors.Is(err, model.ErrSerialNotAvailable) ||
+		errors.Is(err, model.ErrArchived)
+}
+
+// postStockTransaction posts an IN, OUT or ADJUSTMENT request to stock locked
+// with lockMovementStock. stocks is only updated when the posting succeeds.
+func postStockTransaction(ctx context.Context, tx *sql.Tx, transaction model.StockTransaction, stocks map[productLocation]locationStock) (int64, error) {
+	if transaction.TransactionType == model.StockOut && len(transaction.Components) > 0 {
+		return postBundleOut(ctx, tx, transaction, stocks)
+	}
+
+	key := productLocation{productID: transaction.ProductID, locationID: transaction.LocationID}
+	stock := stocks[key]
+
+	var transactionID int64
+	var err error
+	switch {
+	case transaction.TransactionType == model.StockOut:
+		transactionID, err = postStockOut(ctx, tx, transaction, &stock, transaction.Quantity)
+	case transaction.TransactionType == model.StockAdjustment && transaction.Quantity < 0:
+		transactionID, err = postStockOut(ctx, tx, transaction, &stock, -transaction.Quantity)
+	default:
+		transactionID, err = postStockIn(ctx, tx, transaction, &stock, transaction.Quantity)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	stocks[key] = stock
+	return transactionID, nil
+}
+
+// postBundleOut ships a bundle, taking the finished bundles available at the
+// location first and the components of the rest. It returns the id of the
+// first ledger row posted.
+func postBundleOut(ctx context.Context, tx *sql.Tx, transaction model.StockTransaction, stocks map[productLocation]locationStock) (int64, error) {
+	posted := map[productLocation]locationStock{}
+	var firstID int64
+
+	bundleKey := productLocation{productID: transaction.ProductID, locationID: transaction.LocationID}
+	bundleStock := stocks[bundleKey]
+	finished := bundleStock.onHand - bundleStock.reserved
+	if finished > transaction.Quantity {
+		finished = transaction.Quantity
+	}
+	if finished > 0 {
+		transactionID, err := postStockOut(ctx, tx, transaction, &bundleStock, finished)
+		if err != nil {
+			return 0, err
+		}
+		posted[bundleKey] = bundleStock
+		firstID = transactionID
+	}
+
+	notes := transaction.Notes
+	if notes == "" {
+		notes = fmt.Sprintf("Bundle %d", transaction.ProductID)
+	}
+
+	shortfall := transaction.Quantity - finished
+	for _, component := range transaction.Components {
+		if shortfall == 0 {
+			break
+		}
+
+		movement := model.StockTransaction{
+			ProductID:         component.ComponentID,
+			WarehouseID:       transaction.WarehouseID,
+			LocationID:        transaction.LocationID,
+			TransactionType:   model.StockOut,
+			CreatedBy:         transaction.CreatedBy,
+			Notes:             notes,
+			ReferenceDocument: transaction.ReferenceDocument,
+		}
+
+		key := productLocation{productID: component.ComponentID, locationID: transaction.LocationID}
+		stock := stocks[key]
+		transactionID, err := postStockOut(ctx, tx, movement, &stock, component.Quantity*shortfall)
+		if err != nil {
+			return 0, fmt.Errorf("bundle %d: %w", transaction.ProductID, err)
+		}
+		posted[key] = stock
+
+		if firstID == 0 {
+			firstID = transactionID
+		}
+	}
+
+	for key, stock := range posted {
+		stocks[key] = stock
+	}
+
+	return firstID, nil
+}
+
+// AssembleBundle turns components at the assembly's location into Quantity
+// finished bundles, valued at the cost of their components, and returns the id
+// of the bundle's ledger row.
+func (rw *dbReadWriter) AssembleBundle(ctx context.Context, assembly model.StockTransaction) (int64, error) {
+	tx, err := rw.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	stocks, err := lockMovementStock(ctx, tx, []model.StockTransaction{assembly})
+	if err != nil {
+		return 0, err
+	}
+
+	var componentValue float64
+	for _, component := range assembly.Components {
+		movement := model.StockTransaction{
+			ProductID:         component.ComponentID,
+			WarehouseID:       assembly.WarehouseID,
+			LocationID:        assembly.LocationID,
+			TransactionType:   model.StockAssembly,
+			CreatedBy:         assembly.CreatedBy,
+			Notes:             assembly.Notes,
+			ReferenceDocument: assembly.ReferenceDocument,
+		}
+
+		stock := stocks[productLocation{productID: component.ComponentID, locationID: assembly.LocationID}]
+		_, value, err := postValuedStockOut(ctx, tx, movement, &stock, component.Quantity*assembly.Quantity)
+		if err != nil {
+			return 0, err
+		}
+		componentValue += value
+	}
+
+	// Assembled bundles are worth the components that went into them
+	unitCost := componentValue / float64(assembly.Quantity)
+	assembly.UnitCost = &unitCost
+
+	stock := stocks[productLocation{productID: assembly.ProductID, locationID: assembly.LocationID}]
+	transactionID, err := postStockIn(ctx, tx, assembly, &stock, assembly.Quantity)
+	if err != nil {
+		return 0, err
+	}
+
+	return transactionID, tx.Commit()
+}
+
+type productLocation struct {
+	productID  int64
+	locationID int64
+}
+
+// lockMovementStock locks the stock rows of every product and location the
+// movements touch, bundle components included, one product at a time in
+// product order, so that postings touching the same products queue up behind
+// each other instead of deadlocking.
+func lockMovementStock(ctx context.Context, tx *sql.Tx, movements []model.StockTransaction) (map[productLocation]locationStock, error) {
+	seen := map[productLocation]bool{}
+	locationsByProduct := map[int64][]int64{}
+	add := func(productID, locationID int64) {
+		key := productLocation{productID: productID, locationID: locationID}
+		if !seen[key] {
+			seen[key] = true
+			locationsByProduct[productID] = append(locationsByProduct[productID], locationID)
+		}
+	}
+	for _, movement := range movements {
+		add(movement.ProductID, movement.LocationID)
+		for _, component := range movement.Components {
+			add(component.ComponentID, movement.LocationID)
+		}
+	}
+
+	productIDs := make([]int64, 0, len(locationsByProduct))
+	for productID := range locationsByProduct {
+		productIDs = append(productIDs, productID)
+	}
+	sort.Slice(productIDs, func(i, j int) bool { return productIDs[i] < productIDs[j] })
+
+	stocks := map[productLocation]locationStock{}
+	for _, productID := range productIDs {
+		productStocks, err := lockLocationStock(ctx, tx, productID, locationsByProduct[productID]...)
+		if err != nil {
+			return nil, err
+		}
+		for locationID, stock := range productStocks {
+			stocks[productLocation{productID: productID, locationID: locationID}] = stock
+		}
+	}
+
+	return stocks, nil
+}
+
+// postStockIn books quantity into a location locked with lockLocationStock and
+// adds it to the movement's lot and serial numbers, if it has them. Neither
+// the product nor the location may be archived.
+func postStockIn(ctx context.Context, tx *sql.Tx, transaction model.StockTransaction, stock *locationStock, quantity int64) (int64, error) {
+	err := lockReceivingStock(ctx, tx, transaction.ProductID, transaction.LocationID)
+	if err != nil {
+		return 0, err
+	}
+
+	transactionID, _, err := postStockMovement(ctx, tx, transaction, stock, quantity)
+	if err != nil {
+		return 0, err
+	}
+
+	if transaction.LotNumber != "" {
+		err = creditStockLot(ctx, tx, transaction, quantity)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if len(transaction.SerialNumbers) > 0 {
+		serialIDs, err := receiveSerialUnits(ctx, tx, transaction)
+		if err != nil {
+			return 0, err
+		}
+
+		err = linkSerialUnits(ctx, tx, transactionID, serialIDs)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return transactionID, nil
+}
+
+// lockReceivingStock share-locks the product and the location stock is coming
+// into, so neither can be archived before the movement commits, and rejects
+// the movement when either already is.
+func lockReceivingStock(ctx context.Context, tx *sql.Tx, productID, locationID int64) error {
+	lockArchival := `SELECT p.archived_at IS NOT NULL, l.archived_at IS NOT NULL FROM mst_product p, mst_location l 
+		WHERE p.product_id = $1 AND l.location_id = $2 FOR SHARE`
+
+	var productArchived, locationArchived bool
+	err := tx.QueryRowContext(ctx, lockArchival, productID, locationID).Scan(&productArchived, &locationArchived)
+	if err != nil {
+		return err
+	}
+	if productArchived {
+		return fmt.Errorf("%w: product %d", model.ErrArchived, productID)
+	}
+	if locationArchived {
+		return fmt.Errorf("%w: location %d", model.ErrArchived, locationID)
+	}
+
+	return nil
+}
+
+// postStockOut takes quantity out of a location locked with lockLocationStock,
+// posting one ledger row per lot it is picked from, and returns the id of the
+// first row. Serial numbers, when given, decide the lots instead of FEFO.
+func postStockOut(ctx context.Context, tx *sql.Tx, transaction model.StockTransaction, stock *locationStock, quantity int64) (int64, error) {
+	transactionID, _, err := postValuedStockOut(ctx, tx, transaction, stock, quantity)
+	return transactionID, err
+}
+
+// postValuedStockOut is postStockOut that also returns the cost of the stock
+// taken out.
+func postValuedStockOut(ctx context.Context, tx *sql.Tx, transaction model.StockTransaction, stock *locationStock, quantity int64) (int64, float64, error) {
+	picks, err := pickOutgoingStock(ctx, tx, transaction, quantity)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var firstID int64
+	var value float64
+	for _, pick := range picks {
+		movement := transaction
+		movement.LotNumber = pick.lot.lotNumber
+		movement.ExpiryDate = pick.lot.expiryDate
+
+		transactionID, cost, err := postStockMovement(ctx, tx, movement, stock, -pick.quantity)
+		if err != nil {
+			return 0, 0, err
+		}
+		value -= cost.amount
+
+		if pick.lot.lotID != 0 {
+			err = debitStockLot(ctx, tx, pick.lot.lotID, pick.quantity)
+			if err != nil {
+				return 0, 0, err
+			}
+		}
+
+		if len(pick.serialIDs) > 0 {
+			err = moveSerialUnits(ctx, tx, pick.serialIDs, model.SerialShipped, 0, 0)
+			if err != nil {
+				return 0, 0, err
+			}
+
+			err = linkSerialUnits(ctx, tx, transactionID, pick.serialIDs)
+			if err != nil {
+				return 0, 0, err
+			}
+		}
+
+		if firstID == 0 {
+			firstID = transactionID
+		}
+	}
+
+	return firstID, model.RoundCost(value), nil
+}
+
+// pickOutgoingStock locks the lots, and serial numbers if any, of the stock
+// leaving the movement's location and decides what is taken from where.
+func pickOutgoingStock(ctx context.Context, tx *sql.Tx, transaction model.StockTransaction, quantity int64) ([]lotPick, error) {
+	lots, err := lockStockLots(ctx, tx, transaction.ProductID, transaction.LocationID)
+	if err != nil {
+		return nil, err
+	}
+
+	var picks []lotPick
+	if len(transaction.SerialNumbers) > 0 {
+		var units []serialUnit
+		units, err = lockSerialUnits(ctx, tx, transaction.ProductID, transaction.LocationID, transaction.SerialNumbers)
+		if err != nil {
+			return nil, err
+		}
+		picks, err = pickSerials(lots, units)
+	} else {
+		picks, err = pickLots(lots, transaction.LotNumber, quantity)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("product %d at location %d: %w", transaction.ProductID, transaction.LocationID, err)
+	}
+
+	return picks, nil
+}
+
+// postStockMovement applies delta to a location balance locked with
+// lockLocationStock, values it and appends the movement to the ledger.
+func postStockMovement(ctx context.Context, tx *sql.Tx, transaction model.StockTransaction, stock *locationStock, delta int64) (int64, movementCost, error) {
+	insertMovement := `INSERT INTO trx_stock (product_id, warehouse_id, location_id, transaction_type, quantity, balance_after, created_by, lot_number, expiry_date, reason_code, notes, reference_document, reversal_of_id, unit_cost, cost_amount, unit_code, unit_quantity) 
+              VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), $9, NULLIF($10, ''), NULLIF($11, ''), NULLIF($12, ''), NULLIF($13, 0), $14, $15, NULLIF($16, ''), NULLIF($17, 0)) RETURNING transaction_id`
+
+	balance, err := updateLocationStock(ctx, tx, transaction.ProductID, transaction.LocationID, stock, delta)
+	if err != nil {
+		return 0, movementCost{}, err
+	}
+
+	cost, err := postMovementCost(ctx, tx, transaction, delta)
+	if err != nil {
+		return 0, movementCost{}, err
+	}
+
+	var transactionID int64
+	err = tx.QueryRowContext(ctx, insertMovement,
+		transaction.ProductID,
+		transaction.WarehouseID,
+		transaction.LocationID,
+		transaction.TransactionType,
+		delta,
+		balance,
+		transaction.CreatedBy,
+		transaction.LotNumber,
+		transaction.ExpiryDate,
+		transaction.ReasonCode,
+		transaction.Notes,
+		transaction.ReferenceDocument,
+		transaction.ReversalOfID,
+		cost.unitCost,
+		cost.amount,
+		transaction.Unit,
+		transaction.UnitQuantity,
+	).Scan(&transactionID)
+	if err != nil {
+		return 0, movementCost{}, err
+	}
+
+	return transactionID, cost, nil
+}
+
+// locationStock is a product's locked balance at one location.
+type locationStock struct {
+	onHand   int64
+	reserved int64
+}
+
+// lockLocationStock locks the product's stock rows at the given locations and
+// returns their balances, together with the product's cost positions in their
+// warehouses. A location that has never held the product gets a zero balance
+// row first. Rows are locked in location order so that postings touching
+// several locations queue up behind each other instead of deadlocking.
+func lockLocationStock(ctx context.Context, tx *sql.Tx, productID int64, locationIDs ...int64) (map[int64]locationStock, error) {
+	insertMissingStock := `INSERT INTO mst_stock (product_id, warehouse_id, location_id, stock_quantity) 
+		SELECT $1, warehouse_id, location_id, 0 FROM mst_location WHERE location_id = ANY($2) 
+		ON CONFLICT (product_id, location_id) DO NOTHING`
+
+	selectForUpdate := `SELECT location_id, stock_quantity, reserved_quantity FROM mst_stock 
+		WHERE product_id = $1 AND location_id = ANY($2) 
+		ORDER BY location_id FOR UPDATE`
+
+	_, err := tx.ExecContext(ctx, insertMissingStock, productID, pq.Array(locationIDs))
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryContext(ctx, selectForUpdate, productID, pq.Array(locationIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stocks := make(map[int64]locationStock, len(locationIDs))
+	for rows.Next() {
+		var locationID int64
+		var stock locationStock
+		if err := rows.Scan(&locationID, &stock.onHand, &stock.reserved); err != nil {
+			return nil, err
+		}
+		stocks[locationID] = stock
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, locationID := range locationIDs {
+		if _, ok := stocks[locationID]; !ok {
+			return nil, fmt.Errorf("location with id %d not found", locationID)
+		}
+	}
+
+	err = lockStockCost(ctx, tx, productID, locationIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	return stocks, nil
+}
+
+// updateLocationStock applies delta to a balance previously locked with
+// lockLocationStock, keeping stock in step, and returns the new balance.
+// Reserved stock cannot be taken by the movement.
+func updateLocationStock(ctx context.Context, tx *sql.Tx, productID, locationID int64, stock *locationStock, delta int64) (int64, error) {
+	updateStock := `UPDATE mst_stock SET stock_quantity = $1 WHERE product_id = $2 AND location_id = $3`
+
+	newBalance := stock.onHand + delta
+	if newBalance < stock.reserved {
+		return 0, fmt.Errorf("%w: product %d at location %d has %d available, requested %d", model.ErrInsufficientStock, productID, locationID, stock.onHand-stock.reserved, -delta)
+	}
+	// Balances are kept in INT columns
+	if newBalance > math.MaxInt32 {
+		return 0, fmt.Errorf("%w: product %d at location %d would hold %d", model.ErrInvalidQuantity, productID, locationID, newBalance)
+	}
+
+	_, err := tx.ExecContext(ctx, updateStock, newBalance, productID, locationID)
+	if err != nil {
+		return 0, err
+	}
+
+	stock.onHand = newBalance
+	return newBalance, nil
+}
+
+// GetTotalStockByProductAndWarehouse returns the warehouse balance rolled up from all of its locations.
 func (rw *dbReadWriter) GetTotalStockByProductAndWarehouse(ctx context.Context, productID, warehouseID int64) (int64, error) {
-	selectTotalStock := `SELECT stock_quantity FROM mst_stock WHERE product_id = $1 AND warehouse_id = $2`
+	selectTotalStock := `SELECT COALESCE(SUM(stock_quantity), 0) FROM mst_stock WHERE product_id = $1 AND warehouse_id = $2`
 
 	var totalStock int64
 	err := rw.db.QueryRowContext(ctx, selectTotalStock, productID, warehouseID).Scan(&totalStock)
@@ -43,11 +591,31 @@ func (rw *dbReadWriter) GetTotalStockByProductAndWarehouse(ctx context.Context,
 	return totalStock, nil
 }
 
-func (rw *dbReadWriter) GetStockTransactions(ctx context.Context, userID int64) ([]model.StockTransaction, error) {
-	selectAllTransaction := `SELECT transaction_id, product_id, warehouse_id, transaction_type, quantity, transaction_date, created_by 
-	          FROM trx_stock WHERE created_by = $1`
+func (rw *dbReadWriter) GetTotalStockByProductAndLocation(ctx context.Context, productID, locationID int64) (int64, error) {
+	selectTotalStock := `SELECT stock_quantity FROM mst_stock WHERE product_id = $1 AND location_id = $2`
+
+	var totalStock int64
+	err := rw.db.QueryRowContext(ctx, selectTotalStock, productID, locationID).Scan(&totalStock)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return totalStock, nil
+}
+
+// GetStockTransactions lists up to limit of the user's transactions matching
+// filter that follow cursor, newest first.
+func (rw *dbReadWriter) GetStockTransactions(ctx context.Context, filter model.StockTransactionFilter, cursor model.Cursor, limit int32) ([]model.StockTransaction, error) {
+	keyset, order := idKeyset("transaction_id", true, cursor, 3)
+	selectAllTransaction := `SELECT ` + stockTransactionColumns + `
+	          FROM trx_stock WHERE created_by = $1 
+	          AND (COALESCE(CARDINALITY($2::text[]), 0) = 0 OR reason_code = ANY($2))` + keyset + order + ` 
+	          LIMIT $4`
 
-	rows, err := rw.db.QueryContext(ctx, selectAllTransaction, userID)
+	rows, err := rw.db.QueryContext(ctx, selectAllTransaction, filter.CreatedBy, pq.Array(filter.ReasonCodes), cursor.ID, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -55,23 +623,29 @@ func (rw *dbReadWriter) GetStockTransactions(ctx context.Context, userID int64)
 
 	var transactions []model.StockTransaction
 	for rows.Next() {
-		var transaction model.StockTransaction
-		err := rows.Scan(&transaction.TransactionID, &transaction.ProductID, &transaction.WarehouseID, &transaction.TransactionType, &transaction.Quantity, &transaction.TransactionDate, &transaction.CreatedBy)
+		transaction, err := scanStockTransaction(rows)
 		if err != nil {
 			return nil, err
 		}
 		transactions = append(transactions, transaction)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
+	if cursor.Backward {
+		reverseRows(transactions)
+	}
 	return transactions, nil
 }
 
 func (rw *dbReadWriter) GetStockTransactionByID(ctx context.Context, transactionID int64) (model.StockTransaction, error) {
-	var transaction model.StockTransaction
-
-	query := `SELECT transaction_id, product_id, warehouse_id, transaction_type, quantity, transaction_date, created_by 
+	query := `SELECT ` + stockTransactionColumns + `
 	          FROM trx_stock WHERE transaction_id = $1`
-	err := rw.db.QueryRowContext(ctx, query, transactionID).Scan(&transaction.TransactionID, &transaction.ProductID, &transaction.WarehouseID, &transaction.TransactionType, &transaction.Quantity, &transaction.TransactionDate, &transaction.CreatedBy)
+	transaction, err := scanStockTransaction(rw.db.QueryRowContext(ctx, query, transactionID))
+	if err == sql.ErrNoRows {
+		return transaction, fmt.Errorf("%w: %d", model.ErrTransactionNotFound, transactionID)
+	}
 	if err != nil {
 		return transaction, err
 	}
@@ -80,11 +654,12 @@ func (rw *dbReadWriter) GetStockTransactionByID(ctx context.Context, transaction
 }
 
 func (rw *dbReadWriter) GetTotalStocks(ctx context.Context) ([]model.ProductStock, error) {
-	query := `SELECT m.product_id, SUM(quantity) as total_stock, m.product_name, m.sku
-	          FROM trx_stock as t
-			  LEFT JOIN mst_product as m
-			  ON t.product_id = m.product_id
-	          GROUP BY m.product_id`
+	query := `SELECT m.product_id, l.total_stock, COALESCE(r.reserved_stock, 0), m.product_name, m.sku, COALESCE(m.category_id, 0), COALESCE(m.parent_id, 0)
+	          FROM (SELECT product_id, SUM(quantity) as total_stock FROM trx_stock GROUP BY product_id) as l
+	          INNER JOIN mst_product as m ON l.product_id = m.product_id
+	          LEFT JOIN (SELECT product_id, SUM(reserved_quantity) as reserved_stock FROM mst_stock GROUP BY product_id) as r
+	          ON l.product_id = r.product_id
+	          ORDER BY m.product_id`
 
 	rows, err := rw.db.QueryContext(ctx, query)
 	if err != nil {
@@ -92,23 +667,15 @@ func (rw *dbReadWriter) GetTotalStocks(ctx context.Context) ([]model.ProductStoc
 	}
 	defer rows.Close()
 
-	var totalStock []model.ProductStock
-	for rows.Next() {
-		var productStock model.ProductStock
-		err := rows.Scan(&productStock.ProductID, &productStock.TotalStock, &productStock.ProductName, &productStock.SKU)
-		if err != nil {
-			return nil, err
-		}
-		totalStock = append(totalStock, productStock)
-	}
-	return totalStock, nil
+	return scanProductStocks(rows)
 }
 
 func (rw *dbReadWriter) GetTotalStockByLocation(ctx context.Context, locationID int64) ([]model.ProductStock, error) {
-	query := `SELECT product_id, SUM(quantity) as total_stock
-	          FROM trx_stock
-	          WHERE warehouse_id = $1
-	          GROUP BY product_id`
+	query := `SELECT m.product_id, l.total_stock, COALESCE(s.reserved_quantity, 0), m.product_name, m.sku, COALESCE(m.category_id, 0), COALESCE(m.parent_id, 0)
+	          FROM (SELECT product_id, SUM(quantity) as total_stock FROM trx_stock WHERE location_id = $1 GROUP BY product_id) as l
+	          INNER JOIN mst_product as m ON l.product_id = m.product_id
+	          LEFT JOIN mst_stock as s ON l.product_id = s.product_id AND s.location_id = $1
+	          ORDER BY m.product_id`
 
 	rows, err := rw.db.QueryContext(ctx, query, locationID)
 	if err != nil {
@@ -116,14 +683,24 @@ func (rw *dbReadWriter) GetTotalStockByLocation(ctx context.Context, locationID
 	}
 	defer rows.Close()
 
+	return scanProductStocks(rows)
+}
+
+func scanProductStocks(rows *sql.Rows) ([]model.ProductStock, error) {
 	totalStock := []model.ProductStock{}
 	for rows.Next() {
 		var productStock model.ProductStock
-		err := rows.Scan(&productStock.ProductID, &productStock.TotalStock)
+		err := rows.Scan(&productStock.ProductID, &productStock.TotalStock, &productStock.ReservedStock, &productStock.ProductName, &productStock.SKU, &productStock.CategoryID, &productStock.ParentID)
 		if err != nil {
 			return nil, err
 		}
+		productStock.AvailableStock = productStock.TotalStock - productStock.ReservedStock
 		totalStock = append(totalStock, productStock)
 	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
 	return totalStock, nil
 }
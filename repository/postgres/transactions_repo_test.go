@@ -3,15 +3,26 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"math"
 	"regexp"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/budsx/retail-management/model"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 )
 
+// expectReceivable expects the product and the location stock comes into to
+// be share-locked and found unarchived.
+func expectReceivable(mock sqlmock.Sqlmock, productID, locationID int64) {
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT p.archived_at IS NOT NULL, l.archived_at IS NOT NULL FROM mst_product p, mst_location l WHERE p.product_id = $1 AND l.location_id = $2 FOR SHARE`)).
+		WithArgs(productID, locationID).
+		WillReturnRows(sqlmock.NewRows([]string{"product_archived", "location_archived"}).AddRow(false, false))
+}
+
 func Test_CreateStockTransaction(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -19,49 +30,388 @@ func Test_CreateStockTransaction(t *testing.T) {
 	}
 	defer db.Close()
 
+	lockStock := regexp.QuoteMeta(`SELECT location_id, stock_quantity, reserved_quantity FROM mst_stock WHERE product_id = $1 AND location_id = ANY($2) ORDER BY location_id FOR UPDATE`)
+	lockLots := regexp.QuoteMeta(`SELECT lot_id, lot_number, expiry_date, quantity FROM mst_stock_lot WHERE product_id = $1 AND location_id = $2 AND quantity > 0 ORDER BY expiry_date NULLS LAST, lot_id FOR UPDATE`)
+	lotColumns := []string{"lot_id", "lot_number", "expiry_date", "quantity"}
+	bestBefore := model.NewDate(2025, time.January, 10)
+
 	tests := []struct {
 		name        string
 		transaction model.StockTransaction
 		mockSetup   func(sqlmock.Sqlmock)
-		wantErr     bool
+		wantErr     error
 	}{
 		{
 			name: "Successfully create transaction",
 			transaction: model.StockTransaction{
 				ProductID:       1,
 				WarehouseID:     1,
+				LocationID:      2,
 				TransactionType: "IN",
 				Quantity:        10,
 				CreatedBy:       1,
 			},
 			mockSetup: func(mock sqlmock.Sqlmock) {
 				mock.ExpectBegin()
-				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO trx_stock`)).
-					WithArgs(1, 1, "IN", 10, 1).
+				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO mst_stock`)).
+					WithArgs(1, pq.Array([]int64{2})).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(lockStock).
+					WithArgs(1, pq.Array([]int64{2})).
+					WillReturnRows(sqlmock.NewRows([]string{"location_id", "stock_quantity", "reserved_quantity"}).AddRow(2, 40, 0))
+				expectStockCostLock(mock, 1, 2)
+				expectReceivable(mock, 1, 2)
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE mst_stock`)).
+					WithArgs(50, 1, 2).
 					WillReturnResult(sqlmock.NewResult(1, 1))
+				expectUncostedMovement(mock, 1, 1, 40, 10)
+				mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO trx_stock`)).
+					WithArgs(1, 1, 2, "IN", 10, 50, 1, "", nil, "", "", "", 0, 0.0, 0.0, "", 0).
+					WillReturnRows(sqlmock.NewRows([]string{"transaction_id"}).AddRow(1))
+				mock.ExpectCommit()
+			},
+			wantErr: nil,
+		},
+		{
+			name: "First receipt creates the stock row",
+			transaction: model.StockTransaction{
+				ProductID:       3,
+				WarehouseID:     1,
+				LocationID:      2,
+				TransactionType: "IN",
+				Quantity:        10,
+				CreatedBy:       1,
+			},
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO mst_stock (product_id, warehouse_id, location_id, stock_quantity) SELECT $1, warehouse_id, location_id, 0 FROM mst_location WHERE location_id = ANY($2) ON CONFLICT (product_id, location_id) DO NOTHING`)).
+					WithArgs(3, pq.Array([]int64{2})).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectQuery(lockStock).
+					WithArgs(3, pq.Array([]int64{2})).
+					WillReturnRows(sqlmock.NewRows([]string{"location_id", "stock_quantity", "reserved_quantity"}).AddRow(2, 0, 0))
+				expectStockCostLock(mock, 3, 2)
+				expectReceivable(mock, 3, 2)
 				mock.ExpectExec(regexp.QuoteMeta(`UPDATE mst_stock`)).
-					WithArgs(10, 1, 1).
+					WithArgs(10, 3, 2).
 					WillReturnResult(sqlmock.NewResult(1, 1))
+				expectUncostedMovement(mock, 3, 1, 0, 10)
+				mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO trx_stock`)).
+					WithArgs(3, 1, 2, "IN", 10, 10, 1, "", nil, "", "", "", 0, 0.0, 0.0, "", 0).
+					WillReturnRows(sqlmock.NewRows([]string{"transaction_id"}).AddRow(1))
 				mock.ExpectCommit()
 			},
-			wantErr: false,
+			wantErr: nil,
+		},
+		{
+			name: "Stock out to zero",
+			transaction: model.StockTransaction{
+				ProductID:       1,
+				WarehouseID:     1,
+				LocationID:      2,
+				TransactionType: "OUT",
+				Quantity:        40,
+				CreatedBy:       1,
+			},
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO mst_stock`)).
+					WithArgs(1, pq.Array([]int64{2})).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(lockStock).
+					WithArgs(1, pq.Array([]int64{2})).
+					WillReturnRows(sqlmock.NewRows([]string{"location_id", "stock_quantity", "reserved_quantity"}).AddRow(2, 40, 0))
+				expectStockCostLock(mock, 1, 2)
+				mock.ExpectQuery(lockLots).
+					WithArgs(1, 2).
+					WillReturnRows(sqlmock.NewRows(lotColumns))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE mst_stock`)).
+					WithArgs(0, 1, 2).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				expectUncostedMovement(mock, 1, 1, 40, -40)
+				mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO trx_stock`)).
+					WithArgs(1, 1, 2, "OUT", -40, 0, 1, "", nil, "", "", "", 0, 0.0, 0.0, "", 0).
+					WillReturnRows(sqlmock.NewRows([]string{"transaction_id"}).AddRow(1))
+				mock.ExpectCommit()
+			},
+			wantErr: nil,
+		},
+		{
+			name: "Negative adjustment is taken out with its reason",
+			transaction: model.StockTransaction{
+				ProductID:         1,
+				WarehouseID:       1,
+				LocationID:        2,
+				TransactionType:   model.StockAdjustment,
+				Quantity:          -3,
+				CreatedBy:         1,
+				ReasonCode:        "DAMAGE",
+				Notes:             "Dropped pallet",
+				ReferenceDocument: "INC-7",
+			},
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO mst_stock`)).
+					WithArgs(1, pq.Array([]int64{2})).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(lockStock).
+					WithArgs(1, pq.Array([]int64{2})).
+					WillReturnRows(sqlmock.NewRows([]string{"location_id", "stock_quantity", "reserved_quantity"}).AddRow(2, 40, 0))
+				expectStockCostLock(mock, 1, 2)
+				mock.ExpectQuery(lockLots).
+					WithArgs(1, 2).
+					WillReturnRows(sqlmock.NewRows(lotColumns))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE mst_stock`)).
+					WithArgs(37, 1, 2).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				expectUncostedMovement(mock, 1, 1, 40, -3)
+				mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO trx_stock`)).
+					WithArgs(1, 1, 2, "ADJUSTMENT", -3, 37, 1, "", nil, "DAMAGE", "Dropped pallet", "INC-7", 0, 0.0, 0.0, "", 0).
+					WillReturnRows(sqlmock.NewRows([]string{"transaction_id"}).AddRow(1))
+				mock.ExpectCommit()
+			},
+			wantErr: nil,
+		},
+		{
+			name: "Stock out below zero",
+			transaction: model.StockTransaction{
+				ProductID:       1,
+				WarehouseID:     1,
+				LocationID:      2,
+				TransactionType: "OUT",
+				Quantity:        41,
+				CreatedBy:       1,
+			},
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO mst_stock`)).
+					WithArgs(1, pq.Array([]int64{2})).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(lockStock).
+					WithArgs(1, pq.Array([]int64{2})).
+					WillReturnRows(sqlmock.NewRows([]string{"location_id", "stock_quantity", "reserved_quantity"}).AddRow(2, 40, 0))
+				expectStockCostLock(mock, 1, 2)
+				mock.ExpectQuery(lockLots).
+					WithArgs(1, 2).
+					WillReturnRows(sqlmock.NewRows(lotColumns))
+				mock.ExpectRollback()
+			},
+			wantErr: model.ErrInsufficientStock,
+		},
+		{
+			name: "Stock out into reserved stock",
+			transaction: model.StockTransaction{
+				ProductID:       1,
+				WarehouseID:     1,
+				LocationID:      2,
+				TransactionType: "OUT",
+				Quantity:        35,
+				CreatedBy:       1,
+			},
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO mst_stock`)).
+					WithArgs(1, pq.Array([]int64{2})).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(lockStock).
+					WithArgs(1, pq.Array([]int64{2})).
+					WillReturnRows(sqlmock.NewRows([]string{"location_id", "stock_quantity", "reserved_quantity"}).AddRow(2, 40, 10))
+				expectStockCostLock(mock, 1, 2)
+				mock.ExpectQuery(lockLots).
+					WithArgs(1, 2).
+					WillReturnRows(sqlmock.NewRows(lotColumns))
+				mock.ExpectRollback()
+			},
+			wantErr: model.ErrInsufficientStock,
+		},
+		{
+			name: "Stock in beyond the balance range",
+			transaction: model.StockTransaction{
+				ProductID:       1,
+				WarehouseID:     1,
+				LocationID:      2,
+				TransactionType: "IN",
+				Quantity:        math.MaxInt32,
+				CreatedBy:       1,
+			},
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO mst_stock`)).
+					WithArgs(1, pq.Array([]int64{2})).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(lockStock).
+					WithArgs(1, pq.Array([]int64{2})).
+					WillReturnRows(sqlmock.NewRows([]string{"location_id", "stock_quantity", "reserved_quantity"}).AddRow(2, 40, 0))
+				expectStockCostLock(mock, 1, 2)
+				expectReceivable(mock, 1, 2)
+				mock.ExpectRollback()
+			},
+			wantErr: model.ErrInvalidQuantity,
+		},
+		{
+			name: "Receipt into a lot",
+			transaction: model.StockTransaction{
+				ProductID:       1,
+				WarehouseID:     1,
+				LocationID:      2,
+				TransactionType: "IN",
+				Quantity:        10,
+				CreatedBy:       1,
+				LotNumber:       "LOT-C",
+				ExpiryDate:      &bestBefore,
+			},
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO mst_stock`)).
+					WithArgs(1, pq.Array([]int64{2})).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(lockStock).
+					WithArgs(1, pq.Array([]int64{2})).
+					WillReturnRows(sqlmock.NewRows([]string{"location_id", "stock_quantity", "reserved_quantity"}).AddRow(2, 40, 0))
+				expectStockCostLock(mock, 1, 2)
+				expectReceivable(mock, 1, 2)
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE mst_stock`)).
+					WithArgs(50, 1, 2).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				expectUncostedMovement(mock, 1, 1, 40, 10)
+				mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO trx_stock`)).
+					WithArgs(1, 1, 2, "IN", 10, 50, 1, "LOT-C", "2025-01-10", "", "", "", 0, 0.0, 0.0, "", 0).
+					WillReturnRows(sqlmock.NewRows([]string{"transaction_id"}).AddRow(1))
+				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO mst_stock_lot (product_id, warehouse_id, location_id, lot_number, expiry_date, quantity) VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT (product_id, location_id, lot_number) DO UPDATE SET quantity = mst_stock_lot.quantity + EXCLUDED.quantity, expiry_date = COALESCE(mst_stock_lot.expiry_date, EXCLUDED.expiry_date)`)).
+					WithArgs(1, 1, 2, "LOT-C", "2025-01-10", 10).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+			wantErr: nil,
+		},
+		{
+			name: "Stock out picks the earliest expiring lot first",
+			transaction: model.StockTransaction{
+				ProductID:       1,
+				WarehouseID:     1,
+				LocationID:      2,
+				TransactionType: "OUT",
+				Quantity:        15,
+				CreatedBy:       1,
+			},
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO mst_stock`)).
+					WithArgs(1, pq.Array([]int64{2})).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(lockStock).
+					WithArgs(1, pq.Array([]int64{2})).
+					WillReturnRows(sqlmock.NewRows([]string{"location_id", "stock_quantity", "reserved_quantity"}).AddRow(2, 40, 0))
+				expectStockCostLock(mock, 1, 2)
+				mock.ExpectQuery(lockLots).
+					WithArgs(1, 2).
+					WillReturnRows(sqlmock.NewRows(lotColumns).
+						AddRow(7, "LOT-A", bestBefore.Time, 10).
+						AddRow(8, "LOT-B", nil, 20))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE mst_stock`)).
+					WithArgs(30, 1, 2).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				expectUncostedMovement(mock, 1, 1, 40, -10)
+				mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO trx_stock`)).
+					WithArgs(1, 1, 2, "OUT", -10, 30, 1, "LOT-A", "2025-01-10", "", "", "", 0, 0.0, 0.0, "", 0).
+					WillReturnRows(sqlmock.NewRows([]string{"transaction_id"}).AddRow(1))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE mst_stock_lot SET quantity = quantity - $1 WHERE lot_id = $2`)).
+					WithArgs(10, 7).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE mst_stock`)).
+					WithArgs(25, 1, 2).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				expectUncostedMovement(mock, 1, 1, 30, -5)
+				mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO trx_stock`)).
+					WithArgs(1, 1, 2, "OUT", -5, 25, 1, "LOT-B", nil, "", "", "", 0, 0.0, 0.0, "", 0).
+					WillReturnRows(sqlmock.NewRows([]string{"transaction_id"}).AddRow(2))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE mst_stock_lot SET quantity = quantity - $1 WHERE lot_id = $2`)).
+					WithArgs(5, 8).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+			wantErr: nil,
+		},
+		{
+			name: "Stock out of a named lot",
+			transaction: model.StockTransaction{
+				ProductID:       1,
+				WarehouseID:     1,
+				LocationID:      2,
+				TransactionType: "OUT",
+				Quantity:        12,
+				CreatedBy:       1,
+				LotNumber:       "LOT-A",
+			},
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO mst_stock`)).
+					WithArgs(1, pq.Array([]int64{2})).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(lockStock).
+					WithArgs(1, pq.Array([]int64{2})).
+					WillReturnRows(sqlmock.NewRows([]string{"location_id", "stock_quantity", "reserved_quantity"}).AddRow(2, 40, 0))
+				expectStockCostLock(mock, 1, 2)
+				mock.ExpectQuery(lockLots).
+					WithArgs(1, 2).
+					WillReturnRows(sqlmock.NewRows(lotColumns).
+						AddRow(7, "LOT-A", bestBefore.Time, 10).
+						AddRow(8, "LOT-B", nil, 20))
+				mock.ExpectRollback()
+			},
+			wantErr: model.ErrInsufficientStock,
+		},
+		{
+			name: "Unknown location",
+			transaction: model.StockTransaction{
+				ProductID:       1,
+				WarehouseID:     1,
+				LocationID:      2,
+				TransactionType: "IN",
+				Quantity:        10,
+				CreatedBy:       1,
+			},
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO mst_stock`)).
+					WithArgs(1, pq.Array([]int64{2})).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(lockStock).
+					WithArgs(1, pq.Array([]int64{2})).
+					WillReturnRows(sqlmock.NewRows([]string{"location_id", "stock_quantity", "reserved_quantity"}))
+				mock.ExpectRollback()
+			},
+			wantErr: errors.New("location with id 2 not found"),
 		},
 		{
 			name: "Failed insert",
 			transaction: model.StockTransaction{
 				ProductID:       1,
 				WarehouseID:     1,
+				LocationID:      2,
 				TransactionType: "IN",
 				Quantity:        10,
 				CreatedBy:       1,
 			},
 			mockSetup: func(mock sqlmock.Sqlmock) {
 				mock.ExpectBegin()
-				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO trx_stock`)).
+				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO mst_stock`)).
+					WithArgs(1, pq.Array([]int64{2})).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(lockStock).
+					WithArgs(1, pq.Array([]int64{2})).
+					WillReturnRows(sqlmock.NewRows([]string{"location_id", "stock_quantity", "reserved_quantity"}).AddRow(2, 40, 0))
+				expectStockCostLock(mock, 1, 2)
+				expectReceivable(mock, 1, 2)
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE mst_stock`)).
+					WithArgs(50, 1, 2).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				expectUncostedMovement(mock, 1, 1, 40, 10)
+				mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO trx_stock`)).
 					WillReturnError(sql.ErrConnDone)
 				mock.ExpectRollback()
 			},
-			wantErr: true,
+			wantErr: sql.ErrConnDone,
 		},
 	}
 
@@ -71,8 +421,155 @@ func Test_CreateStockTransaction(t *testing.T) {
 			tt.mockSetup(mock)
 
 			err := rw.CreateStockTransaction(context.Background(), tt.transaction)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("CreateStockTransaction() error = %v, wantErr %v", err, tt.wantErr)
+			switch {
+			case tt.wantErr == nil:
+				assert.NoError(t, err)
+			case errors.Is(tt.wantErr, model.ErrInsufficientStock), errors.Is(tt.wantErr, model.ErrInvalidQuantity), errors.Is(tt.wantErr, sql.ErrConnDone):
+				assert.ErrorIs(t, err, tt.wantErr)
+			default:
+				assert.EqualError(t, err, tt.wantErr.Error())
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func Test_CreateStockTransactionBatch(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	lockStock := regexp.QuoteMeta(`SELECT location_id, stock_quantity, reserved_quantity FROM mst_stock WHERE product_id = $1 AND location_id = ANY($2) ORDER BY location_id FOR UPDATE`)
+	lockLots := regexp.QuoteMeta(`SELECT lot_id, lot_number, expiry_date, quantity FROM mst_stock_lot WHERE product_id = $1 AND location_id = $2 AND quantity > 0 ORDER BY expiry_date NULLS LAST, lot_id FOR UPDATE`)
+	stockColumns := []string{"location_id", "stock_quantity", "reserved_quantity"}
+	lotColumns := []string{"lot_id", "lot_number", "expiry_date", "quantity"}
+
+	expectLockStock := func(mock sqlmock.Sqlmock, productID, onHand int) {
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO mst_stock`)).
+			WithArgs(productID, pq.Array([]int64{2})).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(lockStock).
+			WithArgs(productID, pq.Array([]int64{2})).
+			WillReturnRows(sqlmock.NewRows(stockColumns).AddRow(2, onHand, 0))
+		expectStockCostLock(mock, int64(productID), 2)
+	}
+
+	tests := []struct {
+		name      string
+		lines     []model.StockTransaction
+		mockSetup func(sqlmock.Sqlmock)
+		want      []int64
+		wantLines []model.StockBatchLineError
+		wantErr   error
+	}{
+		{
+			name: "all lines are posted",
+			lines: []model.StockTransaction{
+				{ProductID: 2, WarehouseID: 1, LocationID: 2, TransactionType: model.StockIn, Quantity: 5, CreatedBy: 1},
+				{ProductID: 1, WarehouseID: 1, LocationID: 2, TransactionType: model.StockOut, Quantity: 3, CreatedBy: 1},
+			},
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				expectLockStock(mock, 1, 10)
+				expectLockStock(mock, 2, 0)
+				mock.ExpectExec(`SAVEPOINT batch_line`).WillReturnResult(sqlmock.NewResult(0, 0))
+				expectReceivable(mock, 2, 2)
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE mst_stock`)).
+					WithArgs(5, 2, 2).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				expectUncostedMovement(mock, 2, 1, 0, 5)
+				mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO trx_stock`)).
+					WithArgs(2, 1, 2, model.StockIn, 5, 5, 1, "", nil, "", "", "", 0, 0.0, 0.0, "", 0).
+					WillReturnRows(sqlmock.NewRows([]string{"transaction_id"}).AddRow(7))
+				mock.ExpectExec(`RELEASE SAVEPOINT batch_line`).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(`SAVEPOINT batch_line`).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(lockLots).
+					WithArgs(1, 2).
+					WillReturnRows(sqlmock.NewRows(lotColumns))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE mst_stock`)).
+					WithArgs(7, 1, 2).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				expectUncostedMovement(mock, 1, 1, 10, -3)
+				mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO trx_stock`)).
+					WithArgs(1, 1, 2, model.StockOut, -3, 7, 1, "", nil, "", "", "", 0, 0.0, 0.0, "", 0).
+					WillReturnRows(sqlmock.NewRows([]string{"transaction_id"}).AddRow(8))
+				mock.ExpectExec(`RELEASE SAVEPOINT batch_line`).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+			want: []int64{7, 8},
+		},
+		{
+			name: "a short line rejects the batch",
+			lines: []model.StockTransaction{
+				{ProductID: 1, WarehouseID: 1, LocationID: 2, TransactionType: model.StockOut, Quantity: 20, CreatedBy: 1},
+				{ProductID: 1, WarehouseID: 1, LocationID: 2, TransactionType: model.StockIn, Quantity: 5, CreatedBy: 1},
+			},
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				expectLockStock(mock, 1, 10)
+				mock.ExpectExec(`SAVEPOINT batch_line`).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(lockLots).
+					WithArgs(1, 2).
+					WillReturnRows(sqlmock.NewRows(lotColumns))
+				mock.ExpectExec(`ROLLBACK TO SAVEPOINT batch_line`).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(`SAVEPOINT batch_line`).WillReturnResult(sqlmock.NewResult(0, 0))
+				expectReceivable(mock, 1, 2)
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE mst_stock`)).
+					WithArgs(15, 1, 2).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				expectUncostedMovement(mock, 1, 1, 10, 5)
+				mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO trx_stock`)).
+					WithArgs(1, 1, 2, model.StockIn, 5, 15, 1, "", nil, "", "", "", 0, 0.0, 0.0, "", 0).
+					WillReturnRows(sqlmock.NewRows([]string{"transaction_id"}).AddRow(9))
+				mock.ExpectExec(`RELEASE SAVEPOINT batch_line`).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+			wantLines: []model.StockBatchLineError{{Line: 1}},
+		},
+		{
+			name: "a database error fails the batch",
+			lines: []model.StockTransaction{
+				{ProductID: 1, WarehouseID: 1, LocationID: 2, TransactionType: model.StockOut, Quantity: 3, CreatedBy: 1},
+			},
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				expectLockStock(mock, 1, 10)
+				mock.ExpectExec(`SAVEPOINT batch_line`).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(lockLots).
+					WithArgs(1, 2).
+					WillReturnError(sql.ErrConnDone)
+				mock.ExpectRollback()
+			},
+			wantErr: sql.ErrConnDone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rw := &dbReadWriter{db: db}
+			tt.mockSetup(mock)
+
+			got, err := rw.CreateStockTransactionBatch(context.Background(), tt.lines)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				var batchErr *model.StockBatchError
+				assert.False(t, errors.As(err, &batchErr))
+			} else if tt.wantLines != nil {
+				var batchErr *model.StockBatchError
+				assert.ErrorAs(t, err, &batchErr)
+				assert.Len(t, batchErr.Lines, len(tt.wantLines))
+				for i, line := range tt.wantLines {
+					assert.Equal(t, line.Line, batchErr.Lines[i].Line)
+					assert.Contains(t, batchErr.Lines[i].Error, model.ErrInsufficientStock.Error())
+				}
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
 			}
 
 			if err := mock.ExpectationsWereMet(); err != nil {
@@ -103,7 +600,7 @@ func Test_GetTotalStockByProductAndWarehouse(t *testing.T) {
 			warehouseID: 1,
 			mockSetup: func(mock sqlmock.Sqlmock) {
 				rows := sqlmock.NewRows([]string{"stock_quantity"}).AddRow(100)
-				mock.ExpectQuery(regexp.QuoteMeta(`SELECT stock_quantity FROM mst_stock`)).
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT COALESCE(SUM(stock_quantity), 0) FROM mst_stock WHERE product_id = $1 AND warehouse_id = $2`)).
 					WithArgs(int64(1), int64(1)).
 					WillReturnRows(rows)
 			},
@@ -115,7 +612,7 @@ func Test_GetTotalStockByProductAndWarehouse(t *testing.T) {
 			productID:   1,
 			warehouseID: 1,
 			mockSetup: func(mock sqlmock.Sqlmock) {
-				mock.ExpectQuery(regexp.QuoteMeta(`SELECT stock_quantity FROM mst_stock`)).
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT COALESCE(SUM(stock_quantity), 0) FROM mst_stock WHERE product_id = $1 AND warehouse_id = $2`)).
 					WithArgs(int64(1), int64(1)).
 					WillReturnError(sql.ErrNoRows)
 			},
@@ -145,6 +642,81 @@ func Test_GetTotalStockByProductAndWarehouse(t *testing.T) {
 	}
 }
 
+func Test_GetTotalStockByProductAndLocation(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	tests := []struct {
+		name       string
+		productID  int64
+		locationID int64
+		mockSetup  func(sqlmock.Sqlmock)
+		want       int64
+		wantErr    bool
+	}{
+		{
+			name:       "Successfully get location stock",
+			productID:  1,
+			locationID: 2,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"stock_quantity"}).AddRow(40)
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT stock_quantity FROM mst_stock WHERE product_id = $1 AND location_id = $2`)).
+					WithArgs(int64(1), int64(2)).
+					WillReturnRows(rows)
+			},
+			want:    40,
+			wantErr: false,
+		},
+		{
+			name:       "Never stocked at location",
+			productID:  1,
+			locationID: 3,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT stock_quantity FROM mst_stock WHERE product_id = $1 AND location_id = $2`)).
+					WithArgs(int64(1), int64(3)).
+					WillReturnError(sql.ErrNoRows)
+			},
+			want:    0,
+			wantErr: false,
+		},
+		{
+			name:       "Database error",
+			productID:  1,
+			locationID: 3,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT stock_quantity FROM mst_stock WHERE product_id = $1 AND location_id = $2`)).
+					WithArgs(int64(1), int64(3)).
+					WillReturnError(sql.ErrConnDone)
+			},
+			want:    0,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rw := &dbReadWriter{db: db}
+			tt.mockSetup(mock)
+
+			got, err := rw.GetTotalStockByProductAndLocation(context.Background(), tt.productID, tt.locationID)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetTotalStockByProductAndLocation() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("GetTotalStockByProductAndLocation() = %v, want %v", got, tt.want)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
 func Test_GetStockTransactions(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -162,25 +734,33 @@ func Test_GetStockTransactions(t *testing.T) {
 		wantErr   bool
 	}{
 		{
-			name:   "Successfully get transactions",
+			name:   "Successfully get transactions by reason",
 			userID: 1,
 			mockSetup: func(mock sqlmock.Sqlmock) {
 				rows := sqlmock.NewRows([]string{
-					"transaction_id", "product_id", "warehouse_id",
-					"transaction_type", "quantity", "transaction_date", "created_by",
-				}).AddRow(1, 1, 1, "IN", 10, fixedTime, 1)
-				mock.ExpectQuery(regexp.QuoteMeta(`SELECT transaction_id, product_id, warehouse_id, transaction_type, quantity, transaction_date, created_by FROM trx_stock`)).
-					WithArgs(int64(1)).
+					"transaction_id", "product_id", "warehouse_id", "location_id",
+					"transaction_type", "quantity", "balance_after", "transaction_date", "created_by",
+					"destination_warehouse_id", "destination_location_id", "status", "reference_id",
+					"lot_number", "expiry_date", "reason_code", "notes", "reference_document", "reversal_of_id", "unit_cost", "cost_amount", "unit_code", "unit_quantity",
+				}).AddRow(1, 1, 1, 2, "ADJUSTMENT", -2, 48, fixedTime, 1, 0, 0, "COMPLETED", 0, "", nil, "DAMAGE", "Dropped pallet", "INC-7", 0, nil, 0.0, "", 0)
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT `+stockTransactionColumns+` FROM trx_stock WHERE created_by = $1 AND (COALESCE(CARDINALITY($2::text[]), 0) = 0 OR reason_code = ANY($2)) AND ($3::bigint = 0 OR transaction_id < $3) ORDER BY transaction_id DESC LIMIT $4`)).
+					WithArgs(int64(1), pq.Array([]string{"DAMAGE"}), 0, 10).
 					WillReturnRows(rows)
 			},
 			want: []model.StockTransaction{{
-				TransactionID:   1,
-				ProductID:       1,
-				WarehouseID:     1,
-				TransactionType: "IN",
-				Quantity:        10,
-				TransactionDate: fixedTime,
-				CreatedBy:       1,
+				TransactionID:     1,
+				ProductID:         1,
+				WarehouseID:       1,
+				LocationID:        2,
+				TransactionType:   model.StockAdjustment,
+				Quantity:          -2,
+				BalanceAfter:      48,
+				TransactionDate:   fixedTime,
+				CreatedBy:         1,
+				Status:            model.TransactionCompleted,
+				ReasonCode:        "DAMAGE",
+				Notes:             "Dropped pallet",
+				ReferenceDocument: "INC-7",
 			}},
 			wantErr: false,
 		},
@@ -188,8 +768,8 @@ func Test_GetStockTransactions(t *testing.T) {
 			name:   "no transactions",
 			userID: 1,
 			mockSetup: func(mock sqlmock.Sqlmock) {
-				mock.ExpectQuery(regexp.QuoteMeta(`SELECT transaction_id, product_id, warehouse_id, transaction_type, quantity, transaction_date, created_by FROM trx_stock`)).
-					WithArgs(int64(1)).
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT `+stockTransactionColumns+` FROM trx_stock WHERE created_by = $1`)).
+					WithArgs(int64(1), pq.Array([]string{"DAMAGE"}), 0, 10).
 					WillReturnError(sql.ErrNoRows)
 			},
 			want:    nil,
@@ -202,7 +782,7 @@ func Test_GetStockTransactions(t *testing.T) {
 			rw := &dbReadWriter{db: db}
 			tt.mockSetup(mock)
 
-			got, err := rw.GetStockTransactions(context.Background(), tt.userID)
+			got, err := rw.GetStockTransactions(context.Background(), model.StockTransactionFilter{CreatedBy: tt.userID, ReasonCodes: []string{"DAMAGE"}}, model.Cursor{}, 10)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("GetStockTransactions() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -235,16 +815,19 @@ func Test_GetTotalStocks(t *testing.T) {
 			name: "Successfully get total stock",
 			mockSetup: func(mock sqlmock.Sqlmock) {
 				rows := sqlmock.NewRows([]string{
-					"product_id", "total_stock", "product_name", "sku",
-				}).AddRow(1, 100, "Product 1", "SKU001")
-				mock.ExpectQuery(regexp.QuoteMeta(`SELECT m.product_id, SUM(quantity) as total_stock, m.product_name, m.sku FROM trx_stock`)).
+					"product_id", "total_stock", "reserved_stock", "product_name", "sku", "category_id", "parent_id",
+				}).AddRow(1, 100, 15, "Product 1", "SKU001", 3, 0)
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT m.product_id, l.total_stock, COALESCE(r.reserved_stock, 0), m.product_name, m.sku, COALESCE(m.category_id, 0), COALESCE(m.parent_id, 0) FROM (SELECT product_id, SUM(quantity) as total_stock FROM trx_stock GROUP BY product_id) as l INNER JOIN mst_product as m ON l.product_id = m.product_id LEFT JOIN (SELECT product_id, SUM(reserved_quantity) as reserved_stock FROM mst_stock GROUP BY product_id) as r ON l.product_id = r.product_id ORDER BY m.product_id`)).
 					WillReturnRows(rows)
 			},
 			want: []model.ProductStock{{
-				ProductID:   1,
-				TotalStock:  100,
-				ProductName: "Product 1",
-				SKU:         "SKU001",
+				ProductID:      1,
+				TotalStock:     100,
+				ReservedStock:  15,
+				AvailableStock: 85,
+				ProductName:    "Product 1",
+				SKU:            "SKU001",
+				CategoryID:     3,
 			}},
 			wantErr: false,
 		},
@@ -295,24 +878,33 @@ func Test_GetStockTransactionByID(t *testing.T) {
 					"transaction_id",
 					"product_id",
 					"warehouse_id",
+					"location_id",
 					"transaction_type",
 					"quantity",
+					"balance_after",
 					"transaction_date",
 					"created_by",
-				}).AddRow(1, 1, 1, "IN", 10, fixedTime, 1)
+					"destination_warehouse_id",
+					"destination_location_id",
+					"status",
+					"reference_id", "lot_number", "expiry_date", "reason_code", "notes", "reference_document", "reversal_of_id", "unit_cost", "cost_amount", "unit_code", "unit_quantity",
+				}).AddRow(1, 1, 1, 2, "IN", 10, 50, fixedTime, 1, 0, 0, "COMPLETED", 0, "", nil, "", "", "", 0, nil, 0.0, "", 0)
 
-				mock.ExpectQuery(regexp.QuoteMeta(`SELECT transaction_id, product_id, warehouse_id, transaction_type, quantity, transaction_date, created_by FROM trx_stock WHERE transaction_id = $1`)).
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT ` + stockTransactionColumns + ` FROM trx_stock WHERE transaction_id = $1`)).
 					WithArgs(1).
 					WillReturnRows(rows)
 			},
 			want: model.StockTransaction{
 				TransactionID:   1,
-				ProductID:      1,
-				WarehouseID:   1,
+				ProductID:       1,
+				WarehouseID:     1,
+				LocationID:      2,
 				TransactionType: "IN",
-				Quantity:      10,
+				Quantity:        10,
+				BalanceAfter:    50,
 				TransactionDate: fixedTime,
-				CreatedBy:     1,
+				CreatedBy:       1,
+				Status:          model.TransactionCompleted,
 			},
 			wantErr: false,
 		},
@@ -320,7 +912,7 @@ func Test_GetStockTransactionByID(t *testing.T) {
 			name:          "transaction not found",
 			transactionID: 999,
 			mock: func(mock sqlmock.Sqlmock) {
-				mock.ExpectQuery(regexp.QuoteMeta(`SELECT transaction_id, product_id, warehouse_id, transaction_type, quantity, transaction_date, created_by FROM trx_stock WHERE transaction_id = $1`)).
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT ` + stockTransactionColumns + ` FROM trx_stock WHERE transaction_id = $1`)).
 					WithArgs(999).
 					WillReturnError(sql.ErrNoRows)
 			},
@@ -331,7 +923,7 @@ func Test_GetStockTransactionByID(t *testing.T) {
 			name:          "database error",
 			transactionID: 1,
 			mock: func(mock sqlmock.Sqlmock) {
-				mock.ExpectQuery(regexp.QuoteMeta(`SELECT transaction_id, product_id, warehouse_id, transaction_type, quantity, transaction_date, created_by FROM trx_stock WHERE transaction_id = $1`)).
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT ` + stockTransactionColumns + ` FROM trx_stock WHERE transaction_id = $1`)).
 					WithArgs(1).
 					WillReturnError(sql.ErrConnDone)
 			},
@@ -378,21 +970,28 @@ func Test_GetTotalStockByLocation(t *testing.T) {
 			name:       "success with multiple products",
 			locationID: 1,
 			mock: func(mock sqlmock.Sqlmock) {
-				rows := sqlmock.NewRows([]string{"product_id", "total_stock"}).
-					AddRow(1, 100).
-					AddRow(2, 200)
-				mock.ExpectQuery(regexp.QuoteMeta(`SELECT product_id, SUM(quantity) as total_stock FROM trx_stock WHERE warehouse_id = $1 GROUP BY product_id`)).
+				rows := sqlmock.NewRows([]string{"product_id", "total_stock", "reserved_quantity", "product_name", "sku", "category_id", "parent_id"}).
+					AddRow(1, 100, 0, "Product 1", "SKU001", 0, 0).
+					AddRow(2, 200, 20, "Product 2", "SKU002", 0, 0)
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT m.product_id, l.total_stock, COALESCE(s.reserved_quantity, 0), m.product_name, m.sku, COALESCE(m.category_id, 0), COALESCE(m.parent_id, 0) FROM (SELECT product_id, SUM(quantity) as total_stock FROM trx_stock WHERE location_id = $1 GROUP BY product_id) as l INNER JOIN mst_product as m ON l.product_id = m.product_id LEFT JOIN mst_stock as s ON l.product_id = s.product_id AND s.location_id = $1 ORDER BY m.product_id`)).
 					WithArgs(1).
 					WillReturnRows(rows)
 			},
 			want: []model.ProductStock{
 				{
-					ProductID:   1,
-					TotalStock: 100,
+					ProductID:      1,
+					TotalStock:     100,
+					AvailableStock: 100,
+					ProductName:    "Product 1",
+					SKU:            "SKU001",
 				},
 				{
-					ProductID:   2,
-					TotalStock: 200,
+					ProductID:      2,
+					TotalStock:     200,
+					ReservedStock:  20,
+					AvailableStock: 180,
+					ProductName:    "Product 2",
+					SKU:            "SKU002",
 				},
 			},
 			wantErr: false,
@@ -401,8 +1000,8 @@ func Test_GetTotalStockByLocation(t *testing.T) {
 			name:       "success with no stock",
 			locationID: 2,
 			mock: func(mock sqlmock.Sqlmock) {
-				rows := sqlmock.NewRows([]string{"product_id", "total_stock"})
-				mock.ExpectQuery(regexp.QuoteMeta(`SELECT product_id, SUM(quantity) as total_stock FROM trx_stock WHERE warehouse_id = $1 GROUP BY product_id`)).
+				rows := sqlmock.NewRows([]string{"product_id", "total_stock", "reserved_quantity", "product_name", "sku", "category_id", "parent_id"})
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT m.product_id, l.total_stock, COALESCE(s.reserved_quantity, 0), m.product_name, m.sku, COALESCE(m.category_id, 0), COALESCE(m.parent_id, 0) FROM (SELECT product_id, SUM(quantity) as total_stock FROM trx_stock WHERE location_id = $1 GROUP BY product_id) as l INNER JOIN mst_product as m ON l.product_id = m.product_id LEFT JOIN mst_stock as s ON l.product_id = s.product_id AND s.location_id = $1 ORDER BY m.product_id`)).
 					WithArgs(2).
 					WillReturnRows(rows)
 			},
@@ -413,7 +1012,7 @@ func Test_GetTotalStockByLocation(t *testing.T) {
 			name:       "database error",
 			locationID: 3,
 			mock: func(mock sqlmock.Sqlmock) {
-				mock.ExpectQuery(regexp.QuoteMeta(`SELECT product_id, SUM(quantity) as total_stock FROM trx_stock WHERE warehouse_id = $1 GROUP BY product_id`)).
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT m.product_id, l.total_stock, COALESCE(s.reserved_quantity, 0), m.product_name, m.sku, COALESCE(m.category_id, 0), COALESCE(m.parent_id, 0) FROM (SELECT product_id, SUM(quantity) as total_stock FROM trx_stock WHERE location_id = $1 GROUP BY product_id) as l INNER JOIN mst_product as m ON l.product_id = m.product_id LEFT JOIN mst_stock as s ON l.product_id = s.product_id AND s.location_id = $1 ORDER BY m.product_id`)).
 					WithArgs(3).
 					WillReturnError(sql.ErrConnDone)
 			},
@@ -424,9 +1023,9 @@ func Test_GetTotalStockByLocation(t *testing.T) {
 			name:       "scan error",
 			locationID: 4,
 			mock: func(mock sqlmock.Sqlmock) {
-				rows := sqlmock.NewRows([]string{"product_id", "total_stock"}).
-					AddRow("invalid", 100) // This will cause a scan error
-				mock.ExpectQuery(regexp.QuoteMeta(`SELECT product_id, SUM(quantity) as total_stock FROM trx_stock WHERE warehouse_id = $1 GROUP BY product_id`)).
+				rows := sqlmock.NewRows([]string{"product_id", "total_stock", "reserved_quantity", "product_name", "sku", "category_id", "parent_id"}).
+					AddRow("invalid", 100, 0, "Product 1", "SKU001", 0, 0) // This will cause a scan error
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT m.product_id, l.total_stock, COALESCE(s.reserved_quantity, 0), m.product_name, m.sku, COALESCE(m.category_id, 0), COALESCE(m.parent_id, 0) FROM (SELECT product_id, SUM(quantity) as total_stock FROM trx_stock WHERE location_id = $1 GROUP BY product_id) as l INNER JOIN mst_product as m ON l.product_id = m.product_id LEFT JOIN mst_stock as s ON l.product_id = s.product_id AND s.location_id = $1 ORDER BY m.product_id`)).
 					WithArgs(4).
 					WillReturnRows(rows)
 			},
@@ -0,0 +1,293 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/budsx/retail-management/model"
+	"github.com/lib/pq"
+)
+
+// CreateStockTransfer debits the source location and, unless the transfer is
+// shipped as in transit, credits the destination in the same DB transaction.
+// Stock is picked FEFO unless the transfer names a lot or serial numbers; a
+// transfer spanning several lots is posted as one row per lot, the later rows
+// referencing the first, whose id is returned. Stock moving to another
+// warehouse takes its cost with it. An archived product cannot be moved, nor
+// can stock be moved into an archived location.
+func (rw *dbReadWriter) CreateStockTransfer(ctx context.Context, transfer model.StockTransaction) (int64, error) {
+	insertTransfer := `INSERT INTO trx_stock (product_id, warehouse_id, location_id, transaction_type, quantity, balance_after, created_by, destination_warehouse_id, destination_location_id, status, reference_id, lot_number, expiry_date, unit_cost, cost_amount, unit_code, unit_quantity) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NULLIF($11, 0), NULLIF($12, ''), $13, $14, $15, NULLIF($16, ''), NULLIF($17, 0)) RETURNING transaction_id`
+
+	tx, err := rw.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	locationIDs := []int64{transfer.LocationID}
+	if transfer.Status == model.TransactionCompleted {
+		locationIDs = append(locationIDs, transfer.DestinationLocationID)
+	}
+
+	stocks, err := lockLocationStock(ctx, tx, transfer.ProductID, locationIDs...)
+	if err != nil {
+		return 0, err
+	}
+	source := stocks[transfer.LocationID]
+	destination := stocks[transfer.DestinationLocationID]
+
+	err = lockReceivingStock(ctx, tx, transfer.ProductID, transfer.DestinationLocationID)
+	if err != nil {
+		return 0, err
+	}
+
+	picks, err := pickOutgoingStock(ctx, tx, transfer, transfer.Quantity)
+	if err != nil {
+		return 0, err
+	}
+
+	var transferID int64
+	for _, pick := range picks {
+		leg := transfer
+		leg.LotNumber = pick.lot.lotNumber
+		leg.ExpiryDate = pick.lot.expiryDate
+		leg.ReferenceID = transferID
+
+		sourceBalance, err := updateLocationStock(ctx, tx, transfer.ProductID, transfer.LocationID, &source, -pick.quantity)
+		if err != nil {
+			return 0, err
+		}
+
+		if pick.lot.lotID != 0 {
+			err = debitStockLot(ctx, tx, pick.lot.lotID, pick.quantity)
+			if err != nil {
+				return 0, err
+			}
+		}
+
+		var cost movementCost
+		if transfer.DestinationWarehouseID != transfer.WarehouseID {
+			cost, err = postMovementCost(ctx, tx, leg, -pick.quantity)
+			if err != nil {
+				return 0, err
+			}
+		}
+
+		err = tx.QueryRowContext(ctx, insertTransfer,
+			leg.ProductID,
+			leg.WarehouseID,
+			leg.LocationID,
+			model.StockTransfer,
+			-pick.quantity,
+			sourceBalance,
+			leg.CreatedBy,
+			leg.DestinationWarehouseID,
+			leg.DestinationLocationID,
+			leg.Status,
+			leg.ReferenceID,
+			leg.LotNumber,
+			leg.ExpiryDate,
+			cost.unitCost,
+			cost.amount,
+			leg.Unit,
+			leg.UnitQuantity,
+		).Scan(&leg.TransactionID)
+		if err != nil {
+			return 0, err
+		}
+		leg.Quantity = -pick.quantity
+		leg.CostAmount = cost.amount
+
+		if transferID == 0 {
+			transferID = leg.TransactionID
+		}
+
+		if len(pick.serialIDs) > 0 {
+			err = linkSerialUnits(ctx, tx, leg.TransactionID, pick.serialIDs)
+			if err != nil {
+				return 0, err
+			}
+		}
+
+		if transfer.Status == model.TransactionCompleted {
+			err = creditTransferDestination(ctx, tx, leg, leg.CreatedBy, &destination, pick.serialIDs)
+		} else if len(pick.serialIDs) > 0 {
+			err = moveSerialUnits(ctx, tx, pick.serialIDs, model.SerialInTransit, 0, 0)
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return transferID, tx.Commit()
+}
+
+// ReceiveStockTransfer books every leg of an in-transit transfer into its
+// destination location, unless the product or the location has been archived
+// since it was shipped.
+func (rw *dbReadWriter) ReceiveStockTransfer(ctx context.Context, transactionID, receivedBy int64) error {
+	updateStatus := `UPDATE trx_stock SET status = $1 WHERE transaction_id = ANY($2)`
+
+	tx, err := rw.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	legs, err := lockTransferLegs(ctx, tx, transactionID)
+	if err != nil {
+		return err
+	}
+
+	if len(legs) == 0 || legs[0].TransactionID != transactionID {
+		return fmt.Errorf("%w: %d", model.ErrTransferNotFound, transactionID)
+	}
+
+	transfer := legs[0]
+	if transfer.ReferenceID != 0 {
+		return fmt.Errorf("%w: transaction %d is part of transfer %d", model.ErrTransferNotFound, transactionID, transfer.ReferenceID)
+	}
+
+	if transfer.Status != model.TransactionInTransit {
+		return fmt.Errorf("%w: %d", model.ErrTransferNotInTransit, transactionID)
+	}
+
+	stocks, err := lockLocationStock(ctx, tx, transfer.ProductID, transfer.DestinationLocationID)
+	if err != nil {
+		return err
+	}
+	destination := stocks[transfer.DestinationLocationID]
+
+	err = lockReceivingStock(ctx, tx, transfer.ProductID, transfer.DestinationLocationID)
+	if err != nil {
+		return err
+	}
+
+	legIDs := make([]int64, 0, len(legs))
+	for _, leg := range legs {
+		serialIDs, err := selectLinkedSerialUnits(ctx, tx, leg.TransactionID)
+		if err != nil {
+			return err
+		}
+
+		err = creditTransferDestination(ctx, tx, leg, receivedBy, &destination, serialIDs)
+		if err != nil {
+			return err
+		}
+		legIDs = append(legIDs, leg.TransactionID)
+	}
+
+	_, err = tx.ExecContext(ctx, updateStatus, model.TransactionCompleted, pq.Array(legIDs))
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// lockTransferLegs locks the shipping legs of a transfer, the leg with the
+// transfer's own id first.
+func lockTransferLegs(ctx context.Context, tx *sql.Tx, transactionID int64) ([]model.StockTransaction, error) {
+	selectTransfer := `SELECT ` + stockTransactionColumns + `
+		FROM trx_stock WHERE transaction_type = $1 AND quantity < 0 AND (transaction_id = $2 OR reference_id = $2) 
+		ORDER BY transaction_id FOR UPDATE`
+
+	rows, err := tx.QueryContext(ctx, selectTransfer, model.StockTransfer, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var legs []model.StockTransaction
+	for rows.Next() {
+		leg, err := scanStockTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		legs = append(legs, leg)
+	}
+
+	return legs, rows.Err()
+}
+
+// creditTransferDestination books the receiving side of a transfer leg and
+// puts its serialized units, if any, in stock there, valued at what the leg
+// took out of another warehouse. The caller must already hold the lock on the
+// destination stock row.
+func creditTransferDestination(ctx context.Context, tx *sql.Tx, transfer model.StockTransaction, createdBy int64, stock *locationStock, serialIDs []int64) error {
+	insertReceipt := `INSERT INTO trx_stock (product_id, warehouse_id, location_id, transaction_type, quantity, balance_after, created_by, status, reference_id, lot_number, expiry_date, unit_cost, cost_amount, unit_code, unit_quantity) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NULLIF($10, ''), $11, $12, $13, NULLIF($14, ''), NULLIF($15, 0)) RETURNING transaction_id`
+
+	// The shipping leg is stored as a negative delta; the destination receives its inverse
+	quantity := transfer.Quantity
+	if quantity < 0 {
+		quantity = -quantity
+	}
+
+	destinationBalance, err := updateLocationStock(ctx, tx, transfer.ProductID, transfer.DestinationLocationID, stock, quantity)
+	if err != nil {
+		return err
+	}
+
+	if transfer.LotNumber != "" {
+		err = creditStockLot(ctx, tx, model.StockTransaction{
+			ProductID:   transfer.ProductID,
+			WarehouseID: transfer.DestinationWarehouseID,
+			LocationID:  transfer.DestinationLocationID,
+			LotNumber:   transfer.LotNumber,
+			ExpiryDate:  transfer.ExpiryDate,
+		}, quantity)
+		if err != nil {
+			return err
+		}
+	}
+
+	var cost movementCost
+	if transfer.DestinationWarehouseID != transfer.WarehouseID {
+		// The unrounded unit cost books exactly the value that was shipped
+		unitCost := -transfer.CostAmount / float64(quantity)
+		cost, err = postMovementCost(ctx, tx, model.StockTransaction{
+			ProductID:   transfer.ProductID,
+			WarehouseID: transfer.DestinationWarehouseID,
+			UnitCost:    &unitCost,
+		}, quantity)
+		if err != nil {
+			return err
+		}
+	}
+
+	var receiptID int64
+	err = tx.QueryRowContext(ctx, insertReceipt,
+		transfer.ProductID,
+		transfer.DestinationWarehouseID,
+		transfer.DestinationLocationID,
+		model.StockTransfer,
+		quantity,
+		destinationBalance,
+		createdBy,
+		model.TransactionCompleted,
+		transfer.TransactionID,
+		transfer.LotNumber,
+		transfer.ExpiryDate,
+		cost.unitCost,
+		cost.amount,
+		transfer.Unit,
+		transfer.UnitQuantity,
+	).Scan(&receiptID)
+	if err != nil {
+		return err
+	}
+
+	if len(serialIDs) == 0 {
+		return nil
+	}
+
+	err = moveSerialUnits(ctx, tx, serialIDs, model.SerialInStock, transfer.DestinationWarehouseID, transfer.DestinationLocationID)
+	if err != nil {
+		return err
+	}
+
+	return linkSerialUnits(ctx, tx, receiptID, serialIDs)
+}
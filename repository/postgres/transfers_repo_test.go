@@ -0,0 +1,378 @@
+package postgres
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/budsx/retail-management/model"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CreateStockTransfer(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	lockLots := regexp.QuoteMeta(`SELECT lot_id, lot_number, expiry_date, quantity FROM mst_stock_lot WHERE product_id = $1 AND location_id = $2 AND quantity > 0 ORDER BY expiry_date NULLS LAST, lot_id FOR UPDATE`)
+	lotColumns := []string{"lot_id", "lot_number", "expiry_date", "quantity"}
+	bestBefore := time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC)
+
+	transfer := model.StockTransaction{
+		ProductID:              1,
+		WarehouseID:            1,
+		LocationID:             1,
+		TransactionType:        model.StockTransfer,
+		Quantity:               5,
+		CreatedBy:              1,
+		DestinationWarehouseID: 2,
+		DestinationLocationID:  3,
+	}
+
+	tests := []struct {
+		name      string
+		status    model.TransactionStatus
+		mockSetup func(sqlmock.Sqlmock)
+		want      int64
+		wantErr   bool
+	}{
+		{
+			name:   "completed transfer moves stock between locations",
+			status: model.TransactionCompleted,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO mst_stock`)).
+					WithArgs(1, pq.Array([]int64{1, 3})).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT location_id, stock_quantity, reserved_quantity FROM mst_stock`)).
+					WithArgs(1, pq.Array([]int64{1, 3})).
+					WillReturnRows(sqlmock.NewRows([]string{"location_id", "stock_quantity", "reserved_quantity"}).AddRow(1, 20, 0).AddRow(3, 7, 0))
+				expectStockCostLock(mock, 1, 1, 3)
+				expectReceivable(mock, 1, 3)
+				mock.ExpectQuery(lockLots).
+					WithArgs(1, 1).
+					WillReturnRows(sqlmock.NewRows(lotColumns))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE mst_stock SET stock_quantity = $1`)).
+					WithArgs(15, 1, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery(selectPositionQuery).
+					WithArgs(1, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"quantity", "stock_value", "costing_method"}).AddRow(20, 200.0, "FIFO"))
+				mock.ExpectQuery(selectLayersQuery).
+					WithArgs(1, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"layer_id", "unit_cost", "remaining_quantity"}).AddRow(6, 10.0, 20))
+				mock.ExpectExec(updateLayersQuery).
+					WithArgs(pq.Array([]int64{6}), pq.Array([]int64{5})).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(updatePositionQuery).
+					WithArgs(15, 150.0, 1, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO trx_stock`)).
+					WithArgs(1, 1, 1, "TRANSFER", -5, 15, 1, 2, 3, "COMPLETED", 0, "", nil, 10.0, -50.0, "", 0).
+					WillReturnRows(sqlmock.NewRows([]string{"transaction_id"}).AddRow(10))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE mst_stock SET stock_quantity = $1`)).
+					WithArgs(12, 1, 3).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery(selectPositionQuery).
+					WithArgs(1, 2).
+					WillReturnRows(sqlmock.NewRows([]string{"quantity", "stock_value", "costing_method"}).AddRow(7, 56.0, "AVERAGE"))
+				mock.ExpectExec(insertLayerQuery).
+					WithArgs(1, 2, 10.0, 5).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(updatePositionQuery).
+					WithArgs(12, 106.0, 1, 2).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO trx_stock`)).
+					WithArgs(1, 2, 3, "TRANSFER", 5, 12, 1, "COMPLETED", 10, "", nil, 10.0, 50.0, "", 0).
+					WillReturnRows(sqlmock.NewRows([]string{"transaction_id"}).AddRow(11))
+				mock.ExpectCommit()
+			},
+			want:    10,
+			wantErr: false,
+		},
+		{
+			name:   "in-transit transfer only debits the source",
+			status: model.TransactionInTransit,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO mst_stock`)).
+					WithArgs(1, pq.Array([]int64{1})).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT location_id, stock_quantity, reserved_quantity FROM mst_stock`)).
+					WithArgs(1, pq.Array([]int64{1})).
+					WillReturnRows(sqlmock.NewRows([]string{"location_id", "stock_quantity", "reserved_quantity"}).AddRow(1, 20, 0))
+				expectStockCostLock(mock, 1, 1)
+				expectReceivable(mock, 1, 3)
+				mock.ExpectQuery(lockLots).
+					WithArgs(1, 1).
+					WillReturnRows(sqlmock.NewRows(lotColumns))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE mst_stock SET stock_quantity = $1`)).
+					WithArgs(15, 1, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				expectUncostedMovement(mock, 1, 1, 20, -5)
+				mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO trx_stock`)).
+					WithArgs(1, 1, 1, "TRANSFER", -5, 15, 1, 2, 3, "IN_TRANSIT", 0, "", nil, 0.0, 0.0, "", 0).
+					WillReturnRows(sqlmock.NewRows([]string{"transaction_id"}).AddRow(12))
+				mock.ExpectCommit()
+			},
+			want:    12,
+			wantErr: false,
+		},
+		{
+			name:   "transfer spanning two lots carries them to the destination",
+			status: model.TransactionCompleted,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO mst_stock`)).
+					WithArgs(1, pq.Array([]int64{1, 3})).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT location_id, stock_quantity, reserved_quantity FROM mst_stock`)).
+					WithArgs(1, pq.Array([]int64{1, 3})).
+					WillReturnRows(sqlmock.NewRows([]string{"location_id", "stock_quantity", "reserved_quantity"}).AddRow(1, 20, 0).AddRow(3, 7, 0))
+				expectStockCostLock(mock, 1, 1, 3)
+				expectReceivable(mock, 1, 3)
+				mock.ExpectQuery(lockLots).
+					WithArgs(1, 1).
+					WillReturnRows(sqlmock.NewRows(lotColumns).AddRow(4, "LOT-A", bestBefore, 3).AddRow(5, "LOT-B", nil, 10))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE mst_stock SET stock_quantity = $1`)).
+					WithArgs(17, 1, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE mst_stock_lot SET quantity = quantity - $1 WHERE lot_id = $2`)).
+					WithArgs(3, 4).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				expectUncostedMovement(mock, 1, 1, 20, -3)
+				mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO trx_stock`)).
+					WithArgs(1, 1, 1, "TRANSFER", -3, 17, 1, 2, 3, "COMPLETED", 0, "LOT-A", "2025-01-10", 0.0, 0.0, "", 0).
+					WillReturnRows(sqlmock.NewRows([]string{"transaction_id"}).AddRow(20))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE mst_stock SET stock_quantity = $1`)).
+					WithArgs(10, 1, 3).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO mst_stock_lot`)).
+					WithArgs(1, 2, 3, "LOT-A", "2025-01-10", 3).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				expectUncostedMovement(mock, 1, 2, 7, 3)
+				mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO trx_stock`)).
+					WithArgs(1, 2, 3, "TRANSFER", 3, 10, 1, "COMPLETED", 20, "LOT-A", "2025-01-10", 0.0, 0.0, "", 0).
+					WillReturnRows(sqlmock.NewRows([]string{"transaction_id"}).AddRow(21))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE mst_stock SET stock_quantity = $1`)).
+					WithArgs(15, 1, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE mst_stock_lot SET quantity = quantity - $1 WHERE lot_id = $2`)).
+					WithArgs(2, 5).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				expectUncostedMovement(mock, 1, 1, 17, -2)
+				mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO trx_stock`)).
+					WithArgs(1, 1, 1, "TRANSFER", -2, 15, 1, 2, 3, "COMPLETED", 20, "LOT-B", nil, 0.0, 0.0, "", 0).
+					WillReturnRows(sqlmock.NewRows([]string{"transaction_id"}).AddRow(22))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE mst_stock SET stock_quantity = $1`)).
+					WithArgs(12, 1, 3).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO mst_stock_lot`)).
+					WithArgs(1, 2, 3, "LOT-B", nil, 2).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				expectUncostedMovement(mock, 1, 2, 10, 2)
+				mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO trx_stock`)).
+					WithArgs(1, 2, 3, "TRANSFER", 2, 12, 1, "COMPLETED", 22, "LOT-B", nil, 0.0, 0.0, "", 0).
+					WillReturnRows(sqlmock.NewRows([]string{"transaction_id"}).AddRow(23))
+				mock.ExpectCommit()
+			},
+			want:    20,
+			wantErr: false,
+		},
+		{
+			name:   "missing destination stock rolls back",
+			status: model.TransactionCompleted,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO mst_stock`)).
+					WithArgs(1, pq.Array([]int64{1, 3})).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT location_id, stock_quantity, reserved_quantity FROM mst_stock`)).
+					WithArgs(1, pq.Array([]int64{1, 3})).
+					WillReturnRows(sqlmock.NewRows([]string{"location_id", "stock_quantity", "reserved_quantity"}).AddRow(1, 20, 0))
+				mock.ExpectRollback()
+			},
+			want:    0,
+			wantErr: true,
+		},
+		{
+			name:   "archived product cannot be moved",
+			status: model.TransactionInTransit,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO mst_stock`)).
+					WithArgs(1, pq.Array([]int64{1})).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT location_id, stock_quantity, reserved_quantity FROM mst_stock`)).
+					WithArgs(1, pq.Array([]int64{1})).
+					WillReturnRows(sqlmock.NewRows([]string{"location_id", "stock_quantity", "reserved_quantity"}).AddRow(1, 20, 0))
+				expectStockCostLock(mock, 1, 1)
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT p.archived_at IS NOT NULL, l.archived_at IS NOT NULL FROM mst_product p, mst_location l`)).
+					WithArgs(1, 3).
+					WillReturnRows(sqlmock.NewRows([]string{"product_archived", "location_archived"}).AddRow(true, false))
+				mock.ExpectRollback()
+			},
+			want:    0,
+			wantErr: true,
+		},
+		{
+			name:   "insufficient stock at source",
+			status: model.TransactionInTransit,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO mst_stock`)).
+					WithArgs(1, pq.Array([]int64{1})).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT location_id, stock_quantity, reserved_quantity FROM mst_stock`)).
+					WithArgs(1, pq.Array([]int64{1})).
+					WillReturnRows(sqlmock.NewRows([]string{"location_id", "stock_quantity", "reserved_quantity"}).AddRow(1, 4, 0))
+				expectStockCostLock(mock, 1, 1)
+				expectReceivable(mock, 1, 3)
+				mock.ExpectQuery(lockLots).
+					WithArgs(1, 1).
+					WillReturnRows(sqlmock.NewRows(lotColumns))
+				mock.ExpectRollback()
+			},
+			want:    0,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rw := &dbReadWriter{db: db}
+			tt.mockSetup(mock)
+
+			req := transfer
+			req.Status = tt.status
+			got, err := rw.CreateStockTransfer(context.Background(), req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CreateStockTransfer() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			assert.Equal(t, tt.want, got)
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func Test_ReceiveStockTransfer(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	fixedTime := time.Now()
+	columns := []string{
+		"transaction_id", "product_id", "warehouse_id", "location_id",
+		"transaction_type", "quantity", "balance_after", "transaction_date", "created_by",
+		"destination_warehouse_id", "destination_location_id", "status", "reference_id",
+		"lot_number", "expiry_date", "reason_code", "notes", "reference_document", "reversal_of_id", "unit_cost", "cost_amount", "unit_code", "unit_quantity",
+	}
+	selectLegs := regexp.QuoteMeta(`SELECT ` + stockTransactionColumns + ` FROM trx_stock WHERE transaction_type = $1 AND quantity < 0 AND (transaction_id = $2 OR reference_id = $2) ORDER BY transaction_id FOR UPDATE`)
+
+	tests := []struct {
+		name      string
+		mockSetup func(sqlmock.Sqlmock)
+		wantErr   error
+	}{
+		{
+			name: "receive in-transit transfer",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(selectLegs).
+					WithArgs("TRANSFER", 10).
+					WillReturnRows(sqlmock.NewRows(columns).AddRow(10, 1, 1, 1, "TRANSFER", -5, 15, fixedTime, 1, 2, 3, "IN_TRANSIT", 0, "", nil, "", "", "", 0, 10.0, -50.0, "", 0))
+				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO mst_stock`)).
+					WithArgs(1, pq.Array([]int64{3})).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT location_id, stock_quantity, reserved_quantity FROM mst_stock`)).
+					WithArgs(1, pq.Array([]int64{3})).
+					WillReturnRows(sqlmock.NewRows([]string{"location_id", "stock_quantity", "reserved_quantity"}).AddRow(3, 7, 0))
+				expectStockCostLock(mock, 1, 3)
+				expectReceivable(mock, 1, 3)
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT serial_id FROM trx_stock_serial WHERE transaction_id = $1 ORDER BY serial_id`)).
+					WithArgs(10).
+					WillReturnRows(sqlmock.NewRows([]string{"serial_id"}))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE mst_stock SET stock_quantity = $1`)).
+					WithArgs(12, 1, 3).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery(selectPositionQuery).
+					WithArgs(1, 2).
+					WillReturnRows(sqlmock.NewRows([]string{"quantity", "stock_value", "costing_method"}).AddRow(7, 70.0, "FIFO"))
+				mock.ExpectExec(insertLayerQuery).
+					WithArgs(1, 2, 10.0, 5).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(updatePositionQuery).
+					WithArgs(12, 120.0, 1, 2).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO trx_stock`)).
+					WithArgs(1, 2, 3, "TRANSFER", 5, 12, 2, "COMPLETED", 10, "", nil, 10.0, 50.0, "", 0).
+					WillReturnRows(sqlmock.NewRows([]string{"transaction_id"}).AddRow(11))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE trx_stock SET status = $1 WHERE transaction_id = ANY($2)`)).
+					WithArgs("COMPLETED", pq.Array([]int64{10})).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "already received",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(selectLegs).
+					WithArgs("TRANSFER", 10).
+					WillReturnRows(sqlmock.NewRows(columns).AddRow(10, 1, 1, 1, "TRANSFER", -5, 15, fixedTime, 1, 2, 3, "COMPLETED", 0, "", nil, "", "", "", 0, nil, 0.0, "", 0))
+				mock.ExpectRollback()
+			},
+			wantErr: model.ErrTransferNotInTransit,
+		},
+		{
+			name: "transfer not found",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(selectLegs).
+					WithArgs("TRANSFER", 10).
+					WillReturnRows(sqlmock.NewRows(columns))
+				mock.ExpectRollback()
+			},
+			wantErr: model.ErrTransferNotFound,
+		},
+		{
+			name: "leg of another transfer",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(selectLegs).
+					WithArgs("TRANSFER", 10).
+					WillReturnRows(sqlmock.NewRows(columns).AddRow(10, 1, 1, 1, "TRANSFER", -5, 15, fixedTime, 1, 2, 3, "IN_TRANSIT", 8, "", nil, "", "", "", 0, nil, 0.0, "", 0))
+				mock.ExpectRollback()
+			},
+			wantErr: model.ErrTransferNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rw := &dbReadWriter{db: db}
+			tt.mockSetup(mock)
+
+			err := rw.ReceiveStockTransfer(context.Background(), 10, 2)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
@@ -1,11 +1,13 @@
 package repository
 
 import (
+	"github.com/budsx/retail-management/repository/notifier"
 	"github.com/budsx/retail-management/repository/postgres"
 )
 
 type Repository struct {
 	Postgres postgres.PostgresRepository
+	Notifier notifier.Notifier
 }
 
 type DBConfig struct {
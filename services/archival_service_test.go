@@ -0,0 +1,207 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/budsx/retail-management/middleware"
+	"github.com/budsx/retail-management/model"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestService_DeleteProduct(t *testing.T) {
+	srv := NewTestServer(t)
+	defer srv.MockCtrl.Finish()
+
+	ctx := context.Background()
+
+	t.Run("archive", func(t *testing.T) {
+		srv.MockRepo.EXPECT().SetProductArchived(gomock.Any(), int64(4), true).Return(nil)
+
+		err := srv.Service.DeleteProduct(ctx, 4)
+		assert.NoError(t, err)
+	})
+
+	t.Run("restore", func(t *testing.T) {
+		srv.MockRepo.EXPECT().SetProductArchived(gomock.Any(), int64(4), false).Return(nil)
+
+		err := srv.Service.RestoreProduct(ctx, 4)
+		assert.NoError(t, err)
+	})
+
+	t.Run("unknown product", func(t *testing.T) {
+		srv.MockRepo.EXPECT().SetProductArchived(gomock.Any(), int64(9), true).Return(fmt.Errorf("%w: 9", model.ErrProductNotFound))
+
+		err := srv.Service.DeleteProduct(ctx, 9)
+		assert.ErrorIs(t, err, model.ErrProductNotFound)
+	})
+}
+
+func TestService_DeleteWarehouseByUserID(t *testing.T) {
+	srv := NewTestServer(t)
+	defer srv.MockCtrl.Finish()
+
+	ctx := context.WithValue(context.Background(), middleware.ContextKeyUserID, int64(3))
+
+	t.Run("archive", func(t *testing.T) {
+		srv.MockRepo.EXPECT().SetWarehouseArchived(gomock.Any(), int64(3), int64(1), true).Return(nil)
+
+		err := srv.Service.DeleteWarehouseByUserID(ctx, 1)
+		assert.NoError(t, err)
+	})
+
+	t.Run("restore", func(t *testing.T) {
+		srv.MockRepo.EXPECT().SetWarehouseArchived(gomock.Any(), int64(3), int64(1), false).Return(nil)
+
+		err := srv.Service.RestoreWarehouseByUserID(ctx, 1)
+		assert.NoError(t, err)
+	})
+
+	t.Run("another user's warehouse", func(t *testing.T) {
+		srv.MockRepo.EXPECT().SetWarehouseArchived(gomock.Any(), int64(3), int64(2), true).Return(model.ErrWarehouseNotFound)
+
+		err := srv.Service.DeleteWarehouseByUserID(ctx, 2)
+		assert.ErrorIs(t, err, model.ErrWarehouseNotFound)
+	})
+}
+
+func TestService_RestoreLocationByUserID(t *testing.T) {
+	srv := NewTestServer(t)
+	defer srv.MockCtrl.Finish()
+
+	ctx := context.WithValue(context.Background(), middleware.ContextKeyUserID, int64(3))
+	archivedAt := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	location := model.Location{LocationID: 2, LocationName: "Rak B2", WarehouseID: 1, ArchivedAt: &archivedAt}
+
+	t.Run("restore", func(t *testing.T) {
+		srv.MockRepo.EXPECT().ReadLocationByID(gomock.Any(), int64(2)).Return(location, nil)
+		srv.MockRepo.EXPECT().ReadWarehouseByID(gomock.Any(), int64(1)).Return(model.Warehouse{WarehouseID: 1, UserID: 3}, nil)
+		srv.MockRepo.EXPECT().SetLocationArchived(gomock.Any(), int64(3), int64(2), false).Return(nil)
+
+		err := srv.Service.RestoreLocationByUserID(ctx, 2)
+		assert.NoError(t, err)
+	})
+
+	t.Run("warehouse archived", func(t *testing.T) {
+		srv.MockRepo.EXPECT().ReadLocationByID(gomock.Any(), int64(2)).Return(location, nil)
+		srv.MockRepo.EXPECT().ReadWarehouseByID(gomock.Any(), int64(1)).Return(model.Warehouse{WarehouseID: 1, UserID: 3, ArchivedAt: &archivedAt}, nil)
+
+		err := srv.Service.RestoreLocationByUserID(ctx, 2)
+		assert.ErrorIs(t, err, model.ErrArchived)
+	})
+
+	t.Run("another user's location", func(t *testing.T) {
+		srv.MockRepo.EXPECT().ReadLocationByID(gomock.Any(), int64(2)).Return(location, nil)
+		srv.MockRepo.EXPECT().ReadWarehouseByID(gomock.Any(), int64(1)).Return(model.Warehouse{WarehouseID: 1, UserID: 4}, nil)
+
+		err := srv.Service.RestoreLocationByUserID(ctx, 2)
+		assert.ErrorIs(t, err, model.ErrLocationNotFound)
+	})
+
+	t.Run("unknown location", func(t *testing.T) {
+		srv.MockRepo.EXPECT().ReadLocationByID(gomock.Any(), int64(9)).Return(model.Location{}, fmt.Errorf("%w: 9", model.ErrLocationNotFound))
+
+		err := srv.Service.RestoreLocationByUserID(ctx, 9)
+		assert.ErrorIs(t, err, model.ErrLocationNotFound)
+	})
+
+	t.Run("location removed while restoring", func(t *testing.T) {
+		srv.MockRepo.EXPECT().ReadLocationByID(gomock.Any(), int64(2)).Return(location, nil)
+		srv.MockRepo.EXPECT().ReadWarehouseByID(gomock.Any(), int64(1)).Return(model.Warehouse{WarehouseID: 1, UserID: 3}, nil)
+		srv.MockRepo.EXPECT().SetLocationArchived(gomock.Any(), int64(3), int64(2), false).Return(fmt.Errorf("%w: 2", model.ErrLocationNotFound))
+
+		err := srv.Service.RestoreLocationByUserID(ctx, 2)
+		assert.ErrorIs(t, err, model.ErrLocationNotFound)
+	})
+}
+
+func TestService_AddLocation_ArchivedWarehouse(t *testing.T) {
+	srv := NewTestServer(t)
+	defer srv.MockCtrl.Finish()
+
+	ctx := context.WithValue(context.Background(), middleware.ContextKeyUserID, int64(3))
+	ctx = context.WithValue(ctx, middleware.ContextKeyUsername, "gudang")
+	archivedAt := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	srv.MockRepo.EXPECT().ReadWarehouseByID(gomock.Any(), int64(1)).Return(model.Warehouse{WarehouseID: 1, UserID: 3, ArchivedAt: &archivedAt}, nil)
+
+	err := srv.Service.AddLocation(ctx, model.Location{LocationName: "Rak C1", WarehouseID: 1})
+	assert.ErrorIs(t, err, model.ErrArchived)
+}
+
+func TestService_CreateStockTransaction_Archived(t *testing.T) {
+	srv := NewTestServer(t)
+	defer srv.MockCtrl.Finish()
+
+	ctx := context.Background()
+	archivedAt := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	location := model.Location{LocationID: 2, LocationName: "Rak B2", WarehouseID: 1}
+	archivedLocation := model.Location{LocationID: 3, LocationName: "Rak B3", WarehouseID: 1, ArchivedAt: &archivedAt}
+	product := model.Product{ProductID: 1, ProductName: "Beras 5kg"}
+	archivedProduct := model.Product{ProductID: 4, ProductName: "Gula 1kg", ArchivedAt: &archivedAt}
+
+	t.Run("stock in to an archived location", func(t *testing.T) {
+		srv.MockRepo.EXPECT().ReadLocationByID(gomock.Any(), int64(3)).Return(archivedLocation, nil)
+
+		err := srv.Service.CreateStockTransaction(ctx, model.StockTransaction{ProductID: 1, LocationID: 3, TransactionType: model.StockIn, Quantity: 5})
+		assert.ErrorIs(t, err, model.ErrArchived)
+	})
+
+	t.Run("stock in of an archived product", func(t *testing.T) {
+		srv.MockRepo.EXPECT().ReadLocationByID(gomock.Any(), int64(2)).Return(location, nil)
+		srv.MockRepo.EXPECT().ReadProductByID(gomock.Any(), int64(4)).Return(archivedProduct, nil)
+
+		err := srv.Service.CreateStockTransaction(ctx, model.StockTransaction{ProductID: 4, LocationID: 2, TransactionType: model.StockIn, Quantity: 5})
+		assert.ErrorIs(t, err, model.ErrArchived)
+	})
+
+	t.Run("stock out of an archived location", func(t *testing.T) {
+		srv.MockRepo.EXPECT().ReadLocationByID(gomock.Any(), int64(3)).Return(archivedLocation, nil)
+		srv.MockRepo.EXPECT().ReadProductByID(gomock.Any(), int64(1)).Return(product, nil)
+		srv.MockRepo.EXPECT().
+			CreateStockTransaction(gomock.Any(), model.StockTransaction{
+				ProductID:       1,
+				WarehouseID:     1,
+				LocationID:      3,
+				TransactionType: model.StockOut,
+				Quantity:        5,
+			}).
+			Return(nil)
+
+		err := srv.Service.CreateStockTransaction(ctx, model.StockTransaction{ProductID: 1, LocationID: 3, TransactionType: model.StockOut, Quantity: 5})
+		assert.NoError(t, err)
+	})
+
+	t.Run("transfer to an archived location", func(t *testing.T) {
+		srv.MockRepo.EXPECT().ReadLocationByID(gomock.Any(), int64(2)).Return(location, nil)
+		srv.MockRepo.EXPECT().ReadLocationByID(gomock.Any(), int64(3)).Return(archivedLocation, nil)
+
+		err := srv.Service.CreateStockTransaction(ctx, model.StockTransaction{
+			ProductID:             1,
+			LocationID:            2,
+			DestinationLocationID: 3,
+			TransactionType:       model.StockTransfer,
+			Quantity:              5,
+		})
+		assert.ErrorIs(t, err, model.ErrArchived)
+	})
+
+	t.Run("transfer of an archived product", func(t *testing.T) {
+		destination := model.Location{LocationID: 5, LocationName: "Rak C1", WarehouseID: 1}
+		srv.MockRepo.EXPECT().ReadLocationByID(gomock.Any(), int64(2)).Return(location, nil)
+		srv.MockRepo.EXPECT().ReadLocationByID(gomock.Any(), int64(5)).Return(destination, nil)
+		srv.MockRepo.EXPECT().ReadProductByID(gomock.Any(), int64(4)).Return(archivedProduct, nil)
+
+		err := srv.Service.CreateStockTransaction(ctx, model.StockTransaction{
+			ProductID:             4,
+			LocationID:            2,
+			DestinationLocationID: 5,
+			TransactionType:       model.StockTransfer,
+			Quantity:              5,
+		})
+		assert.ErrorIs(t, err, model.ErrArchived)
+	})
+}
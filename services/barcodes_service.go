@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/budsx/retail-management/model"
+)
+
+// AddProductBarcode registers another barcode for a product. EAN-13 and UPC-A
+// codes are checked against their check digit.
+func (svc *Service) AddProductBarcode(ctx context.Context, productID int64, barcode model.ProductBarcode) (model.ProductBarcode, error) {
+	svc.logger.Info(fmt.Sprintf("[REQUEST] AddProductBarcode %d %+v", productID, barcode))
+
+	barcode.Barcode = strings.TrimSpace(barcode.Barcode)
+	barcode.Type = strings.ToUpper(barcode.Type)
+	err := barcode.Validate()
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] %s", err.Error()))
+		return model.ProductBarcode{}, err
+	}
+
+	_, err = svc.repo.Postgres.ReadProductByID(ctx, productID)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Product not found: %s", err.Error()))
+		return model.ProductBarcode{}, fmt.Errorf("product not found")
+	}
+
+	err = svc.repo.Postgres.WriteProductBarcode(ctx, productID, barcode)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to WriteProductBarcode: %s", err.Error()))
+		return model.ProductBarcode{}, fmt.Errorf("failed to add barcode: %w", err)
+	}
+
+	svc.logger.Info("[RESPONSE] Barcode added successfully")
+	return barcode, nil
+}
+
+func (svc *Service) DeleteProductBarcode(ctx context.Context, productID int64, barcode string) error {
+	svc.logger.Info(fmt.Sprintf("[REQUEST] DeleteProductBarcode %d %s", productID, barcode))
+
+	err := svc.repo.Postgres.DeleteProductBarcode(ctx, productID, barcode)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to DeleteProductBarcode: %s", err.Error()))
+		return fmt.Errorf("failed to delete barcode: %w", err)
+	}
+
+	svc.logger.Info("[RESPONSE] Barcode deleted successfully")
+	return nil
+}
+
+// LookupBarcode finds the product a scanned code belongs to, along with the
+// lot and expiry date of a GS1-128 label.
+func (svc *Service) LookupBarcode(ctx context.Context, code string) (model.BarcodeScan, error) {
+	svc.logger.Info(fmt.Sprintf("[REQUEST] LookupBarcode %q", code))
+
+	productID, label, err := svc.scanBarcode(ctx, code)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] %s", err.Error()))
+		return model.BarcodeScan{}, err
+	}
+
+	product, err := svc.repo.Postgres.ReadProductByID(ctx, productID)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to ReadProductByID: %s", err.Error()))
+		return model.BarcodeScan{}, fmt.Errorf("failed to get product %d: %w", productID, err)
+	}
+
+	scan := model.BarcodeScan{
+		Barcode:    code,
+		Product:    product,
+		LotNumber:  label.LotNumber,
+		ExpiryDate: label.ExpiryDate,
+	}
+
+	svc.logger.Info(fmt.Sprintf("[RESPONSE] %+v", scan))
+	return scan, nil
+}
+
+// scanBarcode resolves a scanned code to the product registered under it. A
+// GS1-128 label is looked up by its GTIN and returned parsed; any other code
+// is looked up as it is.
+func (svc *Service) scanBarcode(ctx context.Context, code string) (int64, model.GS1Label, error) {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return 0, model.GS1Label{}, fmt.Errorf("%w: empty barcode", model.ErrInvalidBarcode)
+	}
+
+	var label model.GS1Label
+	candidates := model.GTINCandidates(code)
+	if model.IsGS1(code) {
+		var err error
+		label, err = model.ParseGS1(code)
+		if err != nil {
+			return 0, model.GS1Label{}, err
+		}
+		candidates = model.GTINCandidates(label.GTIN)
+	}
+
+	productID, err := svc.repo.Postgres.ReadProductIDByBarcode(ctx, candidates)
+	if err != nil {
+		return 0, model.GS1Label{}, fmt.Errorf("failed to look up barcode: %w", err)
+	}
+
+	return productID, label, nil
+}
+
+// applyBarcode fills in the product of a movement given by Barcode rather than
+// ProductID, with the lot and expiry date of a GS1-128 label when the movement
+// does not give them. Values the movement gives must match the label.
+func (svc *Service) applyBarcode(ctx context.Context, transaction model.StockTransaction) (model.StockTransaction, error) {
+	if transaction.Barcode == "" {
+		return transaction, nil
+	}
+
+	productID, label, err := svc.scanBarcode(ctx, transaction.Barcode)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] %s", err.Error()))
+		return model.StockTransaction{}, err
+	}
+
+	if transaction.ProductID != 0 && transaction.ProductID != productID {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Barcode %q is product %d, not %d", transaction.Barcode, productID, transaction.ProductID))
+		return model.StockTransaction{}, fmt.Errorf("%w: %q belongs to product %d, not %d", model.ErrInvalidBarcode, transaction.Barcode, productID, transaction.ProductID)
+	}
+	transaction.ProductID = productID
+
+	if label.LotNumber != "" {
+		if transaction.LotNumber != "" && transaction.LotNumber != label.LotNumber {
+			svc.logger.Error(fmt.Sprintf("[ERROR] Lot %s does not match the label's %s", transaction.LotNumber, label.LotNumber))
+			return model.StockTransaction{}, fmt.Errorf("%w: lot_number %s does not match the label's %s", model.ErrInvalidBarcode, transaction.LotNumber, label.LotNumber)
+		}
+		transaction.LotNumber = label.LotNumber
+	}
+
+	if label.ExpiryDate != nil {
+		if transaction.ExpiryDate != nil && !transaction.ExpiryDate.Equal(label.ExpiryDate.Time) {
+			svc.logger.Error(fmt.Sprintf("[ERROR] Expiry date %s does not match the label's %s", transaction.ExpiryDate, label.ExpiryDate))
+			return model.StockTransaction{}, fmt.Errorf("%w: expiry_date %s does not match the label's %s", model.ErrInvalidBarcode, transaction.ExpiryDate, label.ExpiryDate)
+		}
+		transaction.ExpiryDate = label.ExpiryDate
+	}
+
+	transaction.Barcode = ""
+	return transaction, nil
+}
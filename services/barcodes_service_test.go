@@ -0,0 +1,198 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/budsx/retail-management/model"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestService_AddProductBarcode(t *testing.T) {
+	srv := NewTestServer(t)
+	defer srv.MockCtrl.Finish()
+
+	ctx := context.Background()
+	product := model.Product{ProductID: 4, ProductName: "Beras 5kg"}
+
+	t.Run("EAN-13 guessed from the code", func(t *testing.T) {
+		want := model.ProductBarcode{Barcode: "8992761111113", Type: model.BarcodeEAN13}
+		srv.MockRepo.EXPECT().ReadProductByID(gomock.Any(), int64(4)).Return(product, nil)
+		srv.MockRepo.EXPECT().WriteProductBarcode(gomock.Any(), int64(4), want).Return(nil)
+
+		got, err := srv.Service.AddProductBarcode(ctx, 4, model.ProductBarcode{Barcode: " 8992761111113 "})
+		assert.NoError(t, err)
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("UPC-A", func(t *testing.T) {
+		want := model.ProductBarcode{Barcode: "036000291452", Type: model.BarcodeUPCA}
+		srv.MockRepo.EXPECT().ReadProductByID(gomock.Any(), int64(4)).Return(product, nil)
+		srv.MockRepo.EXPECT().WriteProductBarcode(gomock.Any(), int64(4), want).Return(nil)
+
+		_, err := srv.Service.AddProductBarcode(ctx, 4, model.ProductBarcode{Barcode: "036000291452", Type: "upca"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("already registered", func(t *testing.T) {
+		srv.MockRepo.EXPECT().ReadProductByID(gomock.Any(), int64(4)).Return(product, nil)
+		srv.MockRepo.EXPECT().WriteProductBarcode(gomock.Any(), int64(4), gomock.Any()).Return(fmt.Errorf("%w: RAK-B2-001", model.ErrBarcodeInUse))
+
+		_, err := srv.Service.AddProductBarcode(ctx, 4, model.ProductBarcode{Barcode: "RAK-B2-001"})
+		assert.ErrorIs(t, err, model.ErrBarcodeInUse)
+	})
+
+	invalid := []struct {
+		name    string
+		barcode model.ProductBarcode
+	}{
+		{name: "wrong EAN-13 check digit", barcode: model.ProductBarcode{Barcode: "8992761111114"}},
+		{name: "EAN-13 of 12 digits", barcode: model.ProductBarcode{Barcode: "036000291452", Type: model.BarcodeEAN13}},
+		{name: "wrong UPC-A check digit", barcode: model.ProductBarcode{Barcode: "036000291453"}},
+		{name: "code with a slash", barcode: model.ProductBarcode{Barcode: "RAK/B2"}},
+		{name: "unknown type", barcode: model.ProductBarcode{Barcode: "12345", Type: "QR"}},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := srv.Service.AddProductBarcode(ctx, 4, tt.barcode)
+			assert.ErrorIs(t, err, model.ErrInvalidBarcode)
+		})
+	}
+}
+
+func TestService_LookupBarcode(t *testing.T) {
+	srv := NewTestServer(t)
+	defer srv.MockCtrl.Finish()
+
+	ctx := context.Background()
+	product := model.Product{ProductID: 4, ProductName: "Beras 5kg"}
+	gtin := []string{"08992761111113", "8992761111113"}
+	expiry := model.NewDate(2025, 1, 31)
+
+	tests := []struct {
+		name          string
+		code          string
+		wantBarcodes  []string
+		wantLotNumber string
+		wantExpiry    *model.Date
+	}{
+		{
+			name:         "EAN-13",
+			code:         "8992761111113",
+			wantBarcodes: []string{"8992761111113"},
+		},
+		{
+			name:         "UPC-A scanned as EAN-13",
+			code:         "0036000291452",
+			wantBarcodes: []string{"0036000291452", "036000291452"},
+		},
+		{
+			name:          "GS1-128 with brackets",
+			code:          "(01)08992761111113(17)250100(10)LOT-7",
+			wantBarcodes:  gtin,
+			wantLotNumber: "LOT-7",
+			wantExpiry:    &expiry,
+		},
+		{
+			name:          "GS1-128 as scanned",
+			code:          "]C10108992761111113" + "10LOT-7\x1d" + "17250131",
+			wantBarcodes:  gtin,
+			wantLotNumber: "LOT-7",
+			wantExpiry:    &expiry,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv.MockRepo.EXPECT().ReadProductIDByBarcode(gomock.Any(), tt.wantBarcodes).Return(int64(4), nil)
+			srv.MockRepo.EXPECT().ReadProductByID(gomock.Any(), int64(4)).Return(product, nil)
+
+			got, err := srv.Service.LookupBarcode(ctx, tt.code)
+			assert.NoError(t, err)
+			assert.Equal(t, model.BarcodeScan{Barcode: tt.code, Product: product, LotNumber: tt.wantLotNumber, ExpiryDate: tt.wantExpiry}, got)
+		})
+	}
+
+	t.Run("not registered", func(t *testing.T) {
+		srv.MockRepo.EXPECT().ReadProductIDByBarcode(gomock.Any(), []string{"8992761111113"}).Return(int64(0), fmt.Errorf("%w: 8992761111113", model.ErrBarcodeNotFound))
+
+		_, err := srv.Service.LookupBarcode(ctx, "8992761111113")
+		assert.ErrorIs(t, err, model.ErrBarcodeNotFound)
+	})
+
+	for _, code := range []string{
+		"(01)08992761111114",
+		"(01)08992761111113(3103)000500",
+		"(17)250131(10)LOT-7",
+		"(01)08992761111113(17)251340",
+		"]C1010899276111",
+	} {
+		t.Run("unreadable "+code, func(t *testing.T) {
+			_, err := srv.Service.LookupBarcode(ctx, code)
+			assert.ErrorIs(t, err, model.ErrInvalidBarcode)
+		})
+	}
+}
+
+func TestService_CreateStockTransaction_Barcode(t *testing.T) {
+	srv := NewTestServer(t)
+	defer srv.MockCtrl.Finish()
+
+	ctx := context.Background()
+	location := model.Location{LocationID: 2, LocationName: "Rak B2", WarehouseID: 1}
+	product := model.Product{ProductID: 4, ProductName: "Beras 5kg"}
+	expiry := model.NewDate(2025, 1, 31)
+
+	t.Run("lot and expiry from a GS1-128 label", func(t *testing.T) {
+		srv.MockRepo.EXPECT().ReadProductIDByBarcode(gomock.Any(), []string{"08992761111113", "8992761111113"}).Return(int64(4), nil)
+		srv.MockRepo.EXPECT().ReadLocationByID(gomock.Any(), int64(2)).Return(location, nil)
+		srv.MockRepo.EXPECT().ReadProductByID(gomock.Any(), int64(4)).Return(product, nil)
+		srv.MockRepo.EXPECT().
+			CreateStockTransaction(gomock.Any(), model.StockTransaction{
+				ProductID:       4,
+				WarehouseID:     1,
+				LocationID:      2,
+				TransactionType: model.StockIn,
+				Quantity:        10,
+				LotNumber:       "LOT-7",
+				ExpiryDate:      &expiry,
+			}).
+			Return(nil)
+
+		err := srv.Service.CreateStockTransaction(ctx, model.StockTransaction{
+			Barcode:         "(01)08992761111113(17)250131(10)LOT-7",
+			LocationID:      2,
+			TransactionType: model.StockIn,
+			Quantity:        10,
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("lot other than the label's", func(t *testing.T) {
+		srv.MockRepo.EXPECT().ReadProductIDByBarcode(gomock.Any(), gomock.Any()).Return(int64(4), nil)
+
+		err := srv.Service.CreateStockTransaction(ctx, model.StockTransaction{
+			Barcode:         "(01)08992761111113(10)LOT-7",
+			LocationID:      2,
+			TransactionType: model.StockIn,
+			Quantity:        10,
+			LotNumber:       "LOT-8",
+		})
+		assert.ErrorIs(t, err, model.ErrInvalidBarcode)
+	})
+
+	t.Run("barcode of another product", func(t *testing.T) {
+		srv.MockRepo.EXPECT().ReadProductIDByBarcode(gomock.Any(), []string{"8992761111113"}).Return(int64(4), nil)
+
+		err := srv.Service.CreateStockTransaction(ctx, model.StockTransaction{
+			ProductID:       5,
+			Barcode:         "8992761111113",
+			LocationID:      2,
+			TransactionType: model.StockOut,
+			Quantity:        1,
+		})
+		assert.ErrorIs(t, err, model.ErrInvalidBarcode)
+	})
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/budsx/retail-management/model"
+)
+
+// SetBundleComponents replaces the bill of materials of a bundle. Components
+// must be plain, non-serialized products, and a bundle can neither be
+// serialized itself nor be a component of another bundle; the repository
+// checks both again under lock. Neither can be the parent of variants. An
+// empty list turns the bundle back into a plain product.
+func (svc *Service) SetBundleComponents(ctx context.Context, bundleID int64, components []model.BundleComponent) error {
+	svc.logger.Info(fmt.Sprintf("[REQUEST] SetBundleComponents %d %+v", bundleID, components))
+
+	bundle, err := svc.repo.Postgres.ReadProductByID(ctx, bundleID)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Product not found: %s", err.Error()))
+		return fmt.Errorf("product not found")
+	}
+
+	if bundle.HasVariants() && len(components) > 0 {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Product %d keeps its stock per variant", bundleID))
+		return fmt.Errorf("%w: product %d cannot be a bundle", model.ErrVariantParent, bundleID)
+	}
+
+	if bundle.Serialized() && len(components) > 0 {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Product %d is serialized", bundleID))
+		return fmt.Errorf("%w: serialized product %d cannot be a bundle", model.ErrInvalidBundle, bundleID)
+	}
+
+	seen := make(map[int64]bool, len(components))
+	for _, component := range components {
+		if component.Quantity <= 0 || component.ComponentID == bundleID || seen[component.ComponentID] {
+			svc.logger.Error(fmt.Sprintf("[ERROR] Invalid component %+v", component))
+			return fmt.Errorf("%w: components must be distinct other products with a quantity greater than zero", model.ErrInvalidBundle)
+		}
+		seen[component.ComponentID] = true
+
+		product, err := svc.repo.Postgres.ReadProductByID(ctx, component.ComponentID)
+		if err != nil {
+			svc.logger.Error(fmt.Sprintf("[ERROR] Component not found: %s", err.Error()))
+			return fmt.Errorf("%w: product %d not found", model.ErrInvalidBundle, component.ComponentID)
+		}
+
+		if product.HasVariants() {
+			svc.logger.Error(fmt.Sprintf("[ERROR] Product %d keeps its stock per variant", component.ComponentID))
+			return fmt.Errorf("%w: product %d cannot be a component", model.ErrVariantParent, component.ComponentID)
+		}
+
+		if product.IsBundle || product.Serialized() {
+			svc.logger.Error(fmt.Sprintf("[ERROR] Product %d cannot be a component", component.ComponentID))
+			return fmt.Errorf("%w: product %d is a bundle or serialized", model.ErrInvalidBundle, component.ComponentID)
+		}
+	}
+
+	err = svc.repo.Postgres.SetBundleComponents(ctx, bundleID, components)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to SetBundleComponents: %s", err.Error()))
+		return fmt.Errorf("failed to set bundle components: %w", err)
+	}
+
+	svc.logger.Info("[RESPONSE] Bundle components set successfully")
+	return nil
+}
+
+// GetBundleAvailability works out how many of a bundle can be shipped from one
+// location, or from all of them when locationID is 0. Components are only
+// combined within a location, as that is where a bundle OUT takes them from.
+func (svc *Service) GetBundleAvailability(ctx context.Context, bundleID, locationID int64) (model.BundleAvailability, error) {
+	svc.logger.Info(fmt.Sprintf("[REQUEST] GetBundleAvailability %d at location %d", bundleID, locationID))
+
+	components, err := svc.repo.Postgres.ReadBundleComponents(ctx, bundleID)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to ReadBundleComponents: %s", err.Error()))
+		return model.BundleAvailability{}, fmt.Errorf("failed to get bundle components: %w", err)
+	}
+	if len(components) == 0 {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Product %d has no components", bundleID))
+		return model.BundleAvailability{}, fmt.Errorf("%w: %d", model.ErrNotBundle, bundleID)
+	}
+
+	stocks, err := svc.repo.Postgres.GetBundleStock(ctx, bundleID, locationID)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to GetBundleStock: %s", err.Error()))
+		return model.BundleAvailability{}, fmt.Errorf("failed to get bundle stock: %w", err)
+	}
+
+	availability := model.BundleAvailability{ProductID: bundleID}
+	componentStock := map[int64]int64{}
+	stockByLocation := map[int64]map[int64]int64{}
+	for _, stock := range stocks {
+		if stock.ProductID == bundleID {
+			availability.AssembledStock += stock.AvailableStock
+			continue
+		}
+		componentStock[stock.ProductID] += stock.AvailableStock
+		if stockByLocation[stock.LocationID] == nil {
+			stockByLocation[stock.LocationID] = map[int64]int64{}
+		}
+		stockByLocation[stock.LocationID][stock.ProductID] = stock.AvailableStock
+	}
+
+	for _, locationStock := range stockByLocation {
+		buildable := int64(-1)
+		for _, component := range components {
+			sets := locationStock[component.ComponentID] / component.Quantity
+			if buildable < 0 || sets < buildable {
+				buildable = sets
+			}
+		}
+		if buildable > 0 {
+			availability.BuildableStock += buildable
+		}
+	}
+	availability.AvailableStock = availability.AssembledStock + availability.BuildableStock
+
+	availability.Components = make([]model.ComponentAvailability, len(components))
+	for i, component := range components {
+		availability.Components[i] = model.ComponentAvailability{
+			BundleComponent: component,
+			AvailableStock:  componentStock[component.ComponentID],
+		}
+	}
+
+	svc.logger.Info(fmt.Sprintf("[RESPONSE] %+v", availability))
+	return availability, nil
+}
+
+// AssembleBundle turns components at the assembly's location into finished,
+// stocked bundles and returns the ledger row of the bundles.
+func (svc *Service) AssembleBundle(ctx context.Context, assembly model.StockTransaction) (model.StockTransaction, error) {
+	svc.logger.Info(fmt.Sprintf("[REQUEST] AssembleBundle %+v", assembly))
+
+	if assembly.Quantity <= 0 {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Invalid quantity %d", assembly.Quantity))
+		return model.StockTransaction{}, fmt.Errorf("quantity must be greater than zero")
+	}
+
+	warehouseID, err := svc.validateStockLocation(ctx, assembly.WarehouseID, assembly.LocationID, true)
+	if err != nil {
+		return model.StockTransaction{}, err
+	}
+	assembly.WarehouseID = warehouseID
+	assembly.TransactionType = model.StockAssembly
+
+	bundle, err := svc.repo.Postgres.ReadProductByID(ctx, assembly.ProductID)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Product not found: %s", err.Error()))
+		return model.StockTransaction{}, fmt.Errorf("product not found")
+	}
+	if bundle.ArchivedAt != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Product %d is archived", bundle.ProductID))
+		return model.StockTransaction{}, fmt.Errorf("%w: product %d", model.ErrArchived, bundle.ProductID)
+	}
+
+	assembly.Components, err = svc.repo.Postgres.ReadBundleComponents(ctx, assembly.ProductID)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to ReadBundleComponents: %s", err.Error()))
+		return model.StockTransaction{}, fmt.Errorf("failed to get bundle components: %w", err)
+	}
+	if len(assembly.Components) == 0 {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Product %d has no components", assembly.ProductID))
+		return model.StockTransaction{}, fmt.Errorf("%w: %d", model.ErrNotBundle, assembly.ProductID)
+	}
+
+	transactionID, err := svc.repo.Postgres.AssembleBundle(ctx, assembly)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to AssembleBundle: %s", err.Error()))
+		return model.StockTransaction{}, fmt.Errorf("failed to assemble bundle: %w", err)
+	}
+	for _, component := range assembly.Components {
+		svc.queueLowStockCheck(component.ComponentID, assembly.WarehouseID)
+	}
+
+	transaction, err := svc.repo.Postgres.GetStockTransactionByID(ctx, transactionID)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to GetStockTransactionByID: %s", err.Error()))
+		return model.StockTransaction{}, fmt.Errorf("failed to read assembly %d: %w", transactionID, err)
+	}
+
+	svc.logger.Info(fmt.Sprintf("[RESPONSE] Assembled %d of bundle %d as transaction %d", assembly.Quantity, assembly.ProductID, transactionID))
+	return transaction, nil
+}
@@ -0,0 +1,178 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/budsx/retail-management/model"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestService_SetBundleComponents(t *testing.T) {
+	srv := NewTestServer(t)
+	defer srv.MockCtrl.Finish()
+
+	ctx := context.Background()
+	hamper := model.Product{ProductID: 10, ProductName: "Parcel Lebaran"}
+	syrup := model.Product{ProductID: 1, ProductName: "Sirup Marjan"}
+	tracked := true
+	phone := model.Product{ProductID: 3, ProductName: "Ponsel", IsSerialized: &tracked}
+
+	t.Run("components are saved", func(t *testing.T) {
+		components := []model.BundleComponent{{ComponentID: 1, Quantity: 2}}
+		srv.MockRepo.EXPECT().ReadProductByID(gomock.Any(), int64(10)).Return(hamper, nil)
+		srv.MockRepo.EXPECT().ReadProductByID(gomock.Any(), int64(1)).Return(syrup, nil)
+		srv.MockRepo.EXPECT().SetBundleComponents(gomock.Any(), int64(10), components).Return(nil)
+
+		err := srv.Service.SetBundleComponents(ctx, 10, components)
+		assert.NoError(t, err)
+	})
+
+	t.Run("serialized component", func(t *testing.T) {
+		srv.MockRepo.EXPECT().ReadProductByID(gomock.Any(), int64(10)).Return(hamper, nil)
+		srv.MockRepo.EXPECT().ReadProductByID(gomock.Any(), int64(3)).Return(phone, nil)
+
+		err := srv.Service.SetBundleComponents(ctx, 10, []model.BundleComponent{{ComponentID: 3, Quantity: 1}})
+		assert.ErrorIs(t, err, model.ErrInvalidBundle)
+	})
+
+	t.Run("parent of variants as a component", func(t *testing.T) {
+		srv.MockRepo.EXPECT().ReadProductByID(gomock.Any(), int64(10)).Return(hamper, nil)
+		srv.MockRepo.EXPECT().ReadProductByID(gomock.Any(), int64(6)).Return(model.Product{ProductID: 6, VariantAttributes: []string{"size"}}, nil)
+
+		err := srv.Service.SetBundleComponents(ctx, 10, []model.BundleComponent{{ComponentID: 6, Quantity: 1}})
+		assert.ErrorIs(t, err, model.ErrVariantParent)
+	})
+
+	t.Run("bundle cannot contain itself", func(t *testing.T) {
+		srv.MockRepo.EXPECT().ReadProductByID(gomock.Any(), int64(10)).Return(hamper, nil)
+
+		err := srv.Service.SetBundleComponents(ctx, 10, []model.BundleComponent{{ComponentID: 10, Quantity: 1}})
+		assert.ErrorIs(t, err, model.ErrInvalidBundle)
+	})
+}
+
+func TestService_GetBundleAvailability(t *testing.T) {
+	srv := NewTestServer(t)
+	defer srv.MockCtrl.Finish()
+
+	ctx := context.Background()
+	components := []model.BundleComponent{
+		{ComponentID: 1, Quantity: 2},
+		{ComponentID: 2, Quantity: 1},
+	}
+
+	t.Run("components only combine within a location", func(t *testing.T) {
+		srv.MockRepo.EXPECT().ReadBundleComponents(gomock.Any(), int64(10)).Return(components, nil)
+		srv.MockRepo.EXPECT().GetBundleStock(gomock.Any(), int64(10), int64(0)).Return([]model.LocationStock{
+			{ProductID: 1, LocationID: 2, AvailableStock: 7},
+			{ProductID: 2, LocationID: 2, AvailableStock: 5},
+			{ProductID: 10, LocationID: 2, AvailableStock: 1},
+			{ProductID: 1, LocationID: 3, AvailableStock: 10},
+		}, nil)
+
+		availability, err := srv.Service.GetBundleAvailability(ctx, 10, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, model.BundleAvailability{
+			ProductID:      10,
+			AssembledStock: 1,
+			BuildableStock: 3,
+			AvailableStock: 4,
+			Components: []model.ComponentAvailability{
+				{BundleComponent: components[0], AvailableStock: 17},
+				{BundleComponent: components[1], AvailableStock: 5},
+			},
+		}, availability)
+	})
+
+	t.Run("plain product", func(t *testing.T) {
+		srv.MockRepo.EXPECT().ReadBundleComponents(gomock.Any(), int64(1)).Return([]model.BundleComponent{}, nil)
+
+		_, err := srv.Service.GetBundleAvailability(ctx, 1, 0)
+		assert.ErrorIs(t, err, model.ErrNotBundle)
+	})
+}
+
+func TestService_CreateStockTransaction_Bundle(t *testing.T) {
+	srv := NewTestServer(t)
+	defer srv.MockCtrl.Finish()
+
+	ctx := context.Background()
+	location := model.Location{LocationID: 2, LocationName: "Rak B2", WarehouseID: 1}
+	hamper := model.Product{ProductID: 10, ProductName: "Parcel Lebaran", IsBundle: true}
+	components := []model.BundleComponent{{ComponentID: 1, Quantity: 2}}
+
+	srv.MockRepo.EXPECT().ReadLocationByID(gomock.Any(), int64(2)).Return(location, nil)
+	srv.MockRepo.EXPECT().ReadProductByID(gomock.Any(), int64(10)).Return(hamper, nil)
+	srv.MockRepo.EXPECT().ReadBundleComponents(gomock.Any(), int64(10)).Return(components, nil)
+	srv.MockRepo.EXPECT().
+		CreateStockTransaction(gomock.Any(), model.StockTransaction{
+			ProductID:       10,
+			WarehouseID:     1,
+			LocationID:      2,
+			TransactionType: model.StockOut,
+			Quantity:        3,
+			Components:      components,
+		}).
+		Return(nil)
+
+	err := srv.Service.CreateStockTransaction(ctx, model.StockTransaction{
+		ProductID:       10,
+		LocationID:      2,
+		TransactionType: model.StockOut,
+		Quantity:        3,
+	})
+	assert.NoError(t, err)
+}
+
+func TestService_AssembleBundle(t *testing.T) {
+	srv := NewTestServer(t)
+	defer srv.MockCtrl.Finish()
+
+	ctx := context.Background()
+	location := model.Location{LocationID: 2, LocationName: "Rak B2", WarehouseID: 1}
+	components := []model.BundleComponent{{ComponentID: 1, Quantity: 2}}
+
+	t.Run("components become finished bundles", func(t *testing.T) {
+		posted := model.StockTransaction{TransactionID: 12, ProductID: 10, LocationID: 2, TransactionType: model.StockAssembly, Quantity: 3}
+		srv.MockRepo.EXPECT().ReadLocationByID(gomock.Any(), int64(2)).Return(location, nil)
+		srv.MockRepo.EXPECT().ReadProductByID(gomock.Any(), int64(10)).Return(model.Product{ProductID: 10, IsBundle: true}, nil)
+		srv.MockRepo.EXPECT().ReadBundleComponents(gomock.Any(), int64(10)).Return(components, nil)
+		srv.MockRepo.EXPECT().
+			AssembleBundle(gomock.Any(), model.StockTransaction{
+				ProductID:       10,
+				WarehouseID:     1,
+				LocationID:      2,
+				TransactionType: model.StockAssembly,
+				Quantity:        3,
+				CreatedBy:       1,
+				Components:      components,
+			}).
+			Return(int64(12), nil)
+		srv.MockRepo.EXPECT().GetStockTransactionByID(gomock.Any(), int64(12)).Return(posted, nil)
+
+		transaction, err := srv.Service.AssembleBundle(ctx, model.StockTransaction{ProductID: 10, LocationID: 2, Quantity: 3, CreatedBy: 1})
+		assert.NoError(t, err)
+		assert.Equal(t, posted, transaction)
+	})
+
+	t.Run("plain product", func(t *testing.T) {
+		srv.MockRepo.EXPECT().ReadLocationByID(gomock.Any(), int64(2)).Return(location, nil)
+		srv.MockRepo.EXPECT().ReadProductByID(gomock.Any(), int64(1)).Return(model.Product{ProductID: 1}, nil)
+		srv.MockRepo.EXPECT().ReadBundleComponents(gomock.Any(), int64(1)).Return([]model.BundleComponent{}, nil)
+
+		_, err := srv.Service.AssembleBundle(ctx, model.StockTransaction{ProductID: 1, LocationID: 2, Quantity: 3, CreatedBy: 1})
+		assert.ErrorIs(t, err, model.ErrNotBundle)
+	})
+
+	t.Run("archived bundle", func(t *testing.T) {
+		archivedAt := time.Now()
+		srv.MockRepo.EXPECT().ReadLocationByID(gomock.Any(), int64(2)).Return(location, nil)
+		srv.MockRepo.EXPECT().ReadProductByID(gomock.Any(), int64(10)).Return(model.Product{ProductID: 10, IsBundle: true, ArchivedAt: &archivedAt}, nil)
+
+		_, err := srv.Service.AssembleBundle(ctx, model.StockTransaction{ProductID: 10, LocationID: 2, Quantity: 3, CreatedBy: 1})
+		assert.ErrorIs(t, err, model.ErrArchived)
+	})
+}
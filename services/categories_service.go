@@ -0,0 +1,210 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/budsx/retail-management/middleware"
+	"github.com/budsx/retail-management/model"
+)
+
+// AddCategory creates a category under category.ParentID, or at the root when
+// it is 0, and returns it with its new ID.
+func (svc *Service) AddCategory(ctx context.Context, category model.Category) (model.Category, error) {
+	svc.logger.Info(fmt.Sprintf("[REQUEST] AddCategory %+v", category))
+
+	category.CategoryName = strings.TrimSpace(category.CategoryName)
+	err := svc.validateCategory(ctx, category)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] %s", err.Error()))
+		return model.Category{}, err
+	}
+
+	category.CategoryID, err = svc.repo.Postgres.WriteCategory(ctx, category)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to WriteCategory: %s", err.Error()))
+		return model.Category{}, fmt.Errorf("failed to add category: %w", err)
+	}
+
+	svc.logger.Info(fmt.Sprintf("[RESPONSE] Category added successfully: %d", category.CategoryID))
+	return category, nil
+}
+
+// EditCategory renames a category and moves it, with everything below it,
+// under category.ParentID.
+func (svc *Service) EditCategory(ctx context.Context, category model.Category) error {
+	svc.logger.Info(fmt.Sprintf("[REQUEST] EditCategory %+v", category))
+
+	category.CategoryName = strings.TrimSpace(category.CategoryName)
+	if category.CategoryName == "" {
+		svc.logger.Error("[ERROR] Category name is empty")
+		return fmt.Errorf("%w: category name is required", model.ErrInvalidCategory)
+	}
+
+	err := svc.repo.Postgres.UpdateCategory(ctx, category)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to UpdateCategory: %s", err.Error()))
+		return fmt.Errorf("failed to update category: %w", err)
+	}
+
+	svc.logger.Info("[RESPONSE] Category updated successfully")
+	return nil
+}
+
+// DeleteCategory deletes a category that has no products or subcategories.
+func (svc *Service) DeleteCategory(ctx context.Context, categoryID int64) error {
+	svc.logger.Info(fmt.Sprintf("[REQUEST] DeleteCategory %d", categoryID))
+
+	err := svc.repo.Postgres.DeleteCategory(ctx, categoryID)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to DeleteCategory: %s", err.Error()))
+		return fmt.Errorf("failed to delete category: %w", err)
+	}
+
+	svc.logger.Info("[RESPONSE] Category deleted successfully")
+	return nil
+}
+
+// GetCategories returns the whole category tree, its roots holding their
+// subcategories.
+func (svc *Service) GetCategories(ctx context.Context) ([]model.Category, error) {
+	svc.logger.Info("[REQUEST] GetCategories")
+
+	categories, err := svc.repo.Postgres.ReadCategories(ctx)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to ReadCategories: %s", err.Error()))
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+
+	tree := categoryTree(categories, 0)
+	if tree == nil {
+		tree = []model.Category{}
+	}
+
+	svc.logger.Info(fmt.Sprintf("[RESPONSE] %d categories", len(categories)))
+	return tree, nil
+}
+
+// GetCategoryByID returns a category with the subtree below it.
+func (svc *Service) GetCategoryByID(ctx context.Context, categoryID int64) (model.Category, error) {
+	svc.logger.Info(fmt.Sprintf("[REQUEST] GetCategoryByID %d", categoryID))
+
+	category, err := svc.repo.Postgres.ReadCategoryByID(ctx, categoryID)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to ReadCategoryByID: %s", err.Error()))
+		return model.Category{}, fmt.Errorf("failed to get category: %w", err)
+	}
+
+	categories, err := svc.repo.Postgres.ReadCategories(ctx)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to ReadCategories: %s", err.Error()))
+		return model.Category{}, fmt.Errorf("failed to get subcategories of %d: %w", categoryID, err)
+	}
+	category.Children = categoryTree(categories, categoryID)
+
+	svc.logger.Info(fmt.Sprintf("[RESPONSE] %+v", category))
+	return category, nil
+}
+
+// GetCategoryStocks rolls the stock report up the category tree: each category
+// reports the stock of its own products and of every category below it.
+// locationID 0 covers all locations and a nil asOf reports current stock.
+// Products without a category are not counted.
+func (svc *Service) GetCategoryStocks(ctx context.Context, locationID int64, asOf *time.Time) ([]model.CategoryStock, error) {
+	user := middleware.GetUserInfoByContext(ctx)
+	svc.logger.Info(fmt.Sprintf("[REQUEST] GetCategoryStocks %d %v - %+v", locationID, asOf, user))
+
+	stocks, err := svc.readProductStocks(ctx, locationID, asOf)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to get product stocks: %s", err.Error()))
+		return nil, fmt.Errorf("failed to get product stocks: %w", err)
+	}
+
+	categories, err := svc.repo.Postgres.ReadCategories(ctx)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to ReadCategories: %s", err.Error()))
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+
+	categoryStocks := rollupCategoryStocks(categories, stocks)
+
+	svc.logger.Info(fmt.Sprintf("[RESPONSE] %+v", categoryStocks))
+	return categoryStocks, nil
+}
+
+// validateCategory checks a new category has a name and, when it is not a
+// root, an existing parent.
+func (svc *Service) validateCategory(ctx context.Context, category model.Category) error {
+	if category.CategoryName == "" {
+		return fmt.Errorf("%w: category name is required", model.ErrInvalidCategory)
+	}
+
+	if category.ParentID != 0 {
+		_, err := svc.repo.Postgres.ReadCategoryByID(ctx, category.ParentID)
+		if err != nil {
+			return fmt.Errorf("%w: parent category %d not found", model.ErrInvalidCategory, category.ParentID)
+		}
+	}
+
+	return nil
+}
+
+// checkProductCategory checks the category a product is assigned to, or
+// filtered by, exists. 0 means no category.
+func (svc *Service) checkProductCategory(ctx context.Context, categoryID int64) error {
+	if categoryID == 0 {
+		return nil
+	}
+
+	_, err := svc.repo.Postgres.ReadCategoryByID(ctx, categoryID)
+	return err
+}
+
+// categoryTree nests the categories below parentID under their parents.
+func categoryTree(categories []model.Category, parentID int64) []model.Category {
+	children := map[int64][]model.Category{}
+	for _, category := range categories {
+		children[category.ParentID] = append(children[category.ParentID], category)
+	}
+
+	var nest func(parentID int64) []model.Category
+	nest = func(parentID int64) []model.Category {
+		nodes := children[parentID]
+		for i := range nodes {
+			nodes[i].Children = nest(nodes[i].CategoryID)
+		}
+		return nodes
+	}
+
+	return nest(parentID)
+}
+
+// rollupCategoryStocks adds the stock of every product to its category and to
+// each category above it.
+func rollupCategoryStocks(categories []model.Category, stocks []model.ProductStock) []model.CategoryStock {
+	categoryStocks := make([]model.CategoryStock, len(categories))
+	index := make(map[int64]int, len(categories))
+	for i, category := range categories {
+		categoryStocks[i] = model.CategoryStock{
+			CategoryID:   category.CategoryID,
+			CategoryName: category.CategoryName,
+			ParentID:     category.ParentID,
+		}
+		index[category.CategoryID] = i
+	}
+
+	for _, stock := range stocks {
+		// The tree has no loops, but never walk further than its depth can be
+		i, ok := index[stock.CategoryID]
+		for depth := 0; ok && depth < len(categoryStocks); depth++ {
+			categoryStocks[i].TotalStock += stock.TotalStock
+			categoryStocks[i].ReservedStock += stock.ReservedStock
+			categoryStocks[i].AvailableStock += stock.AvailableStock
+			i, ok = index[categoryStocks[i].ParentID]
+		}
+	}
+
+	return categoryStocks
+}
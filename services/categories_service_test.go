@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/budsx/retail-management/middleware"
+	"github.com/budsx/retail-management/model"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestService_AddCategory(t *testing.T) {
+	srv := NewTestServer(t)
+	defer srv.MockCtrl.Finish()
+
+	ctx := context.Background()
+
+	t.Run("subcategory of an existing parent", func(t *testing.T) {
+		srv.MockRepo.EXPECT().ReadCategoryByID(gomock.Any(), int64(1)).Return(model.Category{CategoryID: 1, CategoryName: "Sembako"}, nil)
+		srv.MockRepo.EXPECT().WriteCategory(gomock.Any(), model.Category{CategoryName: "Beras", ParentID: 1}).Return(int64(2), nil)
+
+		got, err := srv.Service.AddCategory(ctx, model.Category{CategoryName: " Beras ", ParentID: 1})
+		assert.NoError(t, err)
+		assert.Equal(t, model.Category{CategoryID: 2, CategoryName: "Beras", ParentID: 1}, got)
+	})
+
+	t.Run("unknown parent", func(t *testing.T) {
+		srv.MockRepo.EXPECT().ReadCategoryByID(gomock.Any(), int64(9)).Return(model.Category{}, fmt.Errorf("%w: 9", model.ErrCategoryNotFound))
+
+		_, err := srv.Service.AddCategory(ctx, model.Category{CategoryName: "Beras", ParentID: 9})
+		assert.ErrorIs(t, err, model.ErrInvalidCategory)
+	})
+
+	t.Run("blank name", func(t *testing.T) {
+		_, err := srv.Service.AddCategory(ctx, model.Category{CategoryName: "  "})
+		assert.ErrorIs(t, err, model.ErrInvalidCategory)
+	})
+}
+
+func TestService_GetCategories(t *testing.T) {
+	srv := NewTestServer(t)
+	defer srv.MockCtrl.Finish()
+
+	ctx := context.Background()
+	categories := []model.Category{
+		{CategoryID: 1, CategoryName: "Sembako"},
+		{CategoryID: 2, CategoryName: "Beras", ParentID: 1},
+		{CategoryID: 3, CategoryName: "Minuman"},
+		{CategoryID: 4, CategoryName: "Beras Merah", ParentID: 2},
+	}
+
+	t.Run("tree of all categories", func(t *testing.T) {
+		srv.MockRepo.EXPECT().ReadCategories(gomock.Any()).Return(categories, nil)
+
+		got, err := srv.Service.GetCategories(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, []model.Category{
+			{CategoryID: 1, CategoryName: "Sembako", Children: []model.Category{
+				{CategoryID: 2, CategoryName: "Beras", ParentID: 1, Children: []model.Category{
+					{CategoryID: 4, CategoryName: "Beras Merah", ParentID: 2},
+				}},
+			}},
+			{CategoryID: 3, CategoryName: "Minuman"},
+		}, got)
+	})
+
+	t.Run("no categories", func(t *testing.T) {
+		srv.MockRepo.EXPECT().ReadCategories(gomock.Any()).Return([]model.Category{}, nil)
+
+		got, err := srv.Service.GetCategories(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, []model.Category{}, got)
+	})
+
+	t.Run("subtree of a category", func(t *testing.T) {
+		srv.MockRepo.EXPECT().ReadCategoryByID(gomock.Any(), int64(2)).Return(categories[1], nil)
+		srv.MockRepo.EXPECT().ReadCategories(gomock.Any()).Return(categories, nil)
+
+		got, err := srv.Service.GetCategoryByID(ctx, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, model.Category{CategoryID: 2, CategoryName: "Beras", ParentID: 1, Children: []model.Category{
+			{CategoryID: 4, CategoryName: "Beras Merah", ParentID: 2},
+		}}, got)
+	})
+}
+
+func TestService_GetCategoryStocks(t *testing.T) {
+	srv := NewTestServer(t)
+	defer srv.MockCtrl.Finish()
+
+	ctx := context.WithValue(context.Background(), middleware.ContextKeyUserID, int64(3))
+	ctx = context.WithValue(ctx, middleware.ContextKeyUsername, "gudang")
+	categories := []model.Category{
+		{CategoryID: 1, CategoryName: "Sembako"},
+		{CategoryID: 2, CategoryName: "Beras", ParentID: 1},
+		{CategoryID: 3, CategoryName: "Minuman"},
+	}
+	stocks := []model.ProductStock{
+		{ProductID: 1, TotalStock: 10, ReservedStock: 2, AvailableStock: 8, CategoryID: 2},
+		{ProductID: 2, TotalStock: 5, AvailableStock: 5, CategoryID: 1},
+		{ProductID: 3, TotalStock: 7, AvailableStock: 7},
+	}
+	want := []model.CategoryStock{
+		{CategoryID: 1, CategoryName: "Sembako", TotalStock: 15, ReservedStock: 2, AvailableStock: 13},
+		{CategoryID: 2, CategoryName: "Beras", ParentID: 1, TotalStock: 10, ReservedStock: 2, AvailableStock: 8},
+		{CategoryID: 3, CategoryName: "Minuman"},
+	}
+
+	t.Run("current stock of all locations", func(t *testing.T) {
+		srv.MockRepo.EXPECT().GetTotalStocks(gomock.Any()).Return(stocks, nil)
+		srv.MockRepo.EXPECT().ReadCategories(gomock.Any()).Return(categories, nil)
+
+		got, err := srv.Service.GetCategoryStocks(ctx, 0, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("stock of a location as of a date", func(t *testing.T) {
+		asOf := time.Date(2024, 1, 31, 23, 59, 59, 0, time.UTC)
+		srv.MockRepo.EXPECT().GetTotalStockByLocationAsOf(gomock.Any(), int64(4), asOf).Return(stocks, nil)
+		srv.MockRepo.EXPECT().ReadCategories(gomock.Any()).Return(categories, nil)
+
+		got, err := srv.Service.GetCategoryStocks(ctx, 4, &asOf)
+		assert.NoError(t, err)
+		assert.Equal(t, want, got)
+	})
+}
+
+func TestService_ProductCategory(t *testing.T) {
+	srv := NewTestServer(t)
+	defer srv.MockCtrl.Finish()
+
+	ctx := context.Background()
+
+	t.Run("product added to a category", func(t *testing.T) {
+		product := model.Product{ProductName: "Beras 5kg", SKU: "BRS-5", Price: 70000, BaseUnit: "PCS", CategoryID: 2}
+		srv.MockRepo.EXPECT().ReadCategoryByID(gomock.Any(), int64(2)).Return(model.Category{CategoryID: 2}, nil)
+		srv.MockRepo.EXPECT().WriteProduct(gomock.Any(), product).Return(nil)
+
+		err := srv.Service.AddProduct(ctx, product)
+		assert.NoError(t, err)
+	})
+
+	t.Run("product moved to an unknown category", func(t *testing.T) {
+		srv.MockRepo.EXPECT().ReadCategoryByID(gomock.Any(), int64(9)).Return(model.Category{}, fmt.Errorf("%w: 9", model.ErrCategoryNotFound))
+
+		err := srv.Service.EditProduct(ctx, model.Product{ProductID: 1, ProductName: "Beras 5kg", CategoryID: 9})
+		assert.ErrorIs(t, err, model.ErrCategoryNotFound)
+	})
+
+	t.Run("products of an unknown category", func(t *testing.T) {
+		srv.MockRepo.EXPECT().ReadCategoryByID(gomock.Any(), int64(9)).Return(model.Category{}, fmt.Errorf("%w: 9", model.ErrCategoryNotFound))
+
+		_, _, err := srv.Service.GetProductsByCursor(ctx, model.ProductFilter{CategoryID: 9}, model.CursorPagination{Limit: 10})
+		assert.ErrorIs(t, err, model.ErrCategoryNotFound)
+	})
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/budsx/retail-management/middleware"
+	"github.com/budsx/retail-management/model"
+)
+
+// StartIdempotentRequest claims an Idempotency-Key for the current user. It
+// reports true when the request should be processed, or returns the stored
+// record of the first request with the same key so that its response can be
+// replayed. A first request that has not finished within the configured lease
+// is taken to be abandoned and the key is claimed again.
+func (svc *Service) StartIdempotentRequest(ctx context.Context, key, fingerprint string) (model.IdempotencyRecord, bool, error) {
+	user := middleware.GetUserInfoByContext(ctx)
+	svc.logger.Info(fmt.Sprintf("[REQUEST] StartIdempotentRequest %s - %+v", key, user))
+
+	record := model.IdempotencyRecord{
+		UserID:             user.UserID,
+		Key:                key,
+		RequestFingerprint: fingerprint,
+	}
+
+	claimed, err := svc.repo.Postgres.CreateIdempotencyKey(ctx, record, svc.config.IdempotencyLease)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to CreateIdempotencyKey: %s", err.Error()))
+		return model.IdempotencyRecord{}, false, fmt.Errorf("failed to create idempotency key: %w", err)
+	}
+	if claimed {
+		return record, true, nil
+	}
+
+	stored, err := svc.repo.Postgres.ReadIdempotencyKey(ctx, user.UserID, key)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to ReadIdempotencyKey: %s", err.Error()))
+		return model.IdempotencyRecord{}, false, fmt.Errorf("failed to read idempotency key: %w", err)
+	}
+
+	if stored.RequestFingerprint != fingerprint {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Idempotency key %s reused with a different request", key))
+		return model.IdempotencyRecord{}, false, model.ErrIdempotencyKeyMismatch
+	}
+	if stored.StatusCode == 0 {
+		return model.IdempotencyRecord{}, false, model.ErrIdempotencyKeyInProgress
+	}
+
+	svc.logger.Info(fmt.Sprintf("[RESPONSE] Replaying %d for idempotency key %s", stored.StatusCode, key))
+	return stored, false, nil
+}
+
+// FinishIdempotentRequest stores the response of a request started with
+// StartIdempotentRequest. Server errors are not stored; the key is released
+// instead so that the client can retry.
+func (svc *Service) FinishIdempotentRequest(ctx context.Context, key string, statusCode int, body []byte) error {
+	user := middleware.GetUserInfoByContext(ctx)
+	svc.logger.Info(fmt.Sprintf("[REQUEST] FinishIdempotentRequest %s %d - %+v", key, statusCode, user))
+
+	if statusCode >= http.StatusInternalServerError {
+		err := svc.repo.Postgres.DeleteIdempotencyKey(ctx, user.UserID, key)
+		if err != nil {
+			svc.logger.Error(fmt.Sprintf("[ERROR] Failed to DeleteIdempotencyKey: %s", err.Error()))
+			return fmt.Errorf("failed to release idempotency key: %w", err)
+		}
+		return nil
+	}
+
+	err := svc.repo.Postgres.SaveIdempotentResponse(ctx, model.IdempotencyRecord{
+		UserID:       user.UserID,
+		Key:          key,
+		StatusCode:   statusCode,
+		ResponseBody: body,
+	})
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to SaveIdempotentResponse: %s", err.Error()))
+		return fmt.Errorf("failed to save idempotent response: %w", err)
+	}
+	return nil
+}
+
+// ExpireIdempotencyKeys forgets keys older than the configured TTL. It is run
+// periodically from main.
+func (svc *Service) ExpireIdempotencyKeys(ctx context.Context) error {
+	expired, err := svc.repo.Postgres.DeleteExpiredIdempotencyKeys(ctx, svc.config.IdempotencyTTL)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to DeleteExpiredIdempotencyKeys: %s", err.Error()))
+		return fmt.Errorf("failed to expire idempotency keys: %w", err)
+	}
+
+	if expired > 0 {
+		svc.logger.Info(fmt.Sprintf("[RESPONSE] Expired %d idempotency keys", expired))
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/budsx/retail-management/middleware"
+	"github.com/budsx/retail-management/model"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestService_StartIdempotentRequest(t *testing.T) {
+	srv := NewTestServer(t)
+	defer srv.MockCtrl.Finish()
+
+	ctx := context.WithValue(context.Background(), middleware.ContextKeyUserID, int64(3))
+	ctx = context.WithValue(ctx, middleware.ContextKeyUsername, "clerk")
+	claim := model.IdempotencyRecord{UserID: 3, Key: "order-1", RequestFingerprint: "abc"}
+
+	t.Run("new key is processed", func(t *testing.T) {
+		srv.MockRepo.EXPECT().CreateIdempotencyKey(gomock.Any(), claim, gomock.Any()).Return(true, nil)
+
+		_, started, err := srv.Service.StartIdempotentRequest(ctx, "order-1", "abc")
+		assert.NoError(t, err)
+		assert.True(t, started)
+	})
+
+	t.Run("retry replays the stored response", func(t *testing.T) {
+		stored := model.IdempotencyRecord{UserID: 3, Key: "order-1", RequestFingerprint: "abc", StatusCode: 201, ResponseBody: []byte(`{}`)}
+		srv.MockRepo.EXPECT().CreateIdempotencyKey(gomock.Any(), claim, gomock.Any()).Return(false, nil)
+		srv.MockRepo.EXPECT().ReadIdempotencyKey(gomock.Any(), int64(3), "order-1").Return(stored, nil)
+
+		got, started, err := srv.Service.StartIdempotentRequest(ctx, "order-1", "abc")
+		assert.NoError(t, err)
+		assert.False(t, started)
+		assert.Equal(t, stored, got)
+	})
+
+	t.Run("key reused with a different body", func(t *testing.T) {
+		srv.MockRepo.EXPECT().CreateIdempotencyKey(gomock.Any(), gomock.Any(), gomock.Any()).Return(false, nil)
+		srv.MockRepo.EXPECT().ReadIdempotencyKey(gomock.Any(), int64(3), "order-1").
+			Return(model.IdempotencyRecord{RequestFingerprint: "abc", StatusCode: 201}, nil)
+
+		_, _, err := srv.Service.StartIdempotentRequest(ctx, "order-1", "def")
+		assert.ErrorIs(t, err, model.ErrIdempotencyKeyMismatch)
+	})
+
+	t.Run("first request still running", func(t *testing.T) {
+		srv.MockRepo.EXPECT().CreateIdempotencyKey(gomock.Any(), claim, gomock.Any()).Return(false, nil)
+		srv.MockRepo.EXPECT().ReadIdempotencyKey(gomock.Any(), int64(3), "order-1").
+			Return(model.IdempotencyRecord{RequestFingerprint: "abc"}, nil)
+
+		_, _, err := srv.Service.StartIdempotentRequest(ctx, "order-1", "abc")
+		assert.ErrorIs(t, err, model.ErrIdempotencyKeyInProgress)
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		srv.MockRepo.EXPECT().CreateIdempotencyKey(gomock.Any(), claim, gomock.Any()).Return(false, errors.New("connection refused"))
+
+		_, _, err := srv.Service.StartIdempotentRequest(ctx, "order-1", "abc")
+		assert.Error(t, err)
+	})
+}
+
+func TestService_FinishIdempotentRequest(t *testing.T) {
+	srv := NewTestServer(t)
+	defer srv.MockCtrl.Finish()
+
+	ctx := context.WithValue(context.Background(), middleware.ContextKeyUserID, int64(3))
+	ctx = context.WithValue(ctx, middleware.ContextKeyUsername, "clerk")
+
+	t.Run("response is stored", func(t *testing.T) {
+		srv.MockRepo.EXPECT().
+			SaveIdempotentResponse(gomock.Any(), model.IdempotencyRecord{UserID: 3, Key: "order-1", StatusCode: 400, ResponseBody: []byte(`{}`)}).
+			Return(nil)
+
+		assert.NoError(t, srv.Service.FinishIdempotentRequest(ctx, "order-1", 400, []byte(`{}`)))
+	})
+
+	t.Run("server error releases the key", func(t *testing.T) {
+		srv.MockRepo.EXPECT().DeleteIdempotencyKey(gomock.Any(), int64(3), "order-1").Return(nil)
+
+		assert.NoError(t, srv.Service.FinishIdempotentRequest(ctx, "order-1", 500, []byte(`{}`)))
+	})
+}
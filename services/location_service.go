@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/budsx/retail-management/middleware"
@@ -19,6 +20,11 @@ func (svc *Service) AddLocation(ctx context.Context, location model.Location) er
 		return fmt.Errorf("unauthorized or warehouse not found")
 	}
 
+	if warehouse.ArchivedAt != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Warehouse %d is archived", warehouse.WarehouseID))
+		return fmt.Errorf("%w: warehouse %d", model.ErrArchived, warehouse.WarehouseID)
+	}
+
 	err = svc.repo.Postgres.WriteLocation(ctx, location)
 	if err != nil {
 		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to add location: %s", err.Error()))
@@ -29,30 +35,78 @@ func (svc *Service) AddLocation(ctx context.Context, location model.Location) er
 	return nil
 }
 
+// readUserLocationWarehouse reads the warehouse of one of the user's
+// locations. Another user's location is reported as not found.
+func (svc *Service) readUserLocationWarehouse(ctx context.Context, userID, locationID int64) (model.Warehouse, error) {
+	location, err := svc.repo.Postgres.ReadLocationByID(ctx, locationID)
+	if errors.Is(err, model.ErrLocationNotFound) {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Location not found: %s", err.Error()))
+		return model.Warehouse{}, err
+	}
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to ReadLocationByID: %s", err.Error()))
+		return model.Warehouse{}, fmt.Errorf("failed to read location %d: %w", locationID, err)
+	}
+
+	warehouse, err := svc.repo.Postgres.ReadWarehouseByID(ctx, location.WarehouseID)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to ReadWarehouseByID: %s", err.Error()))
+		return model.Warehouse{}, fmt.Errorf("failed to read warehouse %d: %w", location.WarehouseID, err)
+	}
+	if warehouse.UserID != userID {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Location %d belongs to another user", locationID))
+		return model.Warehouse{}, fmt.Errorf("%w: %d", model.ErrLocationNotFound, locationID)
+	}
+
+	return warehouse, nil
+}
+
+// DeleteLocationByUserID archives one of the user's locations. The location is
+// kept for the stock history that refers to it and can be restored.
 func (svc *Service) DeleteLocationByUserID(ctx context.Context, locationID int64) error {
 	svc.logger.Info(fmt.Sprintf("[REQUEST] Delete location ID: %d", locationID))
 
 	userID := ctx.Value(middleware.ContextKeyUserID).(int64)
 
-	dbLocation, err := svc.repo.Postgres.ReadLocationByID(ctx, locationID)
+	_, err := svc.readUserLocationWarehouse(ctx, userID, locationID)
 	if err != nil {
-		svc.logger.Error("[ERROR] Location not found")
-		return fmt.Errorf("location not found")
+		return err
 	}
 
-	warehouse, err := svc.repo.Postgres.ReadWarehouseByID(ctx, dbLocation.WarehouseID)
-	if err != nil || warehouse.UserID != userID {
-		svc.logger.Error("[ERROR] Unauthorized or location not found")
-		return fmt.Errorf("unauthorized or location not found")
+	err = svc.repo.Postgres.SetLocationArchived(ctx, userID, locationID, true)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to archive location: %s", err.Error()))
+		return fmt.Errorf("failed to delete location: %w", err)
 	}
 
-	err = svc.repo.Postgres.DeleteLocationByUserID(ctx, userID, locationID)
+	svc.logger.Info("[RESPONSE] Location archived successfully")
+	return nil
+}
+
+// RestoreLocationByUserID brings back one of the user's archived locations.
+// A location cannot be restored while its warehouse is archived.
+func (svc *Service) RestoreLocationByUserID(ctx context.Context, locationID int64) error {
+	svc.logger.Info(fmt.Sprintf("[REQUEST] Restore location ID: %d", locationID))
+
+	userID := ctx.Value(middleware.ContextKeyUserID).(int64)
+
+	warehouse, err := svc.readUserLocationWarehouse(ctx, userID, locationID)
 	if err != nil {
-		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to delete location: %s", err.Error()))
-		return fmt.Errorf("failed to delete location: %w", err)
+		return err
+	}
+
+	if warehouse.ArchivedAt != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Warehouse %d is archived", warehouse.WarehouseID))
+		return fmt.Errorf("%w: warehouse %d", model.ErrArchived, warehouse.WarehouseID)
+	}
+
+	err = svc.repo.Postgres.SetLocationArchived(ctx, userID, locationID, false)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to restore location: %s", err.Error()))
+		return fmt.Errorf("failed to restore location: %w", err)
 	}
 
-	svc.logger.Info("[RESPONSE] Location deleted successfully")
+	svc.logger.Info("[RESPONSE] Location restored successfully")
 	return nil
 }
 
@@ -81,3 +135,37 @@ func (svc *Service) EditLocationByUserID(ctx context.Context, location model.Loc
 	svc.logger.Info("[RESPONSE] Location updated successfully")
 	return nil
 }
+
+// GetLocationsByWarehouse lists the locations of one of the user's warehouses
+// that follow the pagination cursor, leaving out archived ones unless
+// includeArchived is set.
+func (svc *Service) GetLocationsByWarehouse(ctx context.Context, warehouseID int64, includeArchived bool, pagination model.CursorPagination) ([]model.Location, model.PageCursors, error) {
+	user := middleware.GetUserInfoByContext(ctx)
+	svc.logger.Info(fmt.Sprintf("[REQUEST] GetLocationsByWarehouse %d %t %+v - %+v", warehouseID, includeArchived, pagination, user))
+
+	warehouse, err := svc.repo.Postgres.ReadWarehouseByID(ctx, warehouseID)
+	if err != nil || warehouse.UserID != user.UserID {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Warehouse %d not found for user %d", warehouseID, user.UserID))
+		return nil, model.PageCursors{}, model.ErrWarehouseNotFound
+	}
+
+	cursor, err := decodeCursor(pagination, "location_id")
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] %s", err.Error()))
+		return nil, model.PageCursors{}, err
+	}
+
+	locations, err := svc.repo.Postgres.ReadLocationsByWarehouse(ctx, warehouseID, includeArchived, cursor, pagination.Limit+1)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to ReadLocationsByWarehouse: %s", err.Error()))
+		return nil, model.PageCursors{}, fmt.Errorf("failed to get locations: %w", err)
+	}
+
+	start, end, cursors := cursorPage(cursor, pagination.Limit, len(locations), func(i int) model.Cursor {
+		return model.Cursor{ID: locations[i].LocationID, Sort: "location_id"}
+	})
+	locations = locations[start:end]
+
+	svc.logger.Info(fmt.Sprintf("[RESPONSE] %+v %+v", locations, cursors))
+	return locations, cursors, nil
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/budsx/retail-management/model"
+)
+
+// maxPageSize caps the items listed per page.
+const maxPageSize = 100
+
+// decodeCursor checks the page size asked for and reads the cursor, which must
+// have been made for a listing in the sort order given.
+func decodeCursor(pagination model.CursorPagination, sort string) (model.Cursor, error) {
+	if pagination.Limit < 1 || pagination.Limit > maxPageSize {
+		return model.Cursor{}, fmt.Errorf("%w: limit must be between 1 and %d", model.ErrInvalidPagination, maxPageSize)
+	}
+
+	cursor, err := model.DecodeCursor(pagination.Cursor)
+	if err != nil {
+		return model.Cursor{}, err
+	}
+
+	if !cursor.IsZero() && cursor.Sort != sort {
+		return model.Cursor{}, fmt.Errorf("%w: cursor was made for another listing", model.ErrInvalidPagination)
+	}
+	return cursor, nil
+}
+
+// cursorPage works out which of n items, read with one more than limit from
+// cursor, make up the page and the cursors either side of it. cursorAt gives
+// the position of item i.
+func cursorPage(cursor model.Cursor, limit int32, n int, cursorAt func(i int) model.Cursor) (int, int, model.PageCursors) {
+	start, end := 0, n
+	more := n > int(limit)
+	if more && cursor.Backward {
+		start = n - int(limit)
+	} else if more {
+		end = int(limit)
+	}
+
+	var cursors model.PageCursors
+	if start == end {
+		return start, end, cursors
+	}
+
+	// Walking backward there is a next page, the one the cursor came from
+	if more || cursor.Backward {
+		next := cursorAt(end - 1)
+		cursors.NextCursor = next.Encode()
+	}
+	if (more && cursor.Backward) || (!cursor.IsZero() && !cursor.Backward) {
+		prev := cursorAt(start)
+		prev.Backward = true
+		cursors.PrevCursor = prev.Encode()
+	}
+
+	return start, end, cursors
+}
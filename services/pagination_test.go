@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/budsx/retail-management/middleware"
+	"github.com/budsx/retail-management/model"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestService_GetWarehouseByUserID(t *testing.T) {
+	srv := NewTestServer(t)
+	defer srv.MockCtrl.Finish()
+
+	ctx := context.WithValue(context.Background(), middleware.ContextKeyUserID, int64(3))
+	ctx = context.WithValue(ctx, middleware.ContextKeyUsername, "gudang")
+	warehouses := []model.Warehouse{{WarehouseID: 4}, {WarehouseID: 5}, {WarehouseID: 6}}
+
+	t.Run("first page has a next cursor only", func(t *testing.T) {
+		srv.MockRepo.EXPECT().ReadWarehousesByUserID(gomock.Any(), int64(3), false, model.Cursor{}, int32(3)).Return(warehouses, nil)
+
+		got, cursors, err := srv.Service.GetWarehouseByUserID(ctx, false, model.CursorPagination{Limit: 2})
+		assert.NoError(t, err)
+		assert.Equal(t, warehouses[:2], got)
+		assert.Equal(t, model.PageCursors{
+			NextCursor: model.Cursor{ID: 5, Sort: "warehouse_id"}.Encode(),
+		}, cursors)
+	})
+
+	t.Run("last page has a previous cursor only", func(t *testing.T) {
+		cursor := model.Cursor{ID: 5, Sort: "warehouse_id"}
+		srv.MockRepo.EXPECT().ReadWarehousesByUserID(gomock.Any(), int64(3), false, cursor, int32(3)).Return(warehouses[2:], nil)
+
+		got, cursors, err := srv.Service.GetWarehouseByUserID(ctx, false, model.CursorPagination{Cursor: cursor.Encode(), Limit: 2})
+		assert.NoError(t, err)
+		assert.Equal(t, warehouses[2:], got)
+		assert.Equal(t, model.PageCursors{
+			PrevCursor: model.Cursor{ID: 6, Sort: "warehouse_id", Backward: true}.Encode(),
+		}, cursors)
+	})
+
+	t.Run("walking backward keeps the rows nearest the cursor", func(t *testing.T) {
+		cursor := model.Cursor{ID: 7, Sort: "warehouse_id", Backward: true}
+		srv.MockRepo.EXPECT().ReadWarehousesByUserID(gomock.Any(), int64(3), false, cursor, int32(3)).Return(warehouses, nil)
+
+		got, cursors, err := srv.Service.GetWarehouseByUserID(ctx, false, model.CursorPagination{Cursor: cursor.Encode(), Limit: 2})
+		assert.NoError(t, err)
+		assert.Equal(t, warehouses[1:], got)
+		assert.Equal(t, model.PageCursors{
+			NextCursor: model.Cursor{ID: 6, Sort: "warehouse_id"}.Encode(),
+			PrevCursor: model.Cursor{ID: 5, Sort: "warehouse_id", Backward: true}.Encode(),
+		}, cursors)
+	})
+
+	t.Run("cursor of another listing", func(t *testing.T) {
+		cursor := model.Cursor{ID: 5, Sort: "transaction_id"}
+
+		_, _, err := srv.Service.GetWarehouseByUserID(ctx, false, model.CursorPagination{Cursor: cursor.Encode(), Limit: 2})
+		assert.ErrorIs(t, err, model.ErrInvalidPagination)
+	})
+
+	t.Run("malformed cursor", func(t *testing.T) {
+		_, _, err := srv.Service.GetWarehouseByUserID(ctx, false, model.CursorPagination{Cursor: "not a cursor", Limit: 2})
+		assert.ErrorIs(t, err, model.ErrInvalidPagination)
+	})
+
+	t.Run("limit out of range", func(t *testing.T) {
+		_, _, err := srv.Service.GetWarehouseByUserID(ctx, false, model.CursorPagination{Limit: maxPageSize + 1})
+		assert.ErrorIs(t, err, model.ErrInvalidPagination)
+	})
+}
+
+func TestService_GetLocationsByWarehouse(t *testing.T) {
+	srv := NewTestServer(t)
+	defer srv.MockCtrl.Finish()
+
+	ctx := context.WithValue(context.Background(), middleware.ContextKeyUserID, int64(3))
+	ctx = context.WithValue(ctx, middleware.ContextKeyUsername, "gudang")
+
+	t.Run("locations of the user's warehouse", func(t *testing.T) {
+		locations := []model.Location{{LocationID: 1, WarehouseID: 1}, {LocationID: 2, WarehouseID: 1}}
+		srv.MockRepo.EXPECT().ReadWarehouseByID(gomock.Any(), int64(1)).Return(model.Warehouse{WarehouseID: 1, UserID: 3}, nil)
+		srv.MockRepo.EXPECT().ReadLocationsByWarehouse(gomock.Any(), int64(1), false, model.Cursor{}, int32(11)).Return(locations, nil)
+
+		got, cursors, err := srv.Service.GetLocationsByWarehouse(ctx, 1, false, model.CursorPagination{Limit: 10})
+		assert.NoError(t, err)
+		assert.Equal(t, locations, got)
+		assert.Equal(t, model.PageCursors{}, cursors)
+	})
+
+	t.Run("warehouse of another user", func(t *testing.T) {
+		srv.MockRepo.EXPECT().ReadWarehouseByID(gomock.Any(), int64(2)).Return(model.Warehouse{WarehouseID: 2, UserID: 4}, nil)
+
+		_, _, err := srv.Service.GetLocationsByWarehouse(ctx, 2, false, model.CursorPagination{Limit: 10})
+		assert.ErrorIs(t, err, model.ErrWarehouseNotFound)
+	})
+}
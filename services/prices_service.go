@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/budsx/retail-management/model"
+)
+
+// GetPriceHistory lists every price a product has had, followed by its
+// scheduled changes.
+func (svc *Service) GetPriceHistory(ctx context.Context, productID int64) ([]model.ProductPrice, error) {
+	svc.logger.Info(fmt.Sprintf("[REQUEST] GetPriceHistory %d", productID))
+
+	_, err := svc.repo.Postgres.ReadProductByID(ctx, productID)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Product not found: %s", err.Error()))
+		return nil, fmt.Errorf("product not found")
+	}
+
+	prices, err := svc.repo.Postgres.ReadProductPrices(ctx, productID)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to ReadProductPrices: %s", err.Error()))
+		return nil, fmt.Errorf("failed to get price history: %w", err)
+	}
+
+	svc.logger.Info(fmt.Sprintf("[RESPONSE] %d prices", len(prices)))
+	return prices, nil
+}
+
+// GetPriceAt returns the price of a product in effect at the given time.
+func (svc *Service) GetPriceAt(ctx context.Context, productID int64, at time.Time) (model.ProductPrice, error) {
+	svc.logger.Info(fmt.Sprintf("[REQUEST] GetPriceAt %d %s", productID, at))
+
+	price, err := svc.repo.Postgres.ReadPriceAt(ctx, productID, at.UTC())
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to ReadPriceAt: %s", err.Error()))
+		return model.ProductPrice{}, fmt.Errorf("failed to get price: %w", err)
+	}
+
+	svc.logger.Info(fmt.Sprintf("[RESPONSE] %+v", price))
+	return price, nil
+}
+
+// SchedulePriceChange sets the price a product will take at a future time.
+// The change is applied to the product by ApplyScheduledPrices.
+func (svc *Service) SchedulePriceChange(ctx context.Context, price model.ProductPrice) (model.ProductPrice, error) {
+	svc.logger.Info(fmt.Sprintf("[REQUEST] SchedulePriceChange %+v", price))
+
+	if price.Price <= 0 || !price.EffectiveFrom.After(time.Now()) {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Invalid price change %+v", price))
+		return model.ProductPrice{}, fmt.Errorf("%w: price must be greater than zero and effective_from in the future", model.ErrInvalidPriceChange)
+	}
+	price.EffectiveFrom = price.EffectiveFrom.UTC()
+	price.AppliedAt = nil
+
+	_, err := svc.repo.Postgres.ReadProductByID(ctx, price.ProductID)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Product not found: %s", err.Error()))
+		return model.ProductPrice{}, fmt.Errorf("product not found")
+	}
+
+	price.PriceID, err = svc.repo.Postgres.WritePriceChange(ctx, price)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to WritePriceChange: %s", err.Error()))
+		return model.ProductPrice{}, fmt.Errorf("failed to schedule price change: %w", err)
+	}
+
+	svc.logger.Info(fmt.Sprintf("[RESPONSE] Price change scheduled: %d", price.PriceID))
+	return price, nil
+}
+
+// CancelPriceChange drops a scheduled price change that has not taken effect.
+func (svc *Service) CancelPriceChange(ctx context.Context, productID, priceID int64) error {
+	svc.logger.Info(fmt.Sprintf("[REQUEST] CancelPriceChange %d %d", productID, priceID))
+
+	err := svc.repo.Postgres.DeletePriceChange(ctx, productID, priceID)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to DeletePriceChange: %s", err.Error()))
+		return fmt.Errorf("failed to cancel price change: %w", err)
+	}
+
+	svc.logger.Info("[RESPONSE] Price change cancelled")
+	return nil
+}
+
+// ApplyScheduledPrices writes the scheduled price changes that have taken
+// effect to their products. It is run periodically from main.
+func (svc *Service) ApplyScheduledPrices(ctx context.Context) error {
+	repriced, err := svc.repo.Postgres.ApplyScheduledPrices(ctx)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to ApplyScheduledPrices: %s", err.Error()))
+		return fmt.Errorf("failed to apply scheduled prices: %w", err)
+	}
+
+	if repriced > 0 {
+		svc.logger.Info(fmt.Sprintf("[RESPONSE] Repriced %d products", repriced))
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/budsx/retail-management/model"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestService_SchedulePriceChange(t *testing.T) {
+	srv := NewTestServer(t)
+	defer srv.MockCtrl.Finish()
+
+	ctx := context.Background()
+	jakarta := time.FixedZone("WIB", 7*60*60)
+	effectiveFrom := time.Now().Add(48 * time.Hour).In(jakarta).Truncate(time.Second)
+
+	t.Run("future change", func(t *testing.T) {
+		want := model.ProductPrice{PriceID: 9, ProductID: 4, Price: 75000, EffectiveFrom: effectiveFrom.UTC()}
+		srv.MockRepo.EXPECT().ReadProductByID(gomock.Any(), int64(4)).Return(model.Product{ProductID: 4}, nil)
+		srv.MockRepo.EXPECT().WritePriceChange(gomock.Any(), model.ProductPrice{ProductID: 4, Price: 75000, EffectiveFrom: effectiveFrom.UTC()}).Return(int64(9), nil)
+
+		got, err := srv.Service.SchedulePriceChange(ctx, model.ProductPrice{ProductID: 4, Price: 75000, EffectiveFrom: effectiveFrom})
+		assert.NoError(t, err)
+		assert.Equal(t, want, got)
+	})
+
+	invalid := []struct {
+		name  string
+		price model.ProductPrice
+	}{
+		{name: "effective in the past", price: model.ProductPrice{ProductID: 4, Price: 75000, EffectiveFrom: time.Now().Add(-time.Hour)}},
+		{name: "no effective date", price: model.ProductPrice{ProductID: 4, Price: 75000}},
+		{name: "zero price", price: model.ProductPrice{ProductID: 4, EffectiveFrom: effectiveFrom}},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := srv.Service.SchedulePriceChange(ctx, tt.price)
+			assert.ErrorIs(t, err, model.ErrInvalidPriceChange)
+		})
+	}
+}
+
+func TestService_GetPriceAt(t *testing.T) {
+	srv := NewTestServer(t)
+	defer srv.MockCtrl.Finish()
+
+	ctx := context.Background()
+	at := time.Date(2024, 3, 1, 7, 0, 0, 0, time.FixedZone("WIB", 7*60*60))
+
+	t.Run("price in effect", func(t *testing.T) {
+		want := model.ProductPrice{PriceID: 7, ProductID: 4, Price: 72500, EffectiveFrom: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)}
+		srv.MockRepo.EXPECT().ReadPriceAt(gomock.Any(), int64(4), time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)).Return(want, nil)
+
+		got, err := srv.Service.GetPriceAt(ctx, 4, at)
+		assert.NoError(t, err)
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("before the first price", func(t *testing.T) {
+		srv.MockRepo.EXPECT().ReadPriceAt(gomock.Any(), int64(4), gomock.Any()).Return(model.ProductPrice{}, fmt.Errorf("%w: product 4", model.ErrPriceNotFound))
+
+		_, err := srv.Service.GetPriceAt(ctx, 4, at)
+		assert.ErrorIs(t, err, model.ErrPriceNotFound)
+	})
+}
+
+func TestService_ApplyScheduledPrices(t *testing.T) {
+	srv := NewTestServer(t)
+	defer srv.MockCtrl.Finish()
+
+	srv.MockRepo.EXPECT().ApplyScheduledPrices(gomock.Any()).Return(int64(2), nil)
+
+	err := srv.Service.ApplyScheduledPrices(context.Background())
+	assert.NoError(t, err)
+}
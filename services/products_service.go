@@ -3,6 +3,9 @@ package services
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/budsx/retail-management/model"
 )
@@ -10,7 +13,29 @@ import (
 func (svc *Service) AddProduct(ctx context.Context, product model.Product) error {
 	svc.logger.Info(fmt.Sprintf("[REQUEST] Add new product: %+v", product))
 
-	err := svc.repo.Postgres.WriteProduct(ctx, product)
+	if product.BaseUnit == "" {
+		product.BaseUnit = model.DefaultBaseUnit
+	}
+
+	err := validateNewProductUnits(product)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] %s", err.Error()))
+		return err
+	}
+
+	err = svc.checkProductCategory(ctx, product.CategoryID)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to check category: %s", err.Error()))
+		return fmt.Errorf("failed to add product: %w", err)
+	}
+
+	product.VariantAttributes, err = svc.validateVariant(ctx, product)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] %s", err.Error()))
+		return err
+	}
+
+	err = svc.repo.Postgres.WriteProduct(ctx, product)
 	if err != nil {
 		svc.logger.Info(err.Error())
 		return fmt.Errorf("failed to add product: %w", err)
@@ -23,7 +48,24 @@ func (svc *Service) AddProduct(ctx context.Context, product model.Product) error
 func (svc *Service) EditProduct(ctx context.Context, updatedProduct model.Product) error {
 	svc.logger.Info(fmt.Sprintf("[REQUEST] Update product: %+v", updatedProduct))
 
-	err := svc.repo.Postgres.UpdateProductByID(ctx, updatedProduct)
+	for _, unitCode := range []string{updatedProduct.PurchaseUnit, updatedProduct.SalesUnit} {
+		if unitCode == "" {
+			continue
+		}
+		_, err := svc.repo.Postgres.ReadUnitFactor(ctx, updatedProduct.ProductID, unitCode)
+		if err != nil {
+			svc.logger.Error(fmt.Sprintf("[ERROR] Failed to ReadUnitFactor: %s", err.Error()))
+			return fmt.Errorf("failed to update product: %w", err)
+		}
+	}
+
+	err := svc.checkProductCategory(ctx, updatedProduct.CategoryID)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to check category: %s", err.Error()))
+		return fmt.Errorf("failed to update product: %w", err)
+	}
+
+	err = svc.repo.Postgres.UpdateProductByID(ctx, updatedProduct)
 	if err != nil {
 		svc.logger.Info(err.Error())
 		return fmt.Errorf("failed to update product: %w", err)
@@ -33,6 +75,35 @@ func (svc *Service) EditProduct(ctx context.Context, updatedProduct model.Produc
 	return nil
 }
 
+// DeleteProduct archives a product. It is kept for the stock history that
+// refers to it and can be restored.
+func (svc *Service) DeleteProduct(ctx context.Context, productID int64) error {
+	svc.logger.Info(fmt.Sprintf("[REQUEST] Delete product: %d", productID))
+
+	err := svc.repo.Postgres.SetProductArchived(ctx, productID, true)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to archive product: %s", err.Error()))
+		return fmt.Errorf("failed to delete product: %w", err)
+	}
+
+	svc.logger.Info("[RESPONSE] Product archived successfully")
+	return nil
+}
+
+// RestoreProduct brings back an archived product.
+func (svc *Service) RestoreProduct(ctx context.Context, productID int64) error {
+	svc.logger.Info(fmt.Sprintf("[REQUEST] Restore product: %d", productID))
+
+	err := svc.repo.Postgres.SetProductArchived(ctx, productID, false)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to restore product: %s", err.Error()))
+		return fmt.Errorf("failed to restore product: %w", err)
+	}
+
+	svc.logger.Info("[RESPONSE] Product restored successfully")
+	return nil
+}
+
 func (svc *Service) GetProductByID(ctx context.Context, req int64) (model.Product, error) {
 	svc.logger.Info(fmt.Sprintf("[REQUEST] %d", req))
 
@@ -42,21 +113,273 @@ func (svc *Service) GetProductByID(ctx context.Context, req int64) (model.Produc
 		return model.Product{}, fmt.Errorf("failed to get product: %w", err)
 	}
 
+	product.Units, err = svc.repo.Postgres.ReadProductUnits(ctx, req)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to ReadProductUnits: %s", err.Error()))
+		return model.Product{}, fmt.Errorf("failed to get units of product %d: %w", req, err)
+	}
+
+	product.Barcodes, err = svc.repo.Postgres.ReadProductBarcodes(ctx, req)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to ReadProductBarcodes: %s", err.Error()))
+		return model.Product{}, fmt.Errorf("failed to get barcodes of product %d: %w", req, err)
+	}
+
+	if product.IsBundle {
+		product.Components, err = svc.repo.Postgres.ReadBundleComponents(ctx, req)
+		if err != nil {
+			svc.logger.Error(fmt.Sprintf("[ERROR] Failed to ReadBundleComponents: %s", err.Error()))
+			return model.Product{}, fmt.Errorf("failed to get components of product %d: %w", req, err)
+		}
+	}
+
 	svc.logger.Info(fmt.Sprintf("[RESPONSE] %+v", product))
 	return product, nil
 }
 
-func (svc *Service) GetProducts(ctx context.Context, pagination model.Pagination) ([]model.Product, error) {
-	svc.logger.Info(fmt.Sprintf("[REQUEST] Get products with pagination: %+v", pagination))
+// GetProducts lists a page of the products matching filter, with the number of
+// products matching it in total.
+func (svc *Service) GetProducts(ctx context.Context, filter model.ProductFilter, pagination model.Pagination) (model.ProductPage, error) {
+	svc.logger.Info(fmt.Sprintf("[REQUEST] Get products with filter %+v and pagination: %+v", filter, pagination))
+
+	err := validateProductFilter(filter)
+	if err == nil && (pagination.Page < 1 || pagination.Limit < 1 || pagination.Limit > maxPageSize) {
+		err = fmt.Errorf("%w: page must be at least 1 and limit between 1 and %d", model.ErrInvalidProductQuery, maxPageSize)
+	}
+	if err == nil {
+		err = svc.checkProductCategory(ctx, filter.CategoryID)
+	}
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] %s", err.Error()))
+		return model.ProductPage{}, err
+	}
 
 	offset := (pagination.Page - 1) * pagination.Limit
 
-	products, err := svc.repo.Postgres.ReadProductsWithPagination(ctx, pagination.Limit, offset)
+	products, err := svc.repo.Postgres.ReadProductsWithPagination(ctx, filter, pagination.Limit, offset)
+	if err != nil {
+		svc.logger.Info(err.Error())
+		return model.ProductPage{}, fmt.Errorf("failed to get products: %w", err)
+	}
+
+	total, err := svc.repo.Postgres.CountProducts(ctx, filter)
 	if err != nil {
 		svc.logger.Info(err.Error())
-		return nil, fmt.Errorf("failed to get products: %w", err)	
+		return model.ProductPage{}, fmt.Errorf("failed to count products: %w", err)
 	}
 
-	svc.logger.Info(fmt.Sprintf("[RESPONSE] %+v", products))
-	return products, nil
+	page := model.ProductPage{
+		Products: products,
+		Total:    &total,
+		Page:     pagination.Page,
+		Limit:    pagination.Limit,
+	}
+
+	svc.logger.Info(fmt.Sprintf("[RESPONSE] %+v", page))
+	return page, nil
+}
+
+// GetProductsByCursor lists the products matching filter that follow the
+// pagination cursor. The matching products are not counted, so that paging
+// through them stays cheap.
+func (svc *Service) GetProductsByCursor(ctx context.Context, filter model.ProductFilter, pagination model.CursorPagination) (model.ProductPage, model.PageCursors, error) {
+	svc.logger.Info(fmt.Sprintf("[REQUEST] Get products with filter %+v and pagination: %+v", filter, pagination))
+
+	err := validateProductFilter(filter)
+	if err == nil {
+		err = svc.checkProductCategory(ctx, filter.CategoryID)
+	}
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] %s", err.Error()))
+		return model.ProductPage{}, model.PageCursors{}, err
+	}
+
+	if filter.SortBy == "" {
+		filter.SortBy = "product_id"
+	}
+	order := filter.SortBy
+	if filter.Descending {
+		order += " desc"
+	}
+
+	cursor, err := decodeCursor(pagination, order)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] %s", err.Error()))
+		return model.ProductPage{}, model.PageCursors{}, err
+	}
+
+	products, err := svc.repo.Postgres.ReadProductsByCursor(ctx, filter, cursor, pagination.Limit+1)
+	if err != nil {
+		svc.logger.Info(err.Error())
+		return model.ProductPage{}, model.PageCursors{}, fmt.Errorf("failed to get products: %w", err)
+	}
+
+	start, end, cursors := cursorPage(cursor, pagination.Limit, len(products), func(i int) model.Cursor {
+		return model.Cursor{ID: products[i].ProductID, Key: productSortKey(products[i], filter.SortBy), Sort: order}
+	})
+	page := model.ProductPage{
+		Products: products[start:end],
+		Limit:    pagination.Limit,
+	}
+
+	svc.logger.Info(fmt.Sprintf("[RESPONSE] %+v %+v", page, cursors))
+	return page, cursors, nil
+}
+
+// productSortKey is the product's value of the sort field, as kept in cursors.
+func productSortKey(product model.Product, sortBy string) string {
+	switch sortBy {
+	case "product_name":
+		return product.ProductName
+	case "sku":
+		return product.SKU
+	case "price":
+		return strconv.FormatFloat(product.Price, 'f', -1, 64)
+	case "created_at":
+		return product.CreatedAt.Format(time.RFC3339Nano)
+	case "updated_at":
+		return product.UpdatedAt.Format(time.RFC3339Nano)
+	}
+	return ""
+}
+
+// validateVariant checks the variant fields of a new product and returns its
+// variant attributes cleaned up. A variant needs a parent that takes variants
+// and a value for each of the parent's attributes; any other product may name
+// the attributes it will have variants in.
+func (svc *Service) validateVariant(ctx context.Context, product model.Product) ([]string, error) {
+	if product.ParentID == 0 {
+		if len(product.Attributes) > 0 {
+			return nil, fmt.Errorf("%w: attributes are only set on variants", model.ErrInvalidVariant)
+		}
+
+		var variantAttributes []string
+		seen := map[string]bool{}
+		for _, attribute := range product.VariantAttributes {
+			attribute = strings.TrimSpace(attribute)
+			if attribute == "" || seen[attribute] {
+				return nil, fmt.Errorf("%w: variant attributes must be non-empty and unique", model.ErrInvalidVariant)
+			}
+			seen[attribute] = true
+			variantAttributes = append(variantAttributes, attribute)
+		}
+		return variantAttributes, nil
+	}
+
+	if len(product.VariantAttributes) > 0 {
+		return nil, fmt.Errorf("%w: a variant cannot have variants of its own", model.ErrInvalidVariant)
+	}
+
+	parent, err := svc.repo.Postgres.ReadProductByID(ctx, product.ParentID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: parent product %d not found", model.ErrInvalidVariant, product.ParentID)
+	}
+	if !parent.HasVariants() {
+		return nil, fmt.Errorf("%w: product %d does not take variants", model.ErrInvalidVariant, product.ParentID)
+	}
+
+	if len(product.Attributes) != len(parent.VariantAttributes) {
+		return nil, fmt.Errorf("%w: a variant of product %d sets %s", model.ErrInvalidVariant, product.ParentID, strings.Join(parent.VariantAttributes, ", "))
+	}
+	for _, attribute := range parent.VariantAttributes {
+		if strings.TrimSpace(product.Attributes[attribute]) == "" {
+			return nil, fmt.Errorf("%w: a variant of product %d sets %s", model.ErrInvalidVariant, product.ParentID, strings.Join(parent.VariantAttributes, ", "))
+		}
+	}
+
+	return nil, nil
+}
+
+func validateProductFilter(filter model.ProductFilter) error {
+	if filter.SortBy != "" && !model.ProductSortFields[filter.SortBy] {
+		return fmt.Errorf("%w: cannot sort by %q", model.ErrInvalidProductQuery, filter.SortBy)
+	}
+
+	if filter.MinPrice != nil && filter.MaxPrice != nil && *filter.MinPrice > *filter.MaxPrice {
+		return fmt.Errorf("%w: min_price is above max_price", model.ErrInvalidProductQuery)
+	}
+	if filter.CreatedFrom != nil && filter.CreatedTo != nil && filter.CreatedFrom.After(*filter.CreatedTo) {
+		return fmt.Errorf("%w: created_from is after created_to", model.ErrInvalidProductQuery)
+	}
+	if filter.UpdatedFrom != nil && filter.UpdatedTo != nil && filter.UpdatedFrom.After(*filter.UpdatedTo) {
+		return fmt.Errorf("%w: updated_from is after updated_to", model.ErrInvalidProductQuery)
+	}
+
+	return nil
+}
+
+// validateNewProductUnits checks the units a new product is created with: each
+// is a distinct unit other than the base unit, and the purchase and sales units
+// are the base unit or one of them.
+func validateNewProductUnits(product model.Product) error {
+	units := map[string]bool{product.BaseUnit: true}
+	for _, unit := range product.Units {
+		if unit.UnitCode == "" || unit.Factor <= 0 || units[unit.UnitCode] {
+			return fmt.Errorf("%w: unit %q must have a code of its own other than the base unit and a factor greater than zero", model.ErrInvalidUnit, unit.UnitCode)
+		}
+		units[unit.UnitCode] = true
+	}
+
+	for _, unitCode := range []string{product.PurchaseUnit, product.SalesUnit} {
+		if unitCode != "" && !units[unitCode] {
+			return fmt.Errorf("%w: %s", model.ErrUnknownUnit, unitCode)
+		}
+	}
+
+	return nil
+}
+
+// SetProductUnit adds or changes a unit the product can be counted in. The
+// base unit itself cannot be redefined.
+func (svc *Service) SetProductUnit(ctx context.Context, productID int64, unit model.ProductUnit) error {
+	svc.logger.Info(fmt.Sprintf("[REQUEST] SetProductUnit %d %+v", productID, unit))
+
+	if unit.UnitCode == "" || unit.Factor <= 0 {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Invalid unit %+v", unit))
+		return fmt.Errorf("%w: unit code is required and factor must be greater than zero", model.ErrInvalidUnit)
+	}
+
+	product, err := svc.repo.Postgres.ReadProductByID(ctx, productID)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Product not found: %s", err.Error()))
+		return fmt.Errorf("product not found")
+	}
+
+	if unit.UnitCode == product.BaseUnit {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Unit %s is the base unit", unit.UnitCode))
+		return fmt.Errorf("%w: %s is the base unit of product %d", model.ErrInvalidUnit, unit.UnitCode, productID)
+	}
+
+	err = svc.repo.Postgres.UpsertProductUnit(ctx, productID, unit)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to UpsertProductUnit: %s", err.Error()))
+		return fmt.Errorf("failed to set unit: %w", err)
+	}
+
+	svc.logger.Info("[RESPONSE] Unit set successfully")
+	return nil
+}
+
+func (svc *Service) DeleteProductUnit(ctx context.Context, productID int64, unitCode string) error {
+	svc.logger.Info(fmt.Sprintf("[REQUEST] DeleteProductUnit %d %s", productID, unitCode))
+
+	product, err := svc.repo.Postgres.ReadProductByID(ctx, productID)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Product not found: %s", err.Error()))
+		return fmt.Errorf("product not found")
+	}
+
+	if unitCode == product.PurchaseUnit || unitCode == product.SalesUnit {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Unit %s is in use by product %d", unitCode, productID))
+		return fmt.Errorf("%w: %s is the purchase or sales unit of product %d", model.ErrInvalidUnit, unitCode, productID)
+	}
+
+	err = svc.repo.Postgres.DeleteProductUnit(ctx, productID, unitCode)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to DeleteProductUnit: %s", err.Error()))
+		return fmt.Errorf("failed to delete unit: %w", err)
+	}
+
+	svc.logger.Info("[RESPONSE] Unit deleted successfully")
+	return nil
 }
@@ -39,6 +39,7 @@ func TestService_AddProduct(t *testing.T) {
 						Description: "Test Description",
 						Price:      100,
 						SKU:        "TEST-SKU",
+						BaseUnit:   model.DefaultBaseUnit,
 						CreatedAt:  fixedTime,
 					}).
 					Return(nil)
@@ -267,6 +268,12 @@ func TestService_GetProductByID(t *testing.T) {
 				srv.MockRepo.EXPECT().
 					ReadProductByID(gomock.Any(), int64(1)).
 					Return(testProduct, nil)
+				srv.MockRepo.EXPECT().
+					ReadProductUnits(gomock.Any(), int64(1)).
+					Return(nil, nil)
+				srv.MockRepo.EXPECT().
+					ReadProductBarcodes(gomock.Any(), int64(1)).
+					Return(nil, nil)
 			},
 			want:    testProduct,
 			wantErr: false,
@@ -326,6 +333,7 @@ func TestService_GetProducts(t *testing.T) {
 	defer srv.MockCtrl.Finish()
 
 	fixedTime := time.Now()
+	one, two := int64(1), int64(2)
 	testProducts := []model.Product{
 		{
 			ProductID:   1,
@@ -341,11 +349,14 @@ func TestService_GetProducts(t *testing.T) {
 		},
 	}
 
+	minPrice, maxPrice := 500.0, 100.0
+
 	tests := []struct {
 		name       string
+		filter     model.ProductFilter
 		pagination model.Pagination
 		mock       func()
-		want       []model.Product
+		want       model.ProductPage
 		wantErr    bool
 	}{
 		{
@@ -356,10 +367,13 @@ func TestService_GetProducts(t *testing.T) {
 			},
 			mock: func() {
 				srv.MockRepo.EXPECT().
-					ReadProductsWithPagination(gomock.Any(), int32(10), int32(0)).
+					ReadProductsWithPagination(gomock.Any(), model.ProductFilter{}, int32(10), int32(0)).
 					Return(testProducts, nil)
+				srv.MockRepo.EXPECT().
+					CountProducts(gomock.Any(), model.ProductFilter{}).
+					Return(int64(2), nil)
 			},
-			want:    testProducts,
+			want:    model.ProductPage{Products: testProducts, Total: &two, Page: 1, Limit: 10},
 			wantErr: false,
 		},
 		{
@@ -370,24 +384,55 @@ func TestService_GetProducts(t *testing.T) {
 			},
 			mock: func() {
 				srv.MockRepo.EXPECT().
-					ReadProductsWithPagination(gomock.Any(), int32(10), int32(10)).
+					ReadProductsWithPagination(gomock.Any(), model.ProductFilter{}, int32(10), int32(10)).
 					Return([]model.Product{}, nil)
+				srv.MockRepo.EXPECT().
+					CountProducts(gomock.Any(), model.ProductFilter{}).
+					Return(int64(2), nil)
 			},
-			want:    []model.Product{},
+			want:    model.ProductPage{Products: []model.Product{}, Total: &two, Page: 2, Limit: 10},
 			wantErr: false,
 		},
 		{
-			name: "invalid page",
+			name:   "search sorted by price",
+			filter: model.ProductFilter{Search: "teh", SortBy: "price", Descending: true},
 			pagination: model.Pagination{
-				Page:  0,
+				Page:  1,
 				Limit: 10,
 			},
 			mock: func() {
+				filter := model.ProductFilter{Search: "teh", SortBy: "price", Descending: true}
+				srv.MockRepo.EXPECT().
+					ReadProductsWithPagination(gomock.Any(), filter, int32(10), int32(0)).
+					Return(testProducts[1:], nil)
 				srv.MockRepo.EXPECT().
-					ReadProductsWithPagination(gomock.Any(), int32(10), int32(-10)).
-					Return(nil, errors.New("invalid page number"))
+					CountProducts(gomock.Any(), filter).
+					Return(int64(1), nil)
 			},
-			want:    nil,
+			want:    model.ProductPage{Products: testProducts[1:], Total: &one, Page: 1, Limit: 10},
+			wantErr: false,
+		},
+		{
+			name:       "unknown sort field",
+			filter:     model.ProductFilter{SortBy: "password"},
+			pagination: model.Pagination{Page: 1, Limit: 10},
+			mock:       func() {},
+			wantErr:    true,
+		},
+		{
+			name:       "empty price range",
+			filter:     model.ProductFilter{MinPrice: &minPrice, MaxPrice: &maxPrice},
+			pagination: model.Pagination{Page: 1, Limit: 10},
+			mock:       func() {},
+			wantErr:    true,
+		},
+		{
+			name: "invalid page",
+			pagination: model.Pagination{
+				Page:  0,
+				Limit: 10,
+			},
+			mock:    func() {},
 			wantErr: true,
 		},
 		{
@@ -396,12 +441,7 @@ func TestService_GetProducts(t *testing.T) {
 				Page:  1,
 				Limit: 0,
 			},
-			mock: func() {
-				srv.MockRepo.EXPECT().
-					ReadProductsWithPagination(gomock.Any(), int32(0), int32(0)).
-					Return(nil, errors.New("invalid limit"))
-			},
-			want:    nil,
+			mock:    func() {},
 			wantErr: true,
 		},
 		{
@@ -412,10 +452,9 @@ func TestService_GetProducts(t *testing.T) {
 			},
 			mock: func() {
 				srv.MockRepo.EXPECT().
-					ReadProductsWithPagination(gomock.Any(), int32(10), int32(0)).
+					ReadProductsWithPagination(gomock.Any(), model.ProductFilter{}, int32(10), int32(0)).
 					Return(nil, errors.New("database error"))
 			},
-			want:    nil,
 			wantErr: true,
 		},
 	}
@@ -424,7 +463,7 @@ func TestService_GetProducts(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock()
 
-			got, err := srv.Service.GetProducts(context.Background(), tt.pagination)
+			got, err := srv.Service.GetProducts(context.Background(), tt.filter, tt.pagination)
 			if tt.wantErr {
 				assert.Error(t, err)
 				return
@@ -436,6 +475,42 @@ func TestService_GetProducts(t *testing.T) {
 }
 
 // Helper function to check pagination calculation
+func TestService_GetProductsByCursor(t *testing.T) {
+	srv := NewTestServer(t)
+	defer srv.MockCtrl.Finish()
+
+	ctx := context.Background()
+	products := []model.Product{
+		{ProductID: 4, ProductName: "Gula", Price: 150},
+		{ProductID: 2, ProductName: "Beras", Price: 120.5},
+		{ProductID: 9, ProductName: "Garam", Price: 100},
+	}
+	filter := model.ProductFilter{SortBy: "price", Descending: true}
+
+	t.Run("next cursor holds the last product's price", func(t *testing.T) {
+		srv.MockRepo.EXPECT().ReadProductsByCursor(gomock.Any(), filter, model.Cursor{}, int32(3)).Return(products, nil)
+
+		page, cursors, err := srv.Service.GetProductsByCursor(ctx, filter, model.CursorPagination{Limit: 2})
+		assert.NoError(t, err)
+		assert.Equal(t, model.ProductPage{Products: products[:2], Limit: 2}, page)
+		assert.Equal(t, model.PageCursors{
+			NextCursor: model.Cursor{ID: 2, Key: "120.5", Sort: "price desc"}.Encode(),
+		}, cursors)
+	})
+
+	t.Run("cursor made for another sort order", func(t *testing.T) {
+		cursor := model.Cursor{ID: 2, Key: "120.5", Sort: "price"}
+
+		_, _, err := srv.Service.GetProductsByCursor(ctx, filter, model.CursorPagination{Cursor: cursor.Encode(), Limit: 2})
+		assert.ErrorIs(t, err, model.ErrInvalidPagination)
+	})
+
+	t.Run("unknown sort field", func(t *testing.T) {
+		_, _, err := srv.Service.GetProductsByCursor(ctx, model.ProductFilter{SortBy: "stock"}, model.CursorPagination{Limit: 2})
+		assert.ErrorIs(t, err, model.ErrInvalidProductQuery)
+	})
+}
+
 func TestCalculateOffset(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -476,3 +551,57 @@ func TestCalculateOffset(t *testing.T) {
 	}
 }
 
+
+func TestService_AddProduct_Units(t *testing.T) {
+	srv := NewTestServer(t)
+	defer srv.MockCtrl.Finish()
+
+	ctx := context.Background()
+	sack := model.ProductUnit{UnitCode: "SACK", Factor: 50}
+
+	t.Run("bought in sacks", func(t *testing.T) {
+		product := model.Product{ProductName: "Gula Pasir", SKU: "GULA", BaseUnit: "KG", PurchaseUnit: "SACK", SalesUnit: "KG", Units: []model.ProductUnit{sack}}
+		srv.MockRepo.EXPECT().WriteProduct(gomock.Any(), product).Return(nil)
+
+		err := srv.Service.AddProduct(ctx, product)
+		assert.NoError(t, err)
+	})
+
+	t.Run("purchase unit not among its units", func(t *testing.T) {
+		err := srv.Service.AddProduct(ctx, model.Product{ProductName: "Gula Pasir", SKU: "GULA", BaseUnit: "KG", PurchaseUnit: "BOX", Units: []model.ProductUnit{sack}})
+		assert.ErrorIs(t, err, model.ErrUnknownUnit)
+	})
+
+	t.Run("base unit cannot be redefined", func(t *testing.T) {
+		err := srv.Service.AddProduct(ctx, model.Product{ProductName: "Gula Pasir", SKU: "GULA", BaseUnit: "KG", Units: []model.ProductUnit{{UnitCode: "KG", Factor: 1000}}})
+		assert.ErrorIs(t, err, model.ErrInvalidUnit)
+	})
+}
+
+func TestService_SetProductUnit(t *testing.T) {
+	srv := NewTestServer(t)
+	defer srv.MockCtrl.Finish()
+
+	ctx := context.Background()
+	sugar := model.Product{ProductID: 4, ProductName: "Gula Pasir", BaseUnit: "KG", SalesUnit: "KG"}
+
+	t.Run("sack of fifty kilograms", func(t *testing.T) {
+		srv.MockRepo.EXPECT().ReadProductByID(gomock.Any(), int64(4)).Return(sugar, nil)
+		srv.MockRepo.EXPECT().UpsertProductUnit(gomock.Any(), int64(4), model.ProductUnit{UnitCode: "SACK", Factor: 50}).Return(nil)
+
+		err := srv.Service.SetProductUnit(ctx, 4, model.ProductUnit{UnitCode: "SACK", Factor: 50})
+		assert.NoError(t, err)
+	})
+
+	t.Run("base unit cannot be redefined", func(t *testing.T) {
+		srv.MockRepo.EXPECT().ReadProductByID(gomock.Any(), int64(4)).Return(sugar, nil)
+
+		err := srv.Service.SetProductUnit(ctx, 4, model.ProductUnit{UnitCode: "KG", Factor: 1000})
+		assert.ErrorIs(t, err, model.ErrInvalidUnit)
+	})
+
+	t.Run("zero factor", func(t *testing.T) {
+		err := srv.Service.SetProductUnit(ctx, 4, model.ProductUnit{UnitCode: "SACK"})
+		assert.ErrorIs(t, err, model.ErrInvalidUnit)
+	})
+}
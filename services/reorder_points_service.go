@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/budsx/retail-management/middleware"
+	"github.com/budsx/retail-management/model"
+)
+
+// lowStockQueueSize is how many low-stock checks may wait for the checker.
+// Checks that do not fit are dropped; the periodic sweep catches them.
+const lowStockQueueSize = 256
+
+// stockCheck asks the low-stock checker to look at a product in a warehouse.
+type stockCheck struct {
+	productID   int64
+	warehouseID int64
+}
+
+// SetReorderPoint creates or replaces the stock levels kept for a product in
+// one of the user's warehouses.
+func (svc *Service) SetReorderPoint(ctx context.Context, reorderPoint model.ReorderPoint) error {
+	user := middleware.GetUserInfoByContext(ctx)
+	svc.logger.Info(fmt.Sprintf("[REQUEST] SetReorderPoint %+v - %+v", reorderPoint, user))
+
+	if reorderPoint.MinQuantity < 0 || reorderPoint.MaxQuantity < 0 || reorderPoint.ReorderQuantity <= 0 {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Invalid reorder point %+v", reorderPoint))
+		return fmt.Errorf("%w: min and max quantity must not be negative and reorder quantity must be greater than zero", model.ErrInvalidReorderPoint)
+	}
+	if reorderPoint.MaxQuantity != 0 && reorderPoint.MaxQuantity < reorderPoint.MinQuantity {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Max quantity %d below min quantity %d", reorderPoint.MaxQuantity, reorderPoint.MinQuantity))
+		return fmt.Errorf("%w: max quantity must not be below min quantity", model.ErrInvalidReorderPoint)
+	}
+
+	warehouse, err := svc.repo.Postgres.ReadWarehouseByID(ctx, reorderPoint.WarehouseID)
+	if err != nil || warehouse.UserID != user.UserID {
+		svc.logger.Error("[ERROR] Unauthorized or warehouse not found")
+		return fmt.Errorf("unauthorized or warehouse not found")
+	}
+
+	product, err := svc.repo.Postgres.ReadProductByID(ctx, reorderPoint.ProductID)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Product not found: %s", err.Error()))
+		return fmt.Errorf("product not found")
+	}
+	if product.HasVariants() {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Product %d keeps its stock per variant", product.ProductID))
+		return fmt.Errorf("%w: product %d", model.ErrVariantParent, product.ProductID)
+	}
+
+	err = svc.repo.Postgres.UpsertReorderPoint(ctx, reorderPoint)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to UpsertReorderPoint: %s", err.Error()))
+		return fmt.Errorf("failed to set reorder point: %w", err)
+	}
+
+	svc.queueLowStockCheck(reorderPoint.ProductID, reorderPoint.WarehouseID)
+	svc.logger.Info("[RESPONSE] Set reorder point successfully")
+	return nil
+}
+
+func (svc *Service) GetReorderPoints(ctx context.Context, warehouseID int64) ([]model.ReorderPoint, error) {
+	user := middleware.GetUserInfoByContext(ctx)
+	svc.logger.Info(fmt.Sprintf("[REQUEST] GetReorderPoints warehouse %d - %+v", warehouseID, user))
+
+	warehouse, err := svc.repo.Postgres.ReadWarehouseByID(ctx, warehouseID)
+	if err != nil || warehouse.UserID != user.UserID {
+		svc.logger.Error("[ERROR] Unauthorized or warehouse not found")
+		return nil, fmt.Errorf("unauthorized or warehouse not found")
+	}
+
+	reorderPoints, err := svc.repo.Postgres.ReadReorderPointsByWarehouse(ctx, warehouseID)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to ReadReorderPointsByWarehouse: %s", err.Error()))
+		return nil, fmt.Errorf("failed to retrieve reorder points for warehouse %d: %w", warehouseID, err)
+	}
+
+	svc.logger.Info(fmt.Sprintf("[RESPONSE] %+v", reorderPoints))
+	return reorderPoints, nil
+}
+
+func (svc *Service) DeleteReorderPoint(ctx context.Context, warehouseID, productID int64) error {
+	user := middleware.GetUserInfoByContext(ctx)
+	svc.logger.Info(fmt.Sprintf("[REQUEST] DeleteReorderPoint product %d warehouse %d - %+v", productID, warehouseID, user))
+
+	warehouse, err := svc.repo.Postgres.ReadWarehouseByID(ctx, warehouseID)
+	if err != nil || warehouse.UserID != user.UserID {
+		svc.logger.Error("[ERROR] Unauthorized or warehouse not found")
+		return fmt.Errorf("unauthorized or warehouse not found")
+	}
+
+	err = svc.repo.Postgres.DeleteReorderPoint(ctx, productID, warehouseID)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to DeleteReorderPoint: %s", err.Error()))
+		return fmt.Errorf("failed to delete reorder point: %w", err)
+	}
+
+	svc.logger.Info("[RESPONSE] Delete reorder point successfully")
+	return nil
+}
+
+// GetLowStockItems lists the products below their reorder point in the user's
+// warehouses, or in one of them when warehouseID is set.
+func (svc *Service) GetLowStockItems(ctx context.Context, warehouseID int64) ([]model.LowStockItem, error) {
+	user := middleware.GetUserInfoByContext(ctx)
+	svc.logger.Info(fmt.Sprintf("[REQUEST] GetLowStockItems warehouse %d - %+v", warehouseID, user))
+
+	items, err := svc.repo.Postgres.GetLowStockItems(ctx, user.UserID, warehouseID)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to GetLowStockItems: %s", err.Error()))
+		return nil, fmt.Errorf("failed to retrieve low stock items: %w", err)
+	}
+
+	svc.logger.Info(fmt.Sprintf("[RESPONSE] %+v", items))
+	return items, nil
+}
+
+// CheckLowStock alerts on the reorder points matching productID and
+// warehouseID (0 for any) that have dropped below their minimum. Each drop is
+// alerted once: it is logged and passed to the notifier, if one is set up. An
+// alert the notifier fails to deliver is released to be claimed again by the
+// next check.
+func (svc *Service) CheckLowStock(ctx context.Context, productID, warehouseID int64) error {
+	items, err := svc.repo.Postgres.ClaimLowStockAlerts(ctx, productID, warehouseID)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to ClaimLowStockAlerts: %s", err.Error()))
+		return fmt.Errorf("failed to check low stock: %w", err)
+	}
+
+	for _, item := range items {
+		svc.logger.Warn(fmt.Sprintf("[LOW STOCK] %s (%s) in warehouse %d: %d available, reorder point %d, suggested order %d",
+			item.ProductName, item.SKU, item.WarehouseID, item.AvailableStock, item.MinQuantity, item.SuggestedQuantity))
+
+		if svc.repo.Notifier == nil {
+			continue
+		}
+		err := svc.repo.Notifier.NotifyLowStock(ctx, item)
+		if err != nil {
+			svc.logger.Error(fmt.Sprintf("[ERROR] Failed to NotifyLowStock: %s", err.Error()))
+
+			err = svc.repo.Postgres.ReleaseLowStockAlert(ctx, item.ProductID, item.WarehouseID)
+			if err != nil {
+				svc.logger.Error(fmt.Sprintf("[ERROR] Failed to ReleaseLowStockAlert: %s", err.Error()))
+			}
+		}
+	}
+
+	return nil
+}
+
+// RunLowStockChecker checks the balances queued by stock movements until ctx
+// is cancelled. It is run from main.
+func (svc *Service) RunLowStockChecker(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case check := <-svc.lowStockChecks:
+			svc.CheckLowStock(ctx, check.productID, check.warehouseID)
+		}
+	}
+}
+
+// queueLowStockCheck hands a balance that may have dropped to the low-stock
+// checker without waiting for it.
+func (svc *Service) queueLowStockCheck(productID, warehouseID int64) {
+	select {
+	case svc.lowStockChecks <- stockCheck{productID: productID, warehouseID: warehouseID}:
+	default:
+	}
+}
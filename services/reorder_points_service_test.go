@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/budsx/retail-management/middleware"
+	"github.com/budsx/retail-management/model"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestService_SetReorderPoint(t *testing.T) {
+	srv := NewTestServer(t)
+	defer srv.MockCtrl.Finish()
+
+	ctx := context.WithValue(context.Background(), middleware.ContextKeyUserID, int64(3))
+	ctx = context.WithValue(ctx, middleware.ContextKeyUsername, "buyer")
+
+	tests := []struct {
+		name         string
+		reorderPoint model.ReorderPoint
+		mock         func()
+		wantErr      error
+	}{
+		{
+			name:         "levels are saved",
+			reorderPoint: model.ReorderPoint{ProductID: 1, WarehouseID: 2, MinQuantity: 10, MaxQuantity: 50, ReorderQuantity: 40},
+			mock: func() {
+				srv.MockRepo.EXPECT().ReadWarehouseByID(gomock.Any(), int64(2)).Return(model.Warehouse{WarehouseID: 2, UserID: 3}, nil)
+				srv.MockRepo.EXPECT().ReadProductByID(gomock.Any(), int64(1)).Return(model.Product{ProductID: 1}, nil)
+				srv.MockRepo.EXPECT().
+					UpsertReorderPoint(gomock.Any(), model.ReorderPoint{ProductID: 1, WarehouseID: 2, MinQuantity: 10, MaxQuantity: 50, ReorderQuantity: 40}).
+					Return(nil)
+			},
+		},
+		{
+			name:         "parent of variants",
+			reorderPoint: model.ReorderPoint{ProductID: 6, WarehouseID: 2, MinQuantity: 10, ReorderQuantity: 40},
+			mock: func() {
+				srv.MockRepo.EXPECT().ReadWarehouseByID(gomock.Any(), int64(2)).Return(model.Warehouse{WarehouseID: 2, UserID: 3}, nil)
+				srv.MockRepo.EXPECT().ReadProductByID(gomock.Any(), int64(6)).Return(model.Product{ProductID: 6, VariantAttributes: []string{"size"}}, nil)
+			},
+			wantErr: model.ErrVariantParent,
+		},
+		{
+			name:         "max below min",
+			reorderPoint: model.ReorderPoint{ProductID: 1, WarehouseID: 2, MinQuantity: 10, MaxQuantity: 5, ReorderQuantity: 40},
+			mock:         func() {},
+			wantErr:      model.ErrInvalidReorderPoint,
+		},
+		{
+			name:         "nothing to reorder",
+			reorderPoint: model.ReorderPoint{ProductID: 1, WarehouseID: 2, MinQuantity: 10},
+			mock:         func() {},
+			wantErr:      model.ErrInvalidReorderPoint,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			err := srv.Service.SetReorderPoint(ctx, tt.reorderPoint)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+
+	t.Run("warehouse of another user", func(t *testing.T) {
+		srv.MockRepo.EXPECT().ReadWarehouseByID(gomock.Any(), int64(9)).Return(model.Warehouse{WarehouseID: 9, UserID: 8}, nil)
+
+		err := srv.Service.SetReorderPoint(ctx, model.ReorderPoint{ProductID: 1, WarehouseID: 9, MinQuantity: 1, ReorderQuantity: 1})
+		assert.Error(t, err)
+	})
+}
+
+func TestService_CheckLowStock(t *testing.T) {
+	srv := NewTestServer(t)
+	defer srv.MockCtrl.Finish()
+
+	ctx := context.Background()
+	items := []model.LowStockItem{
+		{ProductID: 1, ProductName: "Kopi Arabika", WarehouseID: 2, AvailableStock: 4, MinQuantity: 10, ReorderQuantity: 40, SuggestedQuantity: 40},
+		{ProductID: 3, ProductName: "Teh Melati", WarehouseID: 2, AvailableStock: 0, MinQuantity: 5, ReorderQuantity: 20, SuggestedQuantity: 20},
+	}
+
+	t.Run("each new alert is notified", func(t *testing.T) {
+		srv.MockRepo.EXPECT().ClaimLowStockAlerts(gomock.Any(), int64(0), int64(2)).Return(items, nil)
+		srv.MockNotifier.EXPECT().NotifyLowStock(gomock.Any(), items[0]).Return(nil)
+		srv.MockNotifier.EXPECT().NotifyLowStock(gomock.Any(), items[1]).Return(errors.New("webhook answered 502 Bad Gateway"))
+		srv.MockRepo.EXPECT().ReleaseLowStockAlert(gomock.Any(), int64(3), int64(2)).Return(nil)
+
+		assert.NoError(t, srv.Service.CheckLowStock(ctx, 0, 2))
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		srv.MockRepo.EXPECT().ClaimLowStockAlerts(gomock.Any(), int64(1), int64(2)).Return(nil, errors.New("database error"))
+
+		assert.Error(t, srv.Service.CheckLowStock(ctx, 1, 2))
+	})
+}
+
+func TestService_RunLowStockChecker(t *testing.T) {
+	srv := NewTestServer(t)
+	defer srv.MockCtrl.Finish()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	srv.MockRepo.EXPECT().
+		ClaimLowStockAlerts(gomock.Any(), int64(1), int64(2)).
+		DoAndReturn(func(context.Context, int64, int64) ([]model.LowStockItem, error) {
+			cancel()
+			return nil, nil
+		})
+
+	srv.Service.(*Service).queueLowStockCheck(1, 2)
+	go func() {
+		srv.Service.RunLowStockChecker(ctx)
+		close(done)
+	}()
+	<-done
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/budsx/retail-management/middleware"
+	"github.com/budsx/retail-management/model"
+)
+
+func (svc *Service) CreateReservation(ctx context.Context, reservation model.Reservation) (model.Reservation, error) {
+	svc.logger.Info(fmt.Sprintf("[REQUEST] %+v", reservation))
+
+	if reservation.Quantity <= 0 {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Invalid quantity %d", reservation.Quantity))
+		return model.Reservation{}, fmt.Errorf("%w: quantity must be greater than zero", model.ErrInvalidQuantity)
+	}
+
+	warehouseID, err := svc.validateStockLocation(ctx, reservation.WarehouseID, reservation.LocationID, false)
+	if err != nil {
+		return model.Reservation{}, err
+	}
+	reservation.WarehouseID = warehouseID
+
+	product, err := svc.repo.Postgres.ReadProductByID(ctx, reservation.ProductID)
+	if errors.Is(err, model.ErrProductNotFound) {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Product not found: %s", err.Error()))
+		return model.Reservation{}, err
+	}
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to ReadProductByID: %s", err.Error()))
+		return model.Reservation{}, fmt.Errorf("failed to read product %d: %w", reservation.ProductID, err)
+	}
+	if product.HasVariants() {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Product %d keeps its stock per variant", product.ProductID))
+		return model.Reservation{}, fmt.Errorf("%w: product %d", model.ErrVariantParent, product.ProductID)
+	}
+
+	reservationID, err := svc.repo.Postgres.CreateReservation(ctx, reservation, svc.config.ReservationTTL)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to CreateReservation: %s", err.Error()))
+		return model.Reservation{}, fmt.Errorf("failed to create reservation: %w", err)
+	}
+	svc.queueLowStockCheck(reservation.ProductID, reservation.WarehouseID)
+
+	created, err := svc.repo.Postgres.ReadReservationByID(ctx, reservationID)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to ReadReservationByID: %s", err.Error()))
+		return model.Reservation{}, fmt.Errorf("failed to read reservation %d: %w", reservationID, err)
+	}
+
+	svc.logger.Info(fmt.Sprintf("[RESPONSE] %+v", created))
+	return created, nil
+}
+
+func (svc *Service) GetReservationByID(ctx context.Context, reservationID int64) (model.Reservation, error) {
+	user := middleware.GetUserInfoByContext(ctx)
+	svc.logger.Info(fmt.Sprintf("[REQUEST] GetReservationByID %d - %+v", reservationID, user))
+
+	reservation, err := svc.repo.Postgres.ReadReservationByID(ctx, reservationID)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to ReadReservationByID: %s", err.Error()))
+		return model.Reservation{}, fmt.Errorf("failed to read reservation %d: %w", reservationID, err)
+	}
+
+	svc.logger.Info(fmt.Sprintf("[RESPONSE] %+v", reservation))
+	return reservation, nil
+}
+
+// ConfirmReservation posts the reserved quantity as an OUT transaction. A
+// reservation of a serialized product is confirmed with the serial numbers
+// that are shipped.
+func (svc *Service) ConfirmReservation(ctx context.Context, reservationID int64, serialNumbers []string) (model.Reservation, error) {
+	user := middleware.GetUserInfoByContext(ctx)
+	svc.logger.Info(fmt.Sprintf("[REQUEST] ConfirmReservation %d %v - %+v", reservationID, serialNumbers, user))
+
+	reservation, err := svc.repo.Postgres.ReadReservationByID(ctx, reservationID)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to ReadReservationByID: %s", err.Error()))
+		return model.Reservation{}, fmt.Errorf("failed to read reservation %d: %w", reservationID, err)
+	}
+
+	_, err = svc.validateSerialNumbers(ctx, reservation.ProductID, reservation.Quantity, serialNumbers)
+	if err != nil {
+		return model.Reservation{}, err
+	}
+
+	transactionID, err := svc.repo.Postgres.ConfirmReservation(ctx, reservationID, user.UserID, serialNumbers)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to ConfirmReservation: %s", err.Error()))
+		return model.Reservation{}, fmt.Errorf("failed to confirm reservation: %w", err)
+	}
+
+	reservation, err = svc.repo.Postgres.ReadReservationByID(ctx, reservationID)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to ReadReservationByID: %s", err.Error()))
+		return model.Reservation{}, fmt.Errorf("failed to read reservation %d: %w", reservationID, err)
+	}
+	svc.queueLowStockCheck(reservation.ProductID, reservation.WarehouseID)
+
+	svc.logger.Info(fmt.Sprintf("[RESPONSE] Reservation %d confirmed as transaction %d", reservationID, transactionID))
+	return reservation, nil
+}
+
+func (svc *Service) ReleaseReservation(ctx context.Context, reservationID int64) error {
+	user := middleware.GetUserInfoByContext(ctx)
+	svc.logger.Info(fmt.Sprintf("[REQUEST] ReleaseReservation %d - %+v", reservationID, user))
+
+	err := svc.repo.Postgres.ReleaseReservation(ctx, reservationID)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to ReleaseReservation: %s", err.Error()))
+		return fmt.Errorf("failed to release reservation: %w", err)
+	}
+
+	svc.logger.Info("[RESPONSE] Release reservation successfully")
+	return nil
+}
+
+// ExpireReservations releases the stock held by reservations past their
+// expiry. It is run periodically from main.
+func (svc *Service) ExpireReservations(ctx context.Context) error {
+	expired, err := svc.repo.Postgres.ExpireReservations(ctx)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to ExpireReservations: %s", err.Error()))
+		return fmt.Errorf("failed to expire reservations: %w", err)
+	}
+
+	if expired > 0 {
+		svc.logger.Info(fmt.Sprintf("[RESPONSE] Expired %d reservations", expired))
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/budsx/retail-management/middleware"
+	"github.com/budsx/retail-management/model"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestService_CreateReservation(t *testing.T) {
+	srv := NewTestServer(t)
+	defer srv.MockCtrl.Finish()
+
+	ctx := context.Background()
+	location := model.Location{LocationID: 2, LocationName: "Rak B2", WarehouseID: 1}
+	reserved := model.Reservation{
+		ReservationID: 7,
+		ProductID:     1,
+		WarehouseID:   1,
+		LocationID:    2,
+		Quantity:      5,
+		Status:        model.ReservationActive,
+		Reference:     "SO-001",
+	}
+
+	tests := []struct {
+		name        string
+		reservation model.Reservation
+		mock        func()
+		want        model.Reservation
+		wantErr     error
+	}{
+		{
+			name:        "reserves with the configured ttl",
+			reservation: model.Reservation{ProductID: 1, LocationID: 2, Quantity: 5, Reference: "SO-001"},
+			mock: func() {
+				srv.MockRepo.EXPECT().ReadLocationByID(gomock.Any(), int64(2)).Return(location, nil)
+				srv.MockRepo.EXPECT().ReadProductByID(gomock.Any(), int64(1)).Return(model.Product{ProductID: 1}, nil)
+				srv.MockRepo.EXPECT().
+					CreateReservation(gomock.Any(), model.Reservation{
+						ProductID:   1,
+						WarehouseID: 1,
+						LocationID:  2,
+						Quantity:    5,
+						Reference:   "SO-001",
+					}, 30*time.Minute).
+					Return(int64(7), nil)
+				srv.MockRepo.EXPECT().ReadReservationByID(gomock.Any(), int64(7)).Return(reserved, nil)
+			},
+			want: reserved,
+		},
+		{
+			name:        "zero quantity",
+			reservation: model.Reservation{ProductID: 1, LocationID: 2},
+			mock:        func() {},
+			wantErr:     model.ErrInvalidQuantity,
+		},
+		{
+			name:        "unknown location",
+			reservation: model.Reservation{ProductID: 1, LocationID: 9, Quantity: 5},
+			mock: func() {
+				srv.MockRepo.EXPECT().ReadLocationByID(gomock.Any(), int64(9)).Return(model.Location{}, fmt.Errorf("%w: 9", model.ErrLocationNotFound))
+			},
+			wantErr: model.ErrLocationNotFound,
+		},
+		{
+			name:        "unknown product",
+			reservation: model.Reservation{ProductID: 9, LocationID: 2, Quantity: 5},
+			mock: func() {
+				srv.MockRepo.EXPECT().ReadLocationByID(gomock.Any(), int64(2)).Return(location, nil)
+				srv.MockRepo.EXPECT().ReadProductByID(gomock.Any(), int64(9)).Return(model.Product{}, fmt.Errorf("%w: 9", model.ErrProductNotFound))
+			},
+			wantErr: model.ErrProductNotFound,
+		},
+		{
+			name:        "not enough available stock",
+			reservation: model.Reservation{ProductID: 1, LocationID: 2, Quantity: 50},
+			mock: func() {
+				srv.MockRepo.EXPECT().ReadLocationByID(gomock.Any(), int64(2)).Return(location, nil)
+				srv.MockRepo.EXPECT().ReadProductByID(gomock.Any(), int64(1)).Return(model.Product{ProductID: 1}, nil)
+				srv.MockRepo.EXPECT().
+					CreateReservation(gomock.Any(), gomock.Any(), 30*time.Minute).
+					Return(int64(0), model.ErrInsufficientStock)
+			},
+			wantErr: model.ErrInsufficientStock,
+		},
+		{
+			name:        "parent of variants",
+			reservation: model.Reservation{ProductID: 6, LocationID: 2, Quantity: 5},
+			mock: func() {
+				srv.MockRepo.EXPECT().ReadLocationByID(gomock.Any(), int64(2)).Return(location, nil)
+				srv.MockRepo.EXPECT().ReadProductByID(gomock.Any(), int64(6)).Return(model.Product{ProductID: 6, VariantAttributes: []string{"size"}}, nil)
+			},
+			wantErr: model.ErrVariantParent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			got, err := srv.Service.CreateReservation(ctx, tt.reservation)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestService_ConfirmReservation(t *testing.T) {
+	srv := NewTestServer(t)
+	defer srv.MockCtrl.Finish()
+
+	ctx := context.WithValue(context.Background(), middleware.ContextKeyUserID, int64(3))
+	ctx = context.WithValue(ctx, middleware.ContextKeyUsername, "picker")
+
+	t.Run("posts the out transaction", func(t *testing.T) {
+		active := model.Reservation{ReservationID: 7, ProductID: 1, Quantity: 5, Status: model.ReservationActive}
+		confirmed := model.Reservation{ReservationID: 7, ProductID: 1, Quantity: 5, Status: model.ReservationConfirmed, TransactionID: 11}
+		gomock.InOrder(
+			srv.MockRepo.EXPECT().ReadReservationByID(gomock.Any(), int64(7)).Return(active, nil),
+			srv.MockRepo.EXPECT().ReadProductByID(gomock.Any(), int64(1)).Return(model.Product{ProductID: 1}, nil),
+			srv.MockRepo.EXPECT().ConfirmReservation(gomock.Any(), int64(7), int64(3), []string(nil)).Return(int64(11), nil),
+			srv.MockRepo.EXPECT().ReadReservationByID(gomock.Any(), int64(7)).Return(confirmed, nil),
+		)
+
+		got, err := srv.Service.ConfirmReservation(ctx, 7, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, confirmed, got)
+	})
+
+	t.Run("reservation no longer active", func(t *testing.T) {
+		srv.MockRepo.EXPECT().ReadReservationByID(gomock.Any(), int64(8)).Return(model.Reservation{ReservationID: 8, ProductID: 1, Quantity: 5}, nil)
+		srv.MockRepo.EXPECT().ReadProductByID(gomock.Any(), int64(1)).Return(model.Product{ProductID: 1}, nil)
+		srv.MockRepo.EXPECT().ConfirmReservation(gomock.Any(), int64(8), int64(3), []string(nil)).Return(int64(0), model.ErrReservationNotActive)
+
+		_, err := srv.Service.ConfirmReservation(ctx, 8, nil)
+		assert.ErrorIs(t, err, model.ErrReservationNotActive)
+	})
+
+	t.Run("serialized product needs its serial numbers", func(t *testing.T) {
+		tracked := true
+		srv.MockRepo.EXPECT().ReadReservationByID(gomock.Any(), int64(9)).Return(model.Reservation{ReservationID: 9, ProductID: 5, Quantity: 2}, nil)
+		srv.MockRepo.EXPECT().ReadProductByID(gomock.Any(), int64(5)).Return(model.Product{ProductID: 5, IsSerialized: &tracked}, nil)
+
+		_, err := srv.Service.ConfirmReservation(ctx, 9, []string{"SN-001"})
+		assert.ErrorIs(t, err, model.ErrInvalidMovement)
+	})
+
+	t.Run("reservation not found", func(t *testing.T) {
+		srv.MockRepo.EXPECT().ReadReservationByID(gomock.Any(), int64(10)).Return(model.Reservation{}, fmt.Errorf("%w: 10", model.ErrReservationNotFound))
+
+		_, err := srv.Service.ConfirmReservation(ctx, 10, nil)
+		assert.ErrorIs(t, err, model.ErrReservationNotFound)
+	})
+}
+
+func TestService_GetReservationByID(t *testing.T) {
+	srv := NewTestServer(t)
+	defer srv.MockCtrl.Finish()
+
+	ctx := context.WithValue(context.Background(), middleware.ContextKeyUserID, int64(3))
+	ctx = context.WithValue(ctx, middleware.ContextKeyUsername, "picker")
+
+	t.Run("not found", func(t *testing.T) {
+		srv.MockRepo.EXPECT().ReadReservationByID(gomock.Any(), int64(9)).Return(model.Reservation{}, fmt.Errorf("%w: 9", model.ErrReservationNotFound))
+
+		_, err := srv.Service.GetReservationByID(ctx, 9)
+		assert.ErrorIs(t, err, model.ErrReservationNotFound)
+	})
+
+	t.Run("database failure", func(t *testing.T) {
+		srv.MockRepo.EXPECT().ReadReservationByID(gomock.Any(), int64(9)).Return(model.Reservation{}, sql.ErrConnDone)
+
+		_, err := srv.Service.GetReservationByID(ctx, 9)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+		assert.NotErrorIs(t, err, model.ErrReservationNotFound)
+	})
+}
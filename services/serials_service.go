@@ -0,0 +1,40 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/budsx/retail-management/middleware"
+	"github.com/budsx/retail-management/model"
+)
+
+// GetSerialHistory returns where each unit with the serial number is now and
+// every movement it took part in. Serial numbers are unique per product, so
+// more than one product may have a unit with the same number.
+func (svc *Service) GetSerialHistory(ctx context.Context, serialNumber string) ([]model.Serial, error) {
+	user := middleware.GetUserInfoByContext(ctx)
+	svc.logger.Info(fmt.Sprintf("[REQUEST] GetSerialHistory %s - %+v", serialNumber, user))
+
+	serials, err := svc.repo.Postgres.ReadSerialsByNumber(ctx, serialNumber)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to ReadSerialsByNumber: %s", err.Error()))
+		return nil, fmt.Errorf("failed to read serial %s: %w", serialNumber, err)
+	}
+
+	if len(serials) == 0 {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Serial %s not found", serialNumber))
+		return nil, model.ErrSerialNotFound
+	}
+
+	for i := range serials {
+		movements, err := svc.repo.Postgres.GetSerialMovements(ctx, serials[i].SerialID)
+		if err != nil {
+			svc.logger.Error(fmt.Sprintf("[ERROR] Failed to GetSerialMovements: %s", err.Error()))
+			return nil, fmt.Errorf("failed to read movements of serial %s: %w", serialNumber, err)
+		}
+		serials[i].Movements = movements
+	}
+
+	svc.logger.Info(fmt.Sprintf("[RESPONSE] %+v", serials))
+	return serials, nil
+}
@@ -2,9 +2,11 @@ package services
 
 import (
 	"testing"
+	"time"
 
 	"github.com/budsx/retail-management/utils"
 	"github.com/budsx/retail-management/repository"
+	"github.com/budsx/retail-management/repository/notifier"
 	mocks "github.com/budsx/retail-management/repository/postgres"
 	"github.com/golang/mock/gomock"
 )
@@ -12,6 +14,7 @@ import (
 type TestServer struct {
 	MockCtrl     *gomock.Controller
 	MockRepo     *mocks.MockPostgresRepository
+	MockNotifier *notifier.MockNotifier
 	MockLogger   *utils.Logger
 	Service      RetailManagementService
 }
@@ -19,16 +22,23 @@ type TestServer struct {
 func NewTestServer(t *testing.T) *TestServer {
 	mockCtrl := gomock.NewController(t)
 	mockRepo := mocks.NewMockPostgresRepository(mockCtrl)
+	mockNotifier := notifier.NewMockNotifier(mockCtrl)
 	mockLogger := utils.NewLogger("info")
 
 	svc := NewRetailManagementService(repository.Repository{
 		Postgres: mockRepo,
-	}, mockLogger)
+		Notifier: mockNotifier,
+	}, mockLogger, ServiceConfig{
+		ReservationTTL:    30 * time.Minute,
+		AdjustmentReasons: []string{"DAMAGE", "LOSS", "FOUND", "WRITE_OFF"},
+		IdempotencyTTL:    24 * time.Hour,
+	})
 
 	return &TestServer{
-		MockCtrl:   mockCtrl,
-		MockRepo:   mockRepo,
-		MockLogger: mockLogger,
-		Service:    svc,
+		MockCtrl:     mockCtrl,
+		MockRepo:     mockRepo,
+		MockNotifier: mockNotifier,
+		MockLogger:   mockLogger,
+		Service:      svc,
 	}
 }
\ No newline at end of file
@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"time"
 
 	"github.com/budsx/retail-management/model"
 	"github.com/budsx/retail-management/repository"
@@ -10,32 +11,111 @@ import (
 
 type RetailManagementService interface {
 	GetProductByID(context.Context, int64) (model.Product, error)
-	GetProducts(context.Context, model.Pagination) ([]model.Product, error)
+	GetProducts(ctx context.Context, filter model.ProductFilter, pagination model.Pagination) (model.ProductPage, error)
+	GetProductsByCursor(ctx context.Context, filter model.ProductFilter, pagination model.CursorPagination) (model.ProductPage, model.PageCursors, error)
 	AddProduct(context.Context, model.Product) error
 	EditProduct(context.Context, model.Product) error
+	DeleteProduct(ctx context.Context, productID int64) error
+	RestoreProduct(ctx context.Context, productID int64) error
+	SetProductUnit(ctx context.Context, productID int64, unit model.ProductUnit) error
+	DeleteProductUnit(ctx context.Context, productID int64, unitCode string) error
+	AddProductBarcode(ctx context.Context, productID int64, barcode model.ProductBarcode) (model.ProductBarcode, error)
+	DeleteProductBarcode(ctx context.Context, productID int64, barcode string) error
+	LookupBarcode(ctx context.Context, code string) (model.BarcodeScan, error)
+	GetPriceHistory(ctx context.Context, productID int64) ([]model.ProductPrice, error)
+	GetPriceAt(ctx context.Context, productID int64, at time.Time) (model.ProductPrice, error)
+	SchedulePriceChange(ctx context.Context, price model.ProductPrice) (model.ProductPrice, error)
+	CancelPriceChange(ctx context.Context, productID, priceID int64) error
+	ApplyScheduledPrices(ctx context.Context) error
+
+	AddCategory(ctx context.Context, category model.Category) (model.Category, error)
+	EditCategory(ctx context.Context, category model.Category) error
+	DeleteCategory(ctx context.Context, categoryID int64) error
+	GetCategories(ctx context.Context) ([]model.Category, error)
+	GetCategoryByID(ctx context.Context, categoryID int64) (model.Category, error)
+	GetCategoryStocks(ctx context.Context, locationID int64, asOf *time.Time) ([]model.CategoryStock, error)
 
 	RegisterUser(context.Context, model.User) error
 	ValidateUser(context.Context, model.Credentials) (model.User, error)
 
 	AddWarehouseByUserID(ctx context.Context, warehouse model.Warehouse) error
 	EditWarehouseByUserID(ctx context.Context, warehouse model.Warehouse) error
-	GetWarehouseByUserID(ctx context.Context) ([]model.Warehouse, error)
+	DeleteWarehouseByUserID(ctx context.Context, warehouseID int64) error
+	RestoreWarehouseByUserID(ctx context.Context, warehouseID int64) error
+	GetWarehouseByUserID(ctx context.Context, includeArchived bool, pagination model.CursorPagination) ([]model.Warehouse, model.PageCursors, error)
+	GetInventoryValuation(ctx context.Context, warehouseID int64, asOf time.Time) (model.InventoryValuation, error)
 	AddLocation(ctx context.Context, location model.Location) error
 	EditLocationByUserID(ctx context.Context, location model.Location) error
 	DeleteLocationByUserID(ctx context.Context, locationID int64) error
+	RestoreLocationByUserID(ctx context.Context, locationID int64) error
+	GetLocationsByWarehouse(ctx context.Context, warehouseID int64, includeArchived bool, pagination model.CursorPagination) ([]model.Location, model.PageCursors, error)
 
 	CreateStockTransaction(ctx context.Context, transaction model.StockTransaction) error
-	GetStockTransactions(context.Context) ([]model.StockTransaction, error)
+	CreateStockTransactionBatch(ctx context.Context, batch model.StockTransactionBatch) (model.StockTransactionBatch, error)
+	ReceiveStockTransfer(ctx context.Context, transactionID int64) error
+	ReverseStockTransaction(ctx context.Context, transactionID int64) (model.StockTransaction, error)
+	GetStockTransactions(ctx context.Context, filter model.StockTransactionFilter, pagination model.CursorPagination) ([]model.StockTransaction, model.PageCursors, error)
 	GetStockTransactionByID(context.Context, int64) (model.StockTransaction, error)
 	GetTotalStocks(context.Context) ([]model.ProductStock, error)
 	GetTotalStockByLocation(ctx context.Context, locationID int64) ([]model.ProductStock, error) 
+	GetTotalStocksAsOf(ctx context.Context, asOf time.Time) ([]model.ProductStock, error)
+	GetTotalStockByLocationAsOf(ctx context.Context, locationID int64, asOf time.Time) ([]model.ProductStock, error)
+	GetParentStocks(ctx context.Context, locationID int64, asOf *time.Time) ([]model.ProductStock, error)
+	CreateStockSnapshot(ctx context.Context) error
+	GetExpiringLots(ctx context.Context, warehouseID int64, days int) ([]model.StockLot, error)
+
+	CreateReservation(ctx context.Context, reservation model.Reservation) (model.Reservation, error)
+	GetReservationByID(ctx context.Context, reservationID int64) (model.Reservation, error)
+	ConfirmReservation(ctx context.Context, reservationID int64, serialNumbers []string) (model.Reservation, error)
+	ReleaseReservation(ctx context.Context, reservationID int64) error
+	ExpireReservations(ctx context.Context) error
+
+	GetSerialHistory(ctx context.Context, serialNumber string) ([]model.Serial, error)
+
+	OpenStockTake(ctx context.Context, stockTake model.StockTake) (model.StockTake, error)
+	GetStockTakeByID(ctx context.Context, stockTakeID int64) (model.StockTake, error)
+	RecordStockTakeCounts(ctx context.Context, stockTakeID int64, counts []model.StockTakeCount) (model.StockTake, error)
+	GetStockTakeVariances(ctx context.Context, stockTakeID int64) ([]model.StockTakeLine, error)
+	ApproveStockTake(ctx context.Context, stockTakeID int64) (model.StockTake, error)
+	CancelStockTake(ctx context.Context, stockTakeID int64) error
+
+	SetReorderPoint(ctx context.Context, reorderPoint model.ReorderPoint) error
+	GetReorderPoints(ctx context.Context, warehouseID int64) ([]model.ReorderPoint, error)
+	DeleteReorderPoint(ctx context.Context, warehouseID, productID int64) error
+	GetLowStockItems(ctx context.Context, warehouseID int64) ([]model.LowStockItem, error)
+	CheckLowStock(ctx context.Context, productID, warehouseID int64) error
+	RunLowStockChecker(ctx context.Context)
+
+	SetBundleComponents(ctx context.Context, bundleID int64, components []model.BundleComponent) error
+	GetBundleAvailability(ctx context.Context, bundleID, locationID int64) (model.BundleAvailability, error)
+	AssembleBundle(ctx context.Context, assembly model.StockTransaction) (model.StockTransaction, error)
+
+	StartIdempotentRequest(ctx context.Context, key, fingerprint string) (model.IdempotencyRecord, bool, error)
+	FinishIdempotentRequest(ctx context.Context, key string, statusCode int, body []byte) error
+	ExpireIdempotencyKeys(ctx context.Context) error
+}
+
+// ServiceConfig holds the business settings the service needs from config.
+type ServiceConfig struct {
+	ReservationTTL    time.Duration
+	AdjustmentReasons []string
+	IdempotencyTTL    time.Duration
+	IdempotencyLease  time.Duration
 }
 
 type Service struct {
 	repo   repository.Repository
 	logger utils.Interface
+	config ServiceConfig
+
+	lowStockChecks chan stockCheck
 }
 
-func NewRetailManagementService(repo repository.Repository, logger utils.Interface) RetailManagementService {
-	return &Service{repo: repo, logger: logger}
+func NewRetailManagementService(repo repository.Repository, logger utils.Interface, config ServiceConfig) RetailManagementService {
+	return &Service{
+		repo:           repo,
+		logger:         logger,
+		config:         config,
+		lowStockChecks: make(chan stockCheck, lowStockQueueSize),
+	}
 }
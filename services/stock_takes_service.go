@@ -0,0 +1,222 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/budsx/retail-management/middleware"
+	"github.com/budsx/retail-management/model"
+)
+
+// OpenStockTake starts a count of one of the user's warehouses, or of the
+// given locations in it, and freezes the quantities expected there.
+func (svc *Service) OpenStockTake(ctx context.Context, stockTake model.StockTake) (model.StockTake, error) {
+	user := middleware.GetUserInfoByContext(ctx)
+	svc.logger.Info(fmt.Sprintf("[REQUEST] OpenStockTake %+v - %+v", stockTake, user))
+
+	warehouse, err := svc.repo.Postgres.ReadWarehouseByID(ctx, stockTake.WarehouseID)
+	if err != nil && !errors.Is(err, model.ErrWarehouseNotFound) {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to ReadWarehouseByID: %s", err.Error()))
+		return model.StockTake{}, fmt.Errorf("failed to read warehouse %d: %w", stockTake.WarehouseID, err)
+	}
+	if err != nil || warehouse.UserID != user.UserID {
+		svc.logger.Error("[ERROR] Unauthorized or warehouse not found")
+		return model.StockTake{}, fmt.Errorf("%w: %d", model.ErrWarehouseNotFound, stockTake.WarehouseID)
+	}
+
+	for _, locationID := range stockTake.LocationIDs {
+		_, err = svc.validateStockLocation(ctx, stockTake.WarehouseID, locationID, false)
+		if err != nil {
+			return model.StockTake{}, err
+		}
+	}
+
+	stockTake.CreatedBy = user.UserID
+	stockTakeID, err := svc.repo.Postgres.CreateStockTake(ctx, stockTake)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to CreateStockTake: %s", err.Error()))
+		return model.StockTake{}, fmt.Errorf("failed to open stock take: %w", err)
+	}
+
+	created, err := svc.repo.Postgres.ReadStockTakeByID(ctx, stockTakeID)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to ReadStockTakeByID: %s", err.Error()))
+		return model.StockTake{}, fmt.Errorf("failed to read stock take %d: %w", stockTakeID, err)
+	}
+
+	svc.logger.Info(fmt.Sprintf("[RESPONSE] Stock take %d opened with %d lines", stockTakeID, len(created.Lines)))
+	return created, nil
+}
+
+func (svc *Service) GetStockTakeByID(ctx context.Context, stockTakeID int64) (model.StockTake, error) {
+	user := middleware.GetUserInfoByContext(ctx)
+	svc.logger.Info(fmt.Sprintf("[REQUEST] GetStockTakeByID %d - %+v", stockTakeID, user))
+
+	stockTake, err := svc.readStockTake(ctx, stockTakeID, user.UserID)
+	if err != nil {
+		return model.StockTake{}, err
+	}
+
+	svc.logger.Info(fmt.Sprintf("[RESPONSE] %+v", stockTake))
+	return stockTake, nil
+}
+
+// RecordStockTakeCounts stores counted quantities, whether keyed in one at a
+// time or uploaded in bulk. Counting a line again replaces the earlier count.
+func (svc *Service) RecordStockTakeCounts(ctx context.Context, stockTakeID int64, counts []model.StockTakeCount) (model.StockTake, error) {
+	user := middleware.GetUserInfoByContext(ctx)
+	svc.logger.Info(fmt.Sprintf("[REQUEST] RecordStockTakeCounts %d %+v - %+v", stockTakeID, counts, user))
+
+	if len(counts) == 0 {
+		svc.logger.Error("[ERROR] No counts given")
+		return model.StockTake{}, fmt.Errorf("%w: at least one count is required", model.ErrInvalidQuantity)
+	}
+
+	for _, count := range counts {
+		if count.CountedQuantity < 0 {
+			svc.logger.Error(fmt.Sprintf("[ERROR] Invalid counted quantity %d", count.CountedQuantity))
+			return model.StockTake{}, fmt.Errorf("%w: counted quantity must not be negative", model.ErrInvalidQuantity)
+		}
+	}
+
+	_, err := svc.readStockTake(ctx, stockTakeID, user.UserID)
+	if err != nil {
+		return model.StockTake{}, err
+	}
+
+	checked := make(map[int64]bool, len(counts))
+	for _, count := range counts {
+		if checked[count.ProductID] {
+			continue
+		}
+		checked[count.ProductID] = true
+
+		product, err := svc.repo.Postgres.ReadProductByID(ctx, count.ProductID)
+		if errors.Is(err, model.ErrProductNotFound) {
+			svc.logger.Error(fmt.Sprintf("[ERROR] Product not found: %s", err.Error()))
+			return model.StockTake{}, err
+		}
+		if err != nil {
+			svc.logger.Error(fmt.Sprintf("[ERROR] Failed to ReadProductByID: %s", err.Error()))
+			return model.StockTake{}, fmt.Errorf("failed to read product %d: %w", count.ProductID, err)
+		}
+		if product.HasVariants() {
+			svc.logger.Error(fmt.Sprintf("[ERROR] Product %d keeps its stock per variant", product.ProductID))
+			return model.StockTake{}, fmt.Errorf("%w: product %d", model.ErrVariantParent, product.ProductID)
+		}
+	}
+
+	err = svc.repo.Postgres.RecordStockTakeCounts(ctx, stockTakeID, user.UserID, counts)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to RecordStockTakeCounts: %s", err.Error()))
+		return model.StockTake{}, fmt.Errorf("failed to record stock take counts: %w", err)
+	}
+
+	stockTake, err := svc.repo.Postgres.ReadStockTakeByID(ctx, stockTakeID)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to ReadStockTakeByID: %s", err.Error()))
+		return model.StockTake{}, fmt.Errorf("failed to read stock take %d: %w", stockTakeID, err)
+	}
+
+	svc.logger.Info(fmt.Sprintf("[RESPONSE] Recorded %d counts for stock take %d", len(counts), stockTakeID))
+	return stockTake, nil
+}
+
+// GetStockTakeVariances returns the counted lines whose count differs from the
+// expected quantity.
+func (svc *Service) GetStockTakeVariances(ctx context.Context, stockTakeID int64) ([]model.StockTakeLine, error) {
+	user := middleware.GetUserInfoByContext(ctx)
+	svc.logger.Info(fmt.Sprintf("[REQUEST] GetStockTakeVariances %d - %+v", stockTakeID, user))
+
+	stockTake, err := svc.readStockTake(ctx, stockTakeID, user.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	variances := []model.StockTakeLine{}
+	for _, line := range stockTake.Lines {
+		if line.Variance != nil && *line.Variance != 0 {
+			variances = append(variances, line)
+		}
+	}
+
+	svc.logger.Info(fmt.Sprintf("[RESPONSE] %+v", variances))
+	return variances, nil
+}
+
+// ApproveStockTake posts the variances of an open stock take as ADJUSTMENT
+// transactions and closes it.
+func (svc *Service) ApproveStockTake(ctx context.Context, stockTakeID int64) (model.StockTake, error) {
+	user := middleware.GetUserInfoByContext(ctx)
+	svc.logger.Info(fmt.Sprintf("[REQUEST] ApproveStockTake %d - %+v", stockTakeID, user))
+
+	_, err := svc.readStockTake(ctx, stockTakeID, user.UserID)
+	if err != nil {
+		return model.StockTake{}, err
+	}
+
+	err = svc.repo.Postgres.ApproveStockTake(ctx, stockTakeID, user.UserID)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to ApproveStockTake: %s", err.Error()))
+		return model.StockTake{}, fmt.Errorf("failed to approve stock take: %w", err)
+	}
+
+	stockTake, err := svc.repo.Postgres.ReadStockTakeByID(ctx, stockTakeID)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to ReadStockTakeByID: %s", err.Error()))
+		return model.StockTake{}, fmt.Errorf("failed to read stock take %d: %w", stockTakeID, err)
+	}
+	for _, line := range stockTake.Lines {
+		if line.TransactionID != 0 {
+			svc.queueLowStockCheck(line.ProductID, stockTake.WarehouseID)
+		}
+	}
+
+	svc.logger.Info(fmt.Sprintf("[RESPONSE] Stock take %d approved", stockTakeID))
+	return stockTake, nil
+}
+
+func (svc *Service) CancelStockTake(ctx context.Context, stockTakeID int64) error {
+	user := middleware.GetUserInfoByContext(ctx)
+	svc.logger.Info(fmt.Sprintf("[REQUEST] CancelStockTake %d - %+v", stockTakeID, user))
+
+	_, err := svc.readStockTake(ctx, stockTakeID, user.UserID)
+	if err != nil {
+		return err
+	}
+
+	err = svc.repo.Postgres.CancelStockTake(ctx, stockTakeID)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to CancelStockTake: %s", err.Error()))
+		return fmt.Errorf("failed to cancel stock take: %w", err)
+	}
+
+	svc.logger.Info("[RESPONSE] Cancel stock take successfully")
+	return nil
+}
+
+// readStockTake reads a stock take of one of the user's warehouses.
+func (svc *Service) readStockTake(ctx context.Context, stockTakeID, userID int64) (model.StockTake, error) {
+	stockTake, err := svc.repo.Postgres.ReadStockTakeByID(ctx, stockTakeID)
+	if errors.Is(err, model.ErrStockTakeNotFound) {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Stock take not found: %s", err.Error()))
+		return model.StockTake{}, err
+	}
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to ReadStockTakeByID: %s", err.Error()))
+		return model.StockTake{}, fmt.Errorf("failed to read stock take %d: %w", stockTakeID, err)
+	}
+
+	warehouse, err := svc.repo.Postgres.ReadWarehouseByID(ctx, stockTake.WarehouseID)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to ReadWarehouseByID: %s", err.Error()))
+		return model.StockTake{}, fmt.Errorf("failed to read warehouse %d: %w", stockTake.WarehouseID, err)
+	}
+	if warehouse.UserID != userID {
+		svc.logger.Error("[ERROR] Unauthorized or warehouse not found")
+		return model.StockTake{}, fmt.Errorf("%w: %d", model.ErrStockTakeNotFound, stockTakeID)
+	}
+
+	return stockTake, nil
+}
@@ -0,0 +1,194 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/budsx/retail-management/middleware"
+	"github.com/budsx/retail-management/model"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestService_OpenStockTake(t *testing.T) {
+	srv := NewTestServer(t)
+	defer srv.MockCtrl.Finish()
+
+	ctx := context.WithValue(context.Background(), middleware.ContextKeyUserID, int64(3))
+	ctx = context.WithValue(ctx, middleware.ContextKeyUsername, "counter")
+	warehouse := model.Warehouse{WarehouseID: 1, UserID: 3}
+
+	tests := []struct {
+		name      string
+		stockTake model.StockTake
+		mock      func()
+		wantErr   error
+	}{
+		{
+			name:      "counts the given locations",
+			stockTake: model.StockTake{WarehouseID: 1, LocationIDs: []int64{2}},
+			mock: func() {
+				srv.MockRepo.EXPECT().ReadWarehouseByID(gomock.Any(), int64(1)).Return(warehouse, nil)
+				srv.MockRepo.EXPECT().ReadLocationByID(gomock.Any(), int64(2)).Return(model.Location{LocationID: 2, WarehouseID: 1}, nil)
+				srv.MockRepo.EXPECT().
+					CreateStockTake(gomock.Any(), model.StockTake{WarehouseID: 1, LocationIDs: []int64{2}, CreatedBy: 3}).
+					Return(int64(4), nil)
+				srv.MockRepo.EXPECT().ReadStockTakeByID(gomock.Any(), int64(4)).Return(model.StockTake{StockTakeID: 4, WarehouseID: 1}, nil)
+			},
+		},
+		{
+			name:      "location in another warehouse",
+			stockTake: model.StockTake{WarehouseID: 1, LocationIDs: []int64{5}},
+			mock: func() {
+				srv.MockRepo.EXPECT().ReadWarehouseByID(gomock.Any(), int64(1)).Return(warehouse, nil)
+				srv.MockRepo.EXPECT().ReadLocationByID(gomock.Any(), int64(5)).Return(model.Location{LocationID: 5, WarehouseID: 2}, nil)
+			},
+			wantErr: model.ErrInvalidMovement,
+		},
+		{
+			name:      "warehouse of another user",
+			stockTake: model.StockTake{WarehouseID: 9},
+			mock: func() {
+				srv.MockRepo.EXPECT().ReadWarehouseByID(gomock.Any(), int64(9)).Return(model.Warehouse{WarehouseID: 9, UserID: 8}, nil)
+			},
+			wantErr: model.ErrWarehouseNotFound,
+		},
+		{
+			name:      "unknown warehouse",
+			stockTake: model.StockTake{WarehouseID: 7},
+			mock: func() {
+				srv.MockRepo.EXPECT().ReadWarehouseByID(gomock.Any(), int64(7)).Return(model.Warehouse{}, fmt.Errorf("%w: 7", model.ErrWarehouseNotFound))
+			},
+			wantErr: model.ErrWarehouseNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			_, err := srv.Service.OpenStockTake(ctx, tt.stockTake)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestService_RecordStockTakeCounts(t *testing.T) {
+	srv := NewTestServer(t)
+	defer srv.MockCtrl.Finish()
+
+	ctx := context.WithValue(context.Background(), middleware.ContextKeyUserID, int64(3))
+	ctx = context.WithValue(ctx, middleware.ContextKeyUsername, "counter")
+	stockTake := model.StockTake{StockTakeID: 4, WarehouseID: 1, Status: model.StockTakeOpen}
+	counts := []model.StockTakeCount{{ProductID: 1, LocationID: 2, CountedQuantity: 38}}
+
+	t.Run("counts are recorded by the user", func(t *testing.T) {
+		srv.MockRepo.EXPECT().ReadStockTakeByID(gomock.Any(), int64(4)).Return(stockTake, nil)
+		srv.MockRepo.EXPECT().ReadWarehouseByID(gomock.Any(), int64(1)).Return(model.Warehouse{WarehouseID: 1, UserID: 3}, nil)
+		srv.MockRepo.EXPECT().ReadProductByID(gomock.Any(), int64(1)).Return(model.Product{ProductID: 1}, nil)
+		srv.MockRepo.EXPECT().RecordStockTakeCounts(gomock.Any(), int64(4), int64(3), counts).Return(nil)
+		srv.MockRepo.EXPECT().ReadStockTakeByID(gomock.Any(), int64(4)).Return(stockTake, nil)
+
+		_, err := srv.Service.RecordStockTakeCounts(ctx, 4, counts)
+		assert.NoError(t, err)
+	})
+
+	t.Run("negative count", func(t *testing.T) {
+		_, err := srv.Service.RecordStockTakeCounts(ctx, 4, []model.StockTakeCount{{ProductID: 1, LocationID: 2, CountedQuantity: -1}})
+		assert.ErrorIs(t, err, model.ErrInvalidQuantity)
+	})
+
+	t.Run("unknown product", func(t *testing.T) {
+		srv.MockRepo.EXPECT().ReadStockTakeByID(gomock.Any(), int64(4)).Return(stockTake, nil)
+		srv.MockRepo.EXPECT().ReadWarehouseByID(gomock.Any(), int64(1)).Return(model.Warehouse{WarehouseID: 1, UserID: 3}, nil)
+		srv.MockRepo.EXPECT().ReadProductByID(gomock.Any(), int64(9)).Return(model.Product{}, fmt.Errorf("%w: 9", model.ErrProductNotFound))
+
+		_, err := srv.Service.RecordStockTakeCounts(ctx, 4, []model.StockTakeCount{{ProductID: 9, LocationID: 2, CountedQuantity: 3}})
+		assert.ErrorIs(t, err, model.ErrProductNotFound)
+	})
+
+	t.Run("parent of variants", func(t *testing.T) {
+		srv.MockRepo.EXPECT().ReadStockTakeByID(gomock.Any(), int64(4)).Return(stockTake, nil)
+		srv.MockRepo.EXPECT().ReadWarehouseByID(gomock.Any(), int64(1)).Return(model.Warehouse{WarehouseID: 1, UserID: 3}, nil)
+		srv.MockRepo.EXPECT().ReadProductByID(gomock.Any(), int64(6)).Return(model.Product{ProductID: 6, VariantAttributes: []string{"size"}}, nil)
+
+		_, err := srv.Service.RecordStockTakeCounts(ctx, 4, []model.StockTakeCount{{ProductID: 6, LocationID: 2, CountedQuantity: 3}})
+		assert.ErrorIs(t, err, model.ErrVariantParent)
+	})
+
+	t.Run("stock take of another user", func(t *testing.T) {
+		srv.MockRepo.EXPECT().ReadStockTakeByID(gomock.Any(), int64(4)).Return(stockTake, nil)
+		srv.MockRepo.EXPECT().ReadWarehouseByID(gomock.Any(), int64(1)).Return(model.Warehouse{WarehouseID: 1, UserID: 8}, nil)
+
+		_, err := srv.Service.RecordStockTakeCounts(ctx, 4, counts)
+		assert.ErrorIs(t, err, model.ErrStockTakeNotFound)
+	})
+}
+
+func TestService_GetStockTakeVariances(t *testing.T) {
+	srv := NewTestServer(t)
+	defer srv.MockCtrl.Finish()
+
+	ctx := context.WithValue(context.Background(), middleware.ContextKeyUserID, int64(3))
+	ctx = context.WithValue(ctx, middleware.ContextKeyUsername, "counter")
+	counted, short, exact := int64(36), int64(-4), int64(0)
+	stockTake := model.StockTake{
+		StockTakeID: 4,
+		WarehouseID: 1,
+		Lines: []model.StockTakeLine{
+			{LineID: 10, ProductID: 1, ExpectedQuantity: 40, CountedQuantity: &counted, Variance: &short},
+			{LineID: 11, ProductID: 2, ExpectedQuantity: 10, CountedQuantity: new(int64), Variance: &exact},
+			{LineID: 12, ProductID: 3, ExpectedQuantity: 5},
+		},
+	}
+
+	srv.MockRepo.EXPECT().ReadStockTakeByID(gomock.Any(), int64(4)).Return(stockTake, nil)
+	srv.MockRepo.EXPECT().ReadWarehouseByID(gomock.Any(), int64(1)).Return(model.Warehouse{WarehouseID: 1, UserID: 3}, nil)
+
+	got, err := srv.Service.GetStockTakeVariances(ctx, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, []model.StockTakeLine{stockTake.Lines[0]}, got)
+}
+
+func TestService_ApproveStockTake(t *testing.T) {
+	srv := NewTestServer(t)
+	defer srv.MockCtrl.Finish()
+
+	ctx := context.WithValue(context.Background(), middleware.ContextKeyUserID, int64(3))
+	ctx = context.WithValue(ctx, middleware.ContextKeyUsername, "counter")
+	stockTake := model.StockTake{StockTakeID: 4, WarehouseID: 1, Status: model.StockTakeOpen}
+
+	t.Run("approval is posted by the user", func(t *testing.T) {
+		approved := stockTake
+		approved.Status = model.StockTakeApproved
+		srv.MockRepo.EXPECT().ReadStockTakeByID(gomock.Any(), int64(4)).Return(stockTake, nil)
+		srv.MockRepo.EXPECT().ReadWarehouseByID(gomock.Any(), int64(1)).Return(model.Warehouse{WarehouseID: 1, UserID: 3}, nil)
+		srv.MockRepo.EXPECT().ApproveStockTake(gomock.Any(), int64(4), int64(3)).Return(nil)
+		srv.MockRepo.EXPECT().ReadStockTakeByID(gomock.Any(), int64(4)).Return(approved, nil)
+
+		got, err := srv.Service.ApproveStockTake(ctx, 4)
+		assert.NoError(t, err)
+		assert.Equal(t, model.StockTakeApproved, got.Status)
+	})
+
+	t.Run("stock take not found", func(t *testing.T) {
+		srv.MockRepo.EXPECT().ReadStockTakeByID(gomock.Any(), int64(5)).Return(model.StockTake{}, fmt.Errorf("%w: 5", model.ErrStockTakeNotFound))
+
+		_, err := srv.Service.ApproveStockTake(ctx, 5)
+		assert.ErrorIs(t, err, model.ErrStockTakeNotFound)
+	})
+
+	t.Run("database failure", func(t *testing.T) {
+		srv.MockRepo.EXPECT().ReadStockTakeByID(gomock.Any(), int64(5)).Return(model.StockTake{}, sql.ErrConnDone)
+
+		_, err := srv.Service.ApproveStockTake(ctx, 5)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+		assert.NotErrorIs(t, err, model.ErrStockTakeNotFound)
+	})
+}
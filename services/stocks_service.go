@@ -3,6 +3,8 @@ package services
 import (
 	"context"
 	"fmt"
+	"sort"
+	"time"
 
 	"github.com/budsx/retail-management/middleware"
 	"github.com/budsx/retail-management/model"
@@ -34,3 +36,166 @@ func (svc *Service) GetTotalStocks(ctx context.Context) ([]model.ProductStock, e
 	svc.logger.Info(fmt.Sprintf("[RESPONSE] %+v", totalStock))
 	return totalStock, nil
 }
+
+// GetTotalStocksAsOf reports on-hand stock across all locations as it stood at
+// asOf, rebuilt from the ledger.
+func (svc *Service) GetTotalStocksAsOf(ctx context.Context, asOf time.Time) ([]model.ProductStock, error) {
+	user := middleware.GetUserInfoByContext(ctx)
+	svc.logger.Info(fmt.Sprintf("[REQUEST] GetTotalStocksAsOf %s - %+v", asOf.Format(time.RFC3339), user))
+
+	totalStock, err := svc.repo.Postgres.GetTotalStocksAsOf(ctx, asOf)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to GetTotalStocksAsOf: %s", err.Error()))
+		return nil, fmt.Errorf("failed to retrieve total stock as of %s: %w", asOf.Format(time.RFC3339), err)
+	}
+
+	svc.logger.Info(fmt.Sprintf("[RESPONSE] %+v", totalStock))
+	return totalStock, nil
+}
+
+// GetTotalStockByLocationAsOf is GetTotalStocksAsOf for a single location.
+func (svc *Service) GetTotalStockByLocationAsOf(ctx context.Context, locationID int64, asOf time.Time) ([]model.ProductStock, error) {
+	user := middleware.GetUserInfoByContext(ctx)
+	svc.logger.Info(fmt.Sprintf("[REQUEST] GetTotalStockByLocationAsOf %d %s - %+v", locationID, asOf.Format(time.RFC3339), user))
+
+	totalStock, err := svc.repo.Postgres.GetTotalStockByLocationAsOf(ctx, locationID, asOf)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to GetTotalStockByLocationAsOf: %s", err.Error()))
+		return nil, fmt.Errorf("failed to retrieve total stock for location %d as of %s: %w", locationID, asOf.Format(time.RFC3339), err)
+	}
+
+	svc.logger.Info(fmt.Sprintf("[RESPONSE] %+v", totalStock))
+	return totalStock, nil
+}
+
+// GetParentStocks is the stock report with the stock of every variant added up
+// under its parent product. Products without variants are reported as they
+// are. locationID 0 covers all locations and a nil asOf reports current stock.
+func (svc *Service) GetParentStocks(ctx context.Context, locationID int64, asOf *time.Time) ([]model.ProductStock, error) {
+	user := middleware.GetUserInfoByContext(ctx)
+	svc.logger.Info(fmt.Sprintf("[REQUEST] GetParentStocks %d %v - %+v", locationID, asOf, user))
+
+	stocks, err := svc.readProductStocks(ctx, locationID, asOf)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to get product stocks: %s", err.Error()))
+		return nil, fmt.Errorf("failed to get product stocks: %w", err)
+	}
+
+	var parentIDs []int64
+	seen := map[int64]bool{}
+	for _, stock := range stocks {
+		if stock.ParentID != 0 && !seen[stock.ParentID] {
+			seen[stock.ParentID] = true
+			parentIDs = append(parentIDs, stock.ParentID)
+		}
+	}
+
+	var parents []model.Product
+	if len(parentIDs) > 0 {
+		parents, err = svc.repo.Postgres.ReadProductsByIDs(ctx, parentIDs)
+		if err != nil {
+			svc.logger.Error(fmt.Sprintf("[ERROR] Failed to ReadProductsByIDs: %s", err.Error()))
+			return nil, fmt.Errorf("failed to get parent products: %w", err)
+		}
+	}
+
+	parentStocks := rollupParentStocks(parents, stocks)
+
+	svc.logger.Info(fmt.Sprintf("[RESPONSE] %+v", parentStocks))
+	return parentStocks, nil
+}
+
+// readProductStocks reads the stock report of a location, or of all locations
+// when locationID is 0, as of asOf or now when it is nil.
+func (svc *Service) readProductStocks(ctx context.Context, locationID int64, asOf *time.Time) ([]model.ProductStock, error) {
+	switch {
+	case asOf != nil && locationID != 0:
+		return svc.repo.Postgres.GetTotalStockByLocationAsOf(ctx, locationID, *asOf)
+	case asOf != nil:
+		return svc.repo.Postgres.GetTotalStocksAsOf(ctx, *asOf)
+	case locationID != 0:
+		return svc.repo.Postgres.GetTotalStockByLocation(ctx, locationID)
+	default:
+		return svc.repo.Postgres.GetTotalStocks(ctx)
+	}
+}
+
+// rollupParentStocks replaces the rows of variants with one row per parent
+// holding their sum, keeping the report in product order.
+func rollupParentStocks(parents []model.Product, stocks []model.ProductStock) []model.ProductStock {
+	parentStocks := make([]model.ProductStock, 0, len(stocks))
+	index := map[int64]int{}
+	for _, stock := range stocks {
+		if stock.ParentID == 0 {
+			parentStocks = append(parentStocks, stock)
+			continue
+		}
+
+		i, ok := index[stock.ParentID]
+		if !ok {
+			i = len(parentStocks)
+			index[stock.ParentID] = i
+			parentStocks = append(parentStocks, model.ProductStock{ProductID: stock.ParentID})
+		}
+		parentStocks[i].TotalStock += stock.TotalStock
+		parentStocks[i].ReservedStock += stock.ReservedStock
+		parentStocks[i].AvailableStock += stock.AvailableStock
+	}
+
+	for _, parent := range parents {
+		if i, ok := index[parent.ProductID]; ok {
+			parentStocks[i].ProductName = parent.ProductName
+			parentStocks[i].SKU = parent.SKU
+			parentStocks[i].CategoryID = parent.CategoryID
+		}
+	}
+
+	sort.Slice(parentStocks, func(i, j int) bool {
+		return parentStocks[i].ProductID < parentStocks[j].ProductID
+	})
+	return parentStocks
+}
+
+// CreateStockSnapshot snapshots the ledger balances as of the start of the
+// day. It runs periodically from main and does nothing once the day's snapshot
+// exists. The day is the database's, so that the cutoff lines up with the
+// transaction dates it is compared to.
+func (svc *Service) CreateStockSnapshot(ctx context.Context) error {
+	snapshotAt, created, err := svc.repo.Postgres.CreateStockSnapshot(ctx)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to CreateStockSnapshot: %s", err.Error()))
+		return fmt.Errorf("failed to create stock snapshot: %w", err)
+	}
+
+	if created > 0 {
+		svc.logger.Info(fmt.Sprintf("[RESPONSE] Snapshot of %d balances as of %s", created, snapshotAt.Format(time.RFC3339)))
+	}
+	return nil
+}
+
+// GetExpiringLots lists the lots in one of the user's warehouses that expire
+// within the given number of days, already expired lots included.
+func (svc *Service) GetExpiringLots(ctx context.Context, warehouseID int64, days int) ([]model.StockLot, error) {
+	user := middleware.GetUserInfoByContext(ctx)
+	svc.logger.Info(fmt.Sprintf("[REQUEST] GetExpiringLots warehouse %d within %d days - %+v", warehouseID, days, user))
+
+	if days < 0 {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Invalid days %d", days))
+		return nil, fmt.Errorf("days must not be negative")
+	}
+
+	warehouse, err := svc.repo.Postgres.ReadWarehouseByID(ctx, warehouseID)
+	if err != nil || warehouse.UserID != user.UserID {
+		svc.logger.Error("[ERROR] Unauthorized or warehouse not found")
+		return nil, fmt.Errorf("unauthorized or warehouse not found")
+	}
+
+	lots, err := svc.repo.Postgres.GetExpiringLots(ctx, warehouseID, days)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to GetExpiringLots: %s", err.Error()))
+		return nil, fmt.Errorf("failed to retrieve expiring lots for warehouse %d: %w", warehouseID, err)
+	}
+
+	svc.logger.Info(fmt.Sprintf("[RESPONSE] %+v", lots))
+	return lots, nil
+}
@@ -302,3 +302,108 @@ func TestService_GetTotalStocks_Performance(t *testing.T) {
 	assert.Equal(t, largeStocks, got)
 	assert.Less(t, duration, 100*time.Millisecond)
 }
+
+func TestService_GetExpiringLots(t *testing.T) {
+	srv := NewTestServer(t)
+	defer srv.MockCtrl.Finish()
+
+	ctx := context.WithValue(context.Background(), middleware.ContextKeyUserID, int64(1))
+	ctx = context.WithValue(ctx, middleware.ContextKeyUsername, "testuser")
+	expiry := model.NewDate(2025, time.January, 10)
+	lots := []model.StockLot{{LotID: 4, ProductID: 1, WarehouseID: 1, LotNumber: "LOT-A", ExpiryDate: &expiry, Quantity: 12}}
+
+	tests := []struct {
+		name        string
+		warehouseID int64
+		days        int
+		mock        func()
+		want        []model.StockLot
+		wantErr     bool
+	}{
+		{
+			name:        "success",
+			warehouseID: 1,
+			days:        30,
+			mock: func() {
+				srv.MockRepo.EXPECT().ReadWarehouseByID(gomock.Any(), int64(1)).Return(model.Warehouse{WarehouseID: 1, UserID: 1}, nil)
+				srv.MockRepo.EXPECT().GetExpiringLots(gomock.Any(), int64(1), 30).Return(lots, nil)
+			},
+			want: lots,
+		},
+		{
+			name:        "warehouse of another user",
+			warehouseID: 2,
+			days:        30,
+			mock: func() {
+				srv.MockRepo.EXPECT().ReadWarehouseByID(gomock.Any(), int64(2)).Return(model.Warehouse{WarehouseID: 2, UserID: 9}, nil)
+			},
+			wantErr: true,
+		},
+		{
+			name:        "negative days",
+			warehouseID: 1,
+			days:        -1,
+			mock:        func() {},
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			got, err := srv.Service.GetExpiringLots(ctx, tt.warehouseID, tt.days)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestService_GetTotalStocksAsOf(t *testing.T) {
+	srv := NewTestServer(t)
+	defer srv.MockCtrl.Finish()
+
+	ctx := context.WithValue(context.Background(), middleware.ContextKeyUserID, int64(1))
+	ctx = context.WithValue(ctx, middleware.ContextKeyUsername, "finance")
+	monthEnd := time.Date(2024, time.January, 31, 23, 59, 59, 0, time.UTC)
+
+	t.Run("balances at month end", func(t *testing.T) {
+		want := []model.ProductStock{{ProductID: 1, TotalStock: 120, AvailableStock: 120}}
+		srv.MockRepo.EXPECT().GetTotalStocksAsOf(gomock.Any(), monthEnd).Return(want, nil)
+
+		got, err := srv.Service.GetTotalStocksAsOf(ctx, monthEnd)
+		assert.NoError(t, err)
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("balances of one location", func(t *testing.T) {
+		want := []model.ProductStock{{ProductID: 1, TotalStock: 40, AvailableStock: 40}}
+		srv.MockRepo.EXPECT().GetTotalStockByLocationAsOf(gomock.Any(), int64(2), monthEnd).Return(want, nil)
+
+		got, err := srv.Service.GetTotalStockByLocationAsOf(ctx, 2, monthEnd)
+		assert.NoError(t, err)
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		srv.MockRepo.EXPECT().GetTotalStocksAsOf(gomock.Any(), monthEnd).Return(nil, errors.New("database error"))
+
+		_, err := srv.Service.GetTotalStocksAsOf(ctx, monthEnd)
+		assert.Error(t, err)
+	})
+}
+
+func TestService_CreateStockSnapshot(t *testing.T) {
+	srv := NewTestServer(t)
+	defer srv.MockCtrl.Finish()
+
+	srv.MockRepo.EXPECT().
+		CreateStockSnapshot(gomock.Any()).
+		Return(time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC), int64(12), nil)
+
+	assert.NoError(t, srv.Service.CreateStockSnapshot(context.Background()))
+}
@@ -2,40 +2,452 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
 
 	"github.com/budsx/retail-management/middleware"
 	"github.com/budsx/retail-management/model"
 )
 
+// maxStockBatchLines bounds how many lines one batch may post.
+const maxStockBatchLines = 1000
+
+// maxLedgerQuantity bounds a movement's quantity in base units. The ledger and
+// the stock balances keep quantities in INT columns.
+const maxLedgerQuantity = math.MaxInt32
+
+// movementValidationErrors are the errors validateStockMovement rejects a
+// movement with for what it asks. A batch reports these per line; any other
+// error fails the batch.
+var movementValidationErrors = []error{
+	model.ErrInvalidMovement,
+	model.ErrLocationRequired,
+	model.ErrLocationNotFound,
+	model.ErrProductNotFound,
+	model.ErrInvalidReasonCode,
+	model.ErrInvalidUnitCost,
+	model.ErrUnknownUnit,
+	model.ErrInvalidQuantity,
+	model.ErrVariantParent,
+	model.ErrArchived,
+	model.ErrInvalidBarcode,
+	model.ErrBarcodeNotFound,
+}
+
 func (svc *Service) CreateStockTransaction(ctx context.Context, transaction model.StockTransaction) error {
 	svc.logger.Info(fmt.Sprintf("[REQUEST] %+v", transaction))
-	totalStock, err := svc.repo.Postgres.GetTotalStockByProductAndWarehouse(ctx, transaction.ProductID, transaction.WarehouseID)
+
+	transaction, err := svc.validateStockMovement(ctx, transaction)
 	if err != nil {
-		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to GetTotalStockByProductAndWarehouse: %s", err.Error()))
-		return fmt.Errorf("failed to fetch stock for validation: %w", err)
+		return err
 	}
 
-	if transaction.TransactionType == model.StockIn {
-		transaction.Quantity += totalStock
-	} else {
-		if totalStock < transaction.Quantity {
-			svc.logger.Error(fmt.Sprintf("Bad request - Total stock %d - Transaction %d", totalStock, transaction.Quantity))
-			return fmt.Errorf("stock quantity cannot be negative")
-		}
-		transaction.Quantity = totalStock - transaction.Quantity
+	if transaction.TransactionType == model.StockTransfer {
+		return svc.createStockTransfer(ctx, transaction)
 	}
 
+	// The balance check happens in the repository while the stock row is locked,
+	// so concurrent postings cannot both pass it.
 	err = svc.repo.Postgres.CreateStockTransaction(ctx, transaction)
 	if err != nil {
 		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to CreateStockTransaction: %s", err.Error()))
 		return fmt.Errorf("failed to create stock transaction: %w", err)
 	}
+	svc.queueLowStockCheck(transaction.ProductID, transaction.WarehouseID)
+	for _, component := range transaction.Components {
+		svc.queueLowStockCheck(component.ComponentID, transaction.WarehouseID)
+	}
 
 	svc.logger.Info("[RESPONSE] Create stock successfully")
 	return nil
 }
 
+// CreateStockTransactionBatch posts many IN, OUT and ADJUSTMENT lines all or
+// nothing. Every line is checked before the batch is rejected, so a
+// *model.StockBatchError lists all failing lines rather than just the first.
+func (svc *Service) CreateStockTransactionBatch(ctx context.Context, batch model.StockTransactionBatch) (model.StockTransactionBatch, error) {
+	svc.logger.Info(fmt.Sprintf("[REQUEST] CreateStockTransactionBatch %d lines", len(batch.Lines)))
+
+	if len(batch.Lines) == 0 || len(batch.Lines) > maxStockBatchLines {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Invalid batch size %d", len(batch.Lines)))
+		return model.StockTransactionBatch{}, fmt.Errorf("%w: a batch must have between 1 and %d lines", model.ErrInvalidBatchSize, maxStockBatchLines)
+	}
+
+	lines := make([]model.StockTransaction, len(batch.Lines))
+	var batchErr model.StockBatchError
+	for i, line := range batch.Lines {
+		if line.TransactionType == model.StockTransfer {
+			batchErr.Lines = append(batchErr.Lines, model.StockBatchLineError{Line: i + 1, Error: "transfers cannot be posted in a batch"})
+			continue
+		}
+
+		validated, err := svc.validateStockMovement(ctx, line)
+		if err != nil && !isMovementValidationError(err) {
+			svc.logger.Error(fmt.Sprintf("[ERROR] Failed to validate batch line %d: %s", i+1, err.Error()))
+			return model.StockTransactionBatch{}, fmt.Errorf("failed to validate line %d: %w", i+1, err)
+		}
+		if err != nil {
+			batchErr.Lines = append(batchErr.Lines, model.StockBatchLineError{Line: i + 1, Error: err.Error()})
+			continue
+		}
+		lines[i] = validated
+	}
+	if len(batchErr.Lines) > 0 {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Batch rejected: %+v", batchErr.Lines))
+		return model.StockTransactionBatch{}, &batchErr
+	}
+
+	transactionIDs, err := svc.repo.Postgres.CreateStockTransactionBatch(ctx, lines)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to CreateStockTransactionBatch: %s", err.Error()))
+		return model.StockTransactionBatch{}, fmt.Errorf("failed to create stock transaction batch: %w", err)
+	}
+	for _, line := range lines {
+		svc.queueLowStockCheck(line.ProductID, line.WarehouseID)
+		for _, component := range line.Components {
+			svc.queueLowStockCheck(component.ComponentID, line.WarehouseID)
+		}
+	}
+
+	svc.logger.Info(fmt.Sprintf("[RESPONSE] Posted batch as transactions %v", transactionIDs))
+	return model.StockTransactionBatch{Lines: lines, TransactionIDs: transactionIDs}, nil
+}
+
+// isMovementValidationError reports whether err rejects a movement for what it
+// asks rather than for failing to check it.
+func isMovementValidationError(err error) bool {
+	for _, validationErr := range movementValidationErrors {
+		if errors.Is(err, validationErr) {
+			return true
+		}
+	}
+	return false
+}
+
+func (svc *Service) createStockTransfer(ctx context.Context, transfer model.StockTransaction) error {
+	switch transfer.Status {
+	case "":
+		transfer.Status = model.TransactionCompleted
+	case model.TransactionCompleted, model.TransactionInTransit:
+	default:
+		svc.logger.Error(fmt.Sprintf("[ERROR] Invalid transfer status %s", transfer.Status))
+		return fmt.Errorf("%w: invalid transfer status %s", model.ErrInvalidMovement, transfer.Status)
+	}
+
+	sourceWarehouseID, err := svc.validateStockLocation(ctx, transfer.WarehouseID, transfer.LocationID, false)
+	if err != nil {
+		return err
+	}
+	transfer.WarehouseID = sourceWarehouseID
+
+	destinationWarehouseID, err := svc.validateStockLocation(ctx, transfer.DestinationWarehouseID, transfer.DestinationLocationID, true)
+	if err != nil {
+		return err
+	}
+	transfer.DestinationWarehouseID = destinationWarehouseID
+
+	if transfer.LocationID == transfer.DestinationLocationID {
+		svc.logger.Error("[ERROR] Transfer source and destination are the same location")
+		return fmt.Errorf("%w: transfer source and destination must be different locations", model.ErrInvalidMovement)
+	}
+
+	product, err := svc.validateSerialNumbers(ctx, transfer.ProductID, transfer.Quantity, transfer.SerialNumbers)
+	if err != nil {
+		return err
+	}
+
+	if product.ArchivedAt != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Product %d is archived", product.ProductID))
+		return fmt.Errorf("%w: product %d", model.ErrArchived, product.ProductID)
+	}
+
+	transactionID, err := svc.repo.Postgres.CreateStockTransfer(ctx, transfer)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to CreateStockTransfer: %s", err.Error()))
+		return fmt.Errorf("failed to create stock transfer: %w", err)
+	}
+	svc.queueLowStockCheck(transfer.ProductID, transfer.WarehouseID)
+
+	svc.logger.Info(fmt.Sprintf("[RESPONSE] Create stock transfer %d successfully", transactionID))
+	return nil
+}
+
+func (svc *Service) ReceiveStockTransfer(ctx context.Context, transactionID int64) error {
+	user := middleware.GetUserInfoByContext(ctx)
+	svc.logger.Info(fmt.Sprintf("[REQUEST] ReceiveStockTransfer %d - %+v", transactionID, user))
+
+	transfer, err := svc.repo.Postgres.GetStockTransactionByID(ctx, transactionID)
+	if errors.Is(err, model.ErrTransactionNotFound) {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Transfer not found: %s", err.Error()))
+		return fmt.Errorf("%w: %d", model.ErrTransferNotFound, transactionID)
+	}
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to GetStockTransactionByID: %s", err.Error()))
+		return fmt.Errorf("failed to read transfer %d: %w", transactionID, err)
+	}
+
+	if transfer.TransactionType != model.StockTransfer {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Transaction %d is a %s, not a transfer", transactionID, transfer.TransactionType))
+		return fmt.Errorf("%w: transaction %d is a %s", model.ErrTransferNotFound, transactionID, transfer.TransactionType)
+	}
+	if transfer.Status != model.TransactionInTransit {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Transfer %d is %s", transactionID, transfer.Status))
+		return fmt.Errorf("%w: transfer %d is %s", model.ErrTransferNotInTransit, transactionID, transfer.Status)
+	}
+
+	err = svc.repo.Postgres.ReceiveStockTransfer(ctx, transactionID, user.UserID)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to ReceiveStockTransfer: %s", err.Error()))
+		return fmt.Errorf("failed to receive stock transfer: %w", err)
+	}
+
+	svc.logger.Info("[RESPONSE] Receive stock transfer successfully")
+	return nil
+}
+
+// ReverseStockTransaction undoes a wrongly keyed movement with a compensating
+// REVERSAL entry and returns that entry.
+func (svc *Service) ReverseStockTransaction(ctx context.Context, transactionID int64) (model.StockTransaction, error) {
+	user := middleware.GetUserInfoByContext(ctx)
+	svc.logger.Info(fmt.Sprintf("[REQUEST] ReverseStockTransaction %d - %+v", transactionID, user))
+
+	_, err := svc.repo.Postgres.GetStockTransactionByID(ctx, transactionID)
+	if errors.Is(err, model.ErrTransactionNotFound) {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Transaction not found: %s", err.Error()))
+		return model.StockTransaction{}, err
+	}
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to GetStockTransactionByID: %s", err.Error()))
+		return model.StockTransaction{}, fmt.Errorf("failed to read transaction %d: %w", transactionID, err)
+	}
+
+	reversalID, err := svc.repo.Postgres.ReverseStockTransaction(ctx, transactionID, user.UserID)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to ReverseStockTransaction: %s", err.Error()))
+		return model.StockTransaction{}, fmt.Errorf("failed to reverse stock transaction: %w", err)
+	}
+
+	reversal, err := svc.repo.Postgres.GetStockTransactionByID(ctx, reversalID)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to GetStockTransactionByID: %s", err.Error()))
+		return model.StockTransaction{}, fmt.Errorf("failed to read reversal %d: %w", reversalID, err)
+	}
+	svc.queueLowStockCheck(reversal.ProductID, reversal.WarehouseID)
+
+	svc.logger.Info(fmt.Sprintf("[RESPONSE] Transaction %d reversed by %d", transactionID, reversalID))
+	return reversal, nil
+}
+
+// validateStockMovement checks a movement request and, for IN, OUT and
+// ADJUSTMENT, fills in the warehouse of its location and, for an OUT of a
+// bundle, the bundle's components. Transfers only get the
+// checks they share with other movements here.
+func (svc *Service) validateStockMovement(ctx context.Context, transaction model.StockTransaction) (model.StockTransaction, error) {
+	transaction, err := svc.applyBarcode(ctx, transaction)
+	if err != nil {
+		return model.StockTransaction{}, err
+	}
+
+	if transaction.TransactionType == model.StockAdjustment {
+		err = svc.validateAdjustment(transaction)
+		if err != nil {
+			return model.StockTransaction{}, err
+		}
+	} else if transaction.Quantity <= 0 {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Invalid quantity %d", transaction.Quantity))
+		return model.StockTransaction{}, fmt.Errorf("%w: quantity must be greater than zero", model.ErrInvalidMovement)
+	}
+
+	if transaction.ExpiryDate != nil && transaction.LotNumber == "" {
+		svc.logger.Error("[ERROR] Expiry date given without a lot number")
+		return model.StockTransaction{}, fmt.Errorf("%w: expiry_date requires a lot_number", model.ErrInvalidMovement)
+	}
+
+	// Only IN and stock found by an adjustment bring stock in, and only they
+	// can bring their own cost
+	incoming := transaction.TransactionType == model.StockIn ||
+		(transaction.TransactionType == model.StockAdjustment && transaction.Quantity > 0)
+	if transaction.UnitCost != nil {
+		if *transaction.UnitCost < 0 || !incoming {
+			svc.logger.Error(fmt.Sprintf("[ERROR] Invalid unit cost %v for %s", *transaction.UnitCost, transaction.TransactionType))
+			return model.StockTransaction{}, model.ErrInvalidUnitCost
+		}
+	}
+	transaction.CostAmount = 0
+
+	transaction, err = svc.convertToBaseUnit(ctx, transaction)
+	if err != nil {
+		return model.StockTransaction{}, err
+	}
+
+	switch transaction.TransactionType {
+	case model.StockTransfer:
+		return transaction, nil
+	case model.StockIn, model.StockOut, model.StockAdjustment:
+	default:
+		svc.logger.Error(fmt.Sprintf("[ERROR] Invalid transaction type %s", transaction.TransactionType))
+		return model.StockTransaction{}, fmt.Errorf("%w: invalid transaction type %s", model.ErrInvalidMovement, transaction.TransactionType)
+	}
+
+	warehouseID, err := svc.validateStockLocation(ctx, transaction.WarehouseID, transaction.LocationID, incoming)
+	if err != nil {
+		return model.StockTransaction{}, err
+	}
+	transaction.WarehouseID = warehouseID
+
+	quantity := transaction.Quantity
+	if quantity < 0 {
+		quantity = -quantity
+	}
+	product, err := svc.validateSerialNumbers(ctx, transaction.ProductID, quantity, transaction.SerialNumbers)
+	if err != nil {
+		return model.StockTransaction{}, err
+	}
+
+	if product.HasVariants() {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Product %d keeps its stock per variant", product.ProductID))
+		return model.StockTransaction{}, fmt.Errorf("%w: product %d", model.ErrVariantParent, product.ProductID)
+	}
+
+	if incoming && product.ArchivedAt != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Product %d is archived", product.ProductID))
+		return model.StockTransaction{}, fmt.Errorf("%w: product %d", model.ErrArchived, product.ProductID)
+	}
+
+	if product.IsBundle && transaction.TransactionType == model.StockOut {
+		transaction.Components, err = svc.repo.Postgres.ReadBundleComponents(ctx, transaction.ProductID)
+		if err != nil {
+			svc.logger.Error(fmt.Sprintf("[ERROR] Failed to ReadBundleComponents: %s", err.Error()))
+			return model.StockTransaction{}, fmt.Errorf("failed to read components of bundle %d: %w", transaction.ProductID, err)
+		}
+	}
+
+	return transaction, nil
+}
+
+// convertToBaseUnit turns a quantity given in another unit of the product, and
+// its unit cost, into base units, which is what the ledger holds. The unit and
+// quantity as entered are kept alongside.
+func (svc *Service) convertToBaseUnit(ctx context.Context, transaction model.StockTransaction) (model.StockTransaction, error) {
+	transaction.UnitQuantity = 0
+	factor := int64(1)
+	if transaction.Unit != "" {
+		var err error
+		factor, err = svc.repo.Postgres.ReadUnitFactor(ctx, transaction.ProductID, transaction.Unit)
+		if err != nil {
+			svc.logger.Error(fmt.Sprintf("[ERROR] Failed to ReadUnitFactor: %s", err.Error()))
+			return model.StockTransaction{}, fmt.Errorf("failed to convert %s: %w", transaction.Unit, err)
+		}
+	}
+
+	if transaction.Quantity > maxLedgerQuantity/factor || transaction.Quantity < -maxLedgerQuantity/factor {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Quantity %d %s is out of range", transaction.Quantity, transaction.Unit))
+		return model.StockTransaction{}, fmt.Errorf("%w: %d %s does not fit in base units", model.ErrInvalidQuantity, transaction.Quantity, transaction.Unit)
+	}
+
+	if transaction.Unit == "" {
+		return transaction, nil
+	}
+
+	transaction.UnitQuantity = transaction.Quantity
+	transaction.Quantity *= factor
+	if transaction.UnitCost != nil {
+		unitCost := *transaction.UnitCost / float64(factor)
+		transaction.UnitCost = &unitCost
+	}
+	return transaction, nil
+}
+
+// validateStockLocation checks that the location exists inside the warehouse and
+// returns the location's warehouse ID so callers may omit it. An archived
+// location can still give up its stock but takes no incoming stock.
+func (svc *Service) validateStockLocation(ctx context.Context, warehouseID, locationID int64, incoming bool) (int64, error) {
+	if locationID == 0 {
+		svc.logger.Error("[ERROR] Movement without a location")
+		return 0, model.ErrLocationRequired
+	}
+
+	location, err := svc.repo.Postgres.ReadLocationByID(ctx, locationID)
+	if errors.Is(err, model.ErrLocationNotFound) {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Location not found: %s", err.Error()))
+		return 0, err
+	}
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to ReadLocationByID: %s", err.Error()))
+		return 0, fmt.Errorf("failed to read location %d: %w", locationID, err)
+	}
+
+	if warehouseID != 0 && location.WarehouseID != warehouseID {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Location %d does not belong to warehouse %d", location.LocationID, warehouseID))
+		return 0, fmt.Errorf("%w: location %d does not belong to warehouse %d", model.ErrInvalidMovement, location.LocationID, warehouseID)
+	}
+
+	if incoming && location.ArchivedAt != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Location %d is archived", location.LocationID))
+		return 0, fmt.Errorf("%w: location %d", model.ErrArchived, location.LocationID)
+	}
+
+	return location.WarehouseID, nil
+}
+
+// validateAdjustment checks that an adjustment moves stock and gives one of the
+// configured reason codes.
+func (svc *Service) validateAdjustment(adjustment model.StockTransaction) error {
+	if adjustment.Quantity == 0 {
+		svc.logger.Error("[ERROR] Adjustment quantity is zero")
+		return fmt.Errorf("%w: adjustment quantity must not be zero", model.ErrInvalidMovement)
+	}
+
+	for _, reasonCode := range svc.config.AdjustmentReasons {
+		if adjustment.ReasonCode == reasonCode {
+			return nil
+		}
+	}
+
+	svc.logger.Error(fmt.Sprintf("[ERROR] Invalid adjustment reason %q", adjustment.ReasonCode))
+	return fmt.Errorf("%w: %q", model.ErrInvalidReasonCode, adjustment.ReasonCode)
+}
+
+// validateSerialNumbers checks a movement's serial numbers against its product:
+// a serialized product needs one distinct serial number per unit, any other
+// product none. It returns the product.
+func (svc *Service) validateSerialNumbers(ctx context.Context, productID, quantity int64, serialNumbers []string) (model.Product, error) {
+	product, err := svc.repo.Postgres.ReadProductByID(ctx, productID)
+	if errors.Is(err, model.ErrProductNotFound) {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Product not found: %s", err.Error()))
+		return model.Product{}, err
+	}
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to ReadProductByID: %s", err.Error()))
+		return model.Product{}, fmt.Errorf("failed to read product %d: %w", productID, err)
+	}
+
+	if !product.Serialized() {
+		if len(serialNumbers) > 0 {
+			svc.logger.Error(fmt.Sprintf("[ERROR] Product %d is not serialized", productID))
+			return model.Product{}, fmt.Errorf("%w: product %d is not serialized", model.ErrInvalidMovement, productID)
+		}
+		return product, nil
+	}
+
+	if int64(len(serialNumbers)) != quantity {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Got %d serial numbers for quantity %d", len(serialNumbers), quantity))
+		return model.Product{}, fmt.Errorf("%w: product %d is serialized, expected %d serial numbers, got %d", model.ErrInvalidMovement, productID, quantity, len(serialNumbers))
+	}
+
+	seen := make(map[string]bool, len(serialNumbers))
+	for _, serialNumber := range serialNumbers {
+		if serialNumber == "" || seen[serialNumber] {
+			svc.logger.Error(fmt.Sprintf("[ERROR] Empty or duplicate serial number %q", serialNumber))
+			return model.Product{}, fmt.Errorf("%w: serial numbers must be non-empty and unique", model.ErrInvalidMovement)
+		}
+		seen[serialNumber] = true
+	}
+
+	return product, nil
+}
+
 func (svc *Service) GetStockTransactionByID(ctx context.Context, transactionID int64) (model.StockTransaction, error) {
 	user := middleware.GetUserInfoByContext(ctx)
 	svc.logger.Info(fmt.Sprintf("[REQUEST] GetStockTransactionByID - %+v", user))
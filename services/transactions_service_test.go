@@ -0,0 +1,706 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/budsx/retail-management/middleware"
+	"github.com/budsx/retail-management/model"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestService_CreateStockTransaction(t *testing.T) {
+	srv := NewTestServer(t)
+	defer srv.MockCtrl.Finish()
+
+	ctx := context.Background()
+	location := model.Location{LocationID: 2, LocationName: "Rak B2", WarehouseID: 1}
+	product := model.Product{ProductID: 1, ProductName: "Kopi Arabika"}
+	tracked := true
+	serialized := model.Product{ProductID: 5, ProductName: "Mesin Kopi", IsSerialized: &tracked}
+	bestBefore := model.NewDate(2025, time.January, 10)
+	unitCost := 25000.0
+
+	tests := []struct {
+		name        string
+		transaction model.StockTransaction
+		mock        func()
+		wantErr     bool
+	}{
+		{
+			name: "warehouse without a location",
+			transaction: model.StockTransaction{
+				ProductID:       1,
+				WarehouseID:     1,
+				TransactionType: model.StockIn,
+				Quantity:        10,
+			},
+			mock:    func() {},
+			wantErr: true,
+		},
+		{
+			name: "stock out cannot set its own cost",
+			transaction: model.StockTransaction{
+				ProductID:       1,
+				WarehouseID:     1,
+				LocationID:      2,
+				TransactionType: model.StockOut,
+				Quantity:        10,
+				UnitCost:        &unitCost,
+			},
+			mock:    func() {},
+			wantErr: true,
+		},
+		{
+			name: "stock in adds to location balance",
+			transaction: model.StockTransaction{
+				ProductID:       1,
+				WarehouseID:     1,
+				LocationID:      2,
+				TransactionType: model.StockIn,
+				Quantity:        10,
+			},
+			mock: func() {
+				srv.MockRepo.EXPECT().ReadLocationByID(gomock.Any(), int64(2)).Return(location, nil)
+				srv.MockRepo.EXPECT().ReadProductByID(gomock.Any(), int64(1)).Return(product, nil)
+				srv.MockRepo.EXPECT().
+					CreateStockTransaction(gomock.Any(), model.StockTransaction{
+						ProductID:       1,
+						WarehouseID:     1,
+						LocationID:      2,
+						TransactionType: model.StockIn,
+						Quantity:        10,
+					}).
+					Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "warehouse is taken from location when omitted",
+			transaction: model.StockTransaction{
+				ProductID:       1,
+				LocationID:      2,
+				TransactionType: model.StockOut,
+				Quantity:        15,
+			},
+			mock: func() {
+				srv.MockRepo.EXPECT().ReadLocationByID(gomock.Any(), int64(2)).Return(location, nil)
+				srv.MockRepo.EXPECT().ReadProductByID(gomock.Any(), int64(1)).Return(product, nil)
+				srv.MockRepo.EXPECT().
+					CreateStockTransaction(gomock.Any(), model.StockTransaction{
+						ProductID:       1,
+						WarehouseID:     1,
+						LocationID:      2,
+						TransactionType: model.StockOut,
+						Quantity:        15,
+					}).
+					Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "location belongs to another warehouse",
+			transaction: model.StockTransaction{
+				ProductID:       1,
+				WarehouseID:     3,
+				LocationID:      2,
+				TransactionType: model.StockIn,
+				Quantity:        10,
+			},
+			mock: func() {
+				srv.MockRepo.EXPECT().ReadLocationByID(gomock.Any(), int64(2)).Return(location, nil)
+			},
+			wantErr: true,
+		},
+		{
+			name: "location not found",
+			transaction: model.StockTransaction{
+				ProductID:       1,
+				LocationID:      99,
+				TransactionType: model.StockIn,
+				Quantity:        10,
+			},
+			mock: func() {
+				srv.MockRepo.EXPECT().ReadLocationByID(gomock.Any(), int64(99)).Return(model.Location{}, errors.New("not found"))
+			},
+			wantErr: true,
+		},
+		{
+			name: "stock out exceeds location balance",
+			transaction: model.StockTransaction{
+				ProductID:       1,
+				WarehouseID:     1,
+				LocationID:      2,
+				TransactionType: model.StockOut,
+				Quantity:        41,
+			},
+			mock: func() {
+				srv.MockRepo.EXPECT().ReadLocationByID(gomock.Any(), int64(2)).Return(location, nil)
+				srv.MockRepo.EXPECT().ReadProductByID(gomock.Any(), int64(1)).Return(product, nil)
+				srv.MockRepo.EXPECT().
+					CreateStockTransaction(gomock.Any(), gomock.Any()).
+					Return(model.ErrInsufficientStock)
+			},
+			wantErr: true,
+		},
+		{
+			name: "serialized product with a serial per unit",
+			transaction: model.StockTransaction{
+				ProductID:       5,
+				LocationID:      2,
+				TransactionType: model.StockIn,
+				Quantity:        2,
+				SerialNumbers:   []string{"SN-001", "SN-002"},
+			},
+			mock: func() {
+				srv.MockRepo.EXPECT().ReadLocationByID(gomock.Any(), int64(2)).Return(location, nil)
+				srv.MockRepo.EXPECT().ReadProductByID(gomock.Any(), int64(5)).Return(serialized, nil)
+				srv.MockRepo.EXPECT().
+					CreateStockTransaction(gomock.Any(), model.StockTransaction{
+						ProductID:       5,
+						WarehouseID:     1,
+						LocationID:      2,
+						TransactionType: model.StockIn,
+						Quantity:        2,
+						SerialNumbers:   []string{"SN-001", "SN-002"},
+					}).
+					Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "serialized product without serial numbers",
+			transaction: model.StockTransaction{
+				ProductID:       5,
+				LocationID:      2,
+				TransactionType: model.StockOut,
+				Quantity:        2,
+			},
+			mock: func() {
+				srv.MockRepo.EXPECT().ReadLocationByID(gomock.Any(), int64(2)).Return(location, nil)
+				srv.MockRepo.EXPECT().ReadProductByID(gomock.Any(), int64(5)).Return(serialized, nil)
+			},
+			wantErr: true,
+		},
+		{
+			name: "duplicate serial numbers",
+			transaction: model.StockTransaction{
+				ProductID:       5,
+				LocationID:      2,
+				TransactionType: model.StockOut,
+				Quantity:        2,
+				SerialNumbers:   []string{"SN-001", "SN-001"},
+			},
+			mock: func() {
+				srv.MockRepo.EXPECT().ReadLocationByID(gomock.Any(), int64(2)).Return(location, nil)
+				srv.MockRepo.EXPECT().ReadProductByID(gomock.Any(), int64(5)).Return(serialized, nil)
+			},
+			wantErr: true,
+		},
+		{
+			name: "serial numbers for a product that is not serialized",
+			transaction: model.StockTransaction{
+				ProductID:       1,
+				LocationID:      2,
+				TransactionType: model.StockIn,
+				Quantity:        1,
+				SerialNumbers:   []string{"SN-001"},
+			},
+			mock: func() {
+				srv.MockRepo.EXPECT().ReadLocationByID(gomock.Any(), int64(2)).Return(location, nil)
+				srv.MockRepo.EXPECT().ReadProductByID(gomock.Any(), int64(1)).Return(product, nil)
+			},
+			wantErr: true,
+		},
+		{
+			name: "adjustment with a configured reason",
+			transaction: model.StockTransaction{
+				ProductID:         1,
+				LocationID:        2,
+				TransactionType:   model.StockAdjustment,
+				Quantity:          -3,
+				ReasonCode:        "DAMAGE",
+				ReferenceDocument: "INC-7",
+			},
+			mock: func() {
+				srv.MockRepo.EXPECT().ReadLocationByID(gomock.Any(), int64(2)).Return(location, nil)
+				srv.MockRepo.EXPECT().ReadProductByID(gomock.Any(), int64(1)).Return(product, nil)
+				srv.MockRepo.EXPECT().
+					CreateStockTransaction(gomock.Any(), model.StockTransaction{
+						ProductID:         1,
+						WarehouseID:       1,
+						LocationID:        2,
+						TransactionType:   model.StockAdjustment,
+						Quantity:          -3,
+						ReasonCode:        "DAMAGE",
+						ReferenceDocument: "INC-7",
+					}).
+					Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "adjustment without a reason",
+			transaction: model.StockTransaction{
+				ProductID:       1,
+				LocationID:      2,
+				TransactionType: model.StockAdjustment,
+				Quantity:        5,
+			},
+			mock:    func() {},
+			wantErr: true,
+		},
+		{
+			name: "adjustment with an unknown reason",
+			transaction: model.StockTransaction{
+				ProductID:       1,
+				LocationID:      2,
+				TransactionType: model.StockAdjustment,
+				Quantity:        5,
+				ReasonCode:      "THEFT",
+			},
+			mock:    func() {},
+			wantErr: true,
+		},
+		{
+			name: "zero adjustment",
+			transaction: model.StockTransaction{
+				ProductID:       1,
+				LocationID:      2,
+				TransactionType: model.StockAdjustment,
+				ReasonCode:      "FOUND",
+			},
+			mock:    func() {},
+			wantErr: true,
+		},
+		{
+			name: "unknown transaction type",
+			transaction: model.StockTransaction{
+				ProductID:       1,
+				LocationID:      2,
+				TransactionType: "MOVE",
+				Quantity:        10,
+			},
+			mock:    func() {},
+			wantErr: true,
+		},
+		{
+			name: "expiry date without lot number",
+			transaction: model.StockTransaction{
+				ProductID:       1,
+				LocationID:      2,
+				TransactionType: model.StockIn,
+				Quantity:        10,
+				ExpiryDate:      &bestBefore,
+			},
+			mock:    func() {},
+			wantErr: true,
+		},
+		{
+			name: "zero quantity",
+			transaction: model.StockTransaction{
+				ProductID:       1,
+				LocationID:      2,
+				TransactionType: model.StockIn,
+			},
+			mock:    func() {},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			err := srv.Service.CreateStockTransaction(ctx, tt.transaction)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestService_CreateStockTransaction_Transfer(t *testing.T) {
+	srv := NewTestServer(t)
+	defer srv.MockCtrl.Finish()
+
+	ctx := context.Background()
+	source := model.Location{LocationID: 1, LocationName: "Rak A1", WarehouseID: 1}
+	destination := model.Location{LocationID: 3, LocationName: "Rak C3", WarehouseID: 2}
+	product := model.Product{ProductID: 1, ProductName: "Kopi Arabika"}
+
+	tests := []struct {
+		name     string
+		transfer model.StockTransaction
+		mock     func()
+		wantErr  bool
+	}{
+		{
+			name: "transfer defaults to completed",
+			transfer: model.StockTransaction{
+				ProductID:             1,
+				LocationID:            1,
+				DestinationLocationID: 3,
+				TransactionType:       model.StockTransfer,
+				Quantity:              5,
+			},
+			mock: func() {
+				srv.MockRepo.EXPECT().ReadLocationByID(gomock.Any(), int64(1)).Return(source, nil)
+				srv.MockRepo.EXPECT().ReadLocationByID(gomock.Any(), int64(3)).Return(destination, nil)
+				srv.MockRepo.EXPECT().ReadProductByID(gomock.Any(), int64(1)).Return(product, nil)
+				srv.MockRepo.EXPECT().
+					CreateStockTransfer(gomock.Any(), model.StockTransaction{
+						ProductID:              1,
+						WarehouseID:            1,
+						LocationID:             1,
+						DestinationWarehouseID: 2,
+						DestinationLocationID:  3,
+						TransactionType:        model.StockTransfer,
+						Quantity:               5,
+						Status:                 model.TransactionCompleted,
+					}).
+					Return(int64(10), nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "same source and destination",
+			transfer: model.StockTransaction{
+				ProductID:             1,
+				LocationID:            1,
+				DestinationLocationID: 1,
+				TransactionType:       model.StockTransfer,
+				Quantity:              5,
+			},
+			mock: func() {
+				srv.MockRepo.EXPECT().ReadLocationByID(gomock.Any(), int64(1)).Return(source, nil).Times(2)
+			},
+			wantErr: true,
+		},
+		{
+			name: "insufficient stock at source",
+			transfer: model.StockTransaction{
+				ProductID:             1,
+				LocationID:            1,
+				DestinationLocationID: 3,
+				TransactionType:       model.StockTransfer,
+				Quantity:              25,
+				Status:                model.TransactionInTransit,
+			},
+			mock: func() {
+				srv.MockRepo.EXPECT().ReadLocationByID(gomock.Any(), int64(1)).Return(source, nil)
+				srv.MockRepo.EXPECT().ReadLocationByID(gomock.Any(), int64(3)).Return(destination, nil)
+				srv.MockRepo.EXPECT().ReadProductByID(gomock.Any(), int64(1)).Return(product, nil)
+				srv.MockRepo.EXPECT().
+					CreateStockTransfer(gomock.Any(), gomock.Any()).
+					Return(int64(0), model.ErrInsufficientStock)
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid status",
+			transfer: model.StockTransaction{
+				ProductID:             1,
+				LocationID:            1,
+				DestinationLocationID: 3,
+				TransactionType:       model.StockTransfer,
+				Quantity:              5,
+				Status:                "LOST",
+			},
+			mock:    func() {},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			err := srv.Service.CreateStockTransaction(ctx, tt.transfer)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestService_CreateStockTransaction_Unit(t *testing.T) {
+	srv := NewTestServer(t)
+	defer srv.MockCtrl.Finish()
+
+	ctx := context.Background()
+	location := model.Location{LocationID: 2, LocationName: "Rak B2", WarehouseID: 1}
+	sugar := model.Product{ProductID: 4, ProductName: "Gula Pasir", BaseUnit: "KG"}
+
+	t.Run("sacks are posted in kilograms", func(t *testing.T) {
+		srv.MockRepo.EXPECT().ReadUnitFactor(gomock.Any(), int64(4), "SACK").Return(int64(50), nil)
+		srv.MockRepo.EXPECT().ReadLocationByID(gomock.Any(), int64(2)).Return(location, nil)
+		srv.MockRepo.EXPECT().ReadProductByID(gomock.Any(), int64(4)).Return(sugar, nil)
+		srv.MockRepo.EXPECT().
+			CreateStockTransaction(gomock.Any(), model.StockTransaction{
+				ProductID:       4,
+				WarehouseID:     1,
+				LocationID:      2,
+				TransactionType: model.StockIn,
+				Quantity:        150,
+				Unit:            "SACK",
+				UnitQuantity:    3,
+			}).
+			Return(nil)
+
+		err := srv.Service.CreateStockTransaction(ctx, model.StockTransaction{
+			ProductID:       4,
+			LocationID:      2,
+			TransactionType: model.StockIn,
+			Quantity:        3,
+			Unit:            "SACK",
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("unit cost is converted with the quantity", func(t *testing.T) {
+		sackCost := 700000.0
+		kgCost := 14000.0
+		srv.MockRepo.EXPECT().ReadUnitFactor(gomock.Any(), int64(4), "SACK").Return(int64(50), nil)
+		srv.MockRepo.EXPECT().ReadLocationByID(gomock.Any(), int64(2)).Return(location, nil)
+		srv.MockRepo.EXPECT().ReadProductByID(gomock.Any(), int64(4)).Return(sugar, nil)
+		srv.MockRepo.EXPECT().
+			CreateStockTransaction(gomock.Any(), model.StockTransaction{
+				ProductID:       4,
+				WarehouseID:     1,
+				LocationID:      2,
+				TransactionType: model.StockIn,
+				Quantity:        100,
+				Unit:            "SACK",
+				UnitQuantity:    2,
+				UnitCost:        &kgCost,
+			}).
+			Return(nil)
+
+		err := srv.Service.CreateStockTransaction(ctx, model.StockTransaction{
+			ProductID:       4,
+			LocationID:      2,
+			TransactionType: model.StockIn,
+			Quantity:        2,
+			Unit:            "SACK",
+			UnitCost:        &sackCost,
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("unit not configured for the product", func(t *testing.T) {
+		srv.MockRepo.EXPECT().ReadUnitFactor(gomock.Any(), int64(4), "BOX").Return(int64(0), model.ErrUnknownUnit)
+
+		err := srv.Service.CreateStockTransaction(ctx, model.StockTransaction{
+			ProductID:       4,
+			LocationID:      2,
+			TransactionType: model.StockOut,
+			Quantity:        1,
+			Unit:            "BOX",
+		})
+		assert.ErrorIs(t, err, model.ErrUnknownUnit)
+	})
+
+	t.Run("quantity too large for base units", func(t *testing.T) {
+		srv.MockRepo.EXPECT().ReadUnitFactor(gomock.Any(), int64(4), "SACK").Return(int64(50), nil)
+
+		err := srv.Service.CreateStockTransaction(ctx, model.StockTransaction{
+			ProductID:       4,
+			LocationID:      2,
+			TransactionType: model.StockIn,
+			Quantity:        math.MaxInt32 / 10,
+			Unit:            "SACK",
+		})
+		assert.ErrorIs(t, err, model.ErrInvalidQuantity)
+	})
+
+	t.Run("quantity too large for the ledger", func(t *testing.T) {
+		err := srv.Service.CreateStockTransaction(ctx, model.StockTransaction{
+			ProductID:       4,
+			LocationID:      2,
+			TransactionType: model.StockIn,
+			Quantity:        math.MaxInt32 + 1,
+		})
+		assert.ErrorIs(t, err, model.ErrInvalidQuantity)
+	})
+}
+
+func TestService_CreateStockTransactionBatch(t *testing.T) {
+	srv := NewTestServer(t)
+	defer srv.MockCtrl.Finish()
+
+	ctx := context.Background()
+	location := model.Location{LocationID: 2, LocationName: "Rak B2", WarehouseID: 1}
+	product := model.Product{ProductID: 1, ProductName: "Kopi Arabika"}
+
+	t.Run("valid lines are posted together", func(t *testing.T) {
+		lines := []model.StockTransaction{
+			{ProductID: 1, LocationID: 2, TransactionType: model.StockIn, Quantity: 10},
+			{ProductID: 1, LocationID: 2, TransactionType: model.StockOut, Quantity: 4},
+		}
+		srv.MockRepo.EXPECT().ReadLocationByID(gomock.Any(), int64(2)).Return(location, nil).Times(2)
+		srv.MockRepo.EXPECT().ReadProductByID(gomock.Any(), int64(1)).Return(product, nil).Times(2)
+		srv.MockRepo.EXPECT().
+			CreateStockTransactionBatch(gomock.Any(), []model.StockTransaction{
+				{ProductID: 1, WarehouseID: 1, LocationID: 2, TransactionType: model.StockIn, Quantity: 10},
+				{ProductID: 1, WarehouseID: 1, LocationID: 2, TransactionType: model.StockOut, Quantity: 4},
+			}).
+			Return([]int64{7, 8}, nil)
+
+		got, err := srv.Service.CreateStockTransactionBatch(ctx, model.StockTransactionBatch{Lines: lines})
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{7, 8}, got.TransactionIDs)
+	})
+
+	t.Run("every invalid line is reported", func(t *testing.T) {
+		lines := []model.StockTransaction{
+			{ProductID: 1, LocationID: 2, TransactionType: model.StockIn, Quantity: 0},
+			{ProductID: 1, LocationID: 2, TransactionType: model.StockIn, Quantity: 3},
+			{ProductID: 1, LocationID: 2, TransactionType: model.StockTransfer, Quantity: 3, DestinationLocationID: 4},
+		}
+		srv.MockRepo.EXPECT().ReadLocationByID(gomock.Any(), int64(2)).Return(location, nil)
+		srv.MockRepo.EXPECT().ReadProductByID(gomock.Any(), int64(1)).Return(product, nil)
+
+		_, err := srv.Service.CreateStockTransactionBatch(ctx, model.StockTransactionBatch{Lines: lines})
+		var batchErr *model.StockBatchError
+		assert.ErrorAs(t, err, &batchErr)
+		assert.Equal(t, []int{1, 3}, []int{batchErr.Lines[0].Line, batchErr.Lines[1].Line})
+	})
+
+	t.Run("a missing location is reported on its line", func(t *testing.T) {
+		lines := []model.StockTransaction{{ProductID: 1, LocationID: 9, TransactionType: model.StockIn, Quantity: 3}}
+		srv.MockRepo.EXPECT().ReadLocationByID(gomock.Any(), int64(9)).Return(model.Location{}, fmt.Errorf("%w: 9", model.ErrLocationNotFound))
+
+		_, err := srv.Service.CreateStockTransactionBatch(ctx, model.StockTransactionBatch{Lines: lines})
+		var batchErr *model.StockBatchError
+		assert.ErrorAs(t, err, &batchErr)
+		assert.Equal(t, 1, batchErr.Lines[0].Line)
+	})
+
+	t.Run("a database failure fails the batch", func(t *testing.T) {
+		lines := []model.StockTransaction{{ProductID: 1, LocationID: 2, TransactionType: model.StockIn, Quantity: 3}}
+		srv.MockRepo.EXPECT().ReadLocationByID(gomock.Any(), int64(2)).Return(location, nil)
+		srv.MockRepo.EXPECT().ReadProductByID(gomock.Any(), int64(1)).Return(model.Product{}, sql.ErrConnDone)
+
+		_, err := srv.Service.CreateStockTransactionBatch(ctx, model.StockTransactionBatch{Lines: lines})
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+		var batchErr *model.StockBatchError
+		assert.False(t, errors.As(err, &batchErr))
+	})
+
+	t.Run("balance failures from the repository are passed on", func(t *testing.T) {
+		lines := []model.StockTransaction{{ProductID: 1, LocationID: 2, TransactionType: model.StockOut, Quantity: 50}}
+		srv.MockRepo.EXPECT().ReadLocationByID(gomock.Any(), int64(2)).Return(location, nil)
+		srv.MockRepo.EXPECT().ReadProductByID(gomock.Any(), int64(1)).Return(product, nil)
+		srv.MockRepo.EXPECT().
+			CreateStockTransactionBatch(gomock.Any(), gomock.Any()).
+			Return(nil, &model.StockBatchError{Lines: []model.StockBatchLineError{{Line: 1, Error: "insufficient stock"}}})
+
+		_, err := srv.Service.CreateStockTransactionBatch(ctx, model.StockTransactionBatch{Lines: lines})
+		var batchErr *model.StockBatchError
+		assert.ErrorAs(t, err, &batchErr)
+	})
+
+	t.Run("empty batch", func(t *testing.T) {
+		_, err := srv.Service.CreateStockTransactionBatch(ctx, model.StockTransactionBatch{})
+		assert.ErrorIs(t, err, model.ErrInvalidBatchSize)
+	})
+}
+
+func TestService_ReverseStockTransaction(t *testing.T) {
+	srv := NewTestServer(t)
+	defer srv.MockCtrl.Finish()
+
+	ctx := context.WithValue(context.Background(), middleware.ContextKeyUserID, int64(3))
+	ctx = context.WithValue(ctx, middleware.ContextKeyUsername, "auditor")
+	original := model.StockTransaction{TransactionID: 5, ProductID: 1, LocationID: 2, TransactionType: model.StockIn, Quantity: 10}
+
+	t.Run("returns the compensating entry", func(t *testing.T) {
+		reversal := model.StockTransaction{TransactionID: 9, ProductID: 1, LocationID: 2, TransactionType: model.StockReversal, Quantity: -10, ReversalOfID: 5}
+		srv.MockRepo.EXPECT().GetStockTransactionByID(gomock.Any(), int64(5)).Return(original, nil)
+		srv.MockRepo.EXPECT().ReverseStockTransaction(gomock.Any(), int64(5), int64(3)).Return(int64(9), nil)
+		srv.MockRepo.EXPECT().GetStockTransactionByID(gomock.Any(), int64(9)).Return(reversal, nil)
+
+		got, err := srv.Service.ReverseStockTransaction(ctx, 5)
+		assert.NoError(t, err)
+		assert.Equal(t, reversal, got)
+	})
+
+	t.Run("already reversed", func(t *testing.T) {
+		srv.MockRepo.EXPECT().GetStockTransactionByID(gomock.Any(), int64(5)).Return(original, nil)
+		srv.MockRepo.EXPECT().ReverseStockTransaction(gomock.Any(), int64(5), int64(3)).Return(int64(0), model.ErrTransactionReversed)
+
+		_, err := srv.Service.ReverseStockTransaction(ctx, 5)
+		assert.ErrorIs(t, err, model.ErrTransactionReversed)
+	})
+
+	t.Run("transaction not found", func(t *testing.T) {
+		srv.MockRepo.EXPECT().GetStockTransactionByID(gomock.Any(), int64(6)).Return(model.StockTransaction{}, fmt.Errorf("%w: 6", model.ErrTransactionNotFound))
+
+		_, err := srv.Service.ReverseStockTransaction(ctx, 6)
+		assert.ErrorIs(t, err, model.ErrTransactionNotFound)
+	})
+
+	t.Run("database failure", func(t *testing.T) {
+		srv.MockRepo.EXPECT().GetStockTransactionByID(gomock.Any(), int64(6)).Return(model.StockTransaction{}, sql.ErrConnDone)
+
+		_, err := srv.Service.ReverseStockTransaction(ctx, 6)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+		assert.NotErrorIs(t, err, model.ErrTransactionNotFound)
+	})
+}
+
+func TestService_ReceiveStockTransfer(t *testing.T) {
+	srv := NewTestServer(t)
+	defer srv.MockCtrl.Finish()
+
+	ctx := context.WithValue(context.Background(), middleware.ContextKeyUserID, int64(3))
+	ctx = context.WithValue(ctx, middleware.ContextKeyUsername, "receiver")
+
+	t.Run("books the transfer in", func(t *testing.T) {
+		transfer := model.StockTransaction{TransactionID: 10, TransactionType: model.StockTransfer, Status: model.TransactionInTransit}
+		srv.MockRepo.EXPECT().GetStockTransactionByID(gomock.Any(), int64(10)).Return(transfer, nil)
+		srv.MockRepo.EXPECT().ReceiveStockTransfer(gomock.Any(), int64(10), int64(3)).Return(nil)
+
+		assert.NoError(t, srv.Service.ReceiveStockTransfer(ctx, 10))
+	})
+
+	t.Run("transfer not found", func(t *testing.T) {
+		srv.MockRepo.EXPECT().GetStockTransactionByID(gomock.Any(), int64(11)).Return(model.StockTransaction{}, fmt.Errorf("%w: 11", model.ErrTransactionNotFound))
+
+		err := srv.Service.ReceiveStockTransfer(ctx, 11)
+		assert.ErrorIs(t, err, model.ErrTransferNotFound)
+	})
+
+	t.Run("not a transfer", func(t *testing.T) {
+		srv.MockRepo.EXPECT().GetStockTransactionByID(gomock.Any(), int64(12)).Return(model.StockTransaction{TransactionID: 12, TransactionType: model.StockIn, Status: model.TransactionCompleted}, nil)
+
+		err := srv.Service.ReceiveStockTransfer(ctx, 12)
+		assert.ErrorIs(t, err, model.ErrTransferNotFound)
+	})
+
+	t.Run("already received", func(t *testing.T) {
+		srv.MockRepo.EXPECT().GetStockTransactionByID(gomock.Any(), int64(13)).Return(model.StockTransaction{TransactionID: 13, TransactionType: model.StockTransfer, Status: model.TransactionCompleted}, nil)
+
+		err := srv.Service.ReceiveStockTransfer(ctx, 13)
+		assert.ErrorIs(t, err, model.ErrTransferNotInTransit)
+	})
+
+	t.Run("database failure", func(t *testing.T) {
+		srv.MockRepo.EXPECT().GetStockTransactionByID(gomock.Any(), int64(14)).Return(model.StockTransaction{}, sql.ErrConnDone)
+
+		err := srv.Service.ReceiveStockTransfer(ctx, 14)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+		assert.NotErrorIs(t, err, model.ErrTransferNotFound)
+	})
+}
@@ -0,0 +1,50 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/budsx/retail-management/middleware"
+	"github.com/budsx/retail-management/model"
+)
+
+// GetInventoryValuation values the stock of one of the user's warehouses as of
+// asOf, or now when asOf is zero, at the cost its movements were booked at.
+func (svc *Service) GetInventoryValuation(ctx context.Context, warehouseID int64, asOf time.Time) (model.InventoryValuation, error) {
+	user := middleware.GetUserInfoByContext(ctx)
+	svc.logger.Info(fmt.Sprintf("[REQUEST] GetInventoryValuation warehouse %d as of %s - %+v", warehouseID, asOf, user))
+
+	warehouse, err := svc.repo.Postgres.ReadWarehouseByID(ctx, warehouseID)
+	if err != nil || warehouse.UserID != user.UserID {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Warehouse %d not found for user %d", warehouseID, user.UserID))
+		return model.InventoryValuation{}, model.ErrWarehouseNotFound
+	}
+
+	if asOf.IsZero() {
+		asOf = time.Now()
+	}
+
+	items, err := svc.repo.Postgres.GetInventoryValuation(ctx, warehouseID, asOf)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to GetInventoryValuation: %s", err.Error()))
+		return model.InventoryValuation{}, fmt.Errorf("failed to value warehouse %d: %w", warehouseID, err)
+	}
+
+	valuation := model.InventoryValuation{
+		WarehouseID:   warehouseID,
+		CostingMethod: warehouse.CostingMethod,
+		AsOf:          asOf,
+		Items:         items,
+	}
+	for i, item := range valuation.Items {
+		if item.Quantity != 0 {
+			valuation.Items[i].UnitCost = model.RoundCost(item.StockValue / float64(item.Quantity))
+		}
+		valuation.TotalValue += item.StockValue
+	}
+	valuation.TotalValue = model.RoundCost(valuation.TotalValue)
+
+	svc.logger.Info(fmt.Sprintf("[RESPONSE] %+v", valuation))
+	return valuation, nil
+}
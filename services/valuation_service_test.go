@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/budsx/retail-management/middleware"
+	"github.com/budsx/retail-management/model"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestService_GetInventoryValuation(t *testing.T) {
+	srv := NewTestServer(t)
+	defer srv.MockCtrl.Finish()
+
+	ctx := context.WithValue(context.Background(), middleware.ContextKeyUserID, int64(3))
+	ctx = context.WithValue(ctx, middleware.ContextKeyUsername, "gudang")
+	asOf := time.Date(2024, 3, 31, 23, 59, 59, 0, time.UTC)
+	warehouse := model.Warehouse{WarehouseID: 1, UserID: 3, CostingMethod: model.CostingAverage}
+
+	t.Run("items are valued at their average cost", func(t *testing.T) {
+		srv.MockRepo.EXPECT().ReadWarehouseByID(gomock.Any(), int64(1)).Return(warehouse, nil)
+		srv.MockRepo.EXPECT().GetInventoryValuation(gomock.Any(), int64(1), asOf).Return([]model.ValuationItem{
+			{ProductID: 1, ProductName: "Beras", SKU: "BRS-5", Quantity: 3, StockValue: 200},
+			{ProductID: 2, ProductName: "Minyak", SKU: "MNY-1", Quantity: 4, StockValue: 72.5},
+		}, nil)
+
+		valuation, err := srv.Service.GetInventoryValuation(ctx, 1, asOf)
+		assert.NoError(t, err)
+		assert.Equal(t, model.InventoryValuation{
+			WarehouseID:   1,
+			CostingMethod: model.CostingAverage,
+			AsOf:          asOf,
+			TotalValue:    272.5,
+			Items: []model.ValuationItem{
+				{ProductID: 1, ProductName: "Beras", SKU: "BRS-5", Quantity: 3, StockValue: 200, UnitCost: 66.6667},
+				{ProductID: 2, ProductName: "Minyak", SKU: "MNY-1", Quantity: 4, StockValue: 72.5, UnitCost: 18.125},
+			},
+		}, valuation)
+	})
+
+	t.Run("warehouse of another user", func(t *testing.T) {
+		srv.MockRepo.EXPECT().ReadWarehouseByID(gomock.Any(), int64(2)).Return(model.Warehouse{WarehouseID: 2, UserID: 4}, nil)
+
+		_, err := srv.Service.GetInventoryValuation(ctx, 2, asOf)
+		assert.ErrorIs(t, err, model.ErrWarehouseNotFound)
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		srv.MockRepo.EXPECT().ReadWarehouseByID(gomock.Any(), int64(1)).Return(warehouse, nil)
+		srv.MockRepo.EXPECT().GetInventoryValuation(gomock.Any(), int64(1), asOf).Return(nil, errors.New("connection refused"))
+
+		_, err := srv.Service.GetInventoryValuation(ctx, 1, asOf)
+		assert.Error(t, err)
+	})
+}
+
+func TestService_WarehouseCostingMethod(t *testing.T) {
+	srv := NewTestServer(t)
+	defer srv.MockCtrl.Finish()
+
+	ctx := context.WithValue(context.Background(), middleware.ContextKeyUserID, int64(3))
+
+	t.Run("new warehouse costs FIFO by default", func(t *testing.T) {
+		srv.MockRepo.EXPECT().
+			WriteWarehouse(gomock.Any(), model.Warehouse{WarehouseName: "Gudang Utama", UserID: 3, CostingMethod: model.CostingFIFO}).
+			Return(nil)
+
+		err := srv.Service.AddWarehouseByUserID(ctx, model.Warehouse{WarehouseName: "Gudang Utama"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("unknown costing method", func(t *testing.T) {
+		err := srv.Service.AddWarehouseByUserID(ctx, model.Warehouse{WarehouseName: "Gudang Utama", CostingMethod: "LIFO"})
+		assert.ErrorIs(t, err, model.ErrInvalidCostingMethod)
+	})
+
+	t.Run("edit keeps the costing method when not given", func(t *testing.T) {
+		srv.MockRepo.EXPECT().ReadWarehouseByID(gomock.Any(), int64(1)).
+			Return(model.Warehouse{WarehouseID: 1, UserID: 3, CostingMethod: model.CostingAverage}, nil)
+		srv.MockRepo.EXPECT().
+			UpdateWarehouse(gomock.Any(), model.Warehouse{WarehouseID: 1, WarehouseName: "Gudang Timur", CostingMethod: model.CostingAverage}).
+			Return(nil)
+
+		err := srv.Service.EditWarehouseByUserID(ctx, model.Warehouse{WarehouseID: 1, WarehouseName: "Gudang Timur"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("edit cannot switch the costing method while holding stock", func(t *testing.T) {
+		srv.MockRepo.EXPECT().ReadWarehouseByID(gomock.Any(), int64(1)).
+			Return(model.Warehouse{WarehouseID: 1, UserID: 3, CostingMethod: model.CostingFIFO}, nil)
+		srv.MockRepo.EXPECT().
+			UpdateWarehouse(gomock.Any(), model.Warehouse{WarehouseID: 1, WarehouseName: "Gudang Timur", CostingMethod: model.CostingAverage}).
+			Return(fmt.Errorf("%w: warehouse 1", model.ErrCostingMethodLocked))
+
+		err := srv.Service.EditWarehouseByUserID(ctx, model.Warehouse{WarehouseID: 1, WarehouseName: "Gudang Timur", CostingMethod: model.CostingAverage})
+		assert.ErrorIs(t, err, model.ErrCostingMethodLocked)
+	})
+}
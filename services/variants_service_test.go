@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/budsx/retail-management/middleware"
+	"github.com/budsx/retail-management/model"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestService_AddProductVariant(t *testing.T) {
+	srv := NewTestServer(t)
+	defer srv.MockCtrl.Finish()
+
+	ctx := context.Background()
+	shirt := model.Product{ProductID: 1, ProductName: "Kaos Polos", SKU: "KAOS", BaseUnit: "PCS", VariantAttributes: []string{"size", "color"}}
+
+	t.Run("parent with variant attributes", func(t *testing.T) {
+		srv.MockRepo.EXPECT().WriteProduct(gomock.Any(), model.Product{ProductName: "Kaos Polos", SKU: "KAOS", BaseUnit: "PCS", VariantAttributes: []string{"size", "color"}}).Return(nil)
+
+		err := srv.Service.AddProduct(ctx, model.Product{ProductName: "Kaos Polos", SKU: "KAOS", BaseUnit: "PCS", VariantAttributes: []string{" size", "color "}})
+		assert.NoError(t, err)
+	})
+
+	t.Run("duplicate variant attribute", func(t *testing.T) {
+		err := srv.Service.AddProduct(ctx, model.Product{ProductName: "Kaos Polos", SKU: "KAOS", BaseUnit: "PCS", VariantAttributes: []string{"size", "size"}})
+		assert.ErrorIs(t, err, model.ErrInvalidVariant)
+	})
+
+	t.Run("variant with every attribute", func(t *testing.T) {
+		variant := model.Product{ProductName: "Kaos Polos M Hitam", SKU: "KAOS-M-HTM", BaseUnit: "PCS", ParentID: 1, Attributes: map[string]string{"size": "M", "color": "Hitam"}}
+		srv.MockRepo.EXPECT().ReadProductByID(gomock.Any(), int64(1)).Return(shirt, nil)
+		srv.MockRepo.EXPECT().WriteProduct(gomock.Any(), variant).Return(nil)
+
+		err := srv.Service.AddProduct(ctx, variant)
+		assert.NoError(t, err)
+	})
+
+	t.Run("variant missing an attribute", func(t *testing.T) {
+		srv.MockRepo.EXPECT().ReadProductByID(gomock.Any(), int64(1)).Return(shirt, nil)
+
+		err := srv.Service.AddProduct(ctx, model.Product{ProductName: "Kaos Polos M", SKU: "KAOS-M", ParentID: 1, Attributes: map[string]string{"size": "M", "fit": "Slim"}})
+		assert.ErrorIs(t, err, model.ErrInvalidVariant)
+	})
+
+	t.Run("parent that takes no variants", func(t *testing.T) {
+		srv.MockRepo.EXPECT().ReadProductByID(gomock.Any(), int64(2)).Return(model.Product{ProductID: 2, ProductName: "Beras 5kg"}, nil)
+
+		err := srv.Service.AddProduct(ctx, model.Product{ProductName: "Beras 5kg Pandan", ParentID: 2, Attributes: map[string]string{"size": "5kg"}})
+		assert.ErrorIs(t, err, model.ErrInvalidVariant)
+	})
+
+	t.Run("unknown parent", func(t *testing.T) {
+		srv.MockRepo.EXPECT().ReadProductByID(gomock.Any(), int64(9)).Return(model.Product{}, fmt.Errorf("product not found"))
+
+		err := srv.Service.AddProduct(ctx, model.Product{ProductName: "Kaos Polos M", ParentID: 9, Attributes: map[string]string{"size": "M"}})
+		assert.ErrorIs(t, err, model.ErrInvalidVariant)
+	})
+
+	t.Run("attributes without a parent", func(t *testing.T) {
+		err := srv.Service.AddProduct(ctx, model.Product{ProductName: "Kaos Polos M", Attributes: map[string]string{"size": "M"}})
+		assert.ErrorIs(t, err, model.ErrInvalidVariant)
+	})
+}
+
+func TestService_CreateStockTransaction_VariantParent(t *testing.T) {
+	srv := NewTestServer(t)
+	defer srv.MockCtrl.Finish()
+
+	ctx := context.Background()
+	location := model.Location{LocationID: 2, LocationName: "Rak B2", WarehouseID: 1}
+	shirt := model.Product{ProductID: 1, ProductName: "Kaos Polos", VariantAttributes: []string{"size"}}
+
+	srv.MockRepo.EXPECT().ReadLocationByID(gomock.Any(), int64(2)).Return(location, nil)
+	srv.MockRepo.EXPECT().ReadProductByID(gomock.Any(), int64(1)).Return(shirt, nil)
+
+	err := srv.Service.CreateStockTransaction(ctx, model.StockTransaction{
+		ProductID:       1,
+		LocationID:      2,
+		TransactionType: model.StockIn,
+		Quantity:        10,
+	})
+	assert.ErrorIs(t, err, model.ErrVariantParent)
+}
+
+func TestService_GetParentStocks(t *testing.T) {
+	srv := NewTestServer(t)
+	defer srv.MockCtrl.Finish()
+
+	ctx := context.WithValue(context.Background(), middleware.ContextKeyUserID, int64(3))
+	ctx = context.WithValue(ctx, middleware.ContextKeyUsername, "gudang")
+
+	t.Run("variants added up per parent", func(t *testing.T) {
+		srv.MockRepo.EXPECT().GetTotalStockByLocation(gomock.Any(), int64(2)).Return([]model.ProductStock{
+			{ProductID: 2, ProductName: "Kaos Polos S", SKU: "KAOS-S", TotalStock: 4, AvailableStock: 4, ParentID: 1},
+			{ProductID: 3, ProductName: "Kaos Polos M", SKU: "KAOS-M", TotalStock: 6, ReservedStock: 1, AvailableStock: 5, ParentID: 1},
+			{ProductID: 4, ProductName: "Beras 5kg", SKU: "BRS-5", TotalStock: 7, AvailableStock: 7, CategoryID: 2},
+		}, nil)
+		srv.MockRepo.EXPECT().ReadProductsByIDs(gomock.Any(), []int64{1}).Return([]model.Product{
+			{ProductID: 1, ProductName: "Kaos Polos", SKU: "KAOS", CategoryID: 3},
+		}, nil)
+
+		got, err := srv.Service.GetParentStocks(ctx, 2, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []model.ProductStock{
+			{ProductID: 1, ProductName: "Kaos Polos", SKU: "KAOS", TotalStock: 10, ReservedStock: 1, AvailableStock: 9, CategoryID: 3},
+			{ProductID: 4, ProductName: "Beras 5kg", SKU: "BRS-5", TotalStock: 7, AvailableStock: 7, CategoryID: 2},
+		}, got)
+	})
+
+	t.Run("no variants", func(t *testing.T) {
+		stocks := []model.ProductStock{{ProductID: 4, ProductName: "Beras 5kg", TotalStock: 7, AvailableStock: 7}}
+		srv.MockRepo.EXPECT().GetTotalStocks(gomock.Any()).Return(stocks, nil)
+
+		got, err := srv.Service.GetParentStocks(ctx, 0, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, stocks, got)
+	})
+}
@@ -13,6 +13,14 @@ func (svc *Service) AddWarehouseByUserID(ctx context.Context, warehouse model.Wa
 	userID := ctx.Value(middleware.ContextKeyUserID).(int64)
 	warehouse.UserID = userID
 
+	if warehouse.CostingMethod == "" {
+		warehouse.CostingMethod = model.CostingFIFO
+	}
+	if !validCostingMethod(warehouse.CostingMethod) {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Invalid costing method %s", warehouse.CostingMethod))
+		return model.ErrInvalidCostingMethod
+	}
+
 	err := svc.repo.Postgres.WriteWarehouse(ctx, warehouse)
 	if err != nil {
 		svc.logger.Info(fmt.Sprintf("[ERROR] Failed to add warehouse: %s", err.Error()))
@@ -23,19 +31,32 @@ func (svc *Service) AddWarehouseByUserID(ctx context.Context, warehouse model.Wa
 	return nil
 }
 
-func (svc *Service) GetWarehouseByUserID(ctx context.Context) ([]model.Warehouse, error) {
+// GetWarehouseByUserID lists the user's warehouses that follow the pagination
+// cursor, leaving out archived ones unless includeArchived is set.
+func (svc *Service) GetWarehouseByUserID(ctx context.Context, includeArchived bool, pagination model.CursorPagination) ([]model.Warehouse, model.PageCursors, error) {
 	user := middleware.GetUserInfoByContext(ctx)
 
-	svc.logger.Info(fmt.Sprintf("[REQUEST] Get warehouse by user ID - %+v", user))
+	svc.logger.Info(fmt.Sprintf("[REQUEST] Get warehouse by user ID %t %+v - %+v", includeArchived, pagination, user))
 
-	warehouses, err := svc.repo.Postgres.ReadWarehousesByUserID(ctx, user.UserID)
+	cursor, err := decodeCursor(pagination, "warehouse_id")
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] %s", err.Error()))
+		return nil, model.PageCursors{}, err
+	}
+
+	warehouses, err := svc.repo.Postgres.ReadWarehousesByUserID(ctx, user.UserID, includeArchived, cursor, pagination.Limit+1)
 	if err != nil {
 		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to get warehouses: %s", err.Error()))
-		return nil, fmt.Errorf("failed to get warehouses: %w", err)
+		return nil, model.PageCursors{}, fmt.Errorf("failed to get warehouses: %w", err)
 	}
 
-	svc.logger.Info(fmt.Sprintf("[RESPONSE] Warehouses retrieved successfully: %+v", warehouses))
-	return warehouses, nil
+	start, end, cursors := cursorPage(cursor, pagination.Limit, len(warehouses), func(i int) model.Cursor {
+		return model.Cursor{ID: warehouses[i].WarehouseID, Sort: "warehouse_id"}
+	})
+	warehouses = warehouses[start:end]
+
+	svc.logger.Info(fmt.Sprintf("[RESPONSE] Warehouses retrieved successfully: %+v %+v", warehouses, cursors))
+	return warehouses, cursors, nil
 }
 
 func (svc *Service) EditWarehouseByUserID(ctx context.Context, warehouse model.Warehouse) error {
@@ -49,6 +70,14 @@ func (svc *Service) EditWarehouseByUserID(ctx context.Context, warehouse model.W
 		return fmt.Errorf("unauthorized or warehouse not found")
 	}
 
+	if warehouse.CostingMethod == "" {
+		warehouse.CostingMethod = dbWarehouse.CostingMethod
+	}
+	if !validCostingMethod(warehouse.CostingMethod) {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Invalid costing method %s", warehouse.CostingMethod))
+		return model.ErrInvalidCostingMethod
+	}
+
 	err = svc.repo.Postgres.UpdateWarehouse(ctx, warehouse)
 	if err != nil {
 		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to update warehouse: %s", err.Error()))
@@ -59,21 +88,74 @@ func (svc *Service) EditWarehouseByUserID(ctx context.Context, warehouse model.W
 	return nil
 }
 
-func (svc *Service) GetStockTransactions(ctx context.Context) ([]model.StockTransaction, error) {
+// DeleteWarehouseByUserID archives one of the user's warehouses along with its
+// locations. Their stock history is kept and the warehouse can be restored.
+func (svc *Service) DeleteWarehouseByUserID(ctx context.Context, warehouseID int64) error {
+	svc.logger.Info(fmt.Sprintf("[REQUEST] Delete warehouse ID: %d", warehouseID))
+
+	userID := ctx.Value(middleware.ContextKeyUserID).(int64)
+
+	err := svc.repo.Postgres.SetWarehouseArchived(ctx, userID, warehouseID, true)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to archive warehouse: %s", err.Error()))
+		return fmt.Errorf("failed to delete warehouse: %w", err)
+	}
+
+	svc.logger.Info("[RESPONSE] Warehouse archived successfully")
+	return nil
+}
+
+// RestoreWarehouseByUserID brings back one of the user's archived warehouses
+// with the locations that were archived along with it.
+func (svc *Service) RestoreWarehouseByUserID(ctx context.Context, warehouseID int64) error {
+	svc.logger.Info(fmt.Sprintf("[REQUEST] Restore warehouse ID: %d", warehouseID))
+
+	userID := ctx.Value(middleware.ContextKeyUserID).(int64)
+
+	err := svc.repo.Postgres.SetWarehouseArchived(ctx, userID, warehouseID, false)
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] Failed to restore warehouse: %s", err.Error()))
+		return fmt.Errorf("failed to restore warehouse: %w", err)
+	}
+
+	svc.logger.Info("[RESPONSE] Warehouse restored successfully")
+	return nil
+}
+
+// validCostingMethod reports whether stock can be costed with method.
+func validCostingMethod(method model.CostingMethod) bool {
+	return method == model.CostingFIFO || method == model.CostingAverage
+}
+
+// GetStockTransactions lists the user's transactions matching filter that
+// follow the pagination cursor, newest first.
+func (svc *Service) GetStockTransactions(ctx context.Context, filter model.StockTransactionFilter, pagination model.CursorPagination) ([]model.StockTransaction, model.PageCursors, error) {
 	user := middleware.GetUserInfoByContext(ctx)
-	svc.logger.Info(fmt.Sprintf("[REQUEST] GetStockTransactions - %+v", user))
+	svc.logger.Info(fmt.Sprintf("[REQUEST] GetStockTransactions %+v %+v - %+v", filter, pagination, user))
 
 	if user.UserID == 0 {
 		svc.logger.Info("Invalid User")
-		return []model.StockTransaction{}, fmt.Errorf("Unathorized")
+		return []model.StockTransaction{}, model.PageCursors{}, fmt.Errorf("Unathorized")
 	}
 
-	transactions, err := svc.repo.Postgres.GetStockTransactions(ctx, user.UserID)
+	cursor, err := decodeCursor(pagination, "transaction_id")
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("[ERROR] %s", err.Error()))
+		return []model.StockTransaction{}, model.PageCursors{}, err
+	}
+
+	filter.CreatedBy = user.UserID
+	transactions, err := svc.repo.Postgres.GetStockTransactions(ctx, filter, cursor, pagination.Limit+1)
 	if err != nil {
 		svc.logger.Info(err.Error())
-		return []model.StockTransaction{}, fmt.Errorf("failed to get stock transactions: %w", err)
+		return []model.StockTransaction{}, model.PageCursors{}, fmt.Errorf("failed to get stock transactions: %w", err)
 	}
 
-	svc.logger.Info(fmt.Sprintf("[RESPONSE] %+v", transactions))
-	return transactions, nil
+	start, end, cursors := cursorPage(cursor, pagination.Limit, len(transactions), func(i int) model.Cursor {
+		return model.Cursor{ID: transactions[i].TransactionID, Sort: "transaction_id"}
+	})
+	transactions = transactions[start:end]
+
+	svc.logger.Info(fmt.Sprintf("[RESPONSE] %+v %+v", transactions, cursors))
+	return transactions, cursors, nil
 }
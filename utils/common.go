@@ -27,4 +27,17 @@ func OnShutdown(srv *http.Server) {
 	}
 }
 
+// RunPeriodically calls job every interval until ctx is cancelled.
+func RunPeriodically(ctx context.Context, interval time.Duration, job func(context.Context)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
 
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			job(ctx)
+		}
+	}
+}